@@ -0,0 +1,44 @@
+package alerts
+
+import (
+	"encoding/json"
+
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/validators"
+)
+
+type alertmanagerSource struct {
+	kind string
+}
+
+// NewAlertmanagerSource parses Prometheus Alertmanager webhook notifications.
+func NewAlertmanagerSource() AlertSource {
+	return &alertmanagerSource{kind: models.AlertKindAlertmanager}
+}
+
+func (s *alertmanagerSource) Parse(body []byte) ([]*models.Alert, error) {
+	payload := new(models.AlertmanagerPayload)
+	if err := json.Unmarshal(body, payload); err != nil {
+		return nil, invalidPayload("invalid %s payload: %v", s.kind, err)
+	}
+
+	if err := validators.ValidateStruct(payload); err != nil {
+		return nil, &validators.ValidationError{Err: err}
+	}
+
+	alerts := make([]*models.Alert, 0, len(payload.Alerts))
+	for _, received := range payload.Alerts {
+		alerts = append(alerts, completeAlert(&models.Alert{
+			Source:       s.kind,
+			Fingerprint:  received.Fingerprint,
+			Status:       received.Status,
+			Labels:       received.Labels,
+			Annotations:  received.Annotations,
+			GeneratorURL: received.GeneratorURL,
+			StartsAt:     received.StartsAt,
+			EndsAt:       received.EndsAt,
+		}))
+	}
+
+	return alerts, nil
+}
@@ -0,0 +1,113 @@
+package alerts
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+)
+
+// datadogPayload is the body a Datadog webhook sends when its payload
+// template maps these keys to the matching variables, e.g.
+//
+//	{"id": "$ID", "alert_id": "$ALERT_ID", "title": "$EVENT_TITLE", "body": "$EVENT_MSG",
+//	 "alert_transition": "$ALERT_TRANSITION", "alert_type": "$ALERT_TYPE", "alert_scope": "$ALERT_SCOPE",
+//	 "priority": "$ALERT_PRIORITY", "tags": "$TAGS", "link": "$LINK", "date": "$DATE"}
+type datadogPayload struct {
+	ID              string         `json:"id"`
+	AlertID         string         `json:"alert_id"`
+	Title           string         `json:"title"`
+	Body            string         `json:"body"`
+	AlertTransition string         `json:"alert_transition"`
+	AlertType       string         `json:"alert_type"`
+	AlertScope      string         `json:"alert_scope"`
+	Priority        string         `json:"priority"`
+	Tags            string         `json:"tags"`
+	Link            string         `json:"link"`
+	Date            datadogEpochMS `json:"date"`
+}
+
+// datadogEpochMS is $DATE, milliseconds since the epoch, whether or not the
+// template quotes it.
+type datadogEpochMS time.Time
+
+func (d *datadogEpochMS) UnmarshalJSON(b []byte) error {
+	raw := string(bytes.Trim(b, `"`))
+	if raw == "" || raw == "null" {
+		return nil
+	}
+
+	ms, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return err
+	}
+	*d = datadogEpochMS(time.UnixMilli(ms).UTC())
+	return nil
+}
+
+type datadogSource struct{}
+
+// NewDatadogSource parses Datadog monitor webhooks.
+func NewDatadogSource() AlertSource {
+	return &datadogSource{}
+}
+
+func (s *datadogSource) Parse(body []byte) ([]*models.Alert, error) {
+	payload := new(datadogPayload)
+	if err := json.Unmarshal(body, payload); err != nil {
+		return nil, invalidPayload("invalid datadog payload: %v", err)
+	}
+
+	if payload.AlertID == "" && payload.Title == "" {
+		return nil, invalidPayload("datadog payload needs alert_id or title")
+	}
+
+	// Tags and the scope ("env:prod,service:pix") become labels
+	labels := models.AlertLabels{}
+	for _, list := range []string{payload.Tags, payload.AlertScope} {
+		for _, tag := range strings.Split(list, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(tag), ":")
+			if name != "" {
+				labels[name] = value
+			}
+		}
+	}
+	for name, value := range map[string]string{"alertname": payload.Title, "priority": payload.Priority, "alert_type": payload.AlertType} {
+		if value != "" {
+			labels[name] = value
+		}
+	}
+
+	alert := &models.Alert{
+		Source:       models.AlertKindDatadog,
+		Status:       datadogStatus(payload),
+		Name:         payload.Title,
+		Labels:       labels,
+		Annotations:  models.AlertLabels{"summary": payload.Title, "description": payload.Body},
+		GeneratorURL: payload.Link,
+	}
+
+	// A monitor alerts once per group it is scoped to
+	if payload.AlertID != "" {
+		alert.Fingerprint = labelFingerprint(models.AlertLabels{"alert_id": payload.AlertID, "scope": payload.AlertScope})
+	}
+
+	at := time.Time(payload.Date)
+	if alert.Status == models.AlertResolved {
+		alert.EndsAt = at
+	} else {
+		alert.StartsAt = at
+	}
+
+	return []*models.Alert{completeAlert(alert)}, nil
+}
+
+func datadogStatus(payload *datadogPayload) string {
+	if strings.EqualFold(payload.AlertTransition, "Recovered") || strings.EqualFold(payload.AlertType, "success") {
+		return models.AlertResolved
+	}
+	return models.AlertFiring
+}
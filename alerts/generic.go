@@ -0,0 +1,173 @@
+package alerts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+)
+
+// defaultResolvedValues are the status values that mean an alert is over
+// when a mapping does not list its own.
+var defaultResolvedValues = []string{"resolved", "ok", "recovered", "closed"}
+
+type genericSource struct {
+	source         string
+	alerts         jsonPath
+	name           jsonPath
+	fingerprint    jsonPath
+	status         jsonPath
+	resolvedValues []string
+	summary        jsonPath
+	description    jsonPath
+	url            jsonPath
+	startsAt       jsonPath
+	endsAt         jsonPath
+	labels         map[string]jsonPath
+}
+
+// NewGenericSource parses any JSON payload, locating alert fields with the
+// JSONPath expressions of the mapping. It fails when an expression does not
+// compile, so mappings can be checked before they are saved.
+func NewGenericSource(source string, mapping *models.GenericAlertMapping) (AlertSource, error) {
+	s := &genericSource{source: source, resolvedValues: mapping.ResolvedValues, labels: map[string]jsonPath{}}
+	if len(s.resolvedValues) == 0 {
+		s.resolvedValues = defaultResolvedValues
+	}
+
+	alertsPath := mapping.Alerts
+	if alertsPath == "" {
+		alertsPath = "$"
+	}
+
+	paths := []struct {
+		field    string
+		path     string
+		compiled *jsonPath
+	}{
+		{"alerts", alertsPath, &s.alerts},
+		{"name", mapping.Name, &s.name},
+		{"fingerprint", mapping.Fingerprint, &s.fingerprint},
+		{"status", mapping.Status, &s.status},
+		{"summary", mapping.Summary, &s.summary},
+		{"description", mapping.Description, &s.description},
+		{"url", mapping.URL, &s.url},
+		{"startsAt", mapping.StartsAt, &s.startsAt},
+		{"endsAt", mapping.EndsAt, &s.endsAt},
+	}
+	for _, path := range paths {
+		if path.path == "" {
+			continue
+		}
+		compiled, err := compilePath(path.path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path.field, err)
+		}
+		*path.compiled = compiled
+	}
+
+	for label, path := range mapping.Labels {
+		compiled, err := compilePath(path)
+		if err != nil {
+			return nil, fmt.Errorf("labels.%s: %w", label, err)
+		}
+		s.labels[label] = compiled
+	}
+
+	return s, nil
+}
+
+func (s *genericSource) Parse(body []byte) ([]*models.Alert, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, invalidPayload("invalid JSON payload: %v", err)
+	}
+
+	// A path selecting one array means each element is an alert
+	selected := s.alerts.eval(document)
+	if len(selected) == 1 {
+		if list, ok := selected[0].([]interface{}); ok {
+			selected = list
+		}
+	}
+	if len(selected) == 0 {
+		return nil, invalidPayload("payload has no alerts")
+	}
+
+	alerts := make([]*models.Alert, 0, len(selected))
+	for _, item := range selected {
+		alert := &models.Alert{
+			Source:       s.source,
+			Fingerprint:  text(s.fingerprint.first(item)),
+			Status:       models.AlertFiring,
+			Name:         text(s.name.first(item)),
+			Labels:       models.AlertLabels{},
+			Annotations:  models.AlertLabels{},
+			GeneratorURL: text(s.url.first(item)),
+			StartsAt:     timeValue(s.startsAt.first(item)),
+			EndsAt:       timeValue(s.endsAt.first(item)),
+		}
+
+		if s.status != nil && s.isResolved(text(s.status.first(item))) {
+			alert.Status = models.AlertResolved
+		}
+
+		for label, path := range s.labels {
+			if value := text(path.first(item)); value != "" {
+				alert.Labels[label] = value
+			}
+		}
+
+		if summary := text(s.summary.first(item)); summary != "" {
+			alert.Annotations["summary"] = summary
+		}
+		if description := text(s.description.first(item)); description != "" {
+			alert.Annotations["description"] = description
+		}
+
+		alerts = append(alerts, completeAlert(alert))
+	}
+
+	return alerts, nil
+}
+
+func (s *genericSource) isResolved(status string) bool {
+	for _, value := range s.resolvedValues {
+		if strings.EqualFold(strings.TrimSpace(status), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// timeValue reads an RFC 3339 timestamp, or seconds (or milliseconds) since
+// the epoch. Anything else is treated as unknown.
+func timeValue(value interface{}) time.Time {
+	switch v := value.(type) {
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t.UTC()
+		}
+		return timeValue(json.Number(v))
+	case json.Number:
+		epoch, err := v.Int64()
+		if err != nil {
+			seconds, err := v.Float64()
+			if err != nil {
+				return time.Time{}
+			}
+			epoch = int64(seconds)
+		}
+		if epoch > 1e12 {
+			return time.UnixMilli(epoch).UTC()
+		}
+		return time.Unix(epoch, 0).UTC()
+	}
+	return time.Time{}
+}
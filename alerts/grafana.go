@@ -0,0 +1,73 @@
+package alerts
+
+import (
+	"encoding/json"
+
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/validators"
+)
+
+// grafanaPayload is a Grafana unified alerting webhook notification: the
+// Alertmanager format plus links back to dashboards.
+type grafanaPayload struct {
+	Receiver string         `json:"receiver"`
+	Status   string         `json:"status"`
+	Alerts   []grafanaAlert `json:"alerts" validate:"required,min=1,dive"`
+}
+
+type grafanaAlert struct {
+	models.AlertmanagerAlert
+	DashboardURL string `json:"dashboardURL"`
+	PanelURL     string `json:"panelURL"`
+	ValueString  string `json:"valueString"`
+}
+
+type grafanaSource struct{}
+
+// NewGrafanaSource parses Grafana unified alerting webhook notifications.
+func NewGrafanaSource() AlertSource {
+	return &grafanaSource{}
+}
+
+func (s *grafanaSource) Parse(body []byte) ([]*models.Alert, error) {
+	payload := new(grafanaPayload)
+	if err := json.Unmarshal(body, payload); err != nil {
+		return nil, invalidPayload("invalid grafana payload: %v", err)
+	}
+
+	if err := validators.ValidateStruct(payload); err != nil {
+		return nil, &validators.ValidationError{Err: err}
+	}
+
+	alerts := make([]*models.Alert, 0, len(payload.Alerts))
+	for _, received := range payload.Alerts {
+		annotations := models.AlertLabels{}
+		for name, value := range received.Annotations {
+			annotations[name] = value
+		}
+		// Responders land on the panel rather than the rule editor
+		for name, value := range map[string]string{"dashboard": received.DashboardURL, "panel": received.PanelURL, "value": received.ValueString} {
+			if _, set := annotations[name]; !set && value != "" {
+				annotations[name] = value
+			}
+		}
+
+		generatorURL := received.GeneratorURL
+		if received.PanelURL != "" {
+			generatorURL = received.PanelURL
+		}
+
+		alerts = append(alerts, completeAlert(&models.Alert{
+			Source:       models.AlertKindGrafana,
+			Fingerprint:  received.Fingerprint,
+			Status:       received.Status,
+			Labels:       received.Labels,
+			Annotations:  annotations,
+			GeneratorURL: generatorURL,
+			StartsAt:     received.StartsAt,
+			EndsAt:       received.EndsAt,
+		}))
+	}
+
+	return alerts, nil
+}
@@ -0,0 +1,158 @@
+package alerts
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPath is a compiled JSONPath expression. The supported subset covers
+// what payload mappings need: the root "$", child keys (".name" or
+// "['name']"), array indexes ("[0]", "[-1]") and wildcards (".*" or "[*]").
+type jsonPath []pathStep
+
+type pathStep struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+func compilePath(path string) (jsonPath, error) {
+	path = strings.TrimSpace(path)
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("JSONPath %q must start with $", path)
+	}
+
+	steps := jsonPath{}
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			name := rest[:end]
+			rest = rest[end:]
+
+			switch name {
+			case "":
+				return nil, fmt.Errorf("JSONPath %q has an empty key", path)
+			case "*":
+				steps = append(steps, pathStep{wildcard: true})
+			default:
+				steps = append(steps, pathStep{key: name})
+			}
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("JSONPath %q has an unterminated [", path)
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+
+			switch {
+			case inner == "*":
+				steps = append(steps, pathStep{wildcard: true})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				steps = append(steps, pathStep{key: inner[1 : len(inner)-1]})
+			default:
+				index, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("JSONPath %q has an invalid index %q", path, inner)
+				}
+				steps = append(steps, pathStep{index: index, isIndex: true})
+			}
+		default:
+			return nil, fmt.Errorf("JSONPath %q has an unexpected %q", path, rest[0])
+		}
+	}
+
+	return steps, nil
+}
+
+// eval returns every value the path selects in a document decoded with
+// UseNumber. Missing keys select nothing.
+func (p jsonPath) eval(document interface{}) []interface{} {
+	current := []interface{}{document}
+
+	for _, step := range p {
+		var next []interface{}
+		for _, value := range current {
+			switch {
+			case step.wildcard:
+				switch v := value.(type) {
+				case []interface{}:
+					next = append(next, v...)
+				case map[string]interface{}:
+					keys := make([]string, 0, len(v))
+					for key := range v {
+						keys = append(keys, key)
+					}
+					sort.Strings(keys)
+					for _, key := range keys {
+						next = append(next, v[key])
+					}
+				}
+			case step.isIndex:
+				list, ok := value.([]interface{})
+				if !ok {
+					continue
+				}
+				index := step.index
+				if index < 0 {
+					index += len(list)
+				}
+				if index >= 0 && index < len(list) {
+					next = append(next, list[index])
+				}
+			default:
+				object, ok := value.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if item, ok := object[step.key]; ok {
+					next = append(next, item)
+				}
+			}
+		}
+		current = next
+	}
+
+	return current
+}
+
+// first returns the first selected value, or nil.
+func (p jsonPath) first(document interface{}) interface{} {
+	if p == nil {
+		return nil
+	}
+	if values := p.eval(document); len(values) > 0 {
+		return values[0]
+	}
+	return nil
+}
+
+// text renders a selected value as a label would hold it.
+func text(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(encoded)
+}
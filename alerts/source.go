@@ -0,0 +1,75 @@
+// Package alerts turns the webhook payloads of monitoring systems into
+// alerts for the incident ingestion pipeline.
+package alerts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/validators"
+)
+
+// AlertSource parses one notification of a monitoring system. Alerts keep
+// the fingerprint the system gives them, so a repeated notification for the
+// same problem is recognised.
+type AlertSource interface {
+	Parse(body []byte) ([]*models.Alert, error)
+}
+
+// NewAlertSource returns the adapter for an integration's kind.
+func NewAlertSource(integration *models.AlertIntegration) (AlertSource, error) {
+	switch integration.Kind {
+	case models.AlertKindAlertmanager:
+		return NewAlertmanagerSource(), nil
+	case models.AlertKindGrafana:
+		return NewGrafanaSource(), nil
+	case models.AlertKindDatadog:
+		return NewDatadogSource(), nil
+	case models.AlertKindGeneric:
+		if integration.Mapping == nil {
+			return nil, fmt.Errorf("generic alert integration %d has no mapping", integration.ID)
+		}
+		// Fingerprints of a custom source only mean something within it
+		return NewGenericSource(fmt.Sprintf("%s:%d", models.AlertKindGeneric, integration.ID), integration.Mapping)
+	}
+	return nil, fmt.Errorf("unknown alert integration kind %q", integration.Kind)
+}
+
+// invalidPayload reports a body the adapter cannot make sense of.
+func invalidPayload(format string, args ...interface{}) error {
+	return &validators.ValidationError{Messages: []string{fmt.Sprintf(format, args...)}}
+}
+
+// completeAlert fills in what a source left out: a fingerprint derived from
+// the labels and a name.
+func completeAlert(alert *models.Alert) *models.Alert {
+	if alert.Fingerprint == "" {
+		alert.Fingerprint = labelFingerprint(alert.Labels)
+	}
+	if alert.Name == "" {
+		alert.Name = alert.Labels["alertname"]
+	}
+	if alert.Name == "" {
+		alert.Name = "alert " + alert.Fingerprint
+	}
+	return alert
+}
+
+// labelFingerprint identifies an alert by its label set when the source
+// does not send a fingerprint.
+func labelFingerprint(labels models.AlertLabels) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	hash := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(hash, "%s\xff%s\xff", name, labels[name])
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
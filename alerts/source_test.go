@@ -0,0 +1,132 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabelFingerprint(t *testing.T) {
+	a := labelFingerprint(models.AlertLabels{"alertname": "PixErrors", "service": "pix"})
+	b := labelFingerprint(models.AlertLabels{"service": "pix", "alertname": "PixErrors"})
+	c := labelFingerprint(models.AlertLabels{"alertname": "PixErrors", "service": "boleto"})
+
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, c)
+	assert.Len(t, a, 16)
+}
+
+func TestGrafanaSource(t *testing.T) {
+	parsed, err := NewGrafanaSource().Parse([]byte(`{
+		"receiver": "firewatchers",
+		"status": "firing",
+		"alerts": [{
+			"status": "firing",
+			"fingerprint": "g1",
+			"labels": {"alertname": "PixLatency", "service": "pix"},
+			"annotations": {"summary": "PIX p99 above 2s"},
+			"generatorURL": "https://grafana/alerting/edit",
+			"panelURL": "https://grafana/d/pix?viewPanel=4",
+			"valueString": "[ var='B' value=2.4 ]",
+			"startsAt": "2026-10-18T09:30:00Z"
+		}]
+	}`))
+	require.NoError(t, err)
+	require.Len(t, parsed, 1)
+
+	alert := parsed[0]
+	assert.Equal(t, models.AlertKindGrafana, alert.Source)
+	assert.Equal(t, "g1", alert.Fingerprint)
+	assert.Equal(t, "PixLatency", alert.Name)
+	assert.Equal(t, "https://grafana/d/pix?viewPanel=4", alert.GeneratorURL)
+	assert.Equal(t, "[ var='B' value=2.4 ]", alert.Annotations["value"])
+	assert.Equal(t, time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC), alert.StartsAt.UTC())
+
+	_, err = NewGrafanaSource().Parse([]byte(`{"alerts": []}`))
+	assert.Error(t, err)
+}
+
+func TestDatadogSource(t *testing.T) {
+	source := NewDatadogSource()
+	notification := func(transition string) []byte {
+		return []byte(`{
+			"id": "7001",
+			"alert_id": "123",
+			"title": "[P1] Boleto error rate",
+			"body": "Error rate is 7%",
+			"alert_transition": "` + transition + `",
+			"alert_type": "error",
+			"alert_scope": "service:boleto",
+			"priority": "P1",
+			"tags": "env:prod, team:payments",
+			"link": "https://app.datadoghq.com/monitors/123",
+			"date": 1792315800000
+		}`)
+	}
+
+	triggered, err := source.Parse(notification("Triggered"))
+	require.NoError(t, err)
+	require.Len(t, triggered, 1)
+	assert.Equal(t, models.AlertFiring, triggered[0].Status)
+	assert.Equal(t, models.AlertLabels{
+		"env":        "prod",
+		"team":       "payments",
+		"service":    "boleto",
+		"alertname":  "[P1] Boleto error rate",
+		"priority":   "P1",
+		"alert_type": "error",
+	}, triggered[0].Labels)
+	assert.Equal(t, time.UnixMilli(1792315800000).UTC(), triggered[0].StartsAt)
+
+	recovered, err := source.Parse(notification("Recovered"))
+	require.NoError(t, err)
+	assert.Equal(t, models.AlertResolved, recovered[0].Status)
+	assert.Equal(t, triggered[0].Fingerprint, recovered[0].Fingerprint, "a monitor keeps its fingerprint across transitions")
+}
+
+func TestGenericSource(t *testing.T) {
+	source, err := NewGenericSource("generic:4", &models.GenericAlertMapping{
+		Alerts:      "$.incidents",
+		Name:        "$.check.name",
+		Fingerprint: "$.id",
+		Status:      "$.state",
+		Summary:     "$.message",
+		StartsAt:    "$.opened_at",
+		Labels:      map[string]string{"service": "$.tags[0]", "region": "$['meta']['region']"},
+	})
+	require.NoError(t, err)
+
+	parsed, err := source.Parse([]byte(`{"incidents": [
+		{"id": 31, "state": "open", "check": {"name": "Checkout 5xx"}, "message": "5xx above 1%", "tags": ["checkout"], "meta": {"region": "sa-east-1"}, "opened_at": 1792315800},
+		{"id": 32, "state": "OK", "check": {"name": "Login latency"}}
+	]}`))
+	require.NoError(t, err)
+	require.Len(t, parsed, 2)
+
+	assert.Equal(t, "generic:4", parsed[0].Source)
+	assert.Equal(t, "31", parsed[0].Fingerprint)
+	assert.Equal(t, models.AlertFiring, parsed[0].Status)
+	assert.Equal(t, "Checkout 5xx", parsed[0].Name)
+	assert.Equal(t, "5xx above 1%", parsed[0].Annotations["summary"])
+	assert.Equal(t, models.AlertLabels{"service": "checkout", "region": "sa-east-1"}, parsed[0].Labels)
+	assert.Equal(t, time.Unix(1792315800, 0).UTC(), parsed[0].StartsAt)
+
+	assert.Equal(t, models.AlertResolved, parsed[1].Status, "resolved values match without case")
+	assert.Empty(t, parsed[1].Labels)
+
+	_, err = source.Parse([]byte(`{"incidents": []}`))
+	assert.Error(t, err)
+}
+
+func TestCompilePath(t *testing.T) {
+	for _, path := range []string{"alerts", "$.", "$.alerts[0", "$[x]", "$alerts"} {
+		_, err := compilePath(path)
+		assert.Error(t, err, path)
+	}
+
+	_, err := NewGenericSource("generic:1", &models.GenericAlertMapping{Name: "$.name", Labels: map[string]string{"team": "team"}})
+	assert.ErrorContains(t, err, "labels.team")
+}
@@ -3,19 +3,128 @@ package config
 
 import (
     "os"
+    "strconv"
+    "strings"
+    "time"
 )
 
 type Config struct {
-    Port        string
-    DatabaseURL string
-    JWTSecret   string
+    Port            string
+    DatabaseURL     string
+    JWTSecret       string
+    AccessTokenTTL  time.Duration
+    RefreshTokenTTL time.Duration
+    AppURL          string
+    // Public address of this API, used in links to files it serves
+    APIURL          string
+
+    // Outgoing email
+    MailerDriver         string
+    SMTPHost             string
+    SMTPPort             string
+    SMTPUsername         string
+    SMTPPassword         string
+    MailFrom             string
+    PasswordResetTTL     time.Duration
+    EmailVerificationTTL time.Duration
+
+    // Login throttling
+    LoginMaxAttempts     int
+    LoginIPMaxAttempts   int
+    LoginBackoffBase     time.Duration
+    LoginLockoutDuration time.Duration
+    LoginLockoutMax      time.Duration
+    LoginFailureWindow   time.Duration
+
+    // Two-factor authentication
+    TOTPIssuer        string
+    TOTPRequiredRoles []string
+    LoginChallengeTTL time.Duration
+
+    // Single sign-on through OpenID Connect, enabled when OIDCIssuer is set
+    OIDCIssuer            string
+    OIDCClientID          string
+    OIDCClientSecret      string
+    OIDCRedirectURL       string
+    OIDCScopes            []string
+    OIDCAllowedDomains    []string
+    OIDCPostLoginRedirect string
+    // Skip the local two-factor challenge for single sign-on, for providers
+    // that already enforce MFA
+    OIDCTrustIDPMFA       bool
+
+    // Uploaded files
+    BlobStoreDriver    string
+    BlobStoreDir       string
+    AvatarMaxBytes     int
+    AvatarSize         int
+    AttachmentMaxBytes int
+
+    // Outbound webhooks
+    WebhookPollInterval time.Duration
+    WebhookTimeout      time.Duration
+    WebhookMaxAttempts  int
+    WebhookBackoffBase  time.Duration
+    WebhookBackoffMax   time.Duration
+
+    // Alert ingestion; used when no mapping rule sets the field
+    AlertDefaultType     string
+    AlertDefaultSeverity string
 }
 
 func GetConfig() *Config {
     return &Config{
-        Port:        getEnv("PORT", "3000"),
-        DatabaseURL: getEnv("DATABASE_URL", ""),
-        JWTSecret:   getEnv("JWT_SECRET", ""),
+        Port:            getEnv("PORT", "3000"),
+        DatabaseURL:     getEnv("DATABASE_URL", ""),
+        JWTSecret:       getEnv("JWT_SECRET", ""),
+        AccessTokenTTL:  getDurationEnv("ACCESS_TOKEN_TTL", 15*time.Minute),
+        RefreshTokenTTL: getDurationEnv("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+        AppURL:          getEnv("APP_URL", "http://localhost:3000"),
+        APIURL:          getEnv("API_URL", "http://localhost:8080"),
+
+        MailerDriver:         getEnv("MAILER", "log"),
+        SMTPHost:             getEnv("SMTP_HOST", "localhost"),
+        SMTPPort:             getEnv("SMTP_PORT", "587"),
+        SMTPUsername:         getEnv("SMTP_USERNAME", ""),
+        SMTPPassword:         getEnv("SMTP_PASSWORD", ""),
+        MailFrom:             getEnv("MAIL_FROM", "no-reply@firewatchers.local"),
+        PasswordResetTTL:     getDurationEnv("PASSWORD_RESET_TTL", time.Hour),
+        EmailVerificationTTL: getDurationEnv("EMAIL_VERIFICATION_TTL", 48*time.Hour),
+
+        LoginMaxAttempts:     getIntEnv("LOGIN_MAX_ATTEMPTS", 5),
+        LoginIPMaxAttempts:   getIntEnv("LOGIN_IP_MAX_ATTEMPTS", 50),
+        LoginBackoffBase:     getDurationEnv("LOGIN_BACKOFF_BASE", time.Second),
+        LoginLockoutDuration: getDurationEnv("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+        LoginLockoutMax:      getDurationEnv("LOGIN_LOCKOUT_MAX", 24*time.Hour),
+        LoginFailureWindow:   getDurationEnv("LOGIN_FAILURE_WINDOW", 24*time.Hour),
+
+        TOTPIssuer:        getEnv("TOTP_ISSUER", "Firewatchers"),
+        TOTPRequiredRoles: getListEnv("TOTP_REQUIRED_ROLES"),
+        LoginChallengeTTL: getDurationEnv("LOGIN_CHALLENGE_TTL", 5*time.Minute),
+
+        OIDCIssuer:            getEnv("OIDC_ISSUER", ""),
+        OIDCClientID:          getEnv("OIDC_CLIENT_ID", ""),
+        OIDCClientSecret:      getEnv("OIDC_CLIENT_SECRET", ""),
+        OIDCRedirectURL:       getEnv("OIDC_REDIRECT_URL", "http://localhost:8080/api/v1/auth/oidc/callback"),
+        OIDCScopes:            getListEnvDefault("OIDC_SCOPES", []string{"openid", "email", "profile"}),
+        OIDCAllowedDomains:    getListEnv("OIDC_ALLOWED_DOMAINS"),
+        OIDCPostLoginRedirect: getEnv("OIDC_POST_LOGIN_REDIRECT", getEnv("APP_URL", "http://localhost:3000")),
+        OIDCTrustIDPMFA:       getBoolEnv("OIDC_TRUST_IDP_MFA", false),
+
+        BlobStoreDriver:    getEnv("BLOB_STORE", "local"),
+        BlobStoreDir:       getEnv("BLOB_STORE_DIR", "./data/blobs"),
+        AvatarMaxBytes:     getIntEnv("AVATAR_MAX_BYTES", 2<<20),
+        AvatarSize:         getIntEnv("AVATAR_SIZE", 256),
+        AttachmentMaxBytes: getIntEnv("ATTACHMENT_MAX_BYTES", 25<<20),
+
+        WebhookPollInterval: getDurationEnv("WEBHOOK_POLL_INTERVAL", 5*time.Second),
+        WebhookTimeout:      getDurationEnv("WEBHOOK_TIMEOUT", 10*time.Second),
+        WebhookMaxAttempts:  getIntEnv("WEBHOOK_MAX_ATTEMPTS", 8),
+        WebhookBackoffBase:  getDurationEnv("WEBHOOK_BACKOFF_BASE", 30*time.Second),
+        WebhookBackoffMax:   getDurationEnv("WEBHOOK_BACKOFF_MAX", time.Hour),
+
+        AlertDefaultType:     getEnv("ALERT_DEFAULT_TYPE", ""),
+        AlertDefaultSeverity: getEnv("ALERT_DEFAULT_SEVERITY", ""),
     }
 }
 
@@ -25,3 +134,55 @@ func getEnv(key, fallback string) string {
     }
     return fallback
 }
+
+// getDurationEnv parses values such as "15m" or "720h", falling back on
+// missing or malformed input.
+func getDurationEnv(key string, fallback time.Duration) time.Duration {
+    if value, exists := os.LookupEnv(key); exists {
+        if duration, err := time.ParseDuration(value); err == nil {
+            return duration
+        }
+    }
+    return fallback
+}
+
+// getIntEnv parses integer values, falling back on missing or malformed input.
+func getIntEnv(key string, fallback int) int {
+    if value, exists := os.LookupEnv(key); exists {
+        if number, err := strconv.Atoi(value); err == nil {
+            return number
+        }
+    }
+    return fallback
+}
+
+// getBoolEnv parses values such as "true" or "0", falling back on missing or
+// malformed input.
+func getBoolEnv(key string, fallback bool) bool {
+    if value, exists := os.LookupEnv(key); exists {
+        if flag, err := strconv.ParseBool(value); err == nil {
+            return flag
+        }
+    }
+    return fallback
+}
+
+// getListEnv splits a comma separated value such as "Admin,Incident Manager",
+// dropping empty entries.
+func getListEnv(key string) []string {
+    var values []string
+    for _, value := range strings.Split(os.Getenv(key), ",") {
+        if value = strings.TrimSpace(value); value != "" {
+            values = append(values, value)
+        }
+    }
+    return values
+}
+
+// getListEnvDefault is getListEnv with a fallback for a missing or empty value.
+func getListEnvDefault(key string, fallback []string) []string {
+    if values := getListEnv(key); len(values) > 0 {
+        return values
+    }
+    return fallback
+}
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS incident_events (
+    id          SERIAL PRIMARY KEY,
+    incident_id INTEGER NOT NULL REFERENCES incidents (id) ON DELETE CASCADE,
+    actor_id    INTEGER REFERENCES users (id),
+    field       VARCHAR(64) NOT NULL,
+    old_value   TEXT,
+    new_value   TEXT,
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_incident_events_incident_id ON incident_events (incident_id, created_at);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS incident_updates (
+    id          SERIAL PRIMARY KEY,
+    incident_id INTEGER NOT NULL REFERENCES incidents (id) ON DELETE CASCADE,
+    author_id   INTEGER NOT NULL REFERENCES users (id),
+    body        TEXT NOT NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_incident_updates_incident_id ON incident_updates (incident_id, created_at);
@@ -0,0 +1,19 @@
+-- The 'simple' configuration avoids language specific stemming, since incidents
+-- are written in a mix of Portuguese and English.
+ALTER TABLE incidents
+    ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(summary, '')), 'B') ||
+        setweight(to_tsvector('simple', coalesce(impact, '')), 'C') ||
+        setweight(to_tsvector('simple', coalesce(treatment, '')), 'C') ||
+        setweight(to_tsvector('simple', coalesce(post_mortem, '')), 'D')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_incidents_search_vector ON incidents USING GIN (search_vector);
+
+ALTER TABLE incident_updates
+    ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+        to_tsvector('simple', body)
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_incident_updates_search_vector ON incident_updates USING GIN (search_vector);
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS sources (
+    id     SERIAL PRIMARY KEY,
+    name   VARCHAR(255) NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+INSERT INTO sources (name)
+SELECT name FROM (VALUES ('Internal'), ('External')) AS seed (name)
+WHERE NOT EXISTS (SELECT 1 FROM sources);
+
+ALTER TABLE types ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE statuses ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE severities ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE areas ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE performance_indicators ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE faulty_systems ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE causes ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_types_name ON types (LOWER(name));
+CREATE UNIQUE INDEX IF NOT EXISTS idx_statuses_name ON statuses (LOWER(name));
+CREATE UNIQUE INDEX IF NOT EXISTS idx_severities_name ON severities (LOWER(name));
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_name ON products (LOWER(name));
+CREATE UNIQUE INDEX IF NOT EXISTS idx_areas_name ON areas (LOWER(name));
+CREATE UNIQUE INDEX IF NOT EXISTS idx_performance_indicators_name ON performance_indicators (LOWER(name));
+CREATE UNIQUE INDEX IF NOT EXISTS idx_faulty_systems_name ON faulty_systems (LOWER(name));
+CREATE UNIQUE INDEX IF NOT EXISTS idx_causes_name ON causes (LOWER(name));
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sources_name ON sources (LOWER(name));
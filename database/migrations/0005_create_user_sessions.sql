@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id                  SERIAL PRIMARY KEY,
+    user_id             INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    token_hash          CHAR(64) NOT NULL UNIQUE,
+    previous_token_hash CHAR(64),
+    user_agent          TEXT,
+    ip                  VARCHAR(64),
+    created_at          TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_used_at        TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at          TIMESTAMP NOT NULL,
+    revoked_at          TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user_id ON user_sessions (user_id);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_previous_token_hash ON user_sessions (previous_token_hash);
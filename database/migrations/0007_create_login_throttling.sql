@@ -0,0 +1,24 @@
+-- Failed login attempts per scope ("account" keyed by lower-cased email,
+-- "ip" keyed by client address).
+CREATE TABLE IF NOT EXISTS login_failures (
+    scope           VARCHAR(16) NOT NULL,
+    subject         VARCHAR(255) NOT NULL,
+    failures        INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    locked_until    TIMESTAMP,
+    PRIMARY KEY (scope, subject)
+);
+
+CREATE TABLE IF NOT EXISTS security_events (
+    id         SERIAL PRIMARY KEY,
+    type       VARCHAR(64) NOT NULL,
+    user_id    INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    actor_id   INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    email      VARCHAR(255),
+    ip         VARCHAR(64),
+    detail     TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_security_events_user_id ON security_events (user_id);
+CREATE INDEX IF NOT EXISTS idx_security_events_type_created_at ON security_events (type, created_at DESC);
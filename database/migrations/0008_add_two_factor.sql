@@ -0,0 +1,13 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret VARCHAR(64);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled_at TIMESTAMP;
+-- Last accepted time step, so a code cannot be replayed within its window
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step BIGINT;
+
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id         SERIAL PRIMARY KEY,
+    user_id    INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash  CHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    used_at    TIMESTAMP,
+    UNIQUE (user_id, code_hash)
+);
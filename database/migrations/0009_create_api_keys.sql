@@ -0,0 +1,18 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_service_account BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id           SERIAL PRIMARY KEY,
+    user_id      INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name         VARCHAR(255) NOT NULL,
+    prefix       VARCHAR(16) NOT NULL,
+    key_hash     CHAR(64) NOT NULL UNIQUE,
+    -- Space separated permissions, e.g. "incidents:read incidents:create"
+    scopes       TEXT NOT NULL,
+    created_by   INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    created_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at   TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at   TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys (user_id);
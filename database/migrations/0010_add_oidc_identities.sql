@@ -0,0 +1,6 @@
+-- Users signing in through single sign-on are matched on the provider's
+-- stable subject, not on their email address
+ALTER TABLE users ADD COLUMN IF NOT EXISTS oidc_issuer  TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS oidc_subject TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_oidc_identity ON users (oidc_issuer, oidc_subject) WHERE oidc_subject IS NOT NULL;
@@ -0,0 +1,6 @@
+-- Deactivated users cannot log in, but their rows stay so incidents,
+-- comments and timelines keep pointing at them
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deactivated_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_users_team ON users (team);
+CREATE INDEX IF NOT EXISTS idx_users_role ON users (role);
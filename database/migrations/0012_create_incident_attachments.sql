@@ -0,0 +1,15 @@
+-- Metadata of files attached to incidents; the content lives in the blob store
+CREATE TABLE IF NOT EXISTS incident_attachments (
+    id          SERIAL PRIMARY KEY,
+    incident_id INTEGER NOT NULL REFERENCES incidents (id) ON DELETE CASCADE,
+    uploader_id INTEGER NOT NULL REFERENCES users (id),
+    filename    VARCHAR(255) NOT NULL,
+    mime_type   VARCHAR(255) NOT NULL,
+    size        BIGINT NOT NULL,
+    -- Hex SHA-256 of the content
+    checksum    CHAR(64) NOT NULL,
+    storage_key VARCHAR(255) NOT NULL UNIQUE,
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_incident_attachments_incident_id ON incident_attachments (incident_id, created_at);
@@ -0,0 +1,41 @@
+-- Default sections offered when a postmortem is started for an incident type
+CREATE TABLE IF NOT EXISTS postmortem_templates (
+    type_id              INTEGER PRIMARY KEY REFERENCES types (id) ON DELETE CASCADE,
+    summary              TEXT NOT NULL DEFAULT '',
+    timeline             TEXT NOT NULL DEFAULT '',
+    root_cause           TEXT NOT NULL DEFAULT '',
+    contributing_factors TEXT NOT NULL DEFAULT '',
+    went_well            TEXT NOT NULL DEFAULT '',
+    went_badly           TEXT NOT NULL DEFAULT '',
+    lessons              TEXT NOT NULL DEFAULT '',
+    updated_at           TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- One postmortem per incident; incidents.post_mortem keeps a rendered copy
+-- so full-text search keeps covering it.
+CREATE TABLE IF NOT EXISTS postmortems (
+    incident_id          INTEGER PRIMARY KEY REFERENCES incidents (id) ON DELETE CASCADE,
+    status               VARCHAR(20) NOT NULL DEFAULT 'draft'
+                         CHECK (status IN ('draft', 'in_review', 'approved')),
+    summary              TEXT NOT NULL DEFAULT '',
+    timeline             TEXT NOT NULL DEFAULT '',
+    root_cause           TEXT NOT NULL DEFAULT '',
+    contributing_factors TEXT NOT NULL DEFAULT '',
+    went_well            TEXT NOT NULL DEFAULT '',
+    went_badly           TEXT NOT NULL DEFAULT '',
+    lessons              TEXT NOT NULL DEFAULT '',
+    author_id            INTEGER NOT NULL REFERENCES users (id),
+    created_at           TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at           TIMESTAMP NOT NULL DEFAULT NOW(),
+    submitted_at         TIMESTAMP,
+    approved_at          TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS postmortem_reviewers (
+    incident_id INTEGER NOT NULL REFERENCES postmortems (incident_id) ON DELETE CASCADE,
+    user_id     INTEGER NOT NULL REFERENCES users (id),
+    approved_at TIMESTAMP,
+    PRIMARY KEY (incident_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_postmortem_reviewers_user_id ON postmortem_reviewers (user_id);
@@ -0,0 +1,24 @@
+-- Follow-up work coming out of incidents and their postmortems
+CREATE TABLE IF NOT EXISTS action_items (
+    id           SERIAL PRIMARY KEY,
+    incident_id  INTEGER NOT NULL REFERENCES incidents (id) ON DELETE CASCADE,
+    title        VARCHAR(255) NOT NULL,
+    owner_id     INTEGER REFERENCES users (id),
+    due_at       TIMESTAMP,
+    priority     VARCHAR(10) NOT NULL DEFAULT 'medium'
+                 CHECK (priority IN ('low', 'medium', 'high')),
+    status       VARCHAR(20) NOT NULL DEFAULT 'open'
+                 CHECK (status IN ('open', 'in_progress', 'done', 'canceled')),
+    -- Blocking items keep the incident from being closed while unresolved
+    blocking     BOOLEAN NOT NULL DEFAULT FALSE,
+    ticket_url   VARCHAR(2048),
+    created_by   INTEGER NOT NULL REFERENCES users (id),
+    created_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_action_items_incident_id ON action_items (incident_id);
+CREATE INDEX IF NOT EXISTS idx_action_items_owner_id ON action_items (owner_id, status);
+CREATE INDEX IF NOT EXISTS idx_action_items_due_at ON action_items (due_at)
+    WHERE status IN ('open', 'in_progress');
@@ -0,0 +1,39 @@
+-- Outbound webhooks for incident lifecycle events
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id         SERIAL PRIMARY KEY,
+    name       VARCHAR(255) NOT NULL,
+    url        VARCHAR(2048) NOT NULL,
+    -- Kept in plain text, since every delivery is signed with it
+    secret     VARCHAR(255) NOT NULL,
+    -- Space separated event types, or "*" for every event
+    events     TEXT NOT NULL,
+    active     BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- The delivery queue doubles as the delivery log
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id              SERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+    event_id        VARCHAR(64) NOT NULL,
+    event_type      VARCHAR(64) NOT NULL,
+    -- The exact bytes that are signed and sent
+    payload         TEXT NOT NULL,
+    status          VARCHAR(20) NOT NULL DEFAULT 'pending'
+                    CHECK (status IN ('pending', 'succeeded', 'failed')),
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_attempt_at TIMESTAMP,
+    response_status INTEGER,
+    response_body   TEXT,
+    error           TEXT,
+    redelivery_of   INTEGER REFERENCES webhook_deliveries (id) ON DELETE SET NULL,
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    delivered_at    TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at)
+    WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription_id ON webhook_deliveries (subscription_id, created_at);
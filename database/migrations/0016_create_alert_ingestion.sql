@@ -0,0 +1,36 @@
+-- Rules mapping alert labels onto incident taxonomies
+CREATE TABLE IF NOT EXISTS alert_mapping_rules (
+    id         SERIAL PRIMARY KEY,
+    label      VARCHAR(255) NOT NULL,
+    -- Matched case-insensitively against the label value
+    value      VARCHAR(255) NOT NULL,
+    field      VARCHAR(20) NOT NULL CHECK (field IN ('severity', 'type', 'source', 'product', 'area')),
+    -- ID of an option in the taxonomy behind field
+    option_id  INTEGER NOT NULL,
+    -- Rules are tried in position order; the first match per field wins
+    position   INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Alerts received from monitoring, linked to the incident they opened or
+-- were folded into
+CREATE TABLE IF NOT EXISTS incident_alerts (
+    id            SERIAL PRIMARY KEY,
+    incident_id   INTEGER NOT NULL REFERENCES incidents (id) ON DELETE CASCADE,
+    source        VARCHAR(50) NOT NULL,
+    fingerprint   VARCHAR(255) NOT NULL,
+    name          VARCHAR(255) NOT NULL,
+    status        VARCHAR(20) NOT NULL CHECK (status IN ('firing', 'resolved')),
+    -- JSON objects of the last notification
+    labels        TEXT NOT NULL,
+    annotations   TEXT NOT NULL,
+    generator_url TEXT,
+    starts_at     TIMESTAMP,
+    ends_at       TIMESTAMP,
+    created_at    TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (incident_id, source, fingerprint)
+);
+
+CREATE INDEX IF NOT EXISTS idx_incident_alerts_fingerprint ON incident_alerts (source, fingerprint);
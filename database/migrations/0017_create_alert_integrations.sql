@@ -0,0 +1,18 @@
+-- Monitoring systems posting alerts, each with its own ingestion token
+CREATE TABLE IF NOT EXISTS alert_integrations (
+    id           SERIAL PRIMARY KEY,
+    name         VARCHAR(255) NOT NULL,
+    kind         VARCHAR(20) NOT NULL CHECK (kind IN ('alertmanager', 'grafana', 'datadog', 'generic')),
+    token_prefix VARCHAR(16) NOT NULL,
+    token_hash   CHAR(64) NOT NULL UNIQUE,
+    -- JSONPath mapping of generic integrations, as a JSON object
+    mapping      TEXT,
+    -- Incidents opened from the integration's alerts are reported by this user
+    reporter_id  INTEGER NOT NULL REFERENCES users (id),
+    active       BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by   INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    created_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMP
+);
+
@@ -0,0 +1,14 @@
+-- triaged_by held a timestamp no workflow ever wrote; it now references the
+-- user who accepted, declined or merged the incident
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_name = 'incidents' AND column_name = 'triaged_by' AND data_type <> 'integer') THEN
+        ALTER TABLE incidents DROP COLUMN triaged_by;
+    END IF;
+END $$;
+
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS triaged_by INTEGER REFERENCES users (id);
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS decline_reason TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_incidents_status ON incidents (LOWER(status));
@@ -0,0 +1,18 @@
+-- Typed links between incidents, read as "incident_id <kind>
+-- linked_incident_id". Only one direction of each kind is stored, and related
+-- links keep the lower ID first so a pair is linked once.
+CREATE TABLE IF NOT EXISTS incident_links (
+    id                 SERIAL PRIMARY KEY,
+    incident_id        INTEGER NOT NULL REFERENCES incidents (id) ON DELETE CASCADE,
+    linked_incident_id INTEGER NOT NULL REFERENCES incidents (id) ON DELETE CASCADE,
+    kind               VARCHAR(20) NOT NULL
+                       CHECK (kind IN ('child_of', 'duplicate_of', 'caused_by', 'related')),
+    created_by         INTEGER NOT NULL REFERENCES users (id),
+    created_at         TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (incident_id <> linked_incident_id),
+    UNIQUE (incident_id, linked_incident_id, kind)
+);
+
+CREATE INDEX IF NOT EXISTS idx_incident_links_linked_incident_id ON incident_links (linked_incident_id);
+-- A sub-incident belongs to a single parent
+CREATE UNIQUE INDEX IF NOT EXISTS idx_incident_links_parent ON incident_links (incident_id) WHERE kind = 'child_of';
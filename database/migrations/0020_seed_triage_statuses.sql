@@ -0,0 +1,7 @@
+-- Alert ingestion opens incidents in Triage, and the triage queue ends them
+-- as Declined or Merged, so these statuses must exist. Installs that already
+-- have them, under any casing, keep theirs.
+INSERT INTO statuses (name, active, position)
+SELECT seed.name, TRUE, (SELECT COALESCE(MAX(position), 0) FROM statuses) + seed.offset_position
+FROM (VALUES ('Triage', 1), ('Declined', 2), ('Merged', 3)) AS seed (name, offset_position)
+ON CONFLICT DO NOTHING;
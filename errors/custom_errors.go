@@ -1,6 +1,10 @@
 package errors
 
-import "net/http"
+import (
+    "fmt"
+    "net/http"
+    "time"
+)
 
 type CustomError interface {
     error
@@ -18,4 +22,79 @@ func (e *AuthenticationError) StatusCode() int {
     return http.StatusUnauthorized
 }
 
+type NotFoundError struct {
+    Msg string
+}
+
+func (e *NotFoundError) Error() string {
+    return e.Msg
+}
+
+func (e *NotFoundError) StatusCode() int {
+    return http.StatusNotFound
+}
+
+type ForbiddenError struct {
+    Msg string
+}
+
+func (e *ForbiddenError) Error() string {
+    return e.Msg
+}
+
+func (e *ForbiddenError) StatusCode() int {
+    return http.StatusForbidden
+}
+
+type ConflictError struct {
+    Msg string
+}
+
+func (e *ConflictError) Error() string {
+    return e.Msg
+}
+
+func (e *ConflictError) StatusCode() int {
+    return http.StatusConflict
+}
+
+// InvalidTransitionError is returned when an incident is asked to move
+// between two statuses that are not connected in the lifecycle graph.
+type InvalidTransitionError struct {
+    From string
+    To   string
+}
+
+func (e *InvalidTransitionError) Error() string {
+    return fmt.Sprintf("cannot move incident from %q to %q", e.From, e.To)
+}
+
+func (e *InvalidTransitionError) StatusCode() int {
+    return http.StatusConflict
+}
+
+// TooManyRequestsError is returned while a caller is throttled. RetryAfter is
+// sent back in the Retry-After header.
+type TooManyRequestsError struct {
+    Msg        string
+    RetryAfter time.Duration
+}
+
+func (e *TooManyRequestsError) Error() string {
+    return e.Msg
+}
+
+func (e *TooManyRequestsError) StatusCode() int {
+    return http.StatusTooManyRequests
+}
+
+// RetryAfterSeconds rounds RetryAfter up to whole seconds, never below one.
+func (e *TooManyRequestsError) RetryAfterSeconds() int {
+    seconds := int((e.RetryAfter + time.Second - 1) / time.Second)
+    if seconds < 1 {
+        return 1
+    }
+    return seconds
+}
+
 // Add other custom errors as needed
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/services"
+)
+
+type ActionItemHandler struct {
+	actionItemService services.ActionItemService
+}
+
+func NewActionItemHandler(actionItemService services.ActionItemService) *ActionItemHandler {
+	return &ActionItemHandler{actionItemService: actionItemService}
+}
+
+func (h *ActionItemHandler) CreateActionItem(c *fiber.Ctx) error {
+	log.Println("CreateActionItem: Started processing request")
+
+	incidentID, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("CreateActionItem: Invalid incident ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid incident ID")
+	}
+
+	input := new(models.ActionItemInput)
+	if err := c.BodyParser(input); err != nil {
+		log.Printf("CreateActionItem: Error parsing request body: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+	input.IncidentID = incidentID
+
+	item, err := h.actionItemService.CreateActionItem(c.Context(), input)
+	if err != nil {
+		log.Printf("CreateActionItem: error while creating action item: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Action item created",
+		"data": fiber.Map{
+			"actionItem": item,
+		},
+	})
+}
+
+func (h *ActionItemHandler) GetActionItems(c *fiber.Ctx) error {
+	log.Println("GetActionItems: Started processing request")
+
+	incidentID, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("GetActionItems: Invalid incident ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid incident ID")
+	}
+
+	items, err := h.actionItemService.GetActionItems(incidentID)
+	if err != nil {
+		log.Printf("GetActionItems: error while retrieving action items: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Fetched action items",
+		"data": fiber.Map{
+			"actionItems": items,
+		},
+	})
+}
+
+func (h *ActionItemHandler) GetActionItem(c *fiber.Ctx) error {
+	log.Println("GetActionItem: Started processing request")
+
+	incidentID, itemID, err := actionItemParams(c)
+	if err != nil {
+		return err
+	}
+
+	item, err := h.actionItemService.GetActionItem(incidentID, itemID)
+	if err != nil {
+		log.Printf("GetActionItem: error while retrieving action item: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Fetched action item",
+		"data": fiber.Map{
+			"actionItem": item,
+		},
+	})
+}
+
+func (h *ActionItemHandler) UpdateActionItem(c *fiber.Ctx) error {
+	log.Println("UpdateActionItem: Started processing request")
+
+	incidentID, itemID, err := actionItemParams(c)
+	if err != nil {
+		return err
+	}
+
+	update := new(models.ActionItemUpdate)
+	if err := c.BodyParser(update); err != nil {
+		log.Printf("UpdateActionItem: Error parsing request body: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+	update.IncidentID = incidentID
+	update.ID = itemID
+
+	item, err := h.actionItemService.UpdateActionItem(c.Context(), update)
+	if err != nil {
+		log.Printf("UpdateActionItem: error while updating action item: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Action item updated",
+		"data": fiber.Map{
+			"actionItem": item,
+		},
+	})
+}
+
+func (h *ActionItemHandler) DeleteActionItem(c *fiber.Ctx) error {
+	log.Println("DeleteActionItem: Started processing request")
+
+	incidentID, itemID, err := actionItemParams(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.actionItemService.DeleteActionItem(c.Context(), incidentID, itemID); err != nil {
+		log.Printf("DeleteActionItem: error while deleting action item: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Action item deleted",
+		"data":  "",
+	})
+}
+
+func (h *ActionItemHandler) GetMyActionItems(c *fiber.Ctx) error {
+	log.Println("GetMyActionItems: Started processing request")
+
+	params := new(models.ActionItemQueryParams)
+	if err := c.QueryParser(params); err != nil {
+		log.Printf("GetMyActionItems: Error parsing query parameters: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+
+	items, pagination, err := h.actionItemService.GetMyActionItems(c.Context(), params)
+	if err != nil {
+		log.Printf("GetMyActionItems: error while retrieving action items: %v", err)
+		return err
+	}
+
+	return actionItemListResponse(c, items, pagination)
+}
+
+func (h *ActionItemHandler) GetOverdueActionItems(c *fiber.Ctx) error {
+	log.Println("GetOverdueActionItems: Started processing request")
+
+	params := new(models.ActionItemQueryParams)
+	if err := c.QueryParser(params); err != nil {
+		log.Printf("GetOverdueActionItems: Error parsing query parameters: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+
+	items, pagination, err := h.actionItemService.GetOverdueActionItems(params)
+	if err != nil {
+		log.Printf("GetOverdueActionItems: error while retrieving action items: %v", err)
+		return err
+	}
+
+	return actionItemListResponse(c, items, pagination)
+}
+
+func actionItemParams(c *fiber.Ctx) (int, int, error) {
+	incidentID, err := c.ParamsInt("id")
+	if err != nil {
+		return 0, 0, fiber.NewError(fiber.StatusBadRequest, "Invalid incident ID")
+	}
+
+	itemID, err := c.ParamsInt("itemId")
+	if err != nil {
+		return 0, 0, fiber.NewError(fiber.StatusBadRequest, "Invalid action item ID")
+	}
+
+	return incidentID, itemID, nil
+}
+
+func actionItemListResponse(c *fiber.Ctx, items []*models.ActionItem, pagination *models.Pagination) error {
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Fetched action items",
+		"data": fiber.Map{
+			"actionItems": items,
+			"pagination":  pagination,
+		},
+	})
+}
@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/services"
+)
+
+type AlertHandler struct {
+	alertService services.AlertService
+}
+
+func NewAlertHandler(alertService services.AlertService) *AlertHandler {
+	return &AlertHandler{alertService: alertService}
+}
+
+func (h *AlertHandler) IngestAlertmanager(c *fiber.Ctx) error {
+	log.Println("IngestAlertmanager: Started processing request")
+
+	result, err := h.alertService.IngestAlertmanager(c.Context(), c.Body())
+	if err != nil {
+		log.Printf("IngestAlertmanager: error while ingesting alerts: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Alerts ingested",
+		"data":  result,
+	})
+}
+
+// IngestAlerts takes a notification from the integration authenticated by
+// AlertTokenMiddleware, in whatever format its kind speaks.
+func (h *AlertHandler) IngestAlerts(c *fiber.Ctx) error {
+	log.Println("IngestAlerts: Started processing request")
+
+	integration, ok := c.Locals("alert_integration").(*models.AlertIntegration)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Missing ingestion token")
+	}
+
+	result, err := h.alertService.Ingest(c.Context(), integration, c.Body())
+	if err != nil {
+		log.Printf("IngestAlerts: error while ingesting alerts of integration %d: %v", integration.ID, err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Alerts ingested",
+		"data":  result,
+	})
+}
+
+func (h *AlertHandler) CreateIntegration(c *fiber.Ctx) error {
+	log.Println("CreateIntegration: Started processing request")
+
+	input := new(models.AlertIntegrationInput)
+	if err := c.BodyParser(input); err != nil {
+		log.Printf("CreateIntegration: Error parsing request body: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+
+	created, err := h.alertService.CreateIntegration(c.Context(), input)
+	if err != nil {
+		log.Printf("CreateIntegration: error while creating alert integration: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Alert integration created",
+		"data": fiber.Map{
+			"integration": created.Integration,
+			"token":       created.Token,
+		},
+	})
+}
+
+func (h *AlertHandler) GetIntegrations(c *fiber.Ctx) error {
+	log.Println("GetIntegrations: Started processing request")
+
+	integrations, err := h.alertService.GetIntegrations()
+	if err != nil {
+		log.Printf("GetIntegrations: error while retrieving alert integrations: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Fetched alert integrations",
+		"data": fiber.Map{
+			"integrations": integrations,
+		},
+	})
+}
+
+func (h *AlertHandler) GetIntegration(c *fiber.Ctx) error {
+	log.Println("GetIntegration: Started processing request")
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("GetIntegration: Invalid integration ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid integration ID")
+	}
+
+	integration, err := h.alertService.GetIntegration(id)
+	if err != nil {
+		log.Printf("GetIntegration: error while retrieving alert integration: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Fetched alert integration",
+		"data": fiber.Map{
+			"integration": integration,
+		},
+	})
+}
+
+func (h *AlertHandler) UpdateIntegration(c *fiber.Ctx) error {
+	log.Println("UpdateIntegration: Started processing request")
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("UpdateIntegration: Invalid integration ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid integration ID")
+	}
+
+	update := new(models.AlertIntegrationUpdate)
+	if err := c.BodyParser(update); err != nil {
+		log.Printf("UpdateIntegration: Error parsing request body: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+	update.ID = id
+
+	integration, err := h.alertService.UpdateIntegration(update)
+	if err != nil {
+		log.Printf("UpdateIntegration: error while updating alert integration: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Alert integration updated",
+		"data": fiber.Map{
+			"integration": integration,
+		},
+	})
+}
+
+func (h *AlertHandler) RotateIntegrationToken(c *fiber.Ctx) error {
+	log.Println("RotateIntegrationToken: Started processing request")
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("RotateIntegrationToken: Invalid integration ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid integration ID")
+	}
+
+	rotated, err := h.alertService.RotateIntegrationToken(id)
+	if err != nil {
+		log.Printf("RotateIntegrationToken: error while rotating ingestion token: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Ingestion token rotated",
+		"data": fiber.Map{
+			"integration": rotated.Integration,
+			"token":       rotated.Token,
+		},
+	})
+}
+
+func (h *AlertHandler) DeleteIntegration(c *fiber.Ctx) error {
+	log.Println("DeleteIntegration: Started processing request")
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("DeleteIntegration: Invalid integration ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid integration ID")
+	}
+
+	if err := h.alertService.DeleteIntegration(id); err != nil {
+		log.Printf("DeleteIntegration: error while deleting alert integration: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Alert integration deleted",
+		"data":  "",
+	})
+}
+
+func (h *AlertHandler) GetMappingRules(c *fiber.Ctx) error {
+	log.Println("GetMappingRules: Started processing request")
+
+	rules, err := h.alertService.GetMappingRules()
+	if err != nil {
+		log.Printf("GetMappingRules: error while retrieving alert mapping rules: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Fetched alert mapping rules",
+		"data": fiber.Map{
+			"rules": rules,
+		},
+	})
+}
+
+func (h *AlertHandler) CreateMappingRule(c *fiber.Ctx) error {
+	log.Println("CreateMappingRule: Started processing request")
+
+	input := new(models.AlertMappingRuleInput)
+	if err := c.BodyParser(input); err != nil {
+		log.Printf("CreateMappingRule: Error parsing request body: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+
+	rule, err := h.alertService.CreateMappingRule(input)
+	if err != nil {
+		log.Printf("CreateMappingRule: error while creating alert mapping rule: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Alert mapping rule created",
+		"data": fiber.Map{
+			"rule": rule,
+		},
+	})
+}
+
+func (h *AlertHandler) UpdateMappingRule(c *fiber.Ctx) error {
+	log.Println("UpdateMappingRule: Started processing request")
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("UpdateMappingRule: Invalid rule ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid rule ID")
+	}
+
+	input := new(models.AlertMappingRuleInput)
+	if err := c.BodyParser(input); err != nil {
+		log.Printf("UpdateMappingRule: Error parsing request body: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+	input.ID = id
+
+	rule, err := h.alertService.UpdateMappingRule(input)
+	if err != nil {
+		log.Printf("UpdateMappingRule: error while updating alert mapping rule: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Alert mapping rule updated",
+		"data": fiber.Map{
+			"rule": rule,
+		},
+	})
+}
+
+func (h *AlertHandler) DeleteMappingRule(c *fiber.Ctx) error {
+	log.Println("DeleteMappingRule: Started processing request")
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("DeleteMappingRule: Invalid rule ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid rule ID")
+	}
+
+	if err := h.alertService.DeleteMappingRule(id); err != nil {
+		log.Printf("DeleteMappingRule: error while deleting alert mapping rule: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Alert mapping rule deleted",
+		"data":  "",
+	})
+}
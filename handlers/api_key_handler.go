@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/services"
+)
+
+type APIKeyHandler struct {
+	apiKeyService services.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService: apiKeyService}
+}
+
+// ownerID is the service account in /service-accounts/:id routes and the
+// caller everywhere else.
+func ownerID(c *fiber.Ctx) (int, error) {
+	if c.Params("id") == "" {
+		userID, _ := c.Locals("user_id").(int)
+		return userID, nil
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+	}
+	return id, nil
+}
+
+func (h *APIKeyHandler) CreateAPIKey(c *fiber.Ctx) error {
+	log.Println("CreateAPIKey: Started processing request")
+
+	owner, err := ownerID(c)
+	if err != nil {
+		return err
+	}
+
+	input := new(models.APIKeyInput)
+	if err := c.BodyParser(input); err != nil {
+		log.Printf("CreateAPIKey: Error parsing request body: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+
+	created, err := h.apiKeyService.CreateAPIKey(c.Context(), owner, input)
+	if err != nil {
+		log.Printf("CreateAPIKey: Error creating API key: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error": false,
+		"msg":   "API key created, copy it now as it will not be shown again",
+		"data":  created,
+	})
+}
+
+func (h *APIKeyHandler) ListAPIKeys(c *fiber.Ctx) error {
+	log.Println("ListAPIKeys: Started processing request")
+
+	owner, err := ownerID(c)
+	if err != nil {
+		return err
+	}
+
+	keys, err := h.apiKeyService.ListAPIKeys(c.Context(), owner)
+	if err != nil {
+		log.Printf("ListAPIKeys: Error retrieving API keys: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Fetched API keys",
+		"data": fiber.Map{
+			"apiKeys": keys,
+		},
+	})
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(c *fiber.Ctx) error {
+	log.Println("RevokeAPIKey: Started processing request")
+
+	owner, err := ownerID(c)
+	if err != nil {
+		return err
+	}
+
+	keyID, err := c.ParamsInt("keyId")
+	if err != nil {
+		log.Printf("RevokeAPIKey: Invalid API key ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid API key ID")
+	}
+
+	if err := h.apiKeyService.RevokeAPIKey(c.Context(), owner, keyID); err != nil {
+		log.Printf("RevokeAPIKey: Error revoking API key: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "API key revoked",
+		"data":  "",
+	})
+}
+
+func (h *APIKeyHandler) CreateServiceAccount(c *fiber.Ctx) error {
+	log.Println("CreateServiceAccount: Started processing request")
+
+	input := new(models.ServiceAccountInput)
+	if err := c.BodyParser(input); err != nil {
+		log.Printf("CreateServiceAccount: Error parsing request body: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+
+	account, err := h.apiKeyService.CreateServiceAccount(input)
+	if err != nil {
+		log.Printf("CreateServiceAccount: Error creating service account: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Service account created",
+		"data":  account,
+	})
+}
+
+func (h *APIKeyHandler) GetServiceAccounts(c *fiber.Ctx) error {
+	log.Println("GetServiceAccounts: Started processing request")
+
+	accounts, err := h.apiKeyService.GetServiceAccounts()
+	if err != nil {
+		log.Printf("GetServiceAccounts: Error retrieving service accounts: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Fetched service accounts",
+		"data": fiber.Map{
+			"serviceAccounts": accounts,
+		},
+	})
+}
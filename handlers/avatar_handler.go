@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/services"
+)
+
+type AvatarHandler struct {
+	avatarService services.AvatarService
+}
+
+func NewAvatarHandler(avatarService services.AvatarService) *AvatarHandler {
+	return &AvatarHandler{avatarService: avatarService}
+}
+
+// UploadAvatar takes the image from the "avatar" field of a multipart form.
+func (h *AvatarHandler) UploadAvatar(c *fiber.Ctx) error {
+	log.Println("UploadAvatar: Started processing request")
+
+	fileHeader, err := c.FormFile("avatar")
+	if err != nil {
+		log.Printf("UploadAvatar: Error reading form file: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+
+	maxBytes := h.avatarService.MaxBytes()
+	if fileHeader.Size > int64(maxBytes) {
+		return fiber.NewError(fiber.StatusRequestEntityTooLarge, fmt.Sprintf("Avatar must be at most %d bytes", maxBytes))
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		log.Printf("UploadAvatar: Error opening form file: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, int64(maxBytes)+1))
+	if err != nil {
+		log.Printf("UploadAvatar: Error reading form file: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+
+	user, err := h.avatarService.UploadAvatar(c.Context(), content)
+	if err != nil {
+		log.Printf("UploadAvatar: Error uploading avatar: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Updated avatar",
+		"data":  user,
+	})
+}
+
+// GetAvatar serves the thumbnail, or the uploaded image with ?size=original.
+// Avatar URLs carry a version, so the response can be cached for long.
+func (h *AvatarHandler) GetAvatar(c *fiber.Ctx) error {
+	userID, err := c.ParamsInt("id")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	content, contentType, err := h.avatarService.GetAvatar(c.Context(), userID, c.Query("size") == "original")
+	if err != nil {
+		log.Printf("GetAvatar: Error retrieving avatar: %v", err)
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderCacheControl, "public, max-age=86400")
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	return c.Status(fiber.StatusOK).Send(content)
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/services"
+)
+
+// inlineAttachmentTypes are shown by the browser; anything else, HTML and SVG
+// included, is always downloaded so it cannot run scripts on our origin.
+var inlineAttachmentTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+	"text/plain": true,
+}
+
+type IncidentAttachmentHandler struct {
+	attachmentService services.IncidentAttachmentService
+}
+
+func NewIncidentAttachmentHandler(attachmentService services.IncidentAttachmentService) *IncidentAttachmentHandler {
+	return &IncidentAttachmentHandler{attachmentService: attachmentService}
+}
+
+// UploadAttachment takes the file from the "file" field of a multipart form.
+func (h *IncidentAttachmentHandler) UploadAttachment(c *fiber.Ctx) error {
+	log.Println("UploadAttachment: Started processing request")
+
+	incidentID, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("UploadAttachment: Invalid incident ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid incident ID")
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		log.Printf("UploadAttachment: Error reading form file: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+
+	if maxBytes := h.attachmentService.MaxBytes(); fileHeader.Size > int64(maxBytes) {
+		return fiber.NewError(fiber.StatusRequestEntityTooLarge, fmt.Sprintf("Attachments must be at most %d bytes", maxBytes))
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		log.Printf("UploadAttachment: Error opening form file: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+	defer file.Close()
+
+	attachment, err := h.attachmentService.UploadAttachment(c.Context(), &models.AttachmentUpload{
+		IncidentID: incidentID,
+		Filename:   fileHeader.Filename,
+		Size:       fileHeader.Size,
+		Content:    file,
+	})
+	if err != nil {
+		log.Printf("UploadAttachment: error while uploading attachment: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Attachment uploaded",
+		"data": fiber.Map{
+			"attachment": attachment,
+		},
+	})
+}
+
+func (h *IncidentAttachmentHandler) GetAttachments(c *fiber.Ctx) error {
+	log.Println("GetAttachments: Started processing request")
+
+	incidentID, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("GetAttachments: Invalid incident ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid incident ID")
+	}
+
+	attachments, err := h.attachmentService.GetAttachments(incidentID)
+	if err != nil {
+		log.Printf("GetAttachments: error while retrieving attachments: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Fetched incident attachments",
+		"data": fiber.Map{
+			"attachments": attachments,
+		},
+	})
+}
+
+func (h *IncidentAttachmentHandler) DownloadAttachment(c *fiber.Ctx) error {
+	log.Println("DownloadAttachment: Started processing request")
+
+	incidentID, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("DownloadAttachment: Invalid incident ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid incident ID")
+	}
+
+	attachmentID, err := c.ParamsInt("attachmentId")
+	if err != nil {
+		log.Printf("DownloadAttachment: Invalid attachment ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid attachment ID")
+	}
+
+	attachment, content, err := h.attachmentService.OpenAttachment(c.Context(), incidentID, attachmentID)
+	if err != nil {
+		log.Printf("DownloadAttachment: error while opening attachment: %v", err)
+		return err
+	}
+
+	disposition := "attachment"
+	if baseType, _, _ := strings.Cut(attachment.MimeType, ";"); inlineAttachmentTypes[baseType] {
+		disposition = "inline"
+	}
+
+	c.Set(fiber.HeaderContentType, attachment.MimeType)
+	c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Filename}))
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderETag, `"`+attachment.Checksum+`"`)
+
+	// Fiber closes the stream once it has been sent
+	return c.Status(fiber.StatusOK).SendStream(content, int(attachment.Size))
+}
+
+func (h *IncidentAttachmentHandler) DeleteAttachment(c *fiber.Ctx) error {
+	log.Println("DeleteAttachment: Started processing request")
+
+	incidentID, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("DeleteAttachment: Invalid incident ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid incident ID")
+	}
+
+	attachmentID, err := c.ParamsInt("attachmentId")
+	if err != nil {
+		log.Printf("DeleteAttachment: Invalid attachment ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid attachment ID")
+	}
+
+	if err := h.attachmentService.DeleteAttachment(c.Context(), incidentID, attachmentID); err != nil {
+		log.Printf("DeleteAttachment: error while deleting attachment: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Attachment deleted",
+		"data":  "",
+	})
+}
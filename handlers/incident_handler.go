@@ -54,7 +54,7 @@ func (h *IncidentHandler) GetIncidents(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid input format")
 	}
 
-	incidents, err := h.incidentService.GetIncidents(params)
+	incidents, pagination, err := h.incidentService.GetIncidents(params)
 	
 	if err != nil {
 		log.Printf("GetIncidents: Error fetching incidents: %v", err)
@@ -67,7 +67,8 @@ func (h *IncidentHandler) GetIncidents(c *fiber.Ctx) error {
 		"error": "false",
 		"msg":   "Fetched incidents",
 		"data": fiber.Map{
-			"incidents": incidents,
+			"incidents":  incidents,
+			"pagination": pagination,
 		},
 	})
 }
@@ -110,7 +111,7 @@ func (h *IncidentHandler) UpdateIncidentSummary(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
 	}
 
-	if err := h.incidentService.UpdateIncidentSummary(IncidentSummary); err != nil {
+	if err := h.incidentService.UpdateIncidentSummary(c.Context(), IncidentSummary); err != nil {
 		log.Printf("UpdateIncidentSummary: error while updating incident summary: %v", err)
 		return err;
 	}
@@ -133,7 +134,7 @@ func (h *IncidentHandler) UpdateIncidentStatus(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
 	}
 
-	if err := h.incidentService.UpdateIncidentStatus(IncidentStatus); err != nil {
+	if err := h.incidentService.UpdateIncidentStatus(c.Context(), IncidentStatus); err != nil {
 		log.Printf("UpdateIncidentStatus: error while updating incident summary: %v", err)
 		return err;
 	}
@@ -155,7 +156,7 @@ func (h *IncidentHandler) UpdateIncidentSeverity(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
 	}
 
-	if err := h.incidentService.UpdateIncidentSeverity(incidentSeverity); err != nil {
+	if err := h.incidentService.UpdateIncidentSeverity(c.Context(), incidentSeverity); err != nil {
 		log.Printf("UpdateIncidentSeverity: error while updating incident severity: %v", err)
 		return err
 	}
@@ -177,7 +178,7 @@ func (h *IncidentHandler) UpdateIncidentType(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
 	}
 
-	if err := h.incidentService.UpdateIncidentType(incidentType); err != nil {
+	if err := h.incidentService.UpdateIncidentType(c.Context(), incidentType); err != nil {
 		log.Printf("UpdateIncidentType: error while updating incident type: %v", err)
 		return err
 	}
@@ -201,7 +202,7 @@ func (h *IncidentHandler) UpdateIncidentRoles(c *fiber.Ctx) error {
 
 	fmt.Println(incidentRoles)
 
-	if err := h.incidentService.UpdateIncidentRoles(incidentRoles); err != nil {
+	if err := h.incidentService.UpdateIncidentRoles(c.Context(), incidentRoles); err != nil {
 		log.Printf("UpdateIncidentRoles: error while updating incident roles: %v", err)
 		return err
 	}
@@ -223,7 +224,7 @@ func (h *IncidentHandler) UpdateIncidentCustomFields(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
 	}
 
-	if err := h.incidentService.UpdateIncidentCustomFields(incidentCustomFields); err != nil {
+	if err := h.incidentService.UpdateIncidentCustomFields(c.Context(), incidentCustomFields); err != nil {
 		log.Printf("UpdateIncidentCustomFields: error while updating incident custom fields: %v", err)
 		return err
 	}
@@ -234,3 +235,132 @@ func (h *IncidentHandler) UpdateIncidentCustomFields(c *fiber.Ctx) error {
 		"data":  "",
 	})
 }
+
+func (h *IncidentHandler) GetIncidentTimeline(c *fiber.Ctx) error {
+	log.Println("GetIncidentTimeline: Started processing request")
+
+	incidentID, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("GetIncidentTimeline: Invalid incident ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid incident ID")
+	}
+
+	events, err := h.incidentService.GetIncidentTimeline(incidentID)
+	if err != nil {
+		log.Printf("GetIncidentTimeline: error while retrieving timeline: %v", err)
+		return err
+	}
+
+	log.Printf("GetIncidentTimeline: Successfully fetched %d events", len(events))
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Fetched incident timeline",
+		"data": fiber.Map{
+			"events": events,
+		},
+	})
+}
+
+func (h *IncidentHandler) GetTriageQueue(c *fiber.Ctx) error {
+	log.Println("GetTriageQueue: Started processing request")
+
+	queue, err := h.incidentService.GetTriageQueue()
+	if err != nil {
+		log.Printf("GetTriageQueue: error while retrieving triage queue: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Fetched triage queue",
+		"data": fiber.Map{
+			"incidents": queue,
+		},
+	})
+}
+
+func (h *IncidentHandler) AcceptIncident(c *fiber.Ctx) error {
+	log.Println("AcceptIncident: Started processing request")
+
+	incidentID, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("AcceptIncident: Invalid incident ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid incident ID")
+	}
+
+	accept := new(models.IncidentAccept)
+	if err := c.BodyParser(accept); err != nil {
+		log.Printf("AcceptIncident: Error parsing request body: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+	accept.ID = incidentID
+
+	if err := h.incidentService.AcceptIncident(c.Context(), accept); err != nil {
+		log.Printf("AcceptIncident: error while accepting incident: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Incident accepted",
+		"data":  "",
+	})
+}
+
+func (h *IncidentHandler) DeclineIncident(c *fiber.Ctx) error {
+	log.Println("DeclineIncident: Started processing request")
+
+	incidentID, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("DeclineIncident: Invalid incident ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid incident ID")
+	}
+
+	decline := new(models.IncidentDecline)
+	if err := c.BodyParser(decline); err != nil {
+		log.Printf("DeclineIncident: Error parsing request body: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+	decline.ID = incidentID
+
+	if err := h.incidentService.DeclineIncident(c.Context(), decline); err != nil {
+		log.Printf("DeclineIncident: error while declining incident: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Incident declined",
+		"data":  "",
+	})
+}
+
+func (h *IncidentHandler) MergeIncident(c *fiber.Ctx) error {
+	log.Println("MergeIncident: Started processing request")
+
+	incidentID, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("MergeIncident: Invalid incident ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid incident ID")
+	}
+
+	merge := new(models.IncidentMerge)
+	if err := c.BodyParser(merge); err != nil {
+		log.Printf("MergeIncident: Error parsing request body: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+	merge.ID = incidentID
+
+	if err := h.incidentService.MergeIncident(c.Context(), merge); err != nil {
+		log.Printf("MergeIncident: error while merging incident: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Incident merged",
+		"data": fiber.Map{
+			"incidentID": merge.Into,
+		},
+	})
+}
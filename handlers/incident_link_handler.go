@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/services"
+)
+
+type IncidentLinkHandler struct {
+	incidentLinkService services.IncidentLinkService
+}
+
+func NewIncidentLinkHandler(incidentLinkService services.IncidentLinkService) *IncidentLinkHandler {
+	return &IncidentLinkHandler{incidentLinkService: incidentLinkService}
+}
+
+func (h *IncidentLinkHandler) CreateIncidentLink(c *fiber.Ctx) error {
+	log.Println("CreateIncidentLink: Started processing request")
+
+	incidentID, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("CreateIncidentLink: Invalid incident ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid incident ID")
+	}
+
+	input := new(models.IncidentLinkInput)
+	if err := c.BodyParser(input); err != nil {
+		log.Printf("CreateIncidentLink: Error parsing request body: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+	input.IncidentID = incidentID
+
+	link, err := h.incidentLinkService.CreateIncidentLink(c.Context(), input)
+	if err != nil {
+		log.Printf("CreateIncidentLink: error while creating incident link: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Incident link created",
+		"data": fiber.Map{
+			"link": link,
+		},
+	})
+}
+
+func (h *IncidentLinkHandler) GetIncidentLinks(c *fiber.Ctx) error {
+	log.Println("GetIncidentLinks: Started processing request")
+
+	incidentID, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("GetIncidentLinks: Invalid incident ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid incident ID")
+	}
+
+	links, err := h.incidentLinkService.GetIncidentLinks(incidentID)
+	if err != nil {
+		log.Printf("GetIncidentLinks: error while retrieving incident links: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Fetched incident links",
+		"data": fiber.Map{
+			"links": links,
+		},
+	})
+}
+
+func (h *IncidentLinkHandler) DeleteIncidentLink(c *fiber.Ctx) error {
+	log.Println("DeleteIncidentLink: Started processing request")
+
+	incidentID, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("DeleteIncidentLink: Invalid incident ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid incident ID")
+	}
+
+	linkID, err := c.ParamsInt("linkId")
+	if err != nil {
+		log.Printf("DeleteIncidentLink: Invalid link ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid link ID")
+	}
+
+	if err := h.incidentLinkService.DeleteIncidentLink(c.Context(), incidentID, linkID); err != nil {
+		log.Printf("DeleteIncidentLink: error while deleting incident link: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Incident link deleted",
+		"data":  "",
+	})
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/services"
+)
+
+type IncidentUpdateHandler struct {
+	incidentUpdateService services.IncidentUpdateService
+}
+
+func NewIncidentUpdateHandler(incidentUpdateService services.IncidentUpdateService) *IncidentUpdateHandler {
+	return &IncidentUpdateHandler{incidentUpdateService: incidentUpdateService}
+}
+
+func (h *IncidentUpdateHandler) CreateIncidentUpdate(c *fiber.Ctx) error {
+	log.Println("CreateIncidentUpdate: Started processing request")
+
+	incidentID, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("CreateIncidentUpdate: Invalid incident ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid incident ID")
+	}
+
+	input := new(models.IncidentUpdateInput)
+	if err := c.BodyParser(input); err != nil {
+		log.Printf("CreateIncidentUpdate: Error parsing request body: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+	input.IncidentID = incidentID
+
+	update, err := h.incidentUpdateService.CreateIncidentUpdate(c.Context(), input)
+	if err != nil {
+		log.Printf("CreateIncidentUpdate: error while creating update: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Incident update created",
+		"data": fiber.Map{
+			"update": update,
+		},
+	})
+}
+
+func (h *IncidentUpdateHandler) GetIncidentUpdates(c *fiber.Ctx) error {
+	log.Println("GetIncidentUpdates: Started processing request")
+
+	incidentID, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("GetIncidentUpdates: Invalid incident ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid incident ID")
+	}
+
+	updates, err := h.incidentUpdateService.GetIncidentUpdates(incidentID)
+	if err != nil {
+		log.Printf("GetIncidentUpdates: error while retrieving updates: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Fetched incident updates",
+		"data": fiber.Map{
+			"updates": updates,
+		},
+	})
+}
+
+func (h *IncidentUpdateHandler) EditIncidentUpdate(c *fiber.Ctx) error {
+	log.Println("EditIncidentUpdate: Started processing request")
+
+	incidentID, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("EditIncidentUpdate: Invalid incident ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid incident ID")
+	}
+
+	updateID, err := c.ParamsInt("updateId")
+	if err != nil {
+		log.Printf("EditIncidentUpdate: Invalid update ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid update ID")
+	}
+
+	input := new(models.IncidentUpdateInput)
+	if err := c.BodyParser(input); err != nil {
+		log.Printf("EditIncidentUpdate: Error parsing request body: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+	input.ID = updateID
+	input.IncidentID = incidentID
+
+	update, err := h.incidentUpdateService.EditIncidentUpdate(c.Context(), input)
+	if err != nil {
+		log.Printf("EditIncidentUpdate: error while editing update: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Incident update edited",
+		"data": fiber.Map{
+			"update": update,
+		},
+	})
+}
+
+func (h *IncidentUpdateHandler) DeleteIncidentUpdate(c *fiber.Ctx) error {
+	log.Println("DeleteIncidentUpdate: Started processing request")
+
+	incidentID, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("DeleteIncidentUpdate: Invalid incident ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid incident ID")
+	}
+
+	updateID, err := c.ParamsInt("updateId")
+	if err != nil {
+		log.Printf("DeleteIncidentUpdate: Invalid update ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid update ID")
+	}
+
+	if err := h.incidentUpdateService.DeleteIncidentUpdate(c.Context(), incidentID, updateID); err != nil {
+		log.Printf("DeleteIncidentUpdate: error while deleting update: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Incident update deleted",
+		"data":  "",
+	})
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"log"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/services"
+)
+
+const (
+	oidcStateCookie = "oidc_state"
+	oidcStatePath   = "/api/v1/auth/oidc"
+	// How long the user has to finish logging in at the provider
+	oidcStateTTL = 10 * time.Minute
+)
+
+type OIDCHandler struct {
+	oidcService      services.OIDCService
+	sessionService   services.SessionService
+	twoFactorService services.TwoFactorService
+}
+
+func NewOIDCHandler(oidcService services.OIDCService, sessionService services.SessionService, twoFactorService services.TwoFactorService) *OIDCHandler {
+	return &OIDCHandler{oidcService: oidcService, sessionService: sessionService, twoFactorService: twoFactorService}
+}
+
+func (h *OIDCHandler) Login(c *fiber.Ctx) error {
+	log.Println("OIDCLogin: Started processing request")
+
+	login, err := h.oidcService.BeginLogin(c.Context())
+	if err != nil {
+		log.Printf("OIDCLogin: Error starting single sign-on: %v", err)
+		return err
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcStateCookie,
+		Value:    login.State,
+		Path:     oidcStatePath,
+		Expires:  time.Now().Add(oidcStateTTL),
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+
+	return c.Redirect(login.URL, fiber.StatusFound)
+}
+
+// Callback finishes single sign-on with the same cookies as a password Login
+// and sends the browser back to the app. Users who need two-factor
+// authentication get a challenge instead of a session, as with Login, unless
+// OIDC_TRUST_IDP_MFA leaves it to the identity provider.
+func (h *OIDCHandler) Callback(c *fiber.Ctx) error {
+	log.Println("OIDCCallback: Started processing request")
+
+	callback := new(models.OIDCCallback)
+	if err := c.QueryParser(callback); err != nil {
+		log.Printf("OIDCCallback: Error parsing query: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+	callback.StoredState = c.Cookies(oidcStateCookie)
+
+	// The state is single use whatever the outcome
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcStateCookie,
+		Path:     oidcStatePath,
+		Expires:  time.Unix(0, 0),
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+
+	user, err := h.oidcService.CompleteLogin(c.Context(), callback, c.IP())
+	if err != nil {
+		log.Printf("OIDCCallback: Error completing single sign-on: %v", err)
+		return err
+	}
+
+	if !h.oidcService.TrustsProviderMFA() {
+		challenge, err := h.twoFactorService.StartLoginChallenge(user)
+		if err != nil {
+			log.Printf("OIDCCallback: Error starting two-factor challenge: %v", err)
+			return fiber.NewError(fiber.StatusInternalServerError)
+		}
+
+		if challenge != nil {
+			log.Printf("OIDCCallback: Two-factor challenge issued for user ID %d", user.ID)
+			return c.Redirect(twoFactorRedirect(h.oidcService.PostLoginRedirect(), challenge), fiber.StatusFound)
+		}
+	}
+
+	tokens, err := h.sessionService.CreateSession(user, c.Get(fiber.HeaderUserAgent), c.IP())
+	if err != nil {
+		log.Printf("OIDCCallback: Error creating session: %v", err)
+		return fiber.NewError(fiber.StatusInternalServerError)
+	}
+
+	setAuthCookies(c, tokens)
+
+	log.Printf("OIDCCallback: User ID %d logged in through single sign-on", user.ID)
+
+	return c.Redirect(h.oidcService.PostLoginRedirect(), fiber.StatusFound)
+}
+
+// twoFactorRedirect sends the browser to the app with the challenge to finish
+// at /api/v1/auth/2fa/verify (or /enroll). It travels in the fragment, which
+// stays out of server logs and Referer headers.
+func twoFactorRedirect(app string, challenge *models.TwoFactorChallenge) string {
+	fragment := url.Values{
+		"challenge":          {challenge.Challenge},
+		"enrollmentRequired": {strconv.FormatBool(challenge.EnrollmentRequired)},
+	}
+	return app + "#" + fragment.Encode()
+}
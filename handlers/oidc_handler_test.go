@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/middlewares"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockOIDCService is a mock implementation of the OIDCService interface
+type MockOIDCService struct {
+	mock.Mock
+}
+
+func (m *MockOIDCService) Enabled() bool {
+	return m.Called().Bool(0)
+}
+
+func (m *MockOIDCService) BeginLogin(ctx context.Context) (*models.OIDCLogin, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(*models.OIDCLogin), args.Error(1)
+}
+
+func (m *MockOIDCService) CompleteLogin(ctx context.Context, callback *models.OIDCCallback, ip string) (*models.User, error) {
+	args := m.Called(ctx, callback, ip)
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockOIDCService) PostLoginRedirect() string {
+	return m.Called().String(0)
+}
+
+func (m *MockOIDCService) TrustsProviderMFA() bool {
+	return m.Called().Bool(0)
+}
+
+func TestOIDCCallback(t *testing.T) {
+	tokens := &models.AuthTokens{
+		AccessToken:      "access",
+		AccessExpiresAt:  time.Now().Add(time.Minute),
+		RefreshToken:     "refresh",
+		RefreshExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	tests := []struct {
+		name             string
+		trustProviderMFA bool
+		mockBehavior     func(*MockTwoFactorService, *MockSessionService)
+		expectedLocation string
+		expectedSession  bool
+	}{
+		{
+			name: "Two-Factor Challenge",
+			mockBehavior: func(twoFactor *MockTwoFactorService, sessions *MockSessionService) {
+				twoFactor.On("StartLoginChallenge", mock.AnythingOfType("*models.User")).Return(&models.TwoFactorChallenge{
+					Challenge:          "challenge",
+					EnrollmentRequired: true,
+				}, nil)
+			},
+			expectedLocation: "https://app.example.com#challenge=challenge&enrollmentRequired=true",
+		},
+		{
+			name: "No Two-Factor Needed",
+			mockBehavior: func(twoFactor *MockTwoFactorService, sessions *MockSessionService) {
+				twoFactor.On("StartLoginChallenge", mock.AnythingOfType("*models.User")).Return((*models.TwoFactorChallenge)(nil), nil)
+				sessions.On("CreateSession", mock.AnythingOfType("*models.User"), mock.Anything, mock.Anything).Return(tokens, nil)
+			},
+			expectedLocation: "https://app.example.com",
+			expectedSession:  true,
+		},
+		{
+			name:             "Provider MFA Trusted",
+			trustProviderMFA: true,
+			mockBehavior: func(twoFactor *MockTwoFactorService, sessions *MockSessionService) {
+				sessions.On("CreateSession", mock.AnythingOfType("*models.User"), mock.Anything, mock.Anything).Return(tokens, nil)
+			},
+			expectedLocation: "https://app.example.com",
+			expectedSession:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New(fiber.Config{
+				ErrorHandler: middlewares.ErrorHandler,
+			})
+			mockOIDCService := new(MockOIDCService)
+			mockSessionService := new(MockSessionService)
+			mockTwoFactorService := new(MockTwoFactorService)
+			handler := NewOIDCHandler(mockOIDCService, mockSessionService, mockTwoFactorService)
+
+			app.Get("/callback", handler.Callback)
+
+			mockOIDCService.On("CompleteLogin", mock.Anything, mock.AnythingOfType("*models.OIDCCallback"), mock.Anything).Return(&models.User{ID: 1, Name: "John Doe"}, nil)
+			mockOIDCService.On("TrustsProviderMFA").Return(tt.trustProviderMFA)
+			mockOIDCService.On("PostLoginRedirect").Return("https://app.example.com")
+			tt.mockBehavior(mockTwoFactorService, mockSessionService)
+
+			req := httptest.NewRequest(http.MethodGet, "/callback?code=code&state=state", nil)
+			resp, err := app.Test(req)
+			assert.NoError(t, err)
+
+			assert.Equal(t, http.StatusFound, resp.StatusCode)
+			assert.Equal(t, tt.expectedLocation, resp.Header.Get(fiber.HeaderLocation))
+
+			var sessionCookie bool
+			for _, cookie := range resp.Cookies() {
+				if cookie.Name == accessTokenCookie && cookie.Value != "" {
+					sessionCookie = true
+				}
+			}
+			assert.Equal(t, tt.expectedSession, sessionCookie)
+
+			mockOIDCService.AssertExpectations(t)
+			mockSessionService.AssertExpectations(t)
+			mockTwoFactorService.AssertExpectations(t)
+		})
+	}
+}
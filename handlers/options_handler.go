@@ -178,13 +178,17 @@ func (h *OptionsHandler) GetCauses(c *fiber.Ctx) error {
 }
 
 
-//remove this later on
 func (h *OptionsHandler) GetSources(c *fiber.Ctx) error {
-    sources := []models.Source{
-        {ID: 1, Name: "Internal"},
-        {ID: 2, Name: "External"},
+    log.Println("GetSources: Started processing request")
+
+    sources, err := h.optionsService.GetSources()
+    if err != nil {
+        log.Printf("GetSources: Error fetching sources: %v", err)
+        return fiber.NewError(fiber.StatusInternalServerError, "Error fetching sources")
     }
 
+    log.Printf("GetSources: Successfully fetched %d sources", len(sources))
+
     return c.Status(fiber.StatusOK).JSON(fiber.Map{
         "error": false,
         "msg":   "Fetched sources",
@@ -192,4 +196,138 @@ func (h *OptionsHandler) GetSources(c *fiber.Ctx) error {
             "source": sources,
         },
     })
-}
\ No newline at end of file
+}
+
+func (h *OptionsHandler) ListOptions(c *fiber.Ctx) error {
+	log.Println("ListOptions: Started processing request")
+
+	options, err := h.optionsService.ListOptions(c.Params("taxonomy"))
+	if err != nil {
+		log.Printf("ListOptions: Error fetching options: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Fetched options",
+		"data": fiber.Map{
+			"options": options,
+		},
+	})
+}
+
+func (h *OptionsHandler) CreateOption(c *fiber.Ctx) error {
+	log.Println("CreateOption: Started processing request")
+
+	input := new(models.OptionInput)
+	if err := c.BodyParser(input); err != nil {
+		log.Printf("CreateOption: Error parsing request body: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+	input.Taxonomy = c.Params("taxonomy")
+
+	option, err := h.optionsService.CreateOption(input)
+	if err != nil {
+		log.Printf("CreateOption: Error creating option: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Option created",
+		"data": fiber.Map{
+			"option": option,
+		},
+	})
+}
+
+func (h *OptionsHandler) RenameOption(c *fiber.Ctx) error {
+	log.Println("RenameOption: Started processing request")
+
+	optionID, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("RenameOption: Invalid option ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid option ID")
+	}
+
+	input := new(models.OptionInput)
+	if err := c.BodyParser(input); err != nil {
+		log.Printf("RenameOption: Error parsing request body: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+	input.Taxonomy = c.Params("taxonomy")
+	input.ID = optionID
+
+	option, err := h.optionsService.RenameOption(input)
+	if err != nil {
+		log.Printf("RenameOption: Error renaming option: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Option renamed",
+		"data": fiber.Map{
+			"option": option,
+		},
+	})
+}
+
+func (h *OptionsHandler) ReorderOptions(c *fiber.Ctx) error {
+	log.Println("ReorderOptions: Started processing request")
+
+	order := new(models.OptionOrder)
+	if err := c.BodyParser(order); err != nil {
+		log.Printf("ReorderOptions: Error parsing request body: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+	order.Taxonomy = c.Params("taxonomy")
+
+	options, err := h.optionsService.ReorderOptions(order)
+	if err != nil {
+		log.Printf("ReorderOptions: Error reordering options: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Options reordered",
+		"data": fiber.Map{
+			"options": options,
+		},
+	})
+}
+
+func (h *OptionsHandler) ActivateOption(c *fiber.Ctx) error {
+	return h.setOptionActive(c, true)
+}
+
+func (h *OptionsHandler) DeactivateOption(c *fiber.Ctx) error {
+	return h.setOptionActive(c, false)
+}
+
+func (h *OptionsHandler) setOptionActive(c *fiber.Ctx, active bool) error {
+	log.Printf("SetOptionActive: Started processing request (active=%t)", active)
+
+	optionID, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("SetOptionActive: Invalid option ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid option ID")
+	}
+
+	if err := h.optionsService.SetOptionActive(c.Params("taxonomy"), optionID, active); err != nil {
+		log.Printf("SetOptionActive: Error updating option: %v", err)
+		return err
+	}
+
+	msg := "Option deactivated"
+	if active {
+		msg = "Option activated"
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   msg,
+		"data":  "",
+	})
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/services"
+)
+
+type PostmortemHandler struct {
+	postmortemService services.PostmortemService
+}
+
+func NewPostmortemHandler(postmortemService services.PostmortemService) *PostmortemHandler {
+	return &PostmortemHandler{postmortemService: postmortemService}
+}
+
+func (h *PostmortemHandler) GetPostmortem(c *fiber.Ctx) error {
+	log.Println("GetPostmortem: Started processing request")
+
+	incidentID, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("GetPostmortem: Invalid incident ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid incident ID")
+	}
+
+	postmortem, err := h.postmortemService.GetPostmortem(incidentID)
+	if err != nil {
+		log.Printf("GetPostmortem: error while retrieving postmortem: %v", err)
+		return err
+	}
+
+	return postmortemResponse(c, fiber.StatusOK, "Fetched postmortem", postmortem)
+}
+
+func (h *PostmortemHandler) StartPostmortem(c *fiber.Ctx) error {
+	log.Println("StartPostmortem: Started processing request")
+
+	incidentID, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("StartPostmortem: Invalid incident ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid incident ID")
+	}
+
+	postmortem, err := h.postmortemService.StartPostmortem(c.Context(), incidentID)
+	if err != nil {
+		log.Printf("StartPostmortem: error while starting postmortem: %v", err)
+		return err
+	}
+
+	return postmortemResponse(c, fiber.StatusCreated, "Postmortem started", postmortem)
+}
+
+func (h *PostmortemHandler) EditPostmortem(c *fiber.Ctx) error {
+	log.Println("EditPostmortem: Started processing request")
+
+	incidentID, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("EditPostmortem: Invalid incident ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid incident ID")
+	}
+
+	edit := new(models.PostmortemEdit)
+	if err := c.BodyParser(edit); err != nil {
+		log.Printf("EditPostmortem: Error parsing request body: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+	edit.IncidentID = incidentID
+
+	postmortem, err := h.postmortemService.EditPostmortem(c.Context(), edit)
+	if err != nil {
+		log.Printf("EditPostmortem: error while editing postmortem: %v", err)
+		return err
+	}
+
+	return postmortemResponse(c, fiber.StatusOK, "Postmortem updated", postmortem)
+}
+
+func (h *PostmortemHandler) SubmitPostmortem(c *fiber.Ctx) error {
+	log.Println("SubmitPostmortem: Started processing request")
+
+	incidentID, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("SubmitPostmortem: Invalid incident ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid incident ID")
+	}
+
+	submission := new(models.PostmortemSubmission)
+	if err := c.BodyParser(submission); err != nil {
+		log.Printf("SubmitPostmortem: Error parsing request body: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+	submission.IncidentID = incidentID
+
+	postmortem, err := h.postmortemService.SubmitPostmortem(c.Context(), submission)
+	if err != nil {
+		log.Printf("SubmitPostmortem: error while submitting postmortem: %v", err)
+		return err
+	}
+
+	return postmortemResponse(c, fiber.StatusOK, "Postmortem submitted for review", postmortem)
+}
+
+func (h *PostmortemHandler) ApprovePostmortem(c *fiber.Ctx) error {
+	log.Println("ApprovePostmortem: Started processing request")
+
+	incidentID, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("ApprovePostmortem: Invalid incident ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid incident ID")
+	}
+
+	postmortem, err := h.postmortemService.ApprovePostmortem(c.Context(), incidentID)
+	if err != nil {
+		log.Printf("ApprovePostmortem: error while approving postmortem: %v", err)
+		return err
+	}
+
+	return postmortemResponse(c, fiber.StatusOK, "Postmortem approved", postmortem)
+}
+
+func (h *PostmortemHandler) RequestPostmortemChanges(c *fiber.Ctx) error {
+	log.Println("RequestPostmortemChanges: Started processing request")
+
+	incidentID, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("RequestPostmortemChanges: Invalid incident ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid incident ID")
+	}
+
+	request := new(models.PostmortemChangeRequest)
+	if err := c.BodyParser(request); err != nil {
+		log.Printf("RequestPostmortemChanges: Error parsing request body: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+	request.IncidentID = incidentID
+
+	postmortem, err := h.postmortemService.RequestPostmortemChanges(c.Context(), request)
+	if err != nil {
+		log.Printf("RequestPostmortemChanges: error while requesting changes: %v", err)
+		return err
+	}
+
+	return postmortemResponse(c, fiber.StatusOK, "Postmortem sent back to draft", postmortem)
+}
+
+func (h *PostmortemHandler) GetPostmortemTemplates(c *fiber.Ctx) error {
+	log.Println("GetPostmortemTemplates: Started processing request")
+
+	templates, err := h.postmortemService.GetPostmortemTemplates()
+	if err != nil {
+		log.Printf("GetPostmortemTemplates: error while retrieving templates: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Fetched postmortem templates",
+		"data": fiber.Map{
+			"templates": templates,
+			"default":   models.DefaultPostmortemTemplate,
+		},
+	})
+}
+
+func (h *PostmortemHandler) SavePostmortemTemplate(c *fiber.Ctx) error {
+	log.Println("SavePostmortemTemplate: Started processing request")
+
+	typeID, err := c.ParamsInt("typeId")
+	if err != nil {
+		log.Printf("SavePostmortemTemplate: Invalid type ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid type ID")
+	}
+
+	template := new(models.PostmortemTemplateInput)
+	if err := c.BodyParser(template); err != nil {
+		log.Printf("SavePostmortemTemplate: Error parsing request body: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+	template.TypeID = typeID
+
+	if err := h.postmortemService.SavePostmortemTemplate(template); err != nil {
+		log.Printf("SavePostmortemTemplate: error while saving template: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Postmortem template saved",
+		"data":  "",
+	})
+}
+
+func (h *PostmortemHandler) DeletePostmortemTemplate(c *fiber.Ctx) error {
+	log.Println("DeletePostmortemTemplate: Started processing request")
+
+	typeID, err := c.ParamsInt("typeId")
+	if err != nil {
+		log.Printf("DeletePostmortemTemplate: Invalid type ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid type ID")
+	}
+
+	if err := h.postmortemService.DeletePostmortemTemplate(typeID); err != nil {
+		log.Printf("DeletePostmortemTemplate: error while deleting template: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Postmortem template deleted",
+		"data":  "",
+	})
+}
+
+func postmortemResponse(c *fiber.Ctx, status int, msg string, postmortem *models.Postmortem) error {
+	return c.Status(status).JSON(fiber.Map{
+		"error": false,
+		"msg":   msg,
+		"data": fiber.Map{
+			"postmortem": postmortem,
+		},
+	})
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/services"
+)
+
+type SecurityEventHandler struct {
+	securityEventService services.SecurityEventService
+}
+
+func NewSecurityEventHandler(securityEventService services.SecurityEventService) *SecurityEventHandler {
+	return &SecurityEventHandler{securityEventService: securityEventService}
+}
+
+func (h *SecurityEventHandler) GetSecurityEvents(c *fiber.Ctx) error {
+	log.Println("GetSecurityEvents: Started processing request")
+
+	params := new(models.SecurityEventQueryParams)
+	if err := c.QueryParser(params); err != nil {
+		log.Printf("GetSecurityEvents: Error parsing query parameters: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+
+	events, pagination, err := h.securityEventService.GetSecurityEvents(params)
+	if err != nil {
+		log.Printf("GetSecurityEvents: Error fetching security events: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Fetched security events",
+		"data": fiber.Map{
+			"events":     events,
+			"pagination": pagination,
+		},
+	})
+}
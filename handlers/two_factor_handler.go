@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/services"
+)
+
+type TwoFactorHandler struct {
+	twoFactorService services.TwoFactorService
+	sessionService   services.SessionService
+}
+
+func NewTwoFactorHandler(twoFactorService services.TwoFactorService, sessionService services.SessionService) *TwoFactorHandler {
+	return &TwoFactorHandler{twoFactorService: twoFactorService, sessionService: sessionService}
+}
+
+// Verify is the second login step: it trades a challenge and a code for a
+// session, just like a password-only Login.
+func (h *TwoFactorHandler) Verify(c *fiber.Ctx) error {
+	log.Println("Verify: Started processing request")
+
+	input := new(models.TwoFactorVerify)
+	if err := c.BodyParser(input); err != nil {
+		log.Printf("Verify: Error parsing request body: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+
+	user, recoveryCodes, err := h.twoFactorService.VerifyLoginChallenge(input, c.IP())
+	if err != nil {
+		log.Printf("Verify: Error verifying challenge: %v", err)
+		return err
+	}
+
+	tokens, err := h.sessionService.CreateSession(user, c.Get(fiber.HeaderUserAgent), c.IP())
+	if err != nil {
+		log.Printf("Verify: Error creating session: %v", err)
+		return fiber.NewError(fiber.StatusInternalServerError)
+	}
+
+	setAuthCookies(c, tokens)
+
+	response := fiber.Map{
+		"error": false,
+		"msg":   "Login successful",
+	}
+	if recoveryCodes != nil {
+		response["data"] = fiber.Map{"recoveryCodes": recoveryCodes}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+func (h *TwoFactorHandler) EnrollWithChallenge(c *fiber.Ctx) error {
+	log.Println("EnrollWithChallenge: Started processing request")
+
+	input := new(models.TwoFactorChallengeInput)
+	if err := c.BodyParser(input); err != nil {
+		log.Printf("EnrollWithChallenge: Error parsing request body: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+
+	setup, err := h.twoFactorService.EnrollWithChallenge(input)
+	if err != nil {
+		log.Printf("EnrollWithChallenge: Error starting enrollment: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Two-factor setup started",
+		"data":  setup,
+	})
+}
+
+func (h *TwoFactorHandler) Setup(c *fiber.Ctx) error {
+	log.Println("Setup: Started processing request")
+
+	setup, err := h.twoFactorService.BeginEnrollment(c.Context())
+	if err != nil {
+		log.Printf("Setup: Error starting enrollment: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Two-factor setup started",
+		"data":  setup,
+	})
+}
+
+func (h *TwoFactorHandler) Enable(c *fiber.Ctx) error {
+	log.Println("Enable: Started processing request")
+
+	input := new(models.TwoFactorCode)
+	if err := c.BodyParser(input); err != nil {
+		log.Printf("Enable: Error parsing request body: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+
+	recoveryCodes, err := h.twoFactorService.EnableTwoFactor(c.Context(), input)
+	if err != nil {
+		log.Printf("Enable: Error enabling two-factor: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Two-factor authentication enabled",
+		"data": fiber.Map{
+			"recoveryCodes": recoveryCodes,
+		},
+	})
+}
+
+func (h *TwoFactorHandler) Disable(c *fiber.Ctx) error {
+	log.Println("Disable: Started processing request")
+
+	input := new(models.TwoFactorDisable)
+	if err := c.BodyParser(input); err != nil {
+		log.Printf("Disable: Error parsing request body: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+
+	if err := h.twoFactorService.DisableTwoFactor(c.Context(), input); err != nil {
+		log.Printf("Disable: Error disabling two-factor: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Two-factor authentication disabled",
+	})
+}
+
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	log.Println("RegenerateRecoveryCodes: Started processing request")
+
+	input := new(models.TwoFactorCode)
+	if err := c.BodyParser(input); err != nil {
+		log.Printf("RegenerateRecoveryCodes: Error parsing request body: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+
+	recoveryCodes, err := h.twoFactorService.RegenerateRecoveryCodes(c.Context(), input)
+	if err != nil {
+		log.Printf("RegenerateRecoveryCodes: Error regenerating codes: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Recovery codes regenerated",
+		"data": fiber.Map{
+			"recoveryCodes": recoveryCodes,
+		},
+	})
+}
@@ -2,22 +2,23 @@
 package handlers
 
 import (
-	"fmt"
 	"log"
+	"time"
 
 	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
 	"github.com/pamateus-henrique/infinitepay-firewatchers-api/services"
-	"github.com/pamateus-henrique/infinitepay-firewatchers-api/utils"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type UserHandler struct {
-    userService services.UserService
+    userService      services.UserService
+    sessionService   services.SessionService
+    twoFactorService services.TwoFactorService
 }
 
-func NewUserHandler(userService services.UserService) *UserHandler {
-    return &UserHandler{userService: userService}
+func NewUserHandler(userService services.UserService, sessionService services.SessionService, twoFactorService services.TwoFactorService) *UserHandler {
+    return &UserHandler{userService: userService, sessionService: sessionService, twoFactorService: twoFactorService}
 }
 
 func (h *UserHandler) Register(c *fiber.Ctx) error {
@@ -55,7 +56,7 @@ func (h *UserHandler) Login(c *fiber.Ctx) error {
 
     log.Printf("Login: Parsed login data: %+v", loginData)
 
-    user, err := h.userService.Login(loginData)
+    user, err := h.userService.Login(loginData, c.IP())
     if err != nil {
        log.Printf("Login: Error during login: %v", err)
        return err
@@ -63,20 +64,32 @@ func (h *UserHandler) Login(c *fiber.Ctx) error {
 
     log.Printf("Login: User logged in successfully: %s", user.Name)
 
-
-    jwt, err := utils.GenerateJWT(user.Name, user.ID)
+    challenge, err := h.twoFactorService.StartLoginChallenge(user)
     if err != nil {
-        log.Printf("Login: Error generating JWT: %v", err)
+        log.Printf("Login: Error starting two-factor challenge: %v", err)
         return fiber.NewError(fiber.StatusInternalServerError)
     }
 
-    log.Println("Login: JWT generated successfully")
+    // The password alone is not enough; the client continues at
+    // /api/v1/auth/2fa/verify with the challenge
+    if challenge != nil {
+        log.Println("Login: Two-factor challenge issued")
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "error": false,
+            "msg":   "Two-factor authentication required",
+            "data":  challenge,
+        })
+    }
 
-    // Create Set-Cookie header
-    cookieValue := fmt.Sprintf("jwt=%s; HttpOnly; Path=/; Max-Age=%d; SameSite=Lax", jwt, 3600*24)
-    c.Set("Set-Cookie", cookieValue)
+    tokens, err := h.sessionService.CreateSession(user, c.Get(fiber.HeaderUserAgent), c.IP())
+    if err != nil {
+        log.Printf("Login: Error creating session: %v", err)
+        return fiber.NewError(fiber.StatusInternalServerError)
+    }
+
+    setAuthCookies(c, tokens)
 
-    log.Println("Login: Set-Cookie header set successfully")
+    log.Println("Login: Auth cookies set successfully")
 
     return c.Status(fiber.StatusOK).JSON(fiber.Map{
         "error": false,
@@ -84,41 +97,413 @@ func (h *UserHandler) Login(c *fiber.Ctx) error {
     })
 }
 
-// func (h *UserHandler) GetUser(c *fiber.Ctx) error {
-//     idParam := c.Params("id")
-//     id, err := strconv.Atoi(idParam)
-//     if err != nil {
-//         return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-//             "error": "Invalid user ID",
-//         })
-//     }
+func (h *UserHandler) Refresh(c *fiber.Ctx) error {
+    log.Println("Refresh: Started processing request")
+
+    tokens, err := h.sessionService.RefreshSession(c.Cookies(refreshTokenCookie))
+    if err != nil {
+        log.Printf("Refresh: Error refreshing session: %v", err)
+        clearAuthCookies(c)
+        return err
+    }
+
+    setAuthCookies(c, tokens)
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "error": false,
+        "msg": "Session refreshed",
+    })
+}
+
+func (h *UserHandler) Logout(c *fiber.Ctx) error {
+    log.Println("Logout: Started processing request")
+
+    if err := h.sessionService.Logout(c.Cookies(refreshTokenCookie)); err != nil {
+        log.Printf("Logout: Error revoking session: %v", err)
+        return err
+    }
+
+    clearAuthCookies(c)
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "error": false,
+        "msg": "Logout successful",
+    })
+}
+
+func (h *UserHandler) ListSessions(c *fiber.Ctx) error {
+    log.Println("ListSessions: Started processing request")
+
+    sessions, err := h.sessionService.ListSessions(c.Context())
+    if err != nil {
+        log.Printf("ListSessions: Error retrieving sessions: %v", err)
+        return err
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "error": false,
+        "msg":   "Fetched sessions",
+        "data": fiber.Map{
+            "sessions": sessions,
+        },
+    })
+}
+
+func (h *UserHandler) RevokeSession(c *fiber.Ctx) error {
+    log.Println("RevokeSession: Started processing request")
+
+    sessionID, err := c.ParamsInt("id")
+    if err != nil {
+        log.Printf("RevokeSession: Invalid session ID: %v", err)
+        return fiber.NewError(fiber.StatusBadRequest, "Invalid session ID")
+    }
+
+    if err := h.sessionService.RevokeSession(c.Context(), sessionID); err != nil {
+        log.Printf("RevokeSession: Error revoking session: %v", err)
+        return err
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "error": false,
+        "msg":   "Session revoked",
+        "data":  "",
+    })
+}
+
+const (
+    accessTokenCookie  = "jwt"
+    refreshTokenCookie = "refresh_token"
+    // The refresh token is only sent to the auth endpoints that consume it
+    refreshTokenPath = "/api/v1/auth"
+)
+
+func setAuthCookies(c *fiber.Ctx, tokens *models.AuthTokens) {
+    c.Cookie(&fiber.Cookie{
+        Name:     accessTokenCookie,
+        Value:    tokens.AccessToken,
+        Path:     "/",
+        Expires:  tokens.AccessExpiresAt,
+        HTTPOnly: true,
+        SameSite: fiber.CookieSameSiteLaxMode,
+    })
+    c.Cookie(&fiber.Cookie{
+        Name:     refreshTokenCookie,
+        Value:    tokens.RefreshToken,
+        Path:     refreshTokenPath,
+        Expires:  tokens.RefreshExpiresAt,
+        HTTPOnly: true,
+        SameSite: fiber.CookieSameSiteLaxMode,
+    })
+}
+
+func clearAuthCookies(c *fiber.Ctx) {
+    for _, cookie := range [][2]string{{accessTokenCookie, "/"}, {refreshTokenCookie, refreshTokenPath}} {
+        c.Cookie(&fiber.Cookie{
+            Name:     cookie[0],
+            Path:     cookie[1],
+            Expires:  time.Unix(0, 0),
+            HTTPOnly: true,
+            SameSite: fiber.CookieSameSiteLaxMode,
+        })
+    }
+}
+
+func (h *UserHandler) UnlockUser(c *fiber.Ctx) error {
+    log.Println("UnlockUser: Started processing request")
+
+    userID, err := c.ParamsInt("id")
+    if err != nil {
+        log.Printf("UnlockUser: Invalid user ID: %v", err)
+        return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+    }
+
+    if err := h.userService.UnlockUser(c.Context(), userID); err != nil {
+        log.Printf("UnlockUser: Error unlocking user: %v", err)
+        return err
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "error": false,
+        "msg":   "User unlocked",
+        "data":  "",
+    })
+}
+
+func (h *UserHandler) ForgotPassword(c *fiber.Ctx) error {
+    log.Println("ForgotPassword: Started processing request")
+
+    input := new(models.ForgotPassword)
+    if err := c.BodyParser(input); err != nil {
+        log.Printf("ForgotPassword: Error parsing request body: %v", err)
+        return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+    }
+
+    if err := h.userService.ForgotPassword(input); err != nil {
+        log.Printf("ForgotPassword: Error requesting password reset: %v", err)
+        return err
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "error": false,
+        "msg":   "If the email belongs to an account, a reset link has been sent",
+    })
+}
+
+func (h *UserHandler) ResetPassword(c *fiber.Ctx) error {
+    log.Println("ResetPassword: Started processing request")
+
+    input := new(models.ResetPassword)
+    if err := c.BodyParser(input); err != nil {
+        log.Printf("ResetPassword: Error parsing request body: %v", err)
+        return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+    }
+
+    if err := h.userService.ResetPassword(input); err != nil {
+        log.Printf("ResetPassword: Error resetting password: %v", err)
+        return err
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "error": false,
+        "msg":   "Password reset successful",
+    })
+}
+
+func (h *UserHandler) VerifyEmail(c *fiber.Ctx) error {
+    log.Println("VerifyEmail: Started processing request")
+
+    input := new(models.VerifyEmail)
+    if err := c.BodyParser(input); err != nil {
+        log.Printf("VerifyEmail: Error parsing request body: %v", err)
+        return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+    }
+
+    if err := h.userService.VerifyEmail(input); err != nil {
+        log.Printf("VerifyEmail: Error verifying email: %v", err)
+        return err
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "error": false,
+        "msg":   "Email verified",
+    })
+}
+
+func (h *UserHandler) ResendVerificationEmail(c *fiber.Ctx) error {
+    log.Println("ResendVerificationEmail: Started processing request")
+
+    if err := h.userService.ResendVerificationEmail(c.Context()); err != nil {
+        log.Printf("ResendVerificationEmail: Error sending verification email: %v", err)
+        return err
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "error": false,
+        "msg":   "Verification email sent",
+    })
+}
+
+func (h *UserHandler) GetUsers(c *fiber.Ctx) error {
+    log.Println("GetUsers: Started processing request")
+
+    params := new(models.UserQueryParams)
+    if err := c.QueryParser(params); err != nil {
+        log.Printf("GetUsers: Error parsing query parameters: %v", err)
+        return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+    }
+
+    users, pagination, err := h.userService.GetUsers(params)
+    if err != nil {
+        log.Printf("GetUsers: Error retrieving users: %v", err)
+        return err
+    }
+
+    log.Printf("GetUsers: Successfully retrieved %d users", len(users))
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "error": false,
+        "msg":   "Fetched users",
+        "data": fiber.Map{
+            "users":      users,
+            "pagination": pagination,
+        },
+    })
+}
+
+func (h *UserHandler) GetUser(c *fiber.Ctx) error {
+    log.Println("GetUser: Started processing request")
+
+    userID, err := c.ParamsInt("id")
+    if err != nil {
+        log.Printf("GetUser: Invalid user ID: %v", err)
+        return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+    }
+
+    user, err := h.userService.GetUser(c.Context(), userID)
+    if err != nil {
+        log.Printf("GetUser: Error retrieving user: %v", err)
+        return err
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "error": false,
+        "msg":   "Fetched user",
+        "data":  user,
+    })
+}
+
+func (h *UserHandler) GetProfile(c *fiber.Ctx) error {
+    log.Println("GetProfile: Started processing request")
+
+    user, err := h.userService.GetProfile(c.Context())
+    if err != nil {
+        log.Printf("GetProfile: Error retrieving profile: %v", err)
+        return err
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "error": false,
+        "msg":   "Fetched profile",
+        "data":  user,
+    })
+}
+
+func (h *UserHandler) UpdateProfile(c *fiber.Ctx) error {
+    log.Println("UpdateProfile: Started processing request")
+
+    update := new(models.ProfileUpdate)
+    if err := c.BodyParser(update); err != nil {
+        log.Printf("UpdateProfile: Error parsing request body: %v", err)
+        return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+    }
+
+    user, err := h.userService.UpdateProfile(c.Context(), update)
+    if err != nil {
+        log.Printf("UpdateProfile: Error updating profile: %v", err)
+        return err
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "error": false,
+        "msg":   "Updated profile",
+        "data":  user,
+    })
+}
+
+func (h *UserHandler) ChangePassword(c *fiber.Ctx) error {
+    log.Println("ChangePassword: Started processing request")
 
-//     user, err := h.userService.GetUser(id)
-//     if err != nil {
-//         return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-//             "error": "User not found",
-//         })
-//     }
+    input := new(models.PasswordChange)
+    if err := c.BodyParser(input); err != nil {
+        log.Printf("ChangePassword: Error parsing request body: %v", err)
+        return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+    }
+
+    if err := h.userService.ChangePassword(c.Context(), input); err != nil {
+        log.Printf("ChangePassword: Error changing password: %v", err)
+        return err
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "error": false,
+        "msg":   "Password changed",
+    })
+}
+
+func (h *UserHandler) DeactivateUser(c *fiber.Ctx) error {
+    log.Println("DeactivateUser: Started processing request")
+
+    userID, err := c.ParamsInt("id")
+    if err != nil {
+        log.Printf("DeactivateUser: Invalid user ID: %v", err)
+        return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+    }
 
-//     return c.JSON(user)
-// }
+    if err := h.userService.DeactivateUser(c.Context(), userID); err != nil {
+        log.Printf("DeactivateUser: Error deactivating user: %v", err)
+        return err
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "error": false,
+        "msg":   "User deactivated",
+        "data":  "",
+    })
+}
 
-func (h *UserHandler) GetAllUsersPublicData(c *fiber.Ctx) error {
-    log.Println("GetAllUsersPublicData: Started processing request")
+func (h *UserHandler) ReactivateUser(c *fiber.Ctx) error {
+    log.Println("ReactivateUser: Started processing request")
 
-    users, err := h.userService.GetAllUsersPublicData()
+    userID, err := c.ParamsInt("id")
     if err != nil {
-        log.Printf("GetAllUsersPublicData: Error retrieving users' public data: %v", err)
-        return fiber.NewError(fiber.StatusInternalServerError, "Error retrieving users' data")
+        log.Printf("ReactivateUser: Invalid user ID: %v", err)
+        return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
     }
 
-    log.Printf("GetAllUsersPublicData: Successfully retrieved public data for %d users", len(users))
+    if err := h.userService.ReactivateUser(c.Context(), userID); err != nil {
+        log.Printf("ReactivateUser: Error reactivating user: %v", err)
+        return err
+    }
 
     return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"error": false,
-		"msg":   "Fetched severities",
-		"data": fiber.Map{
-			"users": users,
-		},
-	})
+        "error": false,
+        "msg":   "User reactivated",
+        "data":  "",
+    })
+}
+
+func (h *UserHandler) UpdateUserRole(c *fiber.Ctx) error {
+    log.Println("UpdateUserRole: Started processing request")
+
+    userID, err := c.ParamsInt("id")
+    if err != nil {
+        log.Printf("UpdateUserRole: Invalid user ID: %v", err)
+        return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+    }
+
+    roleUpdate := new(models.UserRoleUpdate)
+    if err := c.BodyParser(roleUpdate); err != nil {
+        log.Printf("UpdateUserRole: Error parsing request body: %v", err)
+        return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+    }
+    roleUpdate.ID = userID
+
+    if err := h.userService.UpdateUserRole(roleUpdate); err != nil {
+        log.Printf("UpdateUserRole: Error updating role: %v", err)
+        return err
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "error": false,
+        "msg":   "Updated user role",
+        "data":  "",
+    })
+}
+
+func (h *UserHandler) UpdateUserTeam(c *fiber.Ctx) error {
+    log.Println("UpdateUserTeam: Started processing request")
+
+    userID, err := c.ParamsInt("id")
+    if err != nil {
+        log.Printf("UpdateUserTeam: Invalid user ID: %v", err)
+        return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+    }
+
+    teamUpdate := new(models.UserTeamUpdate)
+    if err := c.BodyParser(teamUpdate); err != nil {
+        log.Printf("UpdateUserTeam: Error parsing request body: %v", err)
+        return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+    }
+    teamUpdate.ID = userID
+
+    if err := h.userService.UpdateUserTeam(teamUpdate); err != nil {
+        log.Printf("UpdateUserTeam: Error updating team: %v", err)
+        return err
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "error": false,
+        "msg":   "Updated user team",
+        "data":  "",
+    })
 }
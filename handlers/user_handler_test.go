@@ -2,11 +2,13 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
@@ -27,14 +29,154 @@ func (m *MockUserService) Register(user *models.Register) error {
 	return args.Error(0)
 }
 
-func (m *MockUserService) Login(login *models.Login) (*models.User, error) {
-	args := m.Called(login)
+func (m *MockUserService) Login(login *models.Login, ip string) (*models.User, error) {
+	args := m.Called(login, ip)
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserService) GetAllUsersPublicData() ([]*models.UserPublicData, error) {
-	args := m.Called()
-	return args.Get(0).([]*models.UserPublicData), args.Error(1)
+func (m *MockUserService) GetUsers(queryParams *models.UserQueryParams) ([]*models.UserPublicData, *models.Pagination, error) {
+	args := m.Called(queryParams)
+	return args.Get(0).([]*models.UserPublicData), args.Get(1).(*models.Pagination), args.Error(2)
+}
+
+func (m *MockUserService) GetUser(ctx context.Context, userID int) (interface{}, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0), args.Error(1)
+}
+
+func (m *MockUserService) GetProfile(ctx context.Context) (*models.UserProfile, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(*models.UserProfile), args.Error(1)
+}
+
+func (m *MockUserService) UpdateProfile(ctx context.Context, update *models.ProfileUpdate) (*models.UserProfile, error) {
+	args := m.Called(ctx, update)
+	return args.Get(0).(*models.UserProfile), args.Error(1)
+}
+
+func (m *MockUserService) ChangePassword(ctx context.Context, input *models.PasswordChange) error {
+	args := m.Called(ctx, input)
+	return args.Error(0)
+}
+
+func (m *MockUserService) DeactivateUser(ctx context.Context, userID int) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ReactivateUser(ctx context.Context, userID int) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockUserService) UpdateUserRole(roleUpdate *models.UserRoleUpdate) error {
+	args := m.Called(roleUpdate)
+	return args.Error(0)
+}
+
+func (m *MockUserService) UpdateUserTeam(teamUpdate *models.UserTeamUpdate) error {
+	args := m.Called(teamUpdate)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ForgotPassword(input *models.ForgotPassword) error {
+	args := m.Called(input)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ResetPassword(input *models.ResetPassword) error {
+	args := m.Called(input)
+	return args.Error(0)
+}
+
+func (m *MockUserService) VerifyEmail(input *models.VerifyEmail) error {
+	args := m.Called(input)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ResendVerificationEmail(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockUserService) UnlockUser(ctx context.Context, userID int) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// MockSessionService is a mock implementation of the SessionService interface
+type MockSessionService struct {
+	mock.Mock
+}
+
+func (m *MockSessionService) CreateSession(user *models.User, userAgent, ip string) (*models.AuthTokens, error) {
+	args := m.Called(user, userAgent, ip)
+	return args.Get(0).(*models.AuthTokens), args.Error(1)
+}
+
+func (m *MockSessionService) RefreshSession(refreshToken string) (*models.AuthTokens, error) {
+	args := m.Called(refreshToken)
+	return args.Get(0).(*models.AuthTokens), args.Error(1)
+}
+
+func (m *MockSessionService) Logout(refreshToken string) error {
+	args := m.Called(refreshToken)
+	return args.Error(0)
+}
+
+func (m *MockSessionService) ListSessions(ctx context.Context) ([]*models.Session, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*models.Session), args.Error(1)
+}
+
+func (m *MockSessionService) RevokeSession(ctx context.Context, sessionID int) error {
+	args := m.Called(ctx, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockSessionService) IsSessionActive(sessionID int) (bool, error) {
+	args := m.Called(sessionID)
+	return args.Bool(0), args.Error(1)
+}
+
+// MockTwoFactorService is a mock implementation of the TwoFactorService interface
+type MockTwoFactorService struct {
+	mock.Mock
+}
+
+func (m *MockTwoFactorService) StartLoginChallenge(user *models.User) (*models.TwoFactorChallenge, error) {
+	args := m.Called(user)
+	return args.Get(0).(*models.TwoFactorChallenge), args.Error(1)
+}
+
+func (m *MockTwoFactorService) EnrollWithChallenge(input *models.TwoFactorChallengeInput) (*models.TwoFactorSetup, error) {
+	args := m.Called(input)
+	return args.Get(0).(*models.TwoFactorSetup), args.Error(1)
+}
+
+func (m *MockTwoFactorService) VerifyLoginChallenge(input *models.TwoFactorVerify, ip string) (*models.User, []string, error) {
+	args := m.Called(input, ip)
+	return args.Get(0).(*models.User), args.Get(1).([]string), args.Error(2)
+}
+
+func (m *MockTwoFactorService) BeginEnrollment(ctx context.Context) (*models.TwoFactorSetup, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(*models.TwoFactorSetup), args.Error(1)
+}
+
+func (m *MockTwoFactorService) EnableTwoFactor(ctx context.Context, input *models.TwoFactorCode) ([]string, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockTwoFactorService) DisableTwoFactor(ctx context.Context, input *models.TwoFactorDisable) error {
+	args := m.Called(ctx, input)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorService) RegenerateRecoveryCodes(ctx context.Context, input *models.TwoFactorCode) ([]string, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).([]string), args.Error(1)
 }
 
 func TestRegister(t *testing.T) {
@@ -42,7 +184,9 @@ func TestRegister(t *testing.T) {
 		ErrorHandler: middlewares.ErrorHandler,
 	})
 	mockService := new(MockUserService)
-	handler := NewUserHandler(mockService)
+	mockSessionService := new(MockSessionService)
+	mockTwoFactorService := new(MockTwoFactorService)
+	handler := NewUserHandler(mockService, mockSessionService, mockTwoFactorService)
 
 	app.Post("/register", handler.Register)
 
@@ -119,7 +263,9 @@ func TestLogin(t *testing.T) {
 		ErrorHandler: middlewares.ErrorHandler,
 	})
 	mockService := new(MockUserService)
-	handler := NewUserHandler(mockService)
+	mockSessionService := new(MockSessionService)
+	mockTwoFactorService := new(MockTwoFactorService)
+	handler := NewUserHandler(mockService, mockSessionService, mockTwoFactorService)
 
 	app.Post("/login", handler.Login)
 
@@ -134,16 +280,36 @@ func TestLogin(t *testing.T) {
 			name:      "Valid Login",
 			inputJSON: `{"email":"john@example.com","password":"password123"}`,
 			mockBehavior: func() {
-				mockService.On("Login", mock.AnythingOfType("*models.Login")).Return(&models.User{Name: "John Doe"}, nil)
+				mockService.On("Login", mock.AnythingOfType("*models.Login"), mock.Anything).Return(&models.User{Name: "John Doe"}, nil)
+				mockTwoFactorService.On("StartLoginChallenge", mock.AnythingOfType("*models.User")).Return((*models.TwoFactorChallenge)(nil), nil)
+				mockSessionService.On("CreateSession", mock.AnythingOfType("*models.User"), mock.Anything, mock.Anything).Return(&models.AuthTokens{
+					AccessToken:      "access",
+					AccessExpiresAt:  time.Now().Add(time.Minute),
+					RefreshToken:     "refresh",
+					RefreshExpiresAt: time.Now().Add(time.Hour),
+				}, nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody:   `{"error":false,"msg":"Login successful"}`,
 		},
+		{
+			name:      "Two-Factor Challenge",
+			inputJSON: `{"email":"john@example.com","password":"password123"}`,
+			mockBehavior: func() {
+				mockService.On("Login", mock.AnythingOfType("*models.Login"), mock.Anything).Return(&models.User{Name: "John Doe"}, nil)
+				mockTwoFactorService.On("StartLoginChallenge", mock.AnythingOfType("*models.User")).Return(&models.TwoFactorChallenge{
+					Challenge: "challenge",
+					ExpiresAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"error":false,"msg":"Two-factor authentication required","data":{"challenge":"challenge","expiresAt":"2024-05-01T12:00:00Z","enrollmentRequired":false}}`,
+		},
 		{
 			name:      "Missing Password",
 			inputJSON: `{"email":"john@example.com"}`,
 			mockBehavior: func() {
-				mockService.On("Login", mock.AnythingOfType("*models.Login")).Return((*models.User)(nil), &validators.ValidationError{Err: errors.New("Password is required")})
+				mockService.On("Login", mock.AnythingOfType("*models.Login"), mock.Anything).Return((*models.User)(nil), &validators.ValidationError{Err: errors.New("Password is required")})
 			},
 			expectedStatus: http.StatusBadRequest,
 			expectedBody:   `{"error":true,"message":"Validation failed","details":["Password is required"]}`,
@@ -152,7 +318,7 @@ func TestLogin(t *testing.T) {
 			name:      "Invalid Credentials",
 			inputJSON: `{"email":"john@example.com","password":"wrongpassword"}`,
 			mockBehavior: func() {
-				mockService.On("Login", mock.AnythingOfType("*models.Login")).Return((*models.User)(nil), &customErrors.AuthenticationError{Msg: "Invalid Email or password"})
+				mockService.On("Login", mock.AnythingOfType("*models.Login"), mock.Anything).Return((*models.User)(nil), &customErrors.AuthenticationError{Msg: "Invalid Email or password"})
 			},
 			expectedStatus: http.StatusUnauthorized,
 			expectedBody:   `{"error":true,"message":"Invalid Email or password"}`,
@@ -164,6 +330,10 @@ func TestLogin(t *testing.T) {
 			// Reset mock
 			mockService.ExpectedCalls = nil
 			mockService.Calls = nil
+			mockSessionService.ExpectedCalls = nil
+			mockSessionService.Calls = nil
+			mockTwoFactorService.ExpectedCalls = nil
+			mockTwoFactorService.Calls = nil
 
 			// Set up mock behavior
 			tt.mockBehavior()
@@ -185,18 +355,94 @@ func TestLogin(t *testing.T) {
 
 			// Assert that mock expectations were met
 			mockService.AssertExpectations(t)
+			mockSessionService.AssertExpectations(t)
+			mockTwoFactorService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	app := fiber.New(fiber.Config{
+		ErrorHandler: middlewares.ErrorHandler,
+	})
+	mockSessionService := new(MockSessionService)
+	handler := NewUserHandler(new(MockUserService), mockSessionService, new(MockTwoFactorService))
+
+	app.Post("/refresh", handler.Refresh)
+
+	tests := []struct {
+		name            string
+		mockBehavior    func()
+		expectedStatus  int
+		expectedBody    string
+		expectedCookies []string
+	}{
+		{
+			name: "Rotates Tokens",
+			mockBehavior: func() {
+				mockSessionService.On("RefreshSession", "old-refresh").Return(&models.AuthTokens{
+					AccessToken:      "new-access",
+					AccessExpiresAt:  time.Now().Add(time.Minute),
+					RefreshToken:     "new-refresh",
+					RefreshExpiresAt: time.Now().Add(time.Hour),
+				}, nil).Once()
+			},
+			expectedStatus:  http.StatusOK,
+			expectedBody:    `{"error":false,"msg":"Session refreshed"}`,
+			expectedCookies: []string{"jwt=new-access", "refresh_token=new-refresh"},
+		},
+		{
+			name: "Reused Token",
+			mockBehavior: func() {
+				mockSessionService.On("RefreshSession", "old-refresh").Return((*models.AuthTokens)(nil), &customErrors.AuthenticationError{Msg: "invalid refresh token"}).Once()
+			},
+			expectedStatus:  http.StatusUnauthorized,
+			expectedBody:    `{"error":true,"message":"invalid refresh token"}`,
+			expectedCookies: []string{"jwt=;", "refresh_token=;"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSessionService.ExpectedCalls = nil
+			mockSessionService.Calls = nil
+
+			tt.mockBehavior()
+
+			req := httptest.NewRequest(http.MethodPost, "/refresh", nil)
+			req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "old-refresh"})
+
+			resp, err := app.Test(req)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			body, _ := io.ReadAll(resp.Body)
+			assert.JSONEq(t, tt.expectedBody, string(body))
+
+			cookies := resp.Header.Values("Set-Cookie")
+			for i, expected := range tt.expectedCookies {
+				if assert.Len(t, cookies, len(tt.expectedCookies)) {
+					assert.Contains(t, cookies[i], expected)
+				}
+			}
+
+			mockSessionService.AssertExpectations(t)
 		})
 	}
 }
 
-func TestGetAllUsersPublicData(t *testing.T) {
+func TestGetUsers(t *testing.T) {
 	app := fiber.New(fiber.Config{
 		ErrorHandler: middlewares.ErrorHandler,
 	})
 	mockService := new(MockUserService)
-	handler := NewUserHandler(mockService)
+	mockSessionService := new(MockSessionService)
+	mockTwoFactorService := new(MockTwoFactorService)
+	handler := NewUserHandler(mockService, mockSessionService, mockTwoFactorService)
 
-	app.Get("/users/public", handler.GetAllUsersPublicData)
+	app.Get("/users", handler.GetUsers)
+
+	team := "Payments"
 
 	tests := []struct {
 		name           string
@@ -207,28 +453,29 @@ func TestGetAllUsersPublicData(t *testing.T) {
 		{
 			name: "Successful Retrieval",
 			mockBehavior: func() {
-				mockService.On("GetAllUsersPublicData").Return([]*models.UserPublicData{
-					{ID: 1, Name: "John Doe", Avatar: "avatar1.jpg"},
-					{ID: 2, Name: "Jane Smith", Avatar: "avatar2.jpg"},
-				}, nil)
+				mockService.On("GetUsers", &models.UserQueryParams{Team: &team, Page: 2, Limit: 1}).Return([]*models.UserPublicData{
+					{ID: 2, Name: "Jane Smith", Avatar: "avatar2.jpg", Team: "Payments", Role: "Viewer"},
+				}, models.NewPagination(2, 1, 2), nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody: `{
 				"error": false,
-				"msg": "Users' public data retrieved successfully",
-				"data": [
-					{"id": 1, "name": "John Doe", "avatar": "avatar1.jpg"},
-					{"id": 2, "name": "Jane Smith", "avatar": "avatar2.jpg"}
-				]
+				"msg": "Fetched users",
+				"data": {
+					"users": [
+						{"id": 2, "name": "Jane Smith", "avatar": "avatar2.jpg", "team": "Payments", "role": "Viewer"}
+					],
+					"pagination": {"page": 2, "limit": 1, "total": 2, "totalPages": 2, "next": null, "prev": 1}
+				}
 			}`,
 		},
 		{
 			name: "Internal Server Error",
 			mockBehavior: func() {
-				mockService.On("GetAllUsersPublicData").Return([]*models.UserPublicData(nil), errors.New("database error"))
+				mockService.On("GetUsers", mock.Anything).Return([]*models.UserPublicData(nil), (*models.Pagination)(nil), errors.New("database error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
-			expectedBody:   `{"error":true,"message":"Error retrieving users' data"}`,
+			expectedBody:   `{"error":true,"message":"Internal Server Error"}`,
 		},
 	}
 
@@ -242,7 +489,7 @@ func TestGetAllUsersPublicData(t *testing.T) {
 			tt.mockBehavior()
 
 			// Create request
-			req := httptest.NewRequest(http.MethodGet, "/users/public", nil)
+			req := httptest.NewRequest(http.MethodGet, "/users?team=Payments&page=2&limit=1", nil)
 
 			// Perform request
 			resp, err := app.Test(req)
@@ -260,3 +507,27 @@ func TestGetAllUsersPublicData(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateProfileIgnoresTeamAndAvatar(t *testing.T) {
+	app := fiber.New(fiber.Config{
+		ErrorHandler: middlewares.ErrorHandler,
+	})
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService, new(MockSessionService), new(MockTwoFactorService))
+
+	app.Patch("/me", handler.UpdateProfile)
+
+	name := "Jane Smith"
+	mockService.On("UpdateProfile", mock.Anything, &models.ProfileUpdate{Name: &name}).Return(&models.UserProfile{ID: 2, Name: name, Team: "Payments"}, nil)
+
+	req := httptest.NewRequest(http.MethodPatch, "/me", bytes.NewBufferString(`{"name": "Jane Smith", "team": "Security", "avatarUrl": "https://attacker.example/a.png"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Only admins move users between teams, through PATCH /users/:id/team,
+	// and avatars are uploaded and served by the API itself.
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/services"
+)
+
+type WebhookHandler struct {
+	webhookService services.WebhookService
+}
+
+func NewWebhookHandler(webhookService services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService}
+}
+
+func (h *WebhookHandler) CreateSubscription(c *fiber.Ctx) error {
+	log.Println("CreateSubscription: Started processing request")
+
+	input := new(models.WebhookSubscriptionInput)
+	if err := c.BodyParser(input); err != nil {
+		log.Printf("CreateSubscription: Error parsing request body: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+
+	created, err := h.webhookService.CreateSubscription(c.Context(), input)
+	if err != nil {
+		log.Printf("CreateSubscription: error while creating webhook subscription: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Webhook subscription created",
+		"data": fiber.Map{
+			"subscription": created.Subscription,
+			"secret":       created.Secret,
+		},
+	})
+}
+
+func (h *WebhookHandler) GetSubscriptions(c *fiber.Ctx) error {
+	log.Println("GetSubscriptions: Started processing request")
+
+	subscriptions, err := h.webhookService.GetSubscriptions()
+	if err != nil {
+		log.Printf("GetSubscriptions: error while retrieving webhook subscriptions: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Fetched webhook subscriptions",
+		"data": fiber.Map{
+			"subscriptions": subscriptions,
+			"events":        models.WebhookEventTypes(),
+		},
+	})
+}
+
+func (h *WebhookHandler) GetSubscription(c *fiber.Ctx) error {
+	log.Println("GetSubscription: Started processing request")
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("GetSubscription: Invalid webhook ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid webhook ID")
+	}
+
+	subscription, err := h.webhookService.GetSubscription(id)
+	if err != nil {
+		log.Printf("GetSubscription: error while retrieving webhook subscription: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Fetched webhook subscription",
+		"data": fiber.Map{
+			"subscription": subscription,
+		},
+	})
+}
+
+func (h *WebhookHandler) UpdateSubscription(c *fiber.Ctx) error {
+	log.Println("UpdateSubscription: Started processing request")
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("UpdateSubscription: Invalid webhook ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid webhook ID")
+	}
+
+	update := new(models.WebhookSubscriptionUpdate)
+	if err := c.BodyParser(update); err != nil {
+		log.Printf("UpdateSubscription: Error parsing request body: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+	update.ID = id
+
+	subscription, err := h.webhookService.UpdateSubscription(update)
+	if err != nil {
+		log.Printf("UpdateSubscription: error while updating webhook subscription: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Webhook subscription updated",
+		"data": fiber.Map{
+			"subscription": subscription,
+		},
+	})
+}
+
+func (h *WebhookHandler) RotateSecret(c *fiber.Ctx) error {
+	log.Println("RotateSecret: Started processing request")
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("RotateSecret: Invalid webhook ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid webhook ID")
+	}
+
+	rotated, err := h.webhookService.RotateSecret(id)
+	if err != nil {
+		log.Printf("RotateSecret: error while rotating webhook secret: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Webhook secret rotated",
+		"data": fiber.Map{
+			"subscription": rotated.Subscription,
+			"secret":       rotated.Secret,
+		},
+	})
+}
+
+func (h *WebhookHandler) DeleteSubscription(c *fiber.Ctx) error {
+	log.Println("DeleteSubscription: Started processing request")
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("DeleteSubscription: Invalid webhook ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid webhook ID")
+	}
+
+	if err := h.webhookService.DeleteSubscription(id); err != nil {
+		log.Printf("DeleteSubscription: error while deleting webhook subscription: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Webhook subscription deleted",
+		"data":  "",
+	})
+}
+
+func (h *WebhookHandler) GetDeliveries(c *fiber.Ctx) error {
+	log.Println("GetDeliveries: Started processing request")
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("GetDeliveries: Invalid webhook ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid webhook ID")
+	}
+
+	params := new(models.WebhookDeliveryQueryParams)
+	if err := c.QueryParser(params); err != nil {
+		log.Printf("GetDeliveries: Error parsing query parameters: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid input format")
+	}
+	params.SubscriptionID = id
+
+	deliveries, pagination, err := h.webhookService.GetDeliveries(params)
+	if err != nil {
+		log.Printf("GetDeliveries: error while retrieving webhook deliveries: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Fetched webhook deliveries",
+		"data": fiber.Map{
+			"deliveries": deliveries,
+			"pagination": pagination,
+		},
+	})
+}
+
+func (h *WebhookHandler) Redeliver(c *fiber.Ctx) error {
+	log.Println("Redeliver: Started processing request")
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		log.Printf("Redeliver: Invalid webhook ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid webhook ID")
+	}
+
+	deliveryID, err := c.ParamsInt("deliveryId")
+	if err != nil {
+		log.Printf("Redeliver: Invalid delivery ID: %v", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid delivery ID")
+	}
+
+	delivery, err := h.webhookService.Redeliver(id, deliveryID)
+	if err != nil {
+		log.Printf("Redeliver: error while queueing redelivery: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"error": false,
+		"msg":   "Webhook redelivery queued",
+		"data": fiber.Map{
+			"delivery": delivery,
+		},
+	})
+}
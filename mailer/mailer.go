@@ -0,0 +1,31 @@
+package mailer
+
+import (
+	"log"
+
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/config"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers transactional emails such as password resets.
+type Mailer interface {
+	Send(msg *Message) error
+}
+
+// NewMailer picks the implementation configured by MAILER: "smtp" sends real
+// email, anything else logs and keeps messages in memory.
+func NewMailer(cfg *config.Config) Mailer {
+	if cfg.MailerDriver == "smtp" {
+		log.Printf("NewMailer: Sending email through SMTP server %s:%s", cfg.SMTPHost, cfg.SMTPPort)
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+	}
+
+	log.Println("NewMailer: SMTP not configured, emails will only be logged")
+	return NewMemoryMailer()
+}
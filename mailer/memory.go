@@ -0,0 +1,34 @@
+package mailer
+
+import (
+	"log"
+	"sync"
+)
+
+// MemoryMailer logs every message and keeps it in memory instead of sending
+// it, for tests and local development.
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []*Message
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(msg *Message) error {
+	log.Printf("Send: Email to %s with subject %q:\n%s", msg.To, msg.Subject, msg.Body)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+
+	return nil
+}
+
+// Messages returns the messages sent so far, oldest first.
+func (m *MemoryMailer) Messages() []*Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*Message(nil), m.messages...)
+}
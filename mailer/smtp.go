@@ -0,0 +1,58 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+type smtpMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTPMailer(host, port, username, password, from string) Mailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &smtpMailer{
+		addr: net.JoinHostPort(host, port),
+		auth: auth,
+		from: from,
+	}
+}
+
+func (m *smtpMailer) Send(msg *Message) error {
+	log.Printf("Send: Sending %q to %s", msg.Subject, msg.To)
+
+	if err := smtp.SendMail(m.addr, m.auth, m.from, []string{msg.To}, buildMessage(m.from, msg, time.Now())); err != nil {
+		log.Printf("Send: Error sending email: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// buildMessage renders the RFC 5322 headers and body. Header values are
+// stripped of line breaks so user data cannot inject extra headers.
+func buildMessage(from string, msg *Message, date time.Time) []byte {
+	headerValue := strings.NewReplacer("\r", "", "\n", "").Replace
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", headerValue(from))
+	fmt.Fprintf(&b, "To: %s\r\n", headerValue(msg.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerValue(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	return []byte(b.String())
+}
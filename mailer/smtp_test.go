@@ -0,0 +1,28 @@
+package mailer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildMessage(t *testing.T) {
+	date := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	msg := &Message{
+		To:      "john@example.com",
+		Subject: "Reset\r\nBcc: attacker@example.com",
+		Body:    "Hello\nWorld",
+	}
+
+	expected := "From: no-reply@example.com\r\n" +
+		"To: john@example.com\r\n" +
+		"Subject: ResetBcc: attacker@example.com\r\n" +
+		"Date: Wed, 01 May 2024 12:00:00 +0000\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" +
+		"Hello\r\nWorld"
+
+	assert.Equal(t, expected, string(buildMessage("no-reply@example.com", msg, date)))
+}
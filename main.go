@@ -1,15 +1,19 @@
 package main
 
 import (
+	"context"
 	"log"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/config"
 	"github.com/pamateus-henrique/infinitepay-firewatchers-api/database"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/mailer"
 	"github.com/pamateus-henrique/infinitepay-firewatchers-api/middlewares"
 	"github.com/pamateus-henrique/infinitepay-firewatchers-api/repositories"
 	"github.com/pamateus-henrique/infinitepay-firewatchers-api/routes"
 	"github.com/pamateus-henrique/infinitepay-firewatchers-api/services"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/storage"
 )
 
 func main(){
@@ -23,13 +27,17 @@ func main(){
 
 	app := fiber.New(fiber.Config{
 		ErrorHandler: middlewares.ErrorHandler,
+		// Room for the largest attachment plus the multipart envelope
+		BodyLimit: config.GetConfig().AttachmentMaxBytes + 1<<20,
+		// Lets list filters accept comma separated values, e.g. ?status=a,b
+		EnableSplittingOnParsers: true,
 	})
 
 	// Setup CORS
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     "http://localhost:3000, https://yourdomain.com",  // Specify your frontend origins
 		AllowMethods:     "GET,POST,HEAD,PUT,DELETE,PATCH",
-		AllowHeaders:     "Origin, Content-Type, Accept",
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -38,14 +46,52 @@ func main(){
 	userRepo := repositories.NewUserRepository(db)
 	incidentRepo := repositories.NewIncidentRepository(db)
 	optionsRepo := repositories.NewOptionsRepository(db)
+	incidentEventRepo := repositories.NewIncidentEventRepository(db)
+	incidentUpdateRepo := repositories.NewIncidentUpdateRepository(db)
+	sessionRepo := repositories.NewSessionRepository(db)
+	userTokenRepo := repositories.NewUserTokenRepository(db)
+	loginAttemptRepo := repositories.NewLoginAttemptRepository(db)
+	securityEventRepo := repositories.NewSecurityEventRepository(db)
+	recoveryCodeRepo := repositories.NewRecoveryCodeRepository(db)
+	apiKeyRepo := repositories.NewAPIKeyRepository(db)
+	attachmentRepo := repositories.NewIncidentAttachmentRepository(db)
+	postmortemRepo := repositories.NewPostmortemRepository(db)
+	actionItemRepo := repositories.NewActionItemRepository(db)
+	webhookRepo := repositories.NewWebhookRepository(db)
+	alertRepo := repositories.NewAlertRepository(db)
+	incidentLinkRepo := repositories.NewIncidentLinkRepository(db)
 
 	//initialize services
+	mail := mailer.NewMailer(config.GetConfig())
+	blobStore, err := storage.NewBlobStore(config.GetConfig())
+	if err != nil {
+		log.Fatalf("Error setting up file storage: %v", err)
+	}
+	optionsService := services.NewOptionsService(optionsRepo)
+	webhookService := services.NewWebhookService(webhookRepo)
+	incidentService := services.NewIncidentService(incidentRepo, incidentEventRepo, optionsService, webhookService)
 	services := &services.Services{
-		UserService: services.NewUserService(userRepo),
-		IncidentService: services.NewIncidentService(incidentRepo),
-		OptionsService: services.NewOptionsService(optionsRepo),
+		UserService: services.NewUserService(userRepo, userTokenRepo, sessionRepo, loginAttemptRepo, securityEventRepo, mail),
+		IncidentService: incidentService,
+		OptionsService: optionsService,
+		IncidentUpdateService: services.NewIncidentUpdateService(incidentRepo, incidentUpdateRepo),
+		SessionService: services.NewSessionService(sessionRepo, userRepo),
+		SecurityEventService: services.NewSecurityEventService(securityEventRepo),
+		TwoFactorService: services.NewTwoFactorService(userRepo, userTokenRepo, recoveryCodeRepo, loginAttemptRepo, securityEventRepo),
+		APIKeyService: services.NewAPIKeyService(apiKeyRepo, userRepo),
+		OIDCService: services.NewOIDCService(userRepo, securityEventRepo),
+		AvatarService: services.NewAvatarService(userRepo, blobStore),
+		IncidentAttachmentService: services.NewIncidentAttachmentService(incidentRepo, attachmentRepo, blobStore),
+		PostmortemService: services.NewPostmortemService(incidentRepo, postmortemRepo, userRepo, optionsService, webhookService),
+		ActionItemService: services.NewActionItemService(incidentRepo, actionItemRepo, userRepo),
+		WebhookService: webhookService,
+		AlertService: services.NewAlertService(alertRepo, userRepo, incidentService, optionsService),
+		IncidentLinkService: services.NewIncidentLinkService(incidentRepo, incidentLinkRepo),
 	}
 
+	// Deliver queued webhooks in the background
+	go webhookService.Run(context.Background())
+
 	//setup routes
 	routes.SetupRoutes(app, services)
 
@@ -0,0 +1,53 @@
+package middlewares
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+)
+
+// AlertIntegrationAuthenticator resolves an ingestion token to the alert
+// integration it belongs to.
+type AlertIntegrationAuthenticator interface {
+	AuthenticateIntegration(token string) (*models.AlertIntegration, error)
+}
+
+// bearerScheme is how monitoring systems send their ingestion token, e.g.
+// "Authorization: Bearer fwa_...".
+const bearerScheme = "Bearer "
+
+// AlertTokenMiddleware authenticates a monitoring system by its ingestion
+// token. The request acts as the integration's reporter, and the
+// integration is attached to the locals as "alert_integration".
+func AlertTokenMiddleware(integrations AlertIntegrationAuthenticator) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		header := c.Get(fiber.HeaderAuthorization)
+		if len(header) <= len(bearerScheme) || !strings.EqualFold(header[:len(bearerScheme)], bearerScheme) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Missing ingestion token",
+			})
+		}
+
+		integration, err := integrations.AuthenticateIntegration(strings.TrimSpace(header[len(bearerScheme):]))
+
+		var authErr *customErrors.AuthenticationError
+		if errors.As(err, &authErr) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Invalid ingestion token",
+			})
+		}
+		if err != nil {
+			log.Printf("AlertTokenMiddleware: Error checking ingestion token: %v", err)
+			return fiber.NewError(fiber.StatusInternalServerError)
+		}
+
+		c.Locals("user_id", integration.ReporterID)
+		c.Locals("alert_integration", integration)
+
+		return c.Next()
+	}
+}
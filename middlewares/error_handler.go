@@ -2,6 +2,7 @@ package middlewares
 
 import (
     "errors"
+    "strconv"
 
     "github.com/gofiber/fiber/v2"
     "github.com/pamateus-henrique/infinitepay-firewatchers-api/validators"
@@ -37,6 +38,11 @@ func ErrorHandler(c *fiber.Ctx, err error) error {
         message = customErr.Error()
     }
 
+    var tooManyErr *customErrors.TooManyRequestsError
+    if errors.As(err, &tooManyErr) {
+        c.Set(fiber.HeaderRetryAfter, strconv.Itoa(tooManyErr.RetryAfterSeconds()))
+    }
+
 
 
     // Default error response
@@ -0,0 +1,28 @@
+package middlewares
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorHandlerTooManyRequests(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Get("/", func(c *fiber.Ctx) error {
+		return &customErrors.TooManyRequestsError{Msg: "too many failed login attempts", RetryAfter: 1500 * time.Millisecond}
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "2", resp.Header.Get("Retry-After"))
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"error":true,"message":"too many failed login attempts"}`, string(body))
+}
@@ -2,15 +2,72 @@
 package middlewares
 
 import (
-
+	"errors"
+	"log"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	jwtware "github.com/gofiber/jwt/v3"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/pamateus-henrique/infinitepay-firewatchers-api/config"
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
 )
 
-func JWTMiddleware() fiber.Handler {
+// SessionValidator reports whether the login session behind a token is still
+// active, so revoked sessions lose access before their JWT expires.
+type SessionValidator interface {
+	IsSessionActive(sessionID int) (bool, error)
+}
+
+// APIKeyAuthenticator resolves an API key to the user it acts as.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(key string) (*models.APIKeyPrincipal, error)
+}
+
+// apiKeyScheme is the Authorization scheme scripts use instead of a JWT,
+// e.g. "Authorization: ApiKey fw_...".
+const apiKeyScheme = "ApiKey "
+
+// JWTMiddleware authenticates a request with either a JWT (cookie or Bearer
+// header) or an API key, and attaches the user to the request locals.
+func JWTMiddleware(sessions SessionValidator, apiKeys APIKeyAuthenticator) fiber.Handler {
+	jwtHandler := jwtMiddleware(sessions)
+
+	return func(c *fiber.Ctx) error {
+		header := c.Get(fiber.HeaderAuthorization)
+		if len(header) > len(apiKeyScheme) && strings.EqualFold(header[:len(apiKeyScheme)], apiKeyScheme) {
+			return apiKeyAuth(c, apiKeys, strings.TrimSpace(header[len(apiKeyScheme):]))
+		}
+
+		return jwtHandler(c)
+	}
+}
+
+func apiKeyAuth(c *fiber.Ctx, apiKeys APIKeyAuthenticator, key string) error {
+	principal, err := apiKeys.AuthenticateAPIKey(key)
+
+	var authErr *customErrors.AuthenticationError
+	if errors.As(err, &authErr) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid or expired API key",
+		})
+	}
+	if err != nil {
+		log.Printf("JWTMiddleware: Error checking API key: %v", err)
+		return fiber.NewError(fiber.StatusInternalServerError)
+	}
+
+	// Requests made with a key are limited to its scopes by RequirePermission
+	c.Locals("user_id", principal.UserID)
+	c.Locals("role", principal.Role)
+	c.Locals("api_key_id", principal.KeyID)
+	c.Locals("scopes", principal.Scopes)
+
+	return c.Next()
+}
+
+func jwtMiddleware(sessions SessionValidator) fiber.Handler {
 	cfg := config.GetConfig()
 
 	return jwtware.New(jwtware.Config{
@@ -32,8 +89,34 @@ func JWTMiddleware() fiber.Handler {
 				})
 			}
 
-			// Attach the user_id to the request locals
+			// Tokens issued before sessions were added cannot be revoked
+			sessionID, ok := claims["session_id"].(float64)
+			if !ok {
+				return jwtError(c, jwt.ErrTokenInvalidClaims)
+			}
+
+			active, err := sessions.IsSessionActive(int(sessionID))
+			if err != nil {
+				log.Printf("JWTMiddleware: Error checking session: %v", err)
+				return fiber.NewError(fiber.StatusInternalServerError)
+			}
+
+			if !active {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"error": "Session has been revoked",
+				})
+			}
+
+			// Tokens issued before roles were added carry no role claim
+			role, ok := claims["role"].(string)
+			if !ok {
+				role = models.RoleViewer
+			}
+
+			// Attach the user_id, role and session_id to the request locals
 			c.Locals("user_id", int(userId))
+			c.Locals("role", role)
+			c.Locals("session_id", int(sessionID))
 
 			return c.Next()
 		},
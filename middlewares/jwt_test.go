@@ -0,0 +1,112 @@
+package middlewares
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubAuthenticator struct {
+	principals map[string]*models.APIKeyPrincipal
+}
+
+func (s *stubAuthenticator) IsSessionActive(sessionID int) (bool, error) {
+	return true, nil
+}
+
+func (s *stubAuthenticator) AuthenticateAPIKey(key string) (*models.APIKeyPrincipal, error) {
+	if principal, ok := s.principals[key]; ok {
+		return principal, nil
+	}
+	return nil, &customErrors.AuthenticationError{Msg: "invalid or expired API key"}
+}
+
+func TestJWTMiddlewareAPIKey(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	auth := &stubAuthenticator{principals: map[string]*models.APIKeyPrincipal{
+		"fw_reader": {KeyID: 1, UserID: 42, Role: models.RoleResponder, Scopes: models.Scopes{models.PermissionIncidentsRead}},
+	}}
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(JWTMiddleware(auth, auth))
+	app.Get("/read", RequirePermission(models.PermissionIncidentsRead), func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"user_id": c.Locals("user_id")})
+	})
+	app.Post("/update", RequirePermission(models.PermissionIncidentsUpdate), func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusOK)
+	})
+	app.Post("/2fa/setup", RequireSession(), func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusOK)
+	})
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		authorization  string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Valid Key",
+			method:         http.MethodGet,
+			path:           "/read",
+			authorization:  "ApiKey fw_reader",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"user_id":42}`,
+		},
+		{
+			name:           "Scope Missing Even Though The Role Grants It",
+			method:         http.MethodPost,
+			path:           "/update",
+			authorization:  "ApiKey fw_reader",
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"error":true,"message":"This API key is not scoped for this action"}`,
+		},
+		{
+			name:           "Scoped Key Cannot Change Account Security",
+			method:         http.MethodPost,
+			path:           "/2fa/setup",
+			authorization:  "ApiKey fw_reader",
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"error":true,"message":"This action requires a logged in session"}`,
+		},
+		{
+			name:           "Unknown Key",
+			method:         http.MethodGet,
+			path:           "/read",
+			authorization:  "ApiKey fw_unknown",
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"error":"Invalid or expired API key"}`,
+		},
+		{
+			name:           "Bearer Tokens Still Go Through JWT",
+			method:         http.MethodGet,
+			path:           "/read",
+			authorization:  "Bearer not-a-jwt",
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"error":"Invalid or expired JWT"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", tt.authorization)
+
+			resp, err := app.Test(req)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			body, _ := io.ReadAll(resp.Body)
+			assert.JSONEq(t, tt.expectedBody, string(body))
+		})
+	}
+}
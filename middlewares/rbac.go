@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+)
+
+// RequirePermission rejects requests whose authenticated role does not grant
+// the permission, or whose API key was not given it as a scope. It must run
+// after JWTMiddleware.
+func RequirePermission(permission string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, _ := c.Locals("role").(string)
+
+		if !models.HasPermission(role, permission) {
+			log.Printf("RequirePermission: role %q lacks %s on %s %s", role, permission, c.Method(), c.Path())
+			return fiber.NewError(fiber.StatusForbidden, "You do not have permission to perform this action")
+		}
+
+		if scopes, viaAPIKey := c.Locals("scopes").(models.Scopes); viaAPIKey && !scopes.Contains(permission) {
+			log.Printf("RequirePermission: API key lacks scope %s on %s %s", permission, c.Method(), c.Path())
+			return fiber.NewError(fiber.StatusForbidden, "This API key is not scoped for this action")
+		}
+
+		return c.Next()
+	}
+}
+
+// RequireSession rejects requests authenticated with an API key. Account
+// security settings, such as two-factor authentication and sessions, can only
+// be changed by the user logged in, whatever scopes a key was given. It must
+// run after JWTMiddleware.
+func RequireSession() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if _, viaAPIKey := c.Locals("api_key_id").(int); viaAPIKey {
+			log.Printf("RequireSession: API key used on %s %s", c.Method(), c.Path())
+			return fiber.NewError(fiber.StatusForbidden, "This action requires a logged in session")
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,70 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequirePermission(t *testing.T) {
+	tests := []struct {
+		name           string
+		role           string
+		permission     string
+		expectedStatus int
+	}{
+		{
+			name:           "Viewer Can Read",
+			role:           models.RoleViewer,
+			permission:     models.PermissionIncidentsRead,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Viewer Cannot Update",
+			role:           models.RoleViewer,
+			permission:     models.PermissionIncidentsUpdate,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Responder Cannot Manage",
+			role:           models.RoleResponder,
+			permission:     models.PermissionIncidentsManage,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Admin Can Manage Users",
+			role:           models.RoleAdmin,
+			permission:     models.PermissionUsersManage,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Missing Role",
+			role:           "",
+			permission:     models.PermissionIncidentsRead,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New(fiber.Config{
+				ErrorHandler: ErrorHandler,
+			})
+
+			app.Get("/", func(c *fiber.Ctx) error {
+				c.Locals("role", tt.role)
+				return c.Next()
+			}, RequirePermission(tt.permission), func(c *fiber.Ctx) error {
+				return c.SendStatus(http.StatusOK)
+			})
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+		})
+	}
+}
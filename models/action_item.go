@@ -0,0 +1,72 @@
+package models
+
+// Action item statuses; open and in progress items are unresolved.
+const (
+	ActionItemOpen       = "open"
+	ActionItemInProgress = "in_progress"
+	ActionItemDone       = "done"
+	ActionItemCanceled   = "canceled"
+)
+
+// ActionItem is a follow-up task tracked against an incident.
+type ActionItem struct {
+	ID            int         `json:"id" db:"id"`
+	IncidentID    int         `json:"incidentId" db:"incident_id"`
+	IncidentTitle string      `json:"incidentTitle" db:"incident_title"`
+	Title         string      `json:"title" db:"title"`
+	OwnerID       *int        `json:"ownerId" db:"owner_id"`
+	OwnerName     *string     `json:"ownerName" db:"owner_name"`
+	OwnerAvatar   *string     `json:"ownerAvatar" db:"owner_avatar"`
+	DueAt         *CustomTime `json:"dueAt" db:"due_at"`
+	Priority      string      `json:"priority" db:"priority"`
+	Status        string      `json:"status" db:"status"`
+	Blocking      bool        `json:"blocking" db:"blocking"`
+	TicketURL     *string     `json:"ticketUrl" db:"ticket_url"`
+	CreatedBy     int         `json:"createdBy" db:"created_by"`
+	CreatedAt     *CustomTime `json:"createdAt" db:"created_at"`
+	UpdatedAt     *CustomTime `json:"updatedAt" db:"updated_at"`
+	CompletedAt   *CustomTime `json:"completedAt" db:"completed_at"`
+}
+
+// Resolved reports whether the item no longer needs work.
+func (a *ActionItem) Resolved() bool {
+	return a.Status == ActionItemDone || a.Status == ActionItemCanceled
+}
+
+type ActionItemInput struct {
+	IncidentID int         `json:"-"`
+	Title      string      `json:"title" validate:"required,lte=255"`
+	OwnerID    *int        `json:"ownerId" validate:"omitempty,gt=0"`
+	DueAt      *CustomTime `json:"dueAt"`
+	Priority   string      `json:"priority" validate:"omitempty,oneof=low medium high"`
+	Blocking   bool        `json:"blocking"`
+	TicketURL  *string     `json:"ticketUrl" validate:"omitempty,url,lte=2048"`
+}
+
+// ActionItemUpdate changes an action item; omitted fields are kept.
+type ActionItemUpdate struct {
+	IncidentID int         `json:"-"`
+	ID         int         `json:"-"`
+	Title      *string     `json:"title" validate:"omitempty,gte=1,lte=255"`
+	OwnerID    *int        `json:"ownerId" validate:"omitempty,gt=0"`
+	DueAt      *CustomTime `json:"dueAt"`
+	Priority   *string     `json:"priority" validate:"omitempty,oneof=low medium high"`
+	Status     *string     `json:"status" validate:"omitempty,oneof=open in_progress done canceled"`
+	Blocking   *bool       `json:"blocking"`
+	TicketURL  *string     `json:"ticketUrl" validate:"omitempty,url,lte=2048"`
+}
+
+// ActionItemQueryParams filters action items across incidents. Without a
+// status only unresolved items are listed.
+type ActionItemQueryParams struct {
+	OwnerID *int    `query:"-"`
+	Overdue bool    `query:"-"`
+	Status  *string `query:"status" validate:"omitempty,oneof=open in_progress done canceled"`
+	Page    int     `query:"page" validate:"omitempty,gte=1"`
+	Limit   int     `query:"limit" validate:"omitempty,gte=1,lte=100"`
+}
+
+// Offset returns the number of rows to skip for the requested page.
+func (p *ActionItemQueryParams) Offset() int {
+	return (p.Page - 1) * p.Limit
+}
@@ -0,0 +1,257 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Alert states.
+const (
+	AlertFiring   = "firing"
+	AlertResolved = "resolved"
+)
+
+// Kinds of alert integrations, which are also the source alerts are
+// deduplicated within.
+const (
+	AlertKindAlertmanager = "alertmanager"
+	AlertKindGrafana      = "grafana"
+	AlertKindDatadog      = "datadog"
+	AlertKindGeneric      = "generic"
+)
+
+// Incident fields an alert mapping rule can set.
+const (
+	AlertFieldSeverity = "severity"
+	AlertFieldType     = "type"
+	AlertFieldSource   = "source"
+	AlertFieldProduct  = "product"
+	AlertFieldArea     = "area"
+)
+
+// AlertFieldTaxonomy returns the options taxonomy holding the values of a
+// mapping rule field.
+func AlertFieldTaxonomy(field string) string {
+	switch field {
+	case AlertFieldSeverity:
+		return "severity"
+	case AlertFieldType:
+		return "types"
+	case AlertFieldSource:
+		return "sources"
+	case AlertFieldProduct:
+		return "products"
+	case AlertFieldArea:
+		return "areas"
+	}
+	return ""
+}
+
+// AlertLabels is a set of alert labels or annotations, stored as a JSON
+// object.
+type AlertLabels map[string]string
+
+func (l AlertLabels) Value() (driver.Value, error) {
+	if l == nil {
+		return "{}", nil
+	}
+	encoded, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+func (l *AlertLabels) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	case []byte:
+		return json.Unmarshal(v, l)
+	}
+	return fmt.Errorf("cannot scan %T into AlertLabels", value)
+}
+
+// Alert is a single alert from any monitoring source, in the shape the
+// ingestion pipeline works with.
+type Alert struct {
+	Source       string
+	Fingerprint  string
+	Status       string
+	Name         string
+	Labels       AlertLabels
+	Annotations  AlertLabels
+	GeneratorURL string
+	StartsAt     time.Time
+	EndsAt       time.Time
+}
+
+// IncidentAlert is an alert linked to the incident it opened or was folded
+// into.
+type IncidentAlert struct {
+	ID           int         `json:"id" db:"id"`
+	IncidentID   int         `json:"incidentId" db:"incident_id"`
+	Source       string      `json:"source" db:"source"`
+	Fingerprint  string      `json:"fingerprint" db:"fingerprint"`
+	Name         string      `json:"name" db:"name"`
+	Status       string      `json:"status" db:"status"`
+	Labels       AlertLabels `json:"labels" db:"labels"`
+	Annotations  AlertLabels `json:"annotations" db:"annotations"`
+	GeneratorURL *string     `json:"generatorUrl" db:"generator_url"`
+	StartsAt     *CustomTime `json:"startsAt" db:"starts_at"`
+	EndsAt       *CustomTime `json:"endsAt" db:"ends_at"`
+	CreatedAt    *CustomTime `json:"createdAt" db:"created_at"`
+	UpdatedAt    *CustomTime `json:"updatedAt" db:"updated_at"`
+}
+
+// AlertmanagerPayload is the body of a Prometheus Alertmanager webhook
+// notification.
+type AlertmanagerPayload struct {
+	Version           string              `json:"version"`
+	GroupKey          string              `json:"groupKey"`
+	Status            string              `json:"status"`
+	Receiver          string              `json:"receiver"`
+	GroupLabels       AlertLabels         `json:"groupLabels"`
+	CommonLabels      AlertLabels         `json:"commonLabels"`
+	CommonAnnotations AlertLabels         `json:"commonAnnotations"`
+	ExternalURL       string              `json:"externalURL"`
+	Alerts            []AlertmanagerAlert `json:"alerts" validate:"required,min=1,dive"`
+}
+
+type AlertmanagerAlert struct {
+	Status       string      `json:"status" validate:"oneof=firing resolved"`
+	Labels       AlertLabels `json:"labels"`
+	Annotations  AlertLabels `json:"annotations"`
+	StartsAt     time.Time   `json:"startsAt"`
+	EndsAt       time.Time   `json:"endsAt"`
+	GeneratorURL string      `json:"generatorURL"`
+	Fingerprint  string      `json:"fingerprint" validate:"lte=255"`
+}
+
+// AlertIngestResult reports what a notification did to incidents.
+type AlertIngestResult struct {
+	// Incidents opened for alerts nobody was tracking yet
+	Created []int `json:"created"`
+	// Open incidents the alerts were folded into
+	Updated []int `json:"updated"`
+	// Alerts marked resolved on their incident
+	Resolved int `json:"resolved"`
+	// Resolved alerts without an open incident
+	Ignored int `json:"ignored"`
+}
+
+// AlertMappingRule sets an incident field to an option when an alert label
+// has a given value.
+type AlertMappingRule struct {
+	ID        int         `json:"id" db:"id"`
+	Label     string      `json:"label" db:"label"`
+	Value     string      `json:"value" db:"value"`
+	Field     string      `json:"field" db:"field"`
+	OptionID  int         `json:"optionId" db:"option_id"`
+	Position  int         `json:"position" db:"position"`
+	CreatedAt *CustomTime `json:"createdAt" db:"created_at"`
+	UpdatedAt *CustomTime `json:"updatedAt" db:"updated_at"`
+}
+
+// Matches reports whether the labels satisfy the rule.
+func (r *AlertMappingRule) Matches(labels AlertLabels) bool {
+	value, ok := labels[r.Label]
+	return ok && strings.EqualFold(value, r.Value)
+}
+
+type AlertMappingRuleInput struct {
+	ID       int    `json:"-"`
+	Label    string `json:"label" validate:"required,lte=255"`
+	Value    string `json:"value" validate:"required,lte=255"`
+	Field    string `json:"field" validate:"required,oneof=severity type source product area"`
+	OptionID int    `json:"optionId" validate:"required,gt=0"`
+	Position int    `json:"position" validate:"gte=0"`
+}
+
+// AlertIntegration is a monitoring system allowed to post alerts with its
+// own ingestion token. Only the token's hash is stored; TokenPrefix
+// identifies it in listings. Incidents it opens are reported by Reporter.
+type AlertIntegration struct {
+	ID          int                  `json:"id" db:"id"`
+	Name        string               `json:"name" db:"name"`
+	Kind        string               `json:"kind" db:"kind"`
+	TokenPrefix string               `json:"tokenPrefix" db:"token_prefix"`
+	Mapping     *GenericAlertMapping `json:"mapping" db:"mapping"`
+	ReporterID  int                  `json:"reporterId" db:"reporter_id"`
+	Active      bool                 `json:"active" db:"active"`
+	CreatedBy   *int                 `json:"createdBy" db:"created_by"`
+	CreatedAt   *CustomTime          `json:"createdAt" db:"created_at"`
+	UpdatedAt   *CustomTime          `json:"updatedAt" db:"updated_at"`
+	LastUsedAt  *CustomTime          `json:"lastUsedAt" db:"last_used_at"`
+}
+
+type AlertIntegrationInput struct {
+	Name string `json:"name" validate:"required,lte=255"`
+	Kind string `json:"kind" validate:"required,oneof=alertmanager grafana datadog generic"`
+	// ReporterID defaults to the caller
+	ReporterID int `json:"reporterId" validate:"omitempty,gt=0"`
+	// Mapping is required for, and only used by, generic integrations
+	Mapping *GenericAlertMapping `json:"mapping" validate:"required_if=Kind generic"`
+}
+
+// AlertIntegrationUpdate changes an integration; omitted fields are kept.
+type AlertIntegrationUpdate struct {
+	ID         int                  `json:"-"`
+	Name       *string              `json:"name" validate:"omitempty,gte=1,lte=255"`
+	ReporterID *int                 `json:"reporterId" validate:"omitempty,gt=0"`
+	Active     *bool                `json:"active"`
+	Mapping    *GenericAlertMapping `json:"mapping" validate:"omitempty"`
+}
+
+// CreatedAlertIntegration carries the plain ingestion token, which is only
+// shown when the integration is created or its token rotated.
+type CreatedAlertIntegration struct {
+	Integration *AlertIntegration `json:"integration"`
+	Token       string            `json:"token"`
+}
+
+// GenericAlertMapping locates alert fields in a custom JSON payload with
+// JSONPath expressions, e.g. "$.alerts" or "$.labels['service']". Alerts
+// selects the alerts in the body and defaults to "$", the whole body being
+// one alert; every other path is relative to an alert. Status values listed
+// in ResolvedValues mean the alert is over.
+type GenericAlertMapping struct {
+	Alerts         string            `json:"alerts"`
+	Name           string            `json:"name" validate:"required"`
+	Fingerprint    string            `json:"fingerprint"`
+	Status         string            `json:"status"`
+	ResolvedValues []string          `json:"resolvedValues"`
+	Summary        string            `json:"summary"`
+	Description    string            `json:"description"`
+	URL            string            `json:"url"`
+	StartsAt       string            `json:"startsAt"`
+	EndsAt         string            `json:"endsAt"`
+	Labels         map[string]string `json:"labels"`
+}
+
+func (m *GenericAlertMapping) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+func (m *GenericAlertMapping) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	case []byte:
+		return json.Unmarshal(v, m)
+	}
+	return fmt.Errorf("cannot scan %T into GenericAlertMapping", value)
+}
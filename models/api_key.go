@@ -0,0 +1,84 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+)
+
+// Scopes is a set of permissions stored as a space separated string.
+type Scopes []string
+
+func (s Scopes) Value() (driver.Value, error) {
+	return strings.Join(s, " "), nil
+}
+
+func (s *Scopes) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*s = nil
+	case string:
+		*s = strings.Fields(v)
+	case []byte:
+		*s = strings.Fields(string(v))
+	default:
+		return fmt.Errorf("cannot scan %T into Scopes", value)
+	}
+	return nil
+}
+
+// Contains reports whether the permission is in the set.
+func (s Scopes) Contains(permission string) bool {
+	for _, scope := range s {
+		if scope == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKey is a long-lived credential for scripts and bots. Only its hash is
+// stored; Prefix identifies it in listings.
+type APIKey struct {
+	ID         int         `json:"id" db:"id"`
+	UserID     int         `json:"userId" db:"user_id"`
+	Name       string      `json:"name" db:"name"`
+	Prefix     string      `json:"prefix" db:"prefix"`
+	Scopes     Scopes      `json:"scopes" db:"scopes"`
+	CreatedBy  *int        `json:"createdBy" db:"created_by"`
+	CreatedAt  *CustomTime `json:"createdAt" db:"created_at"`
+	ExpiresAt  *CustomTime `json:"expiresAt" db:"expires_at"`
+	LastUsedAt *CustomTime `json:"lastUsedAt" db:"last_used_at"`
+}
+
+type APIKeyInput struct {
+	Name      string      `json:"name" validate:"required,lte=255"`
+	Scopes    []string    `json:"scopes" validate:"required,min=1,dive,required"`
+	ExpiresAt *CustomTime `json:"expiresAt"`
+}
+
+// CreatedAPIKey carries the plain key, which is only ever shown once.
+type CreatedAPIKey struct {
+	APIKey *APIKey `json:"apiKey"`
+	Key    string  `json:"key"`
+}
+
+// APIKeyPrincipal is who a request authenticated with an API key acts as.
+type APIKeyPrincipal struct {
+	KeyID  int    `db:"id"`
+	UserID int    `db:"user_id"`
+	Role   string `db:"role"`
+	Scopes Scopes `db:"scopes"`
+}
+
+// ServiceAccount is a user that cannot log in and acts only through API keys.
+type ServiceAccount struct {
+	ID   int    `json:"id" db:"id"`
+	Name string `json:"name" db:"name"`
+	Role string `json:"role" db:"role"`
+}
+
+type ServiceAccountInput struct {
+	Name string `json:"name" validate:"required,lte=255"`
+	Role string `json:"role" validate:"required"`
+}
@@ -9,4 +9,23 @@ type Register struct {
 type Login struct {
 	Email string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,gte=8,lte=255"`
-}
\ No newline at end of file
+}
+
+// Purposes of the single-use tokens emailed to users.
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+)
+
+type ForgotPassword struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPassword struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,gte=8,lte=255"`
+}
+
+type VerifyEmail struct {
+	Token string `json:"token" validate:"required"`
+}
@@ -8,27 +8,52 @@ type RelatedItem struct {
 }
 
 type IncidentInput struct {
-	Title           string     	`json:"title" db:"title"`
-	Type            string     	`json:"type" db:"type"`
-	Severity        string     	`json:"severity" db:"severity"`
-	Summary         string     	`json:"summary" db:"summary"`
-	Status 			string		`json:"status" db:"status"`
-	Reporter		int			`db:"reporter"`
-	Impact          *string    	`json:"impact,omitempty" db:"impact"`
+	Title           string     	`json:"title" db:"title" validate:"required,lte=255"`
+	Type            string     	`json:"type" db:"type" validate:"required"`
+	Severity        string     	`json:"severity" db:"severity" validate:"required"`
+	Summary         string     	`json:"summary" db:"summary" validate:"lte=10000"`
+	Status 			string		`json:"status" db:"status" validate:"required"`
+	Reporter		int			`json:"-" db:"reporter"`
+	Impact          *string    	`json:"impact,omitempty" db:"impact" validate:"omitempty,lte=10000"`
 	Source          *string    	`json:"source,omitempty" db:"incident_source"`
-	Lead            *int        `json:"lead" db:"lead"`
-	Products        []int      	`json:"products,omitempty" db:"product_id"`
-	Areas           []int      	`json:"areas,omitempty" db:"area_id"`
-	Indicators      []int      	`json:"indicators,omitempty" db:"indicator_id"`
+	Lead            *int        `json:"lead" db:"lead" validate:"omitempty,gt=0"`
+	Products        []int      	`json:"products,omitempty" db:"product_id" validate:"omitempty,dive,gt=0"`
+	Areas           []int      	`json:"areas,omitempty" db:"area_id" validate:"omitempty,dive,gt=0"`
+	Indicators      []int      	`json:"indicators,omitempty" db:"indicator_id" validate:"omitempty,dive,gt=0"`
 	ImpactStartedAt *CustomTime 	`json:"impactStartedAt" db:"impact_started_at"`
 	SlackThread     *string    	`json:"slack_thread,omitempty" db:"slack_thread"`
-	ReportedAt		*CustomTime `db:"reported_at"`
+	ReportedAt		*CustomTime `json:"-" db:"reported_at"`
 }
 
 type IncidentQueryParams struct {
-	Status   *string `query:"status"`
-	Category *string `query:"category"`
-	Severity *string `query:"severity"`
+	Status                []string `query:"status"`
+	Category              []string `query:"category"`
+	Severity              []string `query:"severity"`
+	Type                  []string `query:"type"`
+	Lead                  []int    `query:"lead" validate:"omitempty,dive,gt=0"`
+	Reporter              []int    `query:"reporter" validate:"omitempty,dive,gt=0"`
+	QE                    []int    `query:"qe" validate:"omitempty,dive,gt=0"`
+	Products              []int    `query:"products" validate:"omitempty,dive,gt=0"`
+	Areas                 []int    `query:"areas" validate:"omitempty,dive,gt=0"`
+	Causes                []int    `query:"causes" validate:"omitempty,dive,gt=0"`
+	FaultySystems         []int    `query:"faulty_systems" validate:"omitempty,dive,gt=0"`
+	PerformanceIndicators []int    `query:"performance_indicators" validate:"omitempty,dive,gt=0"`
+	ReportedFrom          *string  `query:"reported_from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	ReportedTo            *string  `query:"reported_to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	ImpactStartedFrom     *string  `query:"impact_started_from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	ImpactStartedTo       *string  `query:"impact_started_to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	ResolvedFrom          *string  `query:"resolved_from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	ResolvedTo            *string  `query:"resolved_to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Query                 *string  `query:"q" validate:"omitempty,max=200"`
+	Page                  int      `query:"page" validate:"omitempty,gte=1"`
+	Limit                 int      `query:"limit" validate:"omitempty,gte=1,lte=100"`
+	Sort                  string   `query:"sort" validate:"omitempty,oneof=impact_started_at reported_at severity status relevance"`
+	Order                 string   `query:"order" validate:"omitempty,oneof=asc desc"`
+}
+
+// Offset returns the number of rows to skip for the requested page.
+func (p *IncidentQueryParams) Offset() int {
+	return (p.Page - 1) * p.Limit
 }
 
 type IncidentOverviewOutput struct {
@@ -38,9 +63,12 @@ type IncidentOverviewOutput struct {
 	Severity        string    `json:"severity"`
 	Summary         string    `json:"summary"`
 	ImpactStartedAt time.Time `json:"impactStartedAt" db:"impact_started_at"`
+	ReportedAt      *CustomTime `json:"reportedAt" db:"reported_at"`
 	Status          string    `json:"status"`
 	Lead            string    `json:"lead"`
 	LeadAvatar      string    `json:"leadAvatar" db:"avatar_url"`
+	Rank            *float64  `json:"rank,omitempty" db:"rank"`
+	Snippet         *string   `json:"snippet,omitempty" db:"snippet"`
 }
 
 type IncidentSummary struct {
@@ -49,18 +77,30 @@ type IncidentSummary struct {
 }
 
 type IncidentStatus struct {
-	ID      int    `json:"id" db:"id"`
-	Status string `json:"status" db:"status"`
+	ID      int    `json:"id" db:"id" validate:"required"`
+	Status string `json:"status" db:"status" validate:"required"`
+}
+
+// IncidentStatusTransition describes a validated status change together with
+// the lifecycle timestamp columns that must be stamped alongside it.
+type IncidentStatusTransition struct {
+	ID         int
+	From       string
+	To         string
+	Timestamps []string
+	At         *CustomTime
+	// Refuse the transition while blocking action items are open
+	CheckBlockingActionItems bool
 }
 
 type IncidentSeverity struct {
-	ID       int    `json:"id" db:"id"`
-	Severity string `json:"severity" db:"severity"`
+	ID       int    `json:"id" db:"id" validate:"required"`
+	Severity string `json:"severity" db:"severity" validate:"required"`
 }
 
 type IncidentType struct {
-	ID      int    `json:"id" db:"id"`
-	Type 	string `json:"type" db:"type"`
+	ID      int    `json:"id" db:"id" validate:"required"`
+	Type 	string `json:"type" db:"type" validate:"required"`
 }
 
 type IncidentRoles struct {
@@ -101,7 +141,9 @@ type IncidentOutput struct {
 	DeclinedAt            *CustomTime        `json:"declinedAt" db:"declined_at"`
 	MergedAt              *CustomTime        `json:"mergedAt" db:"merged_at"`
 	CanceledAt            *CustomTime        `json:"canceledAt" db:"canceled_at"`
-	TriagedBy             *CustomTime        `json:"triagedBy" db:"triaged_by"`
+	TriagedBy             *int              `json:"triagedBy" db:"triaged_by"`
+	TriagedByName         *string           `json:"triagedByName" db:"triaged_by_name"`
+	DeclineReason         *string           `json:"declineReason" db:"decline_reason"`
 	Treatment             *string           `json:"treatment" db:"treatment"`
 	Mitigator             *string           `json:"mitigator" db:"mitigator"`
 	SlackChannel          *string           `json:"slackChannel" db:"slack_channel"`
@@ -123,16 +165,52 @@ type IncidentOutput struct {
 	Causes                []RelatedItem   	`json:"causes" db:"-"`
 	FaultySystems         []RelatedItem    	`json:"faultySystems" db:"-"`
 	PerformanceIndicators []RelatedItem  	`json:"performanceIndicators" db:"-"`
+	Attachments           *AttachmentSummary `json:"attachments" db:"-"`
+	Links                 []*LinkedIncident  `json:"links" db:"-"`
 }
 
 type IncidentCustomFieldsUpdate struct {
-	ID                    int               `json:"id" db:"id"`
-	Products              []int             `json:"products,omitempty"`
-	Areas                 []int             `json:"areas,omitempty"`
-	Causes                []int             `json:"causes,omitempty"`
-	FaultySystems         []int             `json:"faultySystems,omitempty"`
-	PerformanceIndicators []int             `json:"performanceIndicators,omitempty"`
+	ID                    int               `json:"id" db:"id" validate:"required"`
+	Products              []int             `json:"products,omitempty" validate:"omitempty,dive,gt=0"`
+	Areas                 []int             `json:"areas,omitempty" validate:"omitempty,dive,gt=0"`
+	Causes                []int             `json:"causes,omitempty" validate:"omitempty,dive,gt=0"`
+	FaultySystems         []int             `json:"faultySystems,omitempty" validate:"omitempty,dive,gt=0"`
+	PerformanceIndicators []int             `json:"performanceIndicators,omitempty" validate:"omitempty,dive,gt=0"`
 	Impact                *string           `json:"impact,omitempty" db:"impact"`
 	Treatment             *string           `json:"treatment,omitempty" db:"treatment"`
 	Mitigator             *string           `json:"mitigator,omitempty" db:"mitigator"`
 }
+
+// TriageQueueItem is an incident waiting in the triage inbox.
+type TriageQueueItem struct {
+	ID              int         `json:"id" db:"id"`
+	Title           string      `json:"title" db:"title"`
+	Type            string      `json:"type" db:"type"`
+	Severity        string      `json:"severity" db:"severity"`
+	Summary         string      `json:"summary" db:"summary"`
+	Status          string      `json:"status" db:"status"`
+	Reporter        *int        `json:"reporter" db:"reporter"`
+	ReporterName    *string     `json:"reporterName" db:"reporter_name"`
+	ReporterAvatar  *string     `json:"reporterAvatar" db:"reporter_avatar"`
+	ReportedAt      *CustomTime `json:"reportedAt" db:"reported_at"`
+	ImpactStartedAt *CustomTime `json:"impactStartedAt" db:"impact_started_at"`
+	FiringAlerts    int         `json:"firingAlerts" db:"firing_alerts"`
+}
+
+// IncidentAccept takes an incident out of triage and hands it to a lead.
+type IncidentAccept struct {
+	ID   int `json:"-" validate:"required"`
+	Lead int `json:"lead" validate:"required,gt=0"`
+}
+
+// IncidentDecline closes a triaged incident that needs no response.
+type IncidentDecline struct {
+	ID     int    `json:"-" validate:"required"`
+	Reason string `json:"reason" validate:"required,lte=2000"`
+}
+
+// IncidentMerge folds a duplicate incident into the one tracking the problem.
+type IncidentMerge struct {
+	ID   int `json:"-" validate:"required"`
+	Into int `json:"into" validate:"required,gt=0,nefield=ID"`
+}
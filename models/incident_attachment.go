@@ -0,0 +1,35 @@
+package models
+
+import "io"
+
+// IncidentAttachment describes a file such as a screenshot or log excerpt
+// attached to an incident.
+type IncidentAttachment struct {
+	ID             int         `json:"id" db:"id"`
+	IncidentID     int         `json:"incidentId" db:"incident_id"`
+	UploaderID     int         `json:"uploaderId" db:"uploader_id"`
+	UploaderName   string      `json:"uploaderName" db:"uploader_name"`
+	UploaderAvatar *string     `json:"uploaderAvatar" db:"uploader_avatar"`
+	Filename       string      `json:"filename" db:"filename"`
+	MimeType       string      `json:"mimeType" db:"mime_type"`
+	Size           int64       `json:"size" db:"size"`
+	Checksum       string      `json:"checksum" db:"checksum"`
+	StorageKey     string      `json:"-" db:"storage_key"`
+	CreatedAt      *CustomTime `json:"createdAt" db:"created_at"`
+}
+
+// AttachmentUpload is a file being attached. Size is what the client
+// announced; the stored size is counted from Content.
+type AttachmentUpload struct {
+	IncidentID int
+	Filename   string
+	Size       int64
+	Content    io.Reader
+}
+
+// AttachmentSummary is the attachment overview included in an incident.
+type AttachmentSummary struct {
+	Count          int         `json:"count" db:"count"`
+	TotalBytes     int64       `json:"totalBytes" db:"total_bytes"`
+	LastUploadedAt *CustomTime `json:"lastUploadedAt" db:"last_uploaded_at"`
+}
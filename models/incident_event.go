@@ -0,0 +1,14 @@
+package models
+
+// IncidentEvent is a single entry of an incident's timeline.
+type IncidentEvent struct {
+	ID          int         `json:"id" db:"id"`
+	IncidentID  int         `json:"incidentId" db:"incident_id"`
+	ActorID     *int        `json:"actorId" db:"actor_id"`
+	ActorName   *string     `json:"actorName" db:"actor_name"`
+	ActorAvatar *string     `json:"actorAvatar" db:"actor_avatar"`
+	Field       string      `json:"field" db:"field"`
+	OldValue    *string     `json:"oldValue" db:"old_value"`
+	NewValue    *string     `json:"newValue" db:"new_value"`
+	CreatedAt   *CustomTime `json:"createdAt" db:"created_at"`
+}
@@ -0,0 +1,58 @@
+package models
+
+// Kinds of incident links, read as "incident <kind> linked incident". Each
+// kind has an inverse describing the same link from the other side; only
+// child_of, duplicate_of, caused_by and related are stored.
+const (
+	IncidentLinkParentOf     = "parent_of"
+	IncidentLinkChildOf      = "child_of"
+	IncidentLinkDuplicateOf  = "duplicate_of"
+	IncidentLinkDuplicatedBy = "duplicated_by"
+	IncidentLinkCausedBy     = "caused_by"
+	IncidentLinkCauses       = "causes"
+	IncidentLinkRelated      = "related"
+)
+
+var incidentLinkInverses = map[string]string{
+	IncidentLinkParentOf:     IncidentLinkChildOf,
+	IncidentLinkChildOf:      IncidentLinkParentOf,
+	IncidentLinkDuplicateOf:  IncidentLinkDuplicatedBy,
+	IncidentLinkDuplicatedBy: IncidentLinkDuplicateOf,
+	IncidentLinkCausedBy:     IncidentLinkCauses,
+	IncidentLinkCauses:       IncidentLinkCausedBy,
+	IncidentLinkRelated:      IncidentLinkRelated,
+}
+
+// InverseIncidentLinkKind returns how a link of the given kind reads from
+// the linked incident.
+func InverseIncidentLinkKind(kind string) string {
+	return incidentLinkInverses[kind]
+}
+
+// IncidentLink is a link between two incidents as stored.
+type IncidentLink struct {
+	ID               int         `json:"id" db:"id"`
+	IncidentID       int         `json:"incidentId" db:"incident_id"`
+	LinkedIncidentID int         `json:"linkedIncidentId" db:"linked_incident_id"`
+	Kind             string      `json:"kind" db:"kind"`
+	CreatedBy        int         `json:"createdBy" db:"created_by"`
+	CreatedAt        *CustomTime `json:"createdAt" db:"created_at"`
+}
+
+type IncidentLinkInput struct {
+	IncidentID       int    `json:"-" validate:"required"`
+	LinkedIncidentID int    `json:"linkedIncidentId" validate:"required,gt=0,nefield=IncidentID"`
+	Kind             string `json:"kind" validate:"required,oneof=parent_of child_of duplicate_of duplicated_by caused_by causes related"`
+}
+
+// LinkedIncident is an incident linked to the one being viewed, with the
+// kind of the link read from the viewed incident.
+type LinkedIncident struct {
+	LinkID   int    `json:"linkId" db:"link_id"`
+	Kind     string `json:"kind" db:"kind"`
+	ID       int    `json:"id" db:"id"`
+	Title    string `json:"title" db:"title"`
+	Status   string `json:"status" db:"status"`
+	Severity string `json:"severity" db:"severity"`
+	Outgoing bool   `json:"-" db:"outgoing"`
+}
@@ -0,0 +1,19 @@
+package models
+
+// IncidentUpdate is a free-text status note posted by a responder.
+type IncidentUpdate struct {
+	ID           int         `json:"id" db:"id"`
+	IncidentID   int         `json:"incidentId" db:"incident_id"`
+	AuthorID     int         `json:"authorId" db:"author_id"`
+	AuthorName   string      `json:"authorName" db:"author_name"`
+	AuthorAvatar *string     `json:"authorAvatar" db:"author_avatar"`
+	Body         string      `json:"body" db:"body"`
+	CreatedAt    *CustomTime `json:"createdAt" db:"created_at"`
+	UpdatedAt    *CustomTime `json:"updatedAt" db:"updated_at"`
+}
+
+type IncidentUpdateInput struct {
+	ID         int    `json:"-" db:"id"`
+	IncidentID int    `json:"-" db:"incident_id" validate:"required"`
+	Body       string `json:"body" db:"body" validate:"required,lte=10000"`
+}
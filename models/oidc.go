@@ -0,0 +1,17 @@
+package models
+
+// OIDCLogin starts a single sign-on: the browser is redirected to URL and
+// State is kept in a short-lived cookie until the provider calls back.
+type OIDCLogin struct {
+	URL   string
+	State string
+}
+
+type OIDCCallback struct {
+	Code             string `query:"code"`
+	State            string `query:"state"`
+	Error            string `query:"error"`
+	ErrorDescription string `query:"error_description"`
+	// StoredState is the cookie set by the login redirect
+	StoredState string `query:"-"`
+}
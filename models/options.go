@@ -41,11 +41,25 @@ type Cause struct {
 }
 
 type Source struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
+	ID   int    `json:"id" db:"id"`
+	Name string `json:"name" db:"name"`
 }
 
+// Option is the admin view of any taxonomy value, including inactive ones.
+type Option struct {
+	ID       int    `json:"id" db:"id"`
+	Name     string `json:"name" db:"name"`
+	Position int    `json:"position" db:"position"`
+	Active   bool   `json:"active" db:"active"`
+}
 
+type OptionInput struct {
+	Taxonomy string `json:"-"`
+	ID       int    `json:"-"`
+	Name     string `json:"name" validate:"required,lte=255"`
+}
 
-
-
+type OptionOrder struct {
+	Taxonomy string `json:"-"`
+	IDs      []int  `json:"ids" validate:"required,min=1,dive,gt=0"`
+}
@@ -0,0 +1,43 @@
+package models
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// Pagination is the envelope returned alongside paginated list responses.
+// Next and Prev are page numbers and are null at either end of the result set.
+type Pagination struct {
+	Page       int  `json:"page"`
+	Limit      int  `json:"limit"`
+	Total      int  `json:"total"`
+	TotalPages int  `json:"totalPages"`
+	Next       *int `json:"next"`
+	Prev       *int `json:"prev"`
+}
+
+// NewPagination builds the envelope for the given page, page size and total
+// number of matching rows.
+func NewPagination(page, limit, total int) *Pagination {
+	pagination := &Pagination{
+		Page:  page,
+		Limit: limit,
+		Total: total,
+	}
+
+	if limit > 0 {
+		pagination.TotalPages = (total + limit - 1) / limit
+	}
+
+	if page < pagination.TotalPages {
+		next := page + 1
+		pagination.Next = &next
+	}
+
+	if page > 1 {
+		prev := page - 1
+		pagination.Prev = &prev
+	}
+
+	return pagination
+}
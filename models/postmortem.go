@@ -0,0 +1,136 @@
+package models
+
+import "strings"
+
+// Postmortem states. A draft is editable; submitting it asks the named
+// reviewers for approval, and it is approved once every reviewer approved.
+const (
+	PostmortemDraft    = "draft"
+	PostmortemInReview = "in_review"
+	PostmortemApproved = "approved"
+)
+
+// PostmortemSections is the structured content of a postmortem, shared by
+// postmortems and the per-type templates they start from.
+type PostmortemSections struct {
+	Summary             string `json:"summary" db:"summary" validate:"lte=20000"`
+	Timeline            string `json:"timeline" db:"timeline" validate:"lte=20000"`
+	RootCause           string `json:"rootCause" db:"root_cause" validate:"lte=20000"`
+	ContributingFactors string `json:"contributingFactors" db:"contributing_factors" validate:"lte=20000"`
+	WentWell            string `json:"wentWell" db:"went_well" validate:"lte=20000"`
+	WentBadly           string `json:"wentBadly" db:"went_badly" validate:"lte=20000"`
+	Lessons             string `json:"lessons" db:"lessons" validate:"lte=20000"`
+}
+
+// DefaultPostmortemTemplate is used for incident types without a template.
+var DefaultPostmortemTemplate = PostmortemSections{
+	Summary:             "What happened, who was affected and for how long.",
+	Timeline:            "- HH:MM Impact started\n- HH:MM Incident reported\n- HH:MM Mitigated\n- HH:MM Resolved",
+	RootCause:           "The underlying cause, not the trigger.",
+	ContributingFactors: "- ",
+	WentWell:            "- ",
+	WentBadly:           "- ",
+	Lessons:             "- ",
+}
+
+// Markdown renders the sections as a single document, which is what the
+// incident's post_mortem column keeps for search and older clients.
+func (s *PostmortemSections) Markdown() string {
+	sections := []struct{ title, body string }{
+		{"Summary", s.Summary},
+		{"Timeline", s.Timeline},
+		{"Root cause", s.RootCause},
+		{"Contributing factors", s.ContributingFactors},
+		{"What went well", s.WentWell},
+		{"What went badly", s.WentBadly},
+		{"Lessons learned", s.Lessons},
+	}
+
+	var b strings.Builder
+	for _, section := range sections {
+		if strings.TrimSpace(section.body) == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString("## " + section.title + "\n\n" + strings.TrimSpace(section.body))
+	}
+	return b.String()
+}
+
+type Postmortem struct {
+	IncidentID int    `json:"incidentId" db:"incident_id"`
+	Status     string `json:"status" db:"status"`
+	PostmortemSections
+	AuthorID    int                   `json:"authorId" db:"author_id"`
+	AuthorName  string                `json:"authorName" db:"author_name"`
+	Reviewers   []*PostmortemReviewer `json:"reviewers" db:"-"`
+	CreatedAt   *CustomTime           `json:"createdAt" db:"created_at"`
+	UpdatedAt   *CustomTime           `json:"updatedAt" db:"updated_at"`
+	SubmittedAt *CustomTime           `json:"submittedAt" db:"submitted_at"`
+	ApprovedAt  *CustomTime           `json:"approvedAt" db:"approved_at"`
+}
+
+type PostmortemReviewer struct {
+	UserID     int         `json:"userId" db:"user_id"`
+	Name       string      `json:"name" db:"name"`
+	Avatar     *string     `json:"avatar" db:"avatar_url"`
+	ApprovedAt *CustomTime `json:"approvedAt" db:"approved_at"`
+}
+
+// PostmortemEdit changes a draft; omitted sections are kept.
+type PostmortemEdit struct {
+	IncidentID          int     `json:"-"`
+	Summary             *string `json:"summary" validate:"omitempty,lte=20000"`
+	Timeline            *string `json:"timeline" validate:"omitempty,lte=20000"`
+	RootCause           *string `json:"rootCause" validate:"omitempty,lte=20000"`
+	ContributingFactors *string `json:"contributingFactors" validate:"omitempty,lte=20000"`
+	WentWell            *string `json:"wentWell" validate:"omitempty,lte=20000"`
+	WentBadly           *string `json:"wentBadly" validate:"omitempty,lte=20000"`
+	Lessons             *string `json:"lessons" validate:"omitempty,lte=20000"`
+}
+
+// Apply copies the edited sections onto s.
+func (e *PostmortemEdit) Apply(s *PostmortemSections) {
+	for _, field := range []struct {
+		value  *string
+		target *string
+	}{
+		{e.Summary, &s.Summary},
+		{e.Timeline, &s.Timeline},
+		{e.RootCause, &s.RootCause},
+		{e.ContributingFactors, &s.ContributingFactors},
+		{e.WentWell, &s.WentWell},
+		{e.WentBadly, &s.WentBadly},
+		{e.Lessons, &s.Lessons},
+	} {
+		if field.value != nil {
+			*field.target = *field.value
+		}
+	}
+}
+
+// PostmortemSubmission sends a draft to review by the listed users.
+type PostmortemSubmission struct {
+	IncidentID int   `json:"-"`
+	Reviewers  []int `json:"reviewers" validate:"required,min=1,max=10,unique,dive,gt=0"`
+}
+
+// PostmortemChangeRequest sends a postmortem in review back to draft.
+type PostmortemChangeRequest struct {
+	IncidentID int    `json:"-"`
+	Reason     string `json:"reason" validate:"required,lte=2000"`
+}
+
+type PostmortemTemplate struct {
+	TypeID   int    `json:"typeId" db:"type_id"`
+	TypeName string `json:"typeName" db:"type_name"`
+	PostmortemSections
+	UpdatedAt *CustomTime `json:"updatedAt" db:"updated_at"`
+}
+
+type PostmortemTemplateInput struct {
+	TypeID int `json:"-"`
+	PostmortemSections
+}
@@ -0,0 +1,85 @@
+package models
+
+const (
+	RoleViewer          = "Viewer"
+	RoleResponder       = "Responder"
+	RoleIncidentManager = "Incident Manager"
+	RoleAdmin           = "Admin"
+)
+
+const (
+	PermissionIncidentsRead      = "incidents:read"
+	PermissionIncidentsCreate    = "incidents:create"
+	PermissionIncidentsUpdate    = "incidents:update"
+	PermissionIncidentsManage    = "incidents:manage"
+	PermissionUsersManage        = "users:manage"
+	PermissionOptionsManage      = "options:manage"
+	PermissionWebhooksManage     = "webhooks:manage"
+	PermissionIntegrationsManage = "integrations:manage"
+)
+
+// rolePermissions lists what each role may do. Roles are cumulative: every
+// role holds the permissions of the roles below it.
+var rolePermissions = map[string][]string{
+	RoleViewer: {
+		PermissionIncidentsRead,
+		PermissionIncidentsCreate,
+	},
+	RoleResponder: {
+		PermissionIncidentsRead,
+		PermissionIncidentsCreate,
+		PermissionIncidentsUpdate,
+	},
+	RoleIncidentManager: {
+		PermissionIncidentsRead,
+		PermissionIncidentsCreate,
+		PermissionIncidentsUpdate,
+		PermissionIncidentsManage,
+	},
+	RoleAdmin: {
+		PermissionIncidentsRead,
+		PermissionIncidentsCreate,
+		PermissionIncidentsUpdate,
+		PermissionIncidentsManage,
+		PermissionUsersManage,
+		PermissionOptionsManage,
+		PermissionWebhooksManage,
+		PermissionIntegrationsManage,
+	},
+}
+
+// Roles returns the known role names, lowest privilege first.
+func Roles() []string {
+	return []string{RoleViewer, RoleResponder, RoleIncidentManager, RoleAdmin}
+}
+
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// IsValidPermission reports whether the permission is granted by any role.
+func IsValidPermission(permission string) bool {
+	return HasPermission(RoleAdmin, permission)
+}
+
+// HasPermission reports whether the role grants the permission. Unknown roles
+// grant nothing.
+func HasPermission(role, permission string) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+type UserRoleUpdate struct {
+	ID   int    `json:"-"`
+	Role string `json:"role" validate:"required"`
+}
+
+type UserTeamUpdate struct {
+	ID   int    `json:"-"`
+	Team string `json:"team" validate:"required,lte=255"`
+}
@@ -0,0 +1,44 @@
+package models
+
+// Security event types recorded for review.
+const (
+	SecurityEventAccountLocked   = "account_locked"
+	SecurityEventIPLocked        = "ip_locked"
+	SecurityEventAccountUnlocked = "account_unlocked"
+	SecurityEventTwoFactorOn     = "two_factor_enabled"
+	SecurityEventTwoFactorOff    = "two_factor_disabled"
+	SecurityEventRecoveryCode    = "recovery_code_used"
+	SecurityEventOIDCLinked      = "oidc_identity_linked"
+	SecurityEventPasswordChanged = "password_changed"
+	SecurityEventDeactivated     = "account_deactivated"
+	SecurityEventReactivated     = "account_reactivated"
+)
+
+// Login throttling scopes.
+const (
+	LoginScopeAccount = "account"
+	LoginScopeIP      = "ip"
+)
+
+type SecurityEvent struct {
+	ID        int         `json:"id" db:"id"`
+	Type      string      `json:"type" db:"type"`
+	UserID    *int        `json:"userId" db:"user_id"`
+	ActorID   *int        `json:"actorId" db:"actor_id"`
+	Email     *string     `json:"email" db:"email"`
+	IP        *string     `json:"ip" db:"ip"`
+	Detail    *string     `json:"detail" db:"detail"`
+	CreatedAt *CustomTime `json:"createdAt" db:"created_at"`
+}
+
+type SecurityEventQueryParams struct {
+	Type   *string `query:"type"`
+	UserID *int    `query:"user_id" validate:"omitempty,gt=0"`
+	Page   int     `query:"page" validate:"omitempty,gte=1"`
+	Limit  int     `query:"limit" validate:"omitempty,gte=1,lte=100"`
+}
+
+// Offset returns the number of rows to skip for the requested page.
+func (p *SecurityEventQueryParams) Offset() int {
+	return (p.Page - 1) * p.Limit
+}
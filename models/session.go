@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Session is a login session backed by a rotating refresh token.
+type Session struct {
+	ID         int         `json:"id" db:"id"`
+	UserID     int         `json:"-" db:"user_id"`
+	UserAgent  *string     `json:"userAgent" db:"user_agent"`
+	IP         *string     `json:"ip" db:"ip"`
+	CreatedAt  *CustomTime `json:"createdAt" db:"created_at"`
+	LastUsedAt *CustomTime `json:"lastUsedAt" db:"last_used_at"`
+	ExpiresAt  *CustomTime `json:"expiresAt" db:"expires_at"`
+	RevokedAt  *CustomTime `json:"-" db:"revoked_at"`
+	Current    bool        `json:"current" db:"-"`
+}
+
+// AuthTokens is the token pair handed to a client after login or refresh.
+type AuthTokens struct {
+	SessionID        int
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// TokenPurposeLoginChallenge marks the token handed out by the first login
+// step when a second factor is needed.
+const TokenPurposeLoginChallenge = "login_challenge"
+
+// TwoFactorChallenge is returned by Login instead of a session when the user
+// must still prove a second factor, or enroll one first.
+type TwoFactorChallenge struct {
+	Challenge          string    `json:"challenge"`
+	ExpiresAt          time.Time `json:"expiresAt"`
+	EnrollmentRequired bool      `json:"enrollmentRequired"`
+}
+
+// TwoFactorSetup is what an authenticator app needs to start generating
+// codes. The secret is shown once, during enrollment.
+type TwoFactorSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type TwoFactorCode struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type TwoFactorDisable struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type TwoFactorChallengeInput struct {
+	Challenge string `json:"challenge" validate:"required"`
+}
+
+// TwoFactorVerify completes a login challenge with either an authenticator
+// code or one of the recovery codes.
+type TwoFactorVerify struct {
+	Challenge    string `json:"challenge" validate:"required"`
+	Code         string `json:"code" validate:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+	RecoveryCode string `json:"recoveryCode" validate:"required_without=Code,omitempty,lte=32"`
+}
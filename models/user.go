@@ -8,6 +8,64 @@ type User struct {
 	Team string `db:"team" json:"team" validate:"required"`
 	Role string `db:"role" json:"role" validate:"required"`
 	Avatar_url string `db:"avatar_url" json:"avatar_url" validate:"required"`
+	EmailVerifiedAt *CustomTime `db:"email_verified_at" json:"emailVerifiedAt"`
+	TOTPSecret *string `db:"totp_secret" json:"-"`
+	TOTPEnabledAt *CustomTime `db:"totp_enabled_at" json:"twoFactorEnabledAt"`
+	TOTPLastStep *int64 `db:"totp_last_step" json:"-"`
+	IsServiceAccount bool `db:"is_service_account" json:"isServiceAccount"`
+	DeactivatedAt *CustomTime `db:"deactivated_at" json:"deactivatedAt"`
+}
+
+// Profile is what a user may see about an account, without any secrets.
+func (u *User) Profile() *UserProfile {
+	return &UserProfile{
+		ID:                 u.ID,
+		Name:               u.Name,
+		Email:              u.Email,
+		Team:               u.Team,
+		Role:               u.Role,
+		Avatar:             u.Avatar_url,
+		EmailVerifiedAt:    u.EmailVerifiedAt,
+		TwoFactorEnabledAt: u.TOTPEnabledAt,
+		IsServiceAccount:   u.IsServiceAccount,
+		DeactivatedAt:      u.DeactivatedAt,
+	}
+}
+
+// PublicData is what any user may see about another account.
+func (u *User) PublicData() *UserPublicData {
+	return &UserPublicData{
+		ID:     u.ID,
+		Name:   u.Name,
+		Avatar: u.Avatar_url,
+		Team:   u.Team,
+		Role:   u.Role,
+	}
+}
+
+type UserProfile struct {
+	ID                 int         `json:"id"`
+	Name               string      `json:"name"`
+	Email              string      `json:"email"`
+	Team               string      `json:"team"`
+	Role               string      `json:"role"`
+	Avatar             string      `json:"avatar"`
+	EmailVerifiedAt    *CustomTime `json:"emailVerifiedAt"`
+	TwoFactorEnabledAt *CustomTime `json:"twoFactorEnabledAt"`
+	IsServiceAccount   bool        `json:"isServiceAccount"`
+	DeactivatedAt      *CustomTime `json:"deactivatedAt"`
+}
+
+// ProfileUpdate changes the caller's own profile; omitted fields are kept.
+// The team is set by admins through UserTeamUpdate and the avatar is uploaded
+// to POST /me/avatar.
+type ProfileUpdate struct {
+	Name *string `json:"name" validate:"omitempty,gte=1,lte=255"`
+}
+
+type PasswordChange struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	NewPassword     string `json:"newPassword" validate:"required,gte=8,lte=255"`
 }
 
 
@@ -15,4 +73,22 @@ type UserPublicData struct {
 	ID     int    `json:"id" db:"id"`
 	Name   string `json:"name" db:"name"`
 	Avatar string `json:"avatar" db:"avatar_url"`
+	Team   string `json:"team" db:"team"`
+	Role   string `json:"role" db:"role"`
+}
+
+// UserQueryParams filters the user directory. Deactivated users are left out
+// unless IncludeInactive is set.
+type UserQueryParams struct {
+	Search          *string `query:"search" validate:"omitempty,lte=255"`
+	Team            *string `query:"team"`
+	Role            *string `query:"role"`
+	IncludeInactive bool    `query:"include_inactive"`
+	Page            int     `query:"page" validate:"omitempty,gte=1"`
+	Limit           int     `query:"limit" validate:"omitempty,gte=1,lte=100"`
+}
+
+// Offset returns the number of rows to skip for the requested page.
+func (p *UserQueryParams) Offset() int {
+	return (p.Page - 1) * p.Limit
 }
\ No newline at end of file
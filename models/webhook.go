@@ -0,0 +1,184 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+)
+
+// Webhook event types.
+const (
+	WebhookIncidentCreated         = "incident.created"
+	WebhookIncidentStatusChanged   = "incident.status_changed"
+	WebhookIncidentSeverityChanged = "incident.severity_changed"
+	WebhookIncidentLeadAssigned    = "incident.lead_assigned"
+
+	// WebhookAllEvents subscribes to every event type.
+	WebhookAllEvents = "*"
+)
+
+// Webhook delivery states.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookEventTypes returns the event types subscriptions can filter on.
+func WebhookEventTypes() []string {
+	return []string{WebhookIncidentCreated, WebhookIncidentStatusChanged, WebhookIncidentSeverityChanged, WebhookIncidentLeadAssigned}
+}
+
+// WebhookEventFilter is a set of event types stored as a space separated
+// string.
+type WebhookEventFilter []string
+
+func (f WebhookEventFilter) Value() (driver.Value, error) {
+	return strings.Join(f, " "), nil
+}
+
+func (f *WebhookEventFilter) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*f = nil
+	case string:
+		*f = strings.Fields(v)
+	case []byte:
+		*f = strings.Fields(string(v))
+	default:
+		return fmt.Errorf("cannot scan %T into WebhookEventFilter", value)
+	}
+	return nil
+}
+
+// WebhookPayload is a JSON document kept byte for byte, since the signature
+// covers the exact body.
+type WebhookPayload []byte
+
+func (p WebhookPayload) Value() (driver.Value, error) {
+	return string(p), nil
+}
+
+func (p *WebhookPayload) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		*p = WebhookPayload(v)
+	case []byte:
+		*p = append(WebhookPayload(nil), v...)
+	default:
+		return fmt.Errorf("cannot scan %T into WebhookPayload", value)
+	}
+	return nil
+}
+
+func (p WebhookPayload) MarshalJSON() ([]byte, error) {
+	if len(p) == 0 {
+		return []byte("null"), nil
+	}
+	return p, nil
+}
+
+type WebhookSubscription struct {
+	ID        int                `json:"id" db:"id"`
+	Name      string             `json:"name" db:"name"`
+	URL       string             `json:"url" db:"url"`
+	Secret    string             `json:"-" db:"secret"`
+	Events    WebhookEventFilter `json:"events" db:"events"`
+	Active    bool               `json:"active" db:"active"`
+	CreatedBy *int               `json:"createdBy" db:"created_by"`
+	CreatedAt *CustomTime        `json:"createdAt" db:"created_at"`
+	UpdatedAt *CustomTime        `json:"updatedAt" db:"updated_at"`
+}
+
+type WebhookSubscriptionInput struct {
+	Name   string   `json:"name" validate:"required,lte=255"`
+	URL    string   `json:"url" validate:"required,url,startswith=http,lte=2048"`
+	Events []string `json:"events" validate:"required,min=1,unique,dive,required"`
+	// Secret is generated when omitted
+	Secret string `json:"secret" validate:"omitempty,gte=16,lte=255"`
+}
+
+// WebhookSubscriptionUpdate changes a subscription; omitted fields are kept.
+type WebhookSubscriptionUpdate struct {
+	ID     int      `json:"-"`
+	Name   *string  `json:"name" validate:"omitempty,gte=1,lte=255"`
+	URL    *string  `json:"url" validate:"omitempty,url,startswith=http,lte=2048"`
+	Events []string `json:"events" validate:"omitempty,min=1,unique,dive,required"`
+	Active *bool    `json:"active"`
+}
+
+// CreatedWebhookSubscription carries the signing secret, which is only shown
+// when it is created or rotated.
+type CreatedWebhookSubscription struct {
+	Subscription *WebhookSubscription `json:"subscription"`
+	Secret       string               `json:"secret"`
+}
+
+// WebhookEvent is the envelope every delivery sends.
+type WebhookEvent struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt *CustomTime `json:"createdAt"`
+	Data      interface{} `json:"data"`
+}
+
+// IncidentWebhookData is the data of incident events. Previous holds the
+// values the event changed.
+type IncidentWebhookData struct {
+	Incident *IncidentOutput        `json:"incident"`
+	ActorID  *int                   `json:"actorId"`
+	Previous map[string]interface{} `json:"previous,omitempty"`
+}
+
+type WebhookDelivery struct {
+	ID             int            `json:"id" db:"id"`
+	SubscriptionID int            `json:"subscriptionId" db:"subscription_id"`
+	EventID        string         `json:"eventId" db:"event_id"`
+	EventType      string         `json:"eventType" db:"event_type"`
+	Payload        WebhookPayload `json:"payload" db:"payload"`
+	Status         string         `json:"status" db:"status"`
+	Attempts       int            `json:"attempts" db:"attempts"`
+	NextAttemptAt  *CustomTime    `json:"nextAttemptAt" db:"next_attempt_at"`
+	LastAttemptAt  *CustomTime    `json:"lastAttemptAt" db:"last_attempt_at"`
+	ResponseStatus *int           `json:"responseStatus" db:"response_status"`
+	ResponseBody   *string        `json:"responseBody" db:"response_body"`
+	Error          *string        `json:"error" db:"error"`
+	RedeliveryOf   *int           `json:"redeliveryOf" db:"redelivery_of"`
+	CreatedAt      *CustomTime    `json:"createdAt" db:"created_at"`
+	DeliveredAt    *CustomTime    `json:"deliveredAt" db:"delivered_at"`
+}
+
+// ClaimedWebhookDelivery is a due delivery leased to a worker, together with
+// where and how to send it.
+type ClaimedWebhookDelivery struct {
+	ID             int            `db:"id"`
+	SubscriptionID int            `db:"subscription_id"`
+	EventID        string         `db:"event_id"`
+	EventType      string         `db:"event_type"`
+	Payload        WebhookPayload `db:"payload"`
+	Attempts       int            `db:"attempts"`
+	URL            string         `db:"url"`
+	Secret         string         `db:"secret"`
+}
+
+// WebhookAttempt is the outcome of sending a delivery once.
+type WebhookAttempt struct {
+	DeliveryID     int
+	Status         string
+	NextAttemptAt  *CustomTime
+	ResponseStatus *int
+	ResponseBody   *string
+	Error          *string
+}
+
+type WebhookDeliveryQueryParams struct {
+	SubscriptionID int     `query:"-"`
+	Status         *string `query:"status" validate:"omitempty,oneof=pending succeeded failed"`
+	Page           int     `query:"page" validate:"omitempty,gte=1"`
+	Limit          int     `query:"limit" validate:"omitempty,gte=1,lte=100"`
+}
+
+// Offset returns the number of rows to skip for the requested page.
+func (p *WebhookDeliveryQueryParams) Offset() int {
+	return (p.Page - 1) * p.Limit
+}
@@ -0,0 +1,57 @@
+package oidc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/oidc"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	stub := oidctest.NewProvider()
+	defer stub.Close()
+	stub.Claims = map[string]interface{}{"sub": "user-1", "email": "jane@cloudwalk.io", "email_verified": "true", "name": "Jane"}
+
+	ctx := context.Background()
+	provider := oidc.NewProvider(stub.Config("http://localhost/callback"))
+
+	authURL, err := provider.AuthCodeURL(ctx, "state", "nonce", oidc.CodeChallenge("verifier"))
+	require.NoError(t, err)
+
+	code, state, err := stub.Authorize(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "state", state)
+
+	t.Run("Wrong Verifier", func(t *testing.T) {
+		_, err := provider.Exchange(ctx, code, "other-verifier")
+		assert.Error(t, err)
+	})
+
+	code, _, err = stub.Authorize(authURL)
+	require.NoError(t, err)
+
+	idToken, err := provider.Exchange(ctx, code, "verifier")
+	require.NoError(t, err)
+
+	t.Run("Valid Token", func(t *testing.T) {
+		claims, err := provider.VerifyIDToken(ctx, idToken, "nonce")
+		require.NoError(t, err)
+		assert.Equal(t, &oidc.Claims{Subject: "user-1", Email: "jane@cloudwalk.io", EmailVerified: true, Name: "Jane"}, claims)
+	})
+
+	t.Run("Wrong Nonce", func(t *testing.T) {
+		_, err := provider.VerifyIDToken(ctx, idToken, "other-nonce")
+		assert.Error(t, err)
+	})
+
+	t.Run("Wrong Audience", func(t *testing.T) {
+		config := stub.Config("http://localhost/callback")
+		config.ClientID = "someone-else"
+
+		_, err := oidc.NewProvider(config).VerifyIDToken(ctx, idToken, "nonce")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,214 @@
+// Package oidctest runs a local OpenID Connect provider for tests.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/oidc"
+)
+
+const keyID = "oidctest"
+
+// Provider signs in whoever is in Claims: /authorize redirects straight back
+// with a code, and /token returns an ID token for those claims.
+type Provider struct {
+	Server       *httptest.Server
+	ClientID     string
+	ClientSecret string
+
+	// Claims are added to every ID token issued after they are set
+	Claims map[string]interface{}
+
+	key   *rsa.PrivateKey
+	mu    sync.Mutex
+	codes map[string]authorization
+}
+
+type authorization struct {
+	nonce         string
+	codeChallenge string
+	redirectURI   string
+	claims        map[string]interface{}
+}
+
+func NewProvider() *Provider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	p := &Provider{
+		ClientID:     "firewatchers",
+		ClientSecret: "secret",
+		Claims:       map[string]interface{}{},
+		key:          key,
+		codes:        map[string]authorization{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/authorize", p.authorize)
+	mux.HandleFunc("/token", p.token)
+	mux.HandleFunc("/jwks", p.jwks)
+	p.Server = httptest.NewServer(mux)
+
+	return p
+}
+
+func (p *Provider) Close() {
+	p.Server.Close()
+}
+
+// Config is a client configuration pointing at this provider.
+func (p *Provider) Config(redirectURL string) oidc.Config {
+	return oidc.Config{
+		Issuer:       p.Server.URL,
+		ClientID:     p.ClientID,
+		ClientSecret: p.ClientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"openid", "email", "profile"},
+	}
+}
+
+// Authorize follows an authorization URL the way a browser would and returns
+// the code and state the provider redirected back with.
+func (p *Provider) Authorize(authURL string) (code, state string, err error) {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	resp, err := client.Get(authURL)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return "", "", err
+	}
+
+	return location.Query().Get("code"), location.Query().Get("state"), nil
+}
+
+func (p *Provider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]string{
+		"issuer":                 p.Server.URL,
+		"authorization_endpoint": p.Server.URL + "/authorize",
+		"token_endpoint":         p.Server.URL + "/token",
+		"jwks_uri":               p.Server.URL + "/jwks",
+	})
+}
+
+func (p *Provider) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != p.ClientID || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	code := randomString()
+
+	p.mu.Lock()
+	claims := make(map[string]interface{}, len(p.Claims))
+	for name, value := range p.Claims {
+		claims[name] = value
+	}
+	p.codes[code] = authorization{
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+		redirectURI:   query.Get("redirect_uri"),
+		claims:        claims,
+	}
+	p.mu.Unlock()
+
+	redirect, _ := url.Parse(query.Get("redirect_uri"))
+	values := redirect.Query()
+	values.Set("code", code)
+	values.Set("state", query.Get("state"))
+	redirect.RawQuery = values.Encode()
+
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok || clientID != p.ClientID || clientSecret != p.ClientSecret {
+		http.Error(w, "invalid client", http.StatusUnauthorized)
+		return
+	}
+
+	code := r.PostFormValue("code")
+
+	p.mu.Lock()
+	auth, ok := p.codes[code]
+	delete(p.codes, code)
+	p.mu.Unlock()
+
+	if !ok || r.PostFormValue("redirect_uri") != auth.redirectURI ||
+		oidc.CodeChallenge(r.PostFormValue("code_verifier")) != auth.codeChallenge {
+		http.Error(w, "invalid grant", http.StatusBadRequest)
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":   p.Server.URL,
+		"aud":   p.ClientID,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"nonce": auth.nonce,
+	}
+	for name, value := range auth.claims {
+		claims[name] = value
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyID
+	idToken, err := token.SignedString(p.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, map[string]string{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"id_token":     idToken,
+	})
+}
+
+func (p *Provider) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kid": keyID,
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+func randomString() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
@@ -0,0 +1,261 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+)
+
+const actionItemEventField = "action_item"
+
+type ActionItemRepository interface {
+	CreateActionItem(item *models.ActionItem) (int, error)
+	GetActionItems(incidentID int) ([]*models.ActionItem, error)
+	GetActionItemByID(incidentID, itemID int) (*models.ActionItem, error)
+	UpdateActionItem(previous, item *models.ActionItem, actorID int) error
+	DeleteActionItem(item *models.ActionItem, actorID int) error
+	ListActionItems(qp *models.ActionItemQueryParams) ([]*models.ActionItem, int, error)
+}
+
+type actionItemRepository struct {
+	db *sqlx.DB
+}
+
+func NewActionItemRepository(db *sqlx.DB) ActionItemRepository {
+	return &actionItemRepository{db: db}
+}
+
+const actionItemSelect = `
+	SELECT
+		a.id, a.incident_id, a.title, a.owner_id, a.due_at, a.priority, a.status, a.blocking,
+		a.ticket_url, a.created_by, a.created_at, a.updated_at, a.completed_at,
+		i.title AS incident_title,
+		owner.name AS owner_name,
+		owner.avatar_url AS owner_avatar
+	FROM
+		action_items a
+	JOIN
+		incidents i ON a.incident_id = i.id
+	LEFT JOIN
+		users owner ON a.owner_id = owner.id
+	`
+
+// actionItemEventValue is how an item shows up in the incident's timeline.
+func actionItemEventValue(item *models.ActionItem) *string {
+	return stringValue(fmt.Sprintf("%s (%s)", item.Title, item.Status))
+}
+
+func (r *actionItemRepository) CreateActionItem(item *models.ActionItem) (int, error) {
+	log.Printf("CreateActionItem: Creating action item for incident ID %d", item.IncidentID)
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		log.Printf("CreateActionItem: Error starting transaction: %v", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO action_items (incident_id, title, owner_id, due_at, priority, status, blocking, ticket_url, created_by)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	RETURNING id
+	`
+
+	var id int
+	err = tx.Get(&id, query, item.IncidentID, item.Title, item.OwnerID, item.DueAt, item.Priority, item.Status, item.Blocking, item.TicketURL, item.CreatedBy)
+	if err != nil {
+		log.Printf("CreateActionItem: Error executing query: %v", err)
+		return 0, err
+	}
+
+	event := newIncidentEvent(item.IncidentID, item.CreatedBy, actionItemEventField, nil, actionItemEventValue(item))
+	if err := insertIncidentEvents(tx, event); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("CreateActionItem: Error committing transaction: %v", err)
+		return 0, err
+	}
+
+	log.Printf("CreateActionItem: Action item created with ID %d", id)
+	return id, nil
+}
+
+func (r *actionItemRepository) GetActionItems(incidentID int) ([]*models.ActionItem, error) {
+	log.Printf("GetActionItems: Retrieving action items of incident ID %d", incidentID)
+
+	query := actionItemSelect + `WHERE a.incident_id = $1 ORDER BY a.created_at, a.id`
+
+	items := []*models.ActionItem{}
+	if err := r.db.Select(&items, query, incidentID); err != nil {
+		log.Printf("GetActionItems: Error executing query: %v", err)
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *actionItemRepository) GetActionItemByID(incidentID, itemID int) (*models.ActionItem, error) {
+	log.Printf("GetActionItemByID: Retrieving action item %d of incident ID %d", itemID, incidentID)
+
+	query := actionItemSelect + `WHERE a.incident_id = $1 AND a.id = $2`
+
+	item := new(models.ActionItem)
+	err := r.db.Get(item, query, incidentID, itemID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &customErrors.NotFoundError{Msg: fmt.Sprintf("action item with ID %d not found", itemID)}
+	}
+	if err != nil {
+		log.Printf("GetActionItemByID: Error executing query: %v", err)
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// UpdateActionItem saves every field of item, recording status changes in the
+// incident's timeline.
+func (r *actionItemRepository) UpdateActionItem(previous, item *models.ActionItem, actorID int) error {
+	log.Printf("UpdateActionItem: Updating action item %d", item.ID)
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		log.Printf("UpdateActionItem: Error starting transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockActionItemIncident(tx, item.IncidentID); err != nil {
+		return err
+	}
+
+	query := `
+	UPDATE action_items
+	SET title = $1, owner_id = $2, due_at = $3, priority = $4, status = $5, blocking = $6,
+		ticket_url = $7, completed_at = $8, updated_at = NOW()
+	WHERE id = $9 AND incident_id = $10
+	`
+
+	result, err := tx.Exec(query, item.Title, item.OwnerID, item.DueAt, item.Priority, item.Status, item.Blocking,
+		item.TicketURL, item.CompletedAt, item.ID, item.IncidentID)
+	if err != nil {
+		log.Printf("UpdateActionItem: Error executing update query: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("UpdateActionItem: Error getting rows affected: %v", err)
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return &customErrors.NotFoundError{Msg: fmt.Sprintf("action item with ID %d not found", item.ID)}
+	}
+
+	if previous.Status != item.Status {
+		event := newIncidentEvent(item.IncidentID, actorID, actionItemEventField, actionItemEventValue(previous), actionItemEventValue(item))
+		if err := insertIncidentEvents(tx, event); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("UpdateActionItem: Error committing transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *actionItemRepository) DeleteActionItem(item *models.ActionItem, actorID int) error {
+	log.Printf("DeleteActionItem: Deleting action item %d of incident ID %d", item.ID, item.IncidentID)
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		log.Printf("DeleteActionItem: Error starting transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM action_items WHERE id = $1 AND incident_id = $2`, item.ID, item.IncidentID)
+	if err != nil {
+		log.Printf("DeleteActionItem: Error executing delete query: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("DeleteActionItem: Error getting rows affected: %v", err)
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return &customErrors.NotFoundError{Msg: fmt.Sprintf("action item with ID %d not found", item.ID)}
+	}
+
+	event := newIncidentEvent(item.IncidentID, actorID, actionItemEventField, actionItemEventValue(item), nil)
+	if err := insertIncidentEvents(tx, event); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("DeleteActionItem: Error committing transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// ListActionItems lists action items across incidents, soonest due first.
+func (r *actionItemRepository) ListActionItems(qp *models.ActionItemQueryParams) ([]*models.ActionItem, int, error) {
+	log.Println("ListActionItems: Retrieving action items")
+
+	where := `
+	WHERE ($1::integer IS NULL OR a.owner_id = $1)
+	AND (($2::text IS NULL AND a.status IN ('open', 'in_progress')) OR a.status = $2)
+	AND (NOT $3::boolean OR (a.due_at < NOW() AND a.status IN ('open', 'in_progress')))
+	`
+
+	var total int
+	if err := r.db.Get(&total, `SELECT COUNT(*) FROM action_items a`+where, qp.OwnerID, qp.Status, qp.Overdue); err != nil {
+		log.Printf("ListActionItems: Error counting action items: %v", err)
+		return nil, 0, err
+	}
+
+	query := actionItemSelect + where + ` ORDER BY a.due_at ASC NULLS LAST, a.id LIMIT $4 OFFSET $5`
+
+	items := []*models.ActionItem{}
+	if err := r.db.Select(&items, query, qp.OwnerID, qp.Status, qp.Overdue, qp.Limit, qp.Offset()); err != nil {
+		log.Printf("ListActionItems: Error executing query: %v", err)
+		return nil, 0, err
+	}
+
+	log.Printf("ListActionItems: Retrieved %d of %d action items", len(items), total)
+	return items, total, nil
+}
+
+func countOpenBlockingActionItems(tx *sqlx.Tx, incidentID int) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM action_items WHERE incident_id = $1 AND blocking AND status IN ('open', 'in_progress')`
+	if err := tx.Get(&count, query, incidentID); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// lockActionItemIncident holds the incident while one of its action items
+// changes, so a status transition checking its blocking items waits for it.
+func lockActionItemIncident(tx *sqlx.Tx, incidentID int) error {
+	if _, err := tx.Exec(`SELECT 1 FROM incidents WHERE id = $1 FOR SHARE`, incidentID); err != nil {
+		log.Printf("lockActionItemIncident: Error locking incident ID %d: %v", incidentID, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,391 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/jmoiron/sqlx"
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+)
+
+type AlertRepository interface {
+	GetMappingRules() ([]*models.AlertMappingRule, error)
+	GetMappingRuleByID(id int) (*models.AlertMappingRule, error)
+	CreateMappingRule(rule *models.AlertMappingRuleInput) (int, error)
+	UpdateMappingRule(rule *models.AlertMappingRuleInput) error
+	DeleteMappingRule(id int) error
+	FindOpenIncidentAlert(source, fingerprint string, closedStatuses []string) (*models.IncidentAlert, error)
+	RecordIncidentAlert(incidentID int, alert *models.Alert, actorID int) (bool, error)
+	CreateIntegration(integration *models.AlertIntegration, tokenHash string) (int, error)
+	GetIntegrations() ([]*models.AlertIntegration, error)
+	GetIntegrationByID(id int) (*models.AlertIntegration, error)
+	UpdateIntegration(integration *models.AlertIntegration) error
+	UpdateIntegrationToken(id int, prefix, tokenHash string) error
+	DeleteIntegration(id int) error
+	GetIntegrationByToken(tokenHash string) (*models.AlertIntegration, error)
+	TouchIntegration(id int) error
+}
+
+type alertRepository struct {
+	db *sqlx.DB
+}
+
+func NewAlertRepository(db *sqlx.DB) AlertRepository {
+	return &alertRepository{db: db}
+}
+
+const alertMappingRuleColumns = `id, label, value, field, option_id, position, created_at, updated_at`
+
+const alertIntegrationColumns = `
+	i.id, i.name, i.kind, i.token_prefix, i.mapping, i.reporter_id, i.active, i.created_by, i.created_at, i.updated_at,
+	i.last_used_at
+	`
+
+const incidentAlertColumns = `
+	a.id, a.incident_id, a.source, a.fingerprint, a.name, a.status, a.labels, a.annotations, a.generator_url,
+	a.starts_at, a.ends_at, a.created_at, a.updated_at
+	`
+
+func (r *alertRepository) GetMappingRules() ([]*models.AlertMappingRule, error) {
+	log.Println("GetMappingRules: Retrieving alert mapping rules")
+
+	rules := []*models.AlertMappingRule{}
+	if err := r.db.Select(&rules, `SELECT `+alertMappingRuleColumns+` FROM alert_mapping_rules ORDER BY position, id`); err != nil {
+		log.Printf("GetMappingRules: Error executing query: %v", err)
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func (r *alertRepository) GetMappingRuleByID(id int) (*models.AlertMappingRule, error) {
+	rule := new(models.AlertMappingRule)
+	err := r.db.Get(rule, `SELECT `+alertMappingRuleColumns+` FROM alert_mapping_rules WHERE id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &customErrors.NotFoundError{Msg: "alert mapping rule not found"}
+	}
+	if err != nil {
+		log.Printf("GetMappingRuleByID: Error executing query: %v", err)
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (r *alertRepository) CreateMappingRule(rule *models.AlertMappingRuleInput) (int, error) {
+	log.Printf("CreateMappingRule: Mapping label %s=%s to %s option %d", rule.Label, rule.Value, rule.Field, rule.OptionID)
+
+	query := `
+	INSERT INTO alert_mapping_rules (label, value, field, option_id, position)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING id
+	`
+
+	var id int
+	if err := r.db.Get(&id, query, rule.Label, rule.Value, rule.Field, rule.OptionID, rule.Position); err != nil {
+		log.Printf("CreateMappingRule: Error executing query: %v", err)
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *alertRepository) UpdateMappingRule(rule *models.AlertMappingRuleInput) error {
+	log.Printf("UpdateMappingRule: Updating alert mapping rule %d", rule.ID)
+
+	query := `
+	UPDATE alert_mapping_rules
+	SET label = $2, value = $3, field = $4, option_id = $5, position = $6, updated_at = NOW()
+	WHERE id = $1
+	`
+
+	result, err := r.db.Exec(query, rule.ID, rule.Label, rule.Value, rule.Field, rule.OptionID, rule.Position)
+	if err != nil {
+		log.Printf("UpdateMappingRule: Error executing query: %v", err)
+		return err
+	}
+
+	return expectMappingRuleRow(result, rule.ID)
+}
+
+func (r *alertRepository) DeleteMappingRule(id int) error {
+	log.Printf("DeleteMappingRule: Deleting alert mapping rule %d", id)
+
+	result, err := r.db.Exec(`DELETE FROM alert_mapping_rules WHERE id = $1`, id)
+	if err != nil {
+		log.Printf("DeleteMappingRule: Error executing query: %v", err)
+		return err
+	}
+
+	return expectMappingRuleRow(result, id)
+}
+
+// FindOpenIncidentAlert returns the most recent link of the alert to an
+// incident whose status is not one of closedStatuses.
+func (r *alertRepository) FindOpenIncidentAlert(source, fingerprint string, closedStatuses []string) (*models.IncidentAlert, error) {
+	return findOpenIncidentAlert(r.db, source, fingerprint, closedStatuses)
+}
+
+func findOpenIncidentAlert(db sqlx.Ext, source, fingerprint string, closedStatuses []string) (*models.IncidentAlert, error) {
+	query, args, err := sqlx.In(`
+	SELECT `+incidentAlertColumns+`
+	FROM incident_alerts a
+	JOIN incidents i ON i.id = a.incident_id
+	WHERE a.source = ? AND a.fingerprint = ? AND LOWER(i.status) NOT IN (?)
+	ORDER BY a.created_at DESC, a.id DESC
+	LIMIT 1
+	`, source, fingerprint, closedStatuses)
+	if err != nil {
+		return nil, err
+	}
+
+	alert := new(models.IncidentAlert)
+	err = sqlx.Get(db, alert, db.Rebind(query), args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &customErrors.NotFoundError{Msg: "no open incident for alert"}
+	}
+	if err != nil {
+		log.Printf("FindOpenIncidentAlert: Error executing query: %v", err)
+		return nil, err
+	}
+
+	return alert, nil
+}
+
+// lockAlerts takes a transaction scoped advisory lock on each alert, so
+// concurrent notifications for the same alert are handled one at a time,
+// whichever instance receives them. Locks are taken in a fixed order to
+// avoid deadlocks between overlapping batches.
+func lockAlerts(tx *sqlx.Tx, alerts []*models.Alert) error {
+	sorted := append([]*models.Alert(nil), alerts...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Source != sorted[j].Source {
+			return sorted[i].Source < sorted[j].Source
+		}
+		return sorted[i].Fingerprint < sorted[j].Fingerprint
+	})
+
+	for _, alert := range sorted {
+		if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext($1), hashtext($2))`, alert.Source, alert.Fingerprint); err != nil {
+			log.Printf("lockAlerts: Error locking alert %s: %v", alert.Fingerprint, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordIncidentAlert links the alert to the incident, or refreshes the link,
+// and notes on the timeline when the alert starts firing or resolves. It
+// reports whether the timeline was annotated.
+func (r *alertRepository) RecordIncidentAlert(incidentID int, alert *models.Alert, actorID int) (bool, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		log.Printf("RecordIncidentAlert: Error starting transaction: %v", err)
+		return false, err
+	}
+	defer tx.Rollback()
+
+	annotated, err := recordIncidentAlert(tx, incidentID, alert, actorID)
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("RecordIncidentAlert: Error committing transaction: %v", err)
+		return false, err
+	}
+
+	return annotated, nil
+}
+
+func recordIncidentAlert(tx *sqlx.Tx, incidentID int, alert *models.Alert, actorID int) (bool, error) {
+	var previous *string
+	err := tx.Get(&previous, `
+	SELECT status FROM incident_alerts
+	WHERE incident_id = $1 AND source = $2 AND fingerprint = $3
+	FOR UPDATE
+	`, incidentID, alert.Source, alert.Fingerprint)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("RecordIncidentAlert: Error locking alert %s: %v", alert.Fingerprint, err)
+		return false, err
+	}
+
+	var generatorURL *string
+	if alert.GeneratorURL != "" {
+		generatorURL = &alert.GeneratorURL
+	}
+
+	_, err = tx.Exec(`
+	INSERT INTO incident_alerts (incident_id, source, fingerprint, name, status, labels, annotations, generator_url, starts_at, ends_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	ON CONFLICT (incident_id, source, fingerprint) DO UPDATE
+	SET name = EXCLUDED.name, status = EXCLUDED.status, labels = EXCLUDED.labels, annotations = EXCLUDED.annotations,
+		generator_url = EXCLUDED.generator_url, starts_at = EXCLUDED.starts_at, ends_at = EXCLUDED.ends_at, updated_at = NOW()
+	`, incidentID, alert.Source, alert.Fingerprint, alert.Name, alert.Status, alert.Labels, alert.Annotations, generatorURL,
+		models.CustomTime(alert.StartsAt), models.CustomTime(alert.EndsAt))
+	if err != nil {
+		log.Printf("RecordIncidentAlert: Error saving alert %s: %v", alert.Fingerprint, err)
+		return false, err
+	}
+
+	annotated := previous == nil || *previous != alert.Status
+	if annotated {
+		event := newIncidentEvent(incidentID, actorID, "alert", previous, stringValue(fmt.Sprintf("%s: %s", alert.Status, alert.Name)))
+		if err := insertIncidentEvents(tx, event); err != nil {
+			return false, err
+		}
+	}
+
+	return annotated, nil
+}
+
+func (r *alertRepository) CreateIntegration(integration *models.AlertIntegration, tokenHash string) (int, error) {
+	log.Printf("CreateIntegration: Creating %s alert integration %q", integration.Kind, integration.Name)
+
+	query := `
+	INSERT INTO alert_integrations (name, kind, token_prefix, token_hash, mapping, reporter_id, created_by)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING id
+	`
+
+	var id int
+	err := r.db.Get(&id, query, integration.Name, integration.Kind, integration.TokenPrefix, tokenHash, integration.Mapping,
+		integration.ReporterID, integration.CreatedBy)
+	if err != nil {
+		log.Printf("CreateIntegration: Error executing query: %v", err)
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *alertRepository) GetIntegrations() ([]*models.AlertIntegration, error) {
+	log.Println("GetIntegrations: Retrieving alert integrations")
+
+	integrations := []*models.AlertIntegration{}
+	if err := r.db.Select(&integrations, `SELECT `+alertIntegrationColumns+` FROM alert_integrations i ORDER BY i.name, i.id`); err != nil {
+		log.Printf("GetIntegrations: Error executing query: %v", err)
+		return nil, err
+	}
+
+	return integrations, nil
+}
+
+func (r *alertRepository) GetIntegrationByID(id int) (*models.AlertIntegration, error) {
+	integration := new(models.AlertIntegration)
+	err := r.db.Get(integration, `SELECT `+alertIntegrationColumns+` FROM alert_integrations i WHERE i.id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &customErrors.NotFoundError{Msg: fmt.Sprintf("alert integration with ID %d not found", id)}
+	}
+	if err != nil {
+		log.Printf("GetIntegrationByID: Error executing query: %v", err)
+		return nil, err
+	}
+
+	return integration, nil
+}
+
+func (r *alertRepository) UpdateIntegration(integration *models.AlertIntegration) error {
+	log.Printf("UpdateIntegration: Updating alert integration %d", integration.ID)
+
+	query := `
+	UPDATE alert_integrations
+	SET name = $2, mapping = $3, reporter_id = $4, active = $5, updated_at = NOW()
+	WHERE id = $1
+	`
+
+	result, err := r.db.Exec(query, integration.ID, integration.Name, integration.Mapping, integration.ReporterID, integration.Active)
+	if err != nil {
+		log.Printf("UpdateIntegration: Error executing query: %v", err)
+		return err
+	}
+
+	return expectIntegrationRow(result, integration.ID)
+}
+
+func (r *alertRepository) UpdateIntegrationToken(id int, prefix, tokenHash string) error {
+	log.Printf("UpdateIntegrationToken: Rotating token of alert integration %d", id)
+
+	query := `UPDATE alert_integrations SET token_prefix = $2, token_hash = $3, updated_at = NOW() WHERE id = $1`
+	result, err := r.db.Exec(query, id, prefix, tokenHash)
+	if err != nil {
+		log.Printf("UpdateIntegrationToken: Error executing query: %v", err)
+		return err
+	}
+
+	return expectIntegrationRow(result, id)
+}
+
+func (r *alertRepository) DeleteIntegration(id int) error {
+	log.Printf("DeleteIntegration: Deleting alert integration %d", id)
+
+	result, err := r.db.Exec(`DELETE FROM alert_integrations WHERE id = $1`, id)
+	if err != nil {
+		log.Printf("DeleteIntegration: Error executing query: %v", err)
+		return err
+	}
+
+	return expectIntegrationRow(result, id)
+}
+
+// GetIntegrationByToken resolves the token of an active integration whose
+// reporter is still active.
+func (r *alertRepository) GetIntegrationByToken(tokenHash string) (*models.AlertIntegration, error) {
+	query := `
+	SELECT ` + alertIntegrationColumns + `
+	FROM alert_integrations i
+	JOIN users u ON u.id = i.reporter_id
+	WHERE i.token_hash = $1 AND i.active AND u.deactivated_at IS NULL
+	`
+
+	integration := new(models.AlertIntegration)
+	err := r.db.Get(integration, query, tokenHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &customErrors.NotFoundError{Msg: "alert integration not found"}
+	}
+	if err != nil {
+		log.Printf("GetIntegrationByToken: Error executing query: %v", err)
+		return nil, err
+	}
+
+	return integration, nil
+}
+
+// TouchIntegration records a use of the integration, at most once a minute.
+func (r *alertRepository) TouchIntegration(id int) error {
+	query := `UPDATE alert_integrations SET last_used_at = NOW() WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')`
+	if _, err := r.db.Exec(query, id); err != nil {
+		log.Printf("TouchIntegration: Error executing query: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func expectIntegrationRow(result sql.Result, id int) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return &customErrors.NotFoundError{Msg: fmt.Sprintf("alert integration with ID %d not found", id)}
+	}
+	return nil
+}
+
+func expectMappingRuleRow(result sql.Result, id int) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return &customErrors.NotFoundError{Msg: fmt.Sprintf("alert mapping rule with ID %d not found", id)}
+	}
+	return nil
+}
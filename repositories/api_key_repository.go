@@ -0,0 +1,122 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+)
+
+type APIKeyRepository interface {
+	CreateAPIKey(key *models.APIKey, keyHash string) (int, error)
+	ListAPIKeys(userID int) ([]*models.APIKey, error)
+	RevokeAPIKey(userID, id int) error
+	GetAPIKeyPrincipal(keyHash string) (*models.APIKeyPrincipal, error)
+	TouchAPIKey(id int) error
+}
+
+type apiKeyRepository struct {
+	db *sqlx.DB
+}
+
+func NewAPIKeyRepository(db *sqlx.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) CreateAPIKey(key *models.APIKey, keyHash string) (int, error) {
+	log.Printf("CreateAPIKey: Creating API key %q for user ID %d", key.Name, key.UserID)
+
+	query := `
+	INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, created_by, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING id
+	`
+
+	var id int
+	if err := r.db.Get(&id, query, key.UserID, key.Name, key.Prefix, keyHash, key.Scopes, key.CreatedBy, key.ExpiresAt); err != nil {
+		log.Printf("CreateAPIKey: Error executing query: %v", err)
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *apiKeyRepository) ListAPIKeys(userID int) ([]*models.APIKey, error) {
+	log.Printf("ListAPIKeys: Retrieving API keys of user ID %d", userID)
+
+	query := `
+	SELECT id, user_id, name, prefix, scopes, created_by, created_at, expires_at, last_used_at
+	FROM api_keys
+	WHERE user_id = $1 AND revoked_at IS NULL
+	ORDER BY created_at DESC
+	`
+
+	keys := []*models.APIKey{}
+	if err := r.db.Select(&keys, query, userID); err != nil {
+		log.Printf("ListAPIKeys: Error executing query: %v", err)
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (r *apiKeyRepository) RevokeAPIKey(userID, id int) error {
+	log.Printf("RevokeAPIKey: Revoking API key %d of user ID %d", id, userID)
+
+	result, err := r.db.Exec(`UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`, id, userID)
+	if err != nil {
+		log.Printf("RevokeAPIKey: Error executing query: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return &customErrors.NotFoundError{Msg: fmt.Sprintf("API key with ID %d not found", id)}
+	}
+
+	return nil
+}
+
+// GetAPIKeyPrincipal resolves an active, unexpired key of an active user to
+// its owner.
+func (r *apiKeyRepository) GetAPIKeyPrincipal(keyHash string) (*models.APIKeyPrincipal, error) {
+	query := `
+	SELECT k.id, k.user_id, u.role, k.scopes
+	FROM api_keys k
+	JOIN users u ON u.id = k.user_id
+	WHERE k.key_hash = $1 AND k.revoked_at IS NULL AND (k.expires_at IS NULL OR k.expires_at > NOW())
+	AND u.deactivated_at IS NULL
+	`
+
+	principal := new(models.APIKeyPrincipal)
+	err := r.db.Get(principal, query, keyHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &customErrors.NotFoundError{Msg: "API key not found"}
+	}
+	if err != nil {
+		log.Printf("GetAPIKeyPrincipal: Error executing query: %v", err)
+		return nil, err
+	}
+
+	return principal, nil
+}
+
+// TouchAPIKey records a use of the key, at most once a minute so busy bots
+// do not turn every request into a write.
+func (r *apiKeyRepository) TouchAPIKey(id int) error {
+	query := `UPDATE api_keys SET last_used_at = NOW() WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')`
+	if _, err := r.db.Exec(query, id); err != nil {
+		log.Printf("TouchAPIKey: Error executing query: %v", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+)
+
+// attachmentEventField is the timeline field of attachment changes.
+const attachmentEventField = "attachment"
+
+type IncidentAttachmentRepository interface {
+	CreateAttachment(attachment *models.IncidentAttachment) (int, error)
+	GetAttachments(incidentID int) ([]*models.IncidentAttachment, error)
+	GetAttachmentByID(incidentID, attachmentID int) (*models.IncidentAttachment, error)
+	DeleteAttachment(attachment *models.IncidentAttachment, actorID int) error
+}
+
+type incidentAttachmentRepository struct {
+	db *sqlx.DB
+}
+
+func NewIncidentAttachmentRepository(db *sqlx.DB) IncidentAttachmentRepository {
+	return &incidentAttachmentRepository{db: db}
+}
+
+const incidentAttachmentSelect = `
+	SELECT
+		a.id, a.incident_id, a.uploader_id, a.filename, a.mime_type, a.size, a.checksum, a.storage_key, a.created_at,
+		uploader.name AS uploader_name,
+		uploader.avatar_url AS uploader_avatar
+	FROM
+		incident_attachments a
+	JOIN
+		users uploader ON a.uploader_id = uploader.id
+	`
+
+// CreateAttachment stores the metadata and adds the file to the incident's
+// timeline.
+func (r *incidentAttachmentRepository) CreateAttachment(attachment *models.IncidentAttachment) (int, error) {
+	log.Printf("CreateAttachment: Attaching %q to incident ID %d", attachment.Filename, attachment.IncidentID)
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		log.Printf("CreateAttachment: Error starting transaction: %v", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO incident_attachments (incident_id, uploader_id, filename, mime_type, size, checksum, storage_key)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING id
+	`
+
+	var id int
+	err = tx.Get(&id, query, attachment.IncidentID, attachment.UploaderID, attachment.Filename, attachment.MimeType, attachment.Size, attachment.Checksum, attachment.StorageKey)
+	if err != nil {
+		log.Printf("CreateAttachment: Error executing query: %v", err)
+		return 0, err
+	}
+
+	event := &models.IncidentEvent{IncidentID: attachment.IncidentID, ActorID: &attachment.UploaderID, Field: attachmentEventField, NewValue: &attachment.Filename}
+	if err := insertIncidentEvents(tx, event); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("CreateAttachment: Error committing transaction: %v", err)
+		return 0, err
+	}
+
+	log.Printf("CreateAttachment: Attachment created with ID %d", id)
+	return id, nil
+}
+
+func (r *incidentAttachmentRepository) GetAttachments(incidentID int) ([]*models.IncidentAttachment, error) {
+	log.Printf("GetAttachments: Retrieving attachments of incident ID %d", incidentID)
+
+	query := incidentAttachmentSelect + `WHERE a.incident_id = $1 ORDER BY a.created_at DESC, a.id DESC`
+
+	attachments := []*models.IncidentAttachment{}
+	if err := r.db.Select(&attachments, query, incidentID); err != nil {
+		log.Printf("GetAttachments: Error executing query: %v", err)
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+func (r *incidentAttachmentRepository) GetAttachmentByID(incidentID, attachmentID int) (*models.IncidentAttachment, error) {
+	log.Printf("GetAttachmentByID: Retrieving attachment %d of incident ID %d", attachmentID, incidentID)
+
+	query := incidentAttachmentSelect + `WHERE a.incident_id = $1 AND a.id = $2`
+
+	attachment := new(models.IncidentAttachment)
+	err := r.db.Get(attachment, query, incidentID, attachmentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &customErrors.NotFoundError{Msg: fmt.Sprintf("attachment with ID %d not found", attachmentID)}
+	}
+	if err != nil {
+		log.Printf("GetAttachmentByID: Error executing query: %v", err)
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+func (r *incidentAttachmentRepository) DeleteAttachment(attachment *models.IncidentAttachment, actorID int) error {
+	log.Printf("DeleteAttachment: Deleting attachment %d of incident ID %d", attachment.ID, attachment.IncidentID)
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		log.Printf("DeleteAttachment: Error starting transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM incident_attachments WHERE id = $1 AND incident_id = $2`, attachment.ID, attachment.IncidentID)
+	if err != nil {
+		log.Printf("DeleteAttachment: Error executing query: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return &customErrors.NotFoundError{Msg: fmt.Sprintf("attachment with ID %d not found", attachment.ID)}
+	}
+
+	event := &models.IncidentEvent{IncidentID: attachment.IncidentID, ActorID: &actorID, Field: attachmentEventField, OldValue: &attachment.Filename}
+	if err := insertIncidentEvents(tx, event); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("DeleteAttachment: Error committing transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+)
+
+type IncidentEventRepository interface {
+	GetIncidentTimeline(incidentID int) ([]*models.IncidentEvent, error)
+}
+
+type incidentEventRepository struct {
+	db *sqlx.DB
+}
+
+func NewIncidentEventRepository(db *sqlx.DB) IncidentEventRepository {
+	return &incidentEventRepository{db: db}
+}
+
+func (r *incidentEventRepository) GetIncidentTimeline(incidentID int) ([]*models.IncidentEvent, error) {
+	log.Printf("GetIncidentTimeline: Retrieving events for incident ID %d", incidentID)
+
+	query := `
+	SELECT
+		e.id, e.incident_id, e.actor_id, e.field, e.old_value, e.new_value, e.created_at,
+		u.name AS actor_name,
+		u.avatar_url AS actor_avatar
+	FROM
+		incident_events e
+	LEFT JOIN
+		users u ON e.actor_id = u.id
+	WHERE
+		e.incident_id = $1
+	ORDER BY
+		e.created_at, e.id
+	`
+
+	events := []*models.IncidentEvent{}
+	if err := r.db.Select(&events, query, incidentID); err != nil {
+		log.Printf("GetIncidentTimeline: Error executing query: %v", err)
+		return nil, err
+	}
+
+	log.Printf("GetIncidentTimeline: Successfully retrieved %d events", len(events))
+	return events, nil
+}
+
+// insertIncidentEvents records timeline entries inside the caller's
+// transaction so they commit or roll back together with the change itself.
+func insertIncidentEvents(tx *sqlx.Tx, events ...*models.IncidentEvent) error {
+	query := `INSERT INTO incident_events (incident_id, actor_id, field, old_value, new_value) VALUES ($1, $2, $3, $4, $5)`
+
+	for _, event := range events {
+		if _, err := tx.Exec(query, event.IncidentID, event.ActorID, event.Field, event.OldValue, event.NewValue); err != nil {
+			log.Printf("insertIncidentEvents: Error recording %s event: %v", event.Field, err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newIncidentEvent(incidentID, actorID int, field string, oldValue, newValue *string) *models.IncidentEvent {
+	event := &models.IncidentEvent{
+		IncidentID: incidentID,
+		Field:      field,
+		OldValue:   oldValue,
+		NewValue:   newValue,
+	}
+
+	if actorID != 0 {
+		event.ActorID = &actorID
+	}
+
+	return event
+}
+
+func stringValue(value string) *string {
+	return &value
+}
+
+func intValue(value *int) *string {
+	if value == nil {
+		return nil
+	}
+	s := strconv.Itoa(*value)
+	return &s
+}
+
+func sameValue(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
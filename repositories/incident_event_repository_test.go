@@ -0,0 +1,150 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingConnector is a database/sql driver that records the statements it
+// executes and answers queries with canned rows, enough to exercise a
+// repository transaction without a database.
+type recordingConnector struct {
+	// rows holds the single row returned by queries containing the key
+	rows      map[string]cannedRow
+	execs     []recordedExec
+	queries   []recordedExec
+	committed bool
+}
+
+type cannedRow struct {
+	columns []string
+	values  []driver.Value
+}
+
+type recordedExec struct {
+	query string
+	args  []driver.Value
+}
+
+func (c *recordingConnector) Connect(context.Context) (driver.Conn, error) {
+	return &recordingConn{c}, nil
+}
+func (c *recordingConnector) Driver() driver.Driver { return nil }
+
+func (c *recordingConnector) execsOf(prefix string) []recordedExec {
+	var matched []recordedExec
+	for _, exec := range c.execs {
+		if strings.HasPrefix(strings.TrimSpace(exec.query), prefix) {
+			matched = append(matched, exec)
+		}
+	}
+	return matched
+}
+
+type recordingConn struct{ c *recordingConnector }
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return &recordingStmt{c: c.c, query: query}, nil
+}
+func (c *recordingConn) Close() error              { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *recordingConn) Commit() error             { c.c.committed = true; return nil }
+func (c *recordingConn) Rollback() error           { return nil }
+
+type recordingStmt struct {
+	c     *recordingConnector
+	query string
+}
+
+func (s *recordingStmt) Close() error  { return nil }
+func (s *recordingStmt) NumInput() int { return -1 }
+
+func (s *recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.c.execs = append(s.c.execs, recordedExec{query: s.query, args: args})
+	return driver.RowsAffected(1), nil
+}
+
+func (s *recordingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.c.queries = append(s.c.queries, recordedExec{query: s.query, args: args})
+	for key, row := range s.c.rows {
+		if strings.Contains(s.query, key) {
+			return &recordingRows{row: row}, nil
+		}
+	}
+	return &recordingRows{}, nil
+}
+
+type recordingRows struct {
+	row  cannedRow
+	done bool
+}
+
+func (r *recordingRows) Columns() []string { return r.row.columns }
+
+func (r *recordingRows) Close() error { return nil }
+
+func (r *recordingRows) Next(dest []driver.Value) error {
+	if r.row.values == nil || r.done {
+		return io.EOF
+	}
+	copy(dest, r.row.values)
+	r.done = true
+	return nil
+}
+
+func newRecordingIncidentRepository(rows map[string]cannedRow) (*incidentRepository, *recordingConnector) {
+	connector := &recordingConnector{rows: rows}
+	return &incidentRepository{db: sqlx.NewDb(sql.OpenDB(connector), "pgx")}, connector
+}
+
+func TestUpdateIncidentSeverityRecordsEvent(t *testing.T) {
+	t.Run("a change is recorded with its actor and both values", func(t *testing.T) {
+		repo, db := newRecordingIncidentRepository(map[string]cannedRow{"SELECT severity": {[]string{"severity"}, []driver.Value{"SEV3"}}})
+
+		require.NoError(t, repo.UpdateIncidentSeverity(&models.IncidentSeverity{ID: 4, Severity: "SEV1"}, 7))
+
+		events := db.execsOf("INSERT INTO incident_events")
+		require.Len(t, events, 1)
+		assert.Equal(t, []driver.Value{int64(4), int64(7), "severity", "SEV3", "SEV1"}, events[0].args)
+		assert.True(t, db.committed, "the event commits with the update")
+	})
+
+	t.Run("setting the same value records nothing", func(t *testing.T) {
+		repo, db := newRecordingIncidentRepository(map[string]cannedRow{"SELECT severity": {[]string{"severity"}, []driver.Value{"SEV1"}}})
+
+		require.NoError(t, repo.UpdateIncidentSeverity(&models.IncidentSeverity{ID: 4, Severity: "SEV1"}, 7))
+		assert.Empty(t, db.execsOf("INSERT INTO incident_events"))
+	})
+
+	t.Run("unknown incidents are not found", func(t *testing.T) {
+		repo, db := newRecordingIncidentRepository(nil)
+
+		var notFound *customErrors.NotFoundError
+		assert.ErrorAs(t, repo.UpdateIncidentSeverity(&models.IncidentSeverity{ID: 4, Severity: "SEV1"}, 7), &notFound)
+		assert.Empty(t, db.execs)
+		assert.False(t, db.committed)
+	})
+}
+
+func TestUpdateIncidentRolesRecordsChangedRolesOnly(t *testing.T) {
+	repo, db := newRecordingIncidentRepository(map[string]cannedRow{
+		"SELECT id, lead, qe": {[]string{"id", "lead", "qe"}, []driver.Value{int64(4), int64(2), int64(5)}},
+	})
+	lead, qe := 3, 5
+
+	require.NoError(t, repo.UpdateIncidentRoles(&models.IncidentRoles{ID: 4, Lead: &lead, QE: &qe}, 7))
+
+	events := db.execsOf("INSERT INTO incident_events")
+	require.Len(t, events, 1, "the unchanged QE is not recorded")
+	assert.Equal(t, []driver.Value{int64(4), int64(7), "lead", "2", "3"}, events[0].args)
+}
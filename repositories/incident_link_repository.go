@@ -0,0 +1,233 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+)
+
+const incidentLinkEventField = "link"
+
+type IncidentLinkRepository interface {
+	CreateIncidentLink(link *models.IncidentLink) (int, error)
+	GetIncidentLinks(incidentID int) ([]*models.LinkedIncident, error)
+	GetIncidentLinkByID(incidentID, linkID int) (*models.IncidentLink, error)
+	DeleteIncidentLink(link *models.IncidentLink, actorID int) error
+}
+
+type incidentLinkRepository struct {
+	db *sqlx.DB
+}
+
+func NewIncidentLinkRepository(db *sqlx.DB) IncidentLinkRepository {
+	return &incidentLinkRepository{db: db}
+}
+
+// incidentLinkEvents records a link on the timeline of both incidents, each
+// reading it from its own side.
+func incidentLinkEvents(link *models.IncidentLink, actorID int, removed bool) []*models.IncidentEvent {
+	sides := []struct {
+		incidentID int
+		value      *string
+	}{
+		{link.IncidentID, stringValue(fmt.Sprintf("%s #%d", link.Kind, link.LinkedIncidentID))},
+		{link.LinkedIncidentID, stringValue(fmt.Sprintf("%s #%d", models.InverseIncidentLinkKind(link.Kind), link.IncidentID))},
+	}
+
+	events := make([]*models.IncidentEvent, 0, len(sides))
+	for _, side := range sides {
+		if removed {
+			events = append(events, newIncidentEvent(side.incidentID, actorID, incidentLinkEventField, side.value, nil))
+		} else {
+			events = append(events, newIncidentEvent(side.incidentID, actorID, incidentLinkEventField, nil, side.value))
+		}
+	}
+	return events
+}
+
+// CreateIncidentLink saves a link in its stored direction. Directed kinds
+// are refused when the linked incident already leads back to the incident
+// through links of the same kind.
+func (r *incidentLinkRepository) CreateIncidentLink(link *models.IncidentLink) (int, error) {
+	log.Printf("CreateIncidentLink: Linking incident ID %d %s %d", link.IncidentID, link.Kind, link.LinkedIncidentID)
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		log.Printf("CreateIncidentLink: Error starting transaction: %v", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Serialize link changes so two links cannot close a cycle together
+	if _, err := tx.Exec(`LOCK TABLE incident_links IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		log.Printf("CreateIncidentLink: Error locking links: %v", err)
+		return 0, err
+	}
+
+	if link.Kind != models.IncidentLinkRelated {
+		cycleQuery := `
+		WITH RECURSIVE reachable (id) AS (
+			SELECT linked_incident_id FROM incident_links WHERE incident_id = $1 AND kind = $3
+			UNION
+			SELECT l.linked_incident_id FROM incident_links l JOIN reachable r ON l.incident_id = r.id WHERE l.kind = $3
+		)
+		SELECT EXISTS (SELECT 1 FROM reachable WHERE id = $2)
+		`
+
+		var cycle bool
+		if err := tx.Get(&cycle, cycleQuery, link.LinkedIncidentID, link.IncidentID, link.Kind); err != nil {
+			log.Printf("CreateIncidentLink: Error checking for cycles: %v", err)
+			return 0, err
+		}
+		if cycle {
+			return 0, &customErrors.ConflictError{Msg: fmt.Sprintf("linking incident %d %s %d would create a cycle", link.IncidentID, link.Kind, link.LinkedIncidentID)}
+		}
+	}
+
+	if link.Kind == models.IncidentLinkChildOf {
+		var parents int
+		if err := tx.Get(&parents, `SELECT COUNT(*) FROM incident_links WHERE incident_id = $1 AND kind = $2`, link.IncidentID, link.Kind); err != nil {
+			log.Printf("CreateIncidentLink: Error checking for a parent: %v", err)
+			return 0, err
+		}
+		if parents > 0 {
+			return 0, &customErrors.ConflictError{Msg: fmt.Sprintf("incident %d already has a parent", link.IncidentID)}
+		}
+	}
+
+	query := `
+	INSERT INTO incident_links (incident_id, linked_incident_id, kind, created_by)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT DO NOTHING
+	RETURNING id
+	`
+
+	var id int
+	err = tx.Get(&id, query, link.IncidentID, link.LinkedIncidentID, link.Kind, link.CreatedBy)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, &customErrors.ConflictError{Msg: fmt.Sprintf("incident %d is already linked %s %d", link.IncidentID, link.Kind, link.LinkedIncidentID)}
+	}
+	if err != nil {
+		log.Printf("CreateIncidentLink: Error executing query: %v", err)
+		return 0, err
+	}
+
+	if err := insertIncidentEvents(tx, incidentLinkEvents(link, link.CreatedBy, false)...); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("CreateIncidentLink: Error committing transaction: %v", err)
+		return 0, err
+	}
+
+	log.Printf("CreateIncidentLink: Link created with ID %d", id)
+	return id, nil
+}
+
+func (r *incidentLinkRepository) GetIncidentLinks(incidentID int) ([]*models.LinkedIncident, error) {
+	log.Printf("GetIncidentLinks: Retrieving links of incident ID %d", incidentID)
+
+	links, err := selectLinkedIncidents(r.db, incidentID)
+	if err != nil {
+		log.Printf("GetIncidentLinks: Error executing query: %v", err)
+		return nil, err
+	}
+
+	return links, nil
+}
+
+// selectLinkedIncidents lists the incidents linked to one, in either
+// direction, with each kind read from that incident.
+func selectLinkedIncidents(db sqlx.Queryer, incidentID int) ([]*models.LinkedIncident, error) {
+	query := `
+	SELECT
+		l.id AS link_id, l.kind, l.incident_id = $1 AS outgoing,
+		i.id, i.title, i.status, i.severity
+	FROM
+		incident_links l
+	JOIN
+		incidents i ON i.id = CASE WHEN l.incident_id = $1 THEN l.linked_incident_id ELSE l.incident_id END
+	WHERE
+		l.incident_id = $1 OR l.linked_incident_id = $1
+	ORDER BY l.created_at, l.id
+	`
+
+	links := []*models.LinkedIncident{}
+	if err := sqlx.Select(db, &links, query, incidentID); err != nil {
+		return nil, err
+	}
+
+	for _, link := range links {
+		if !link.Outgoing {
+			link.Kind = models.InverseIncidentLinkKind(link.Kind)
+		}
+	}
+	return links, nil
+}
+
+// GetIncidentLinkByID returns a link the incident is on either side of.
+func (r *incidentLinkRepository) GetIncidentLinkByID(incidentID, linkID int) (*models.IncidentLink, error) {
+	log.Printf("GetIncidentLinkByID: Retrieving link %d of incident ID %d", linkID, incidentID)
+
+	query := `
+	SELECT id, incident_id, linked_incident_id, kind, created_by, created_at
+	FROM incident_links
+	WHERE id = $2 AND (incident_id = $1 OR linked_incident_id = $1)
+	`
+
+	link := new(models.IncidentLink)
+	err := r.db.Get(link, query, incidentID, linkID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &customErrors.NotFoundError{Msg: fmt.Sprintf("incident link with ID %d not found", linkID)}
+	}
+	if err != nil {
+		log.Printf("GetIncidentLinkByID: Error executing query: %v", err)
+		return nil, err
+	}
+
+	return link, nil
+}
+
+func (r *incidentLinkRepository) DeleteIncidentLink(link *models.IncidentLink, actorID int) error {
+	log.Printf("DeleteIncidentLink: Deleting link %d", link.ID)
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		log.Printf("DeleteIncidentLink: Error starting transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM incident_links WHERE id = $1`, link.ID)
+	if err != nil {
+		log.Printf("DeleteIncidentLink: Error executing delete query: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("DeleteIncidentLink: Error getting rows affected: %v", err)
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return &customErrors.NotFoundError{Msg: fmt.Sprintf("incident link with ID %d not found", link.ID)}
+	}
+
+	if err := insertIncidentEvents(tx, incidentLinkEvents(link, actorID, true)...); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("DeleteIncidentLink: Error committing transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
@@ -1,25 +1,36 @@
 package repositories
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/jmoiron/sqlx"
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
 	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
 )
 
 type IncidentRepository interface {
 	CreateIncident(incident *models.IncidentInput) (int, error)
-	GetIncidents(queryParams *models.IncidentQueryParams) ([]*models.IncidentOverviewOutput, error)
+	CreateAlertIncident(incident *models.IncidentInput, alerts []*models.Alert, closedStatuses []string) (int, error)
+	GetIncidents(queryParams *models.IncidentQueryParams) ([]*models.IncidentOverviewOutput, int, error)
 	GetIncidentByID(id int) (*models.IncidentOutput, error)
-	UpdateIncidentSummary(incident *models.IncidentSummary) error
-	UpdateIncidentStatus(incident *models.IncidentStatus) error
-	UpdateIncidentSeverity(incident *models.IncidentSeverity) error
-	UpdateIncidentType(incident *models.IncidentType) error
-	UpdateIncidentRoles(incident *models.IncidentRoles) error
-	UpdateIncidentCustomFields(incident *models.IncidentCustomFieldsUpdate) error
+	UpdateIncidentSummary(incident *models.IncidentSummary, actorID int) error
+	GetIncidentStatus(id int) (string, error)
+	UpdateIncidentStatus(transition *models.IncidentStatusTransition, actorID int) error
+	UpdateIncidentSeverity(incident *models.IncidentSeverity, actorID int) error
+	UpdateIncidentType(incident *models.IncidentType, actorID int) error
+	UpdateIncidentRoles(incident *models.IncidentRoles, actorID int) error
+	UpdateIncidentCustomFields(incident *models.IncidentCustomFieldsUpdate, actorID int) error
+	GetTriageQueue(triageStatus string) ([]*models.TriageQueueItem, error)
+	AcceptIncident(transition *models.IncidentStatusTransition, lead, actorID int) error
+	DeclineIncident(transition *models.IncidentStatusTransition, reason string, actorID int) error
+	MergeIncident(transition *models.IncidentStatusTransition, into int, closedStatuses []string, actorID int) error
 }
 
 type incidentRepository struct {
@@ -51,6 +62,67 @@ func (r *incidentRepository) CreateIncident(incident *models.IncidentInput) (int
 		}
 	}()
 
+	incidentID, err := insertIncident(tx, incident)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Println("CreateIncident: Successfully created incident and related data")
+	return incidentID, nil
+}
+
+// CreateAlertIncident opens an incident for alerts no open incident tracks
+// and links them to it in the same transaction. Each alert is locked first,
+// so when another notification got to one of them already a ConflictError
+// is returned instead of a second incident.
+func (r *incidentRepository) CreateAlertIncident(incident *models.IncidentInput, alerts []*models.Alert, closedStatuses []string) (int, error) {
+	log.Printf("CreateAlertIncident: Opening incident for %d alerts", len(alerts))
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		log.Printf("CreateAlertIncident: Error starting transaction: %v", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := lockAlerts(tx, alerts); err != nil {
+		return 0, err
+	}
+
+	for _, alert := range alerts {
+		tracked, err := findOpenIncidentAlert(tx, alert.Source, alert.Fingerprint, closedStatuses)
+		var notFound *customErrors.NotFoundError
+		if err != nil && !errors.As(err, &notFound) {
+			return 0, err
+		}
+		if tracked != nil {
+			return 0, &customErrors.ConflictError{Msg: fmt.Sprintf("alert %s is already tracked by incident %d", alert.Fingerprint, tracked.IncidentID)}
+		}
+	}
+
+	incidentID, err := insertIncident(tx, incident)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, alert := range alerts {
+		if _, err := recordIncidentAlert(tx, incidentID, alert, incident.Reporter); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("CreateAlertIncident: Error committing transaction: %v", err)
+		return 0, err
+	}
+
+	log.Printf("CreateAlertIncident: Incident created with ID: %d", incidentID)
+	return incidentID, nil
+}
+
+// insertIncident saves a new incident with its related options and the
+// creation event.
+func insertIncident(tx *sqlx.Tx, incident *models.IncidentInput) (int, error) {
 	fields := []string{"title", "type", "severity", "summary", "reporter", "status", "reported_at"}
 	placeholders := []string{":title", ":type", ":severity", ":summary", ":reporter", ":status", ":reported_at"}
 
@@ -154,58 +226,203 @@ func (r *incidentRepository) CreateIncident(incident *models.IncidentInput) (int
 		}
 	}
 
-	log.Println("CreateIncident: Successfully created incident and related data")
+	err = insertIncidentEvents(tx, newIncidentEvent(incidentID, incident.Reporter, "created", nil, stringValue(incident.Status)))
+	if err != nil {
+		return 0, err
+	}
+
 	return incidentID, nil
 }
 
-func (r *incidentRepository) GetIncidents(queryParams *models.IncidentQueryParams) ([]*models.IncidentOverviewOutput, error) {
+// incidentSortColumns maps the public sort keys to the columns they order by.
+var incidentSortColumns = map[string]string{
+	"impact_started_at": "i.impact_started_at",
+	"reported_at":       "i.reported_at",
+	"severity":          "i.severity",
+	"status":            "i.status",
+}
+
+func (r *incidentRepository) GetIncidents(queryParams *models.IncidentQueryParams) ([]*models.IncidentOverviewOutput, int, error) {
 	log.Println("GetIncidents: Starting query construction")
-	
-	query := `SELECT i.id, i.title, i.type, i.severity, i.summary, i.status, i.impact_started_at, u.name as lead, u.avatar_url FROM incidents as i LEFT JOIN users as u on i.lead = u.id WHERE 1=1 `
 
+	where, params := incidentFilters(queryParams)
+
+	var total int
+	countQuery, countArgs, err := r.bindNamedIn(`SELECT COUNT(*) FROM incidents AS i`+where, params)
+	if err != nil {
+		log.Printf("GetIncidents: Error building count query: %v", err)
+		return nil, 0, err
+	}
+	if err := r.db.Get(&total, countQuery, countArgs...); err != nil {
+		log.Printf("GetIncidents: Error counting incidents: %v", err)
+		return nil, 0, err
+	}
+
+	columns := `i.id, i.title, i.type, i.severity, i.summary, i.status, i.impact_started_at, i.reported_at, u.name as lead, u.avatar_url`
+
+	sortColumn, ok := incidentSortColumns[queryParams.Sort]
+	if !ok {
+		sortColumn = incidentSortColumns["reported_at"]
+	}
+
+	joins := ` LEFT JOIN users as u on i.lead = u.id`
+
+	if queryParams.Query != nil {
+		// Comments match too, so an incident ranks by its best matching text
+		// and, when only a comment matches, the snippet comes from that comment.
+		joins += ` LEFT JOIN LATERAL (
+			SELECT iu.body, ts_rank(iu.search_vector, websearch_to_tsquery('simple', :q)) AS rank
+			FROM incident_updates iu
+			WHERE iu.incident_id = i.id AND iu.search_vector @@ websearch_to_tsquery('simple', :q)
+			ORDER BY rank DESC, iu.id
+			LIMIT 1
+		) AS best_update ON true`
+
+		columns += `,
+			GREATEST(ts_rank(i.search_vector, websearch_to_tsquery('simple', :q)), COALESCE(best_update.rank, 0)) AS rank,
+			ts_headline('simple',
+				CASE WHEN i.search_vector @@ websearch_to_tsquery('simple', :q)
+					THEN concat_ws(' ', i.title, i.summary, i.impact, i.treatment, i.post_mortem)
+					ELSE best_update.body
+				END,
+				websearch_to_tsquery('simple', :q), 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2') AS snippet`
+
+		// Searches are ranked by relevance unless another order is requested.
+		if queryParams.Sort == "" || queryParams.Sort == "relevance" {
+			sortColumn = "rank"
+		}
+	}
+
+	order := "DESC"
+	if queryParams.Order == "asc" {
+		order = "ASC"
+	}
+
+	query := `SELECT ` + columns + ` FROM incidents as i` + joins + where
+	query += fmt.Sprintf(" ORDER BY %s %s NULLS LAST, i.id %s LIMIT :limit OFFSET :offset", sortColumn, order, order)
+	params["limit"] = queryParams.Limit
+	params["offset"] = queryParams.Offset()
+
+	query, args, err := r.bindNamedIn(query, params)
+	if err != nil {
+		log.Printf("GetIncidents: Error building query: %v", err)
+		return nil, 0, err
+	}
+
+	incidents := []*models.IncidentOverviewOutput{}
+	if err := r.db.Select(&incidents, query, args...); err != nil {
+		log.Printf("GetIncidents: Error executing query: %v", err)
+		return nil, 0, err
+	}
+
+	log.Printf("GetIncidents: Successfully retrieved %d of %d incidents", len(incidents), total)
+	return incidents, total, nil
+}
+
+
+// incidentFilters translates the list filters into a WHERE clause over the
+// incidents table aliased as i, along with its named parameters.
+func incidentFilters(queryParams *models.IncidentQueryParams) (string, map[string]interface{}) {
+	var conditions []string
 	params := make(map[string]interface{})
 
-	if queryParams.Category != nil {
-		query += "AND i.category = :category"
-		params["category"] = *queryParams.Category
+	valueFilters := []struct {
+		values interface{}
+		count  int
+		column string
+		param  string
+	}{
+		{queryParams.Status, len(queryParams.Status), "i.status", "status"},
+		{queryParams.Category, len(queryParams.Category), "i.category", "category"},
+		{queryParams.Severity, len(queryParams.Severity), "i.severity", "severity"},
+		{queryParams.Type, len(queryParams.Type), "i.type", "type"},
+		{queryParams.Lead, len(queryParams.Lead), "i.lead", "lead"},
+		{queryParams.Reporter, len(queryParams.Reporter), "i.reporter", "reporter"},
+		{queryParams.QE, len(queryParams.QE), "i.qe", "qe"},
 	}
 
-	if queryParams.Severity != nil {
-		query += "AND i.severity = :severity"
-		params["severity"] = *queryParams.Severity
+	for _, filter := range valueFilters {
+		if filter.count == 0 {
+			continue
+		}
+		conditions = append(conditions, fmt.Sprintf("%s IN (:%s)", filter.column, filter.param))
+		params[filter.param] = filter.values
 	}
 
-	if queryParams.Status != nil {
-		query += "AND i.status = :status"
-		params["status"] = *queryParams.Status
+	// Membership filters match incidents linked to any of the given options.
+	membershipFilters := []struct {
+		ids      []int
+		table    string
+		idColumn string
+		param    string
+	}{
+		{queryParams.Products, "incident_products", "product_id", "products"},
+		{queryParams.Areas, "incident_areas", "area_id", "areas"},
+		{queryParams.Causes, "incident_causes", "cause_id", "causes"},
+		{queryParams.FaultySystems, "incident_faulty_systems", "faulty_system_id", "faulty_systems"},
+		{queryParams.PerformanceIndicators, "incident_performance_indicators", "performance_indicator_id", "performance_indicators"},
 	}
-	
-    rows, err := r.db.NamedQuery(query, params)
-    if err != nil {
-        log.Printf("GetIncidents: Error executing query: %v", err)
-        return nil, err
-    }
-    defer rows.Close()
 
-    var incidents []*models.IncidentOverviewOutput
-    for rows.Next() {
-        var incident models.IncidentOverviewOutput
-        if err := rows.StructScan(&incident); err != nil {
-            log.Printf("GetIncidents: Error scanning row: %v", err)
-            return nil, err
-        }
-        incidents = append(incidents, &incident)
-    }
+	for _, filter := range membershipFilters {
+		if len(filter.ids) == 0 {
+			continue
+		}
+		conditions = append(conditions, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM %s rel WHERE rel.incident_id = i.id AND rel.%s IN (:%s))",
+			filter.table, filter.idColumn, filter.param,
+		))
+		params[filter.param] = filter.ids
+	}
 
-    if err = rows.Err(); err != nil {
-        log.Printf("GetIncidents: Error after scanning all rows: %v", err)
-        return nil, err
-    }
+	rangeFilters := []struct {
+		value     *string
+		condition string
+		param     string
+	}{
+		{queryParams.ReportedFrom, "i.reported_at >= :reported_from", "reported_from"},
+		{queryParams.ReportedTo, "i.reported_at <= :reported_to", "reported_to"},
+		{queryParams.ImpactStartedFrom, "i.impact_started_at >= :impact_started_from", "impact_started_from"},
+		{queryParams.ImpactStartedTo, "i.impact_started_at <= :impact_started_to", "impact_started_to"},
+		{queryParams.ResolvedFrom, "i.resolved_at >= :resolved_from", "resolved_from"},
+		{queryParams.ResolvedTo, "i.resolved_at <= :resolved_to", "resolved_to"},
+	}
+
+	for _, filter := range rangeFilters {
+		if filter.value == nil {
+			continue
+		}
+		conditions = append(conditions, filter.condition)
+		params[filter.param] = *filter.value
+	}
+
+	if queryParams.Query != nil {
+		conditions = append(conditions, `(i.search_vector @@ websearch_to_tsquery('simple', :q)
+			OR EXISTS (SELECT 1 FROM incident_updates iu WHERE iu.incident_id = i.id AND iu.search_vector @@ websearch_to_tsquery('simple', :q)))`)
+		params["q"] = *queryParams.Query
+	}
 
-	log.Printf("GetIncidents: Successfully retrieved %d incidents", len(incidents))
-	return incidents, nil;
+	if len(conditions) == 0 {
+		return "", params
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), params
 }
 
+// bindNamedIn resolves named parameters, expands slice arguments used in
+// IN clauses and rebinds the query for the postgres driver.
+func (r *incidentRepository) bindNamedIn(query string, params map[string]interface{}) (string, []interface{}, error) {
+	query, args, err := sqlx.Named(query, params)
+	if err != nil {
+		return "", nil, err
+	}
+
+	query, args, err = sqlx.In(query, args...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return r.db.Rebind(query), args, nil
+}
 
 func (r *incidentRepository) GetIncidentByID(id int) (*models.IncidentOutput, error) {
     log.Printf("GetIncidentByID: Starting query for incident ID %d", id)
@@ -219,7 +436,8 @@ func (r *incidentRepository) GetIncidentByID(id int) (*models.IncidentOutput, er
     	reporter_user.name AS reporter_name,
 		reporter_user.avatar_url AS reporter_avatar,
     	qe_user.name AS qe_name,
-		qe_user.avatar_url AS qe_avatar
+		qe_user.avatar_url AS qe_avatar,
+		triage_user.name AS triaged_by_name
 	FROM 
     	incidents i
 	LEFT JOIN 
@@ -228,6 +446,8 @@ func (r *incidentRepository) GetIncidentByID(id int) (*models.IncidentOutput, er
     	users reporter_user ON i.reporter = reporter_user.id
 	LEFT JOIN 
     	users qe_user ON i.qe = qe_user.id	
+	LEFT JOIN 
+    	users triage_user ON i.triaged_by = triage_user.id
 	WHERE 
     	i.id = $1
 	`
@@ -244,116 +464,193 @@ func (r *incidentRepository) GetIncidentByID(id int) (*models.IncidentOutput, er
         return nil, err
     }
 
+    incidentOutput.Attachments = new(models.AttachmentSummary)
+    summaryQuery := `SELECT COUNT(*) AS count, COALESCE(SUM(size), 0) AS total_bytes, MAX(created_at) AS last_uploaded_at FROM incident_attachments WHERE incident_id = $1`
+    if err := r.db.Get(incidentOutput.Attachments, summaryQuery, id); err != nil {
+        log.Printf("Error while retrieving attachment summary for incident %v: %s", id, err)
+        return nil, err
+    }
+
+    links, err := selectLinkedIncidents(r.db, id)
+    if err != nil {
+        log.Printf("Error while retrieving links of incident %v: %s", id, err)
+        return nil, err
+    }
+    incidentOutput.Links = links
+
     log.Printf("GetIncidentByID: Successfully retrieved incident with ID %d", id)
     return incidentOutput, nil
 }
 
-func (r *incidentRepository) UpdateIncidentSummary(incident *models.IncidentSummary) error {
+func (r *incidentRepository) UpdateIncidentSummary(incident *models.IncidentSummary, actorID int) error {
     log.Printf("UpdateIncidentSummary: Updating summary for incident ID %d", incident.ID)
 
-    query := `UPDATE incidents SET summary = $1 WHERE id = $2`
-
-    result, err := r.db.Exec(query, incident.Summary, incident.ID)
-    if err != nil {
-        log.Printf("UpdateIncidentSummary: Error executing update query: %v", err)
-        return err
-    }
-
-    rowsAffected, err := result.RowsAffected()
-    if err != nil {
-        log.Printf("UpdateIncidentSummary: Error getting rows affected: %v", err)
+    if err := r.updateIncidentField(incident.ID, actorID, "summary", incident.Summary); err != nil {
+        log.Printf("UpdateIncidentSummary: Error updating summary: %v", err)
         return err
     }
 
-    if rowsAffected == 0 {
-        log.Printf("UpdateIncidentSummary: No rows affected. Incident with ID %d not found", incident.ID)
-        return fmt.Errorf("incident with ID %d not found", incident.ID)
-    }
-
     log.Printf("UpdateIncidentSummary: Successfully updated summary for incident ID %d", incident.ID)
     return nil
 }
 
-func (r *incidentRepository) UpdateIncidentStatus(incident *models.IncidentStatus) error {
-    log.Printf("UpdateIncidentStatus: Updating summary for incident ID %d", incident.ID)
 
-    query := `UPDATE incidents SET status = $1 WHERE id = $2`
+func (r *incidentRepository) GetIncidentStatus(id int) (string, error) {
+    log.Printf("GetIncidentStatus: Retrieving status for incident ID %d", id)
+
+    var status string
+    err := r.db.Get(&status, `SELECT status FROM incidents WHERE id = $1`, id)
+    if errors.Is(err, sql.ErrNoRows) {
+        log.Printf("GetIncidentStatus: Incident with ID %d not found", id)
+        return "", &customErrors.NotFoundError{Msg: fmt.Sprintf("incident with ID %d not found", id)}
+    }
+    if err != nil {
+        log.Printf("GetIncidentStatus: Error executing query: %v", err)
+        return "", err
+    }
+
+    return status, nil
+}
 
-    result, err := r.db.Exec(query, incident.Status, incident.ID)
+func (r *incidentRepository) UpdateIncidentStatus(transition *models.IncidentStatusTransition, actorID int) error {
+    log.Printf("UpdateIncidentStatus: Moving incident ID %d from %q to %q", transition.ID, transition.From, transition.To)
+
+    tx, err := r.db.Beginx()
     if err != nil {
-        log.Printf("UpdateIncidentStatus: Error executing update query: %v", err)
+        log.Printf("UpdateIncidentStatus: Error starting transaction: %v", err)
         return err
     }
+    defer tx.Rollback()
 
-    rowsAffected, err := result.RowsAffected()
-    if err != nil {
-        log.Printf("UpdateIncidentStatus: Error getting rows affected: %v", err)
+    if err = applyStatusTransition(tx, transition, actorID); err != nil {
         return err
     }
 
-    if rowsAffected == 0 {
-        log.Printf("UpdateIncidentStatus: No rows affected. Incident with ID %d not found", incident.ID)
-        return fmt.Errorf("incident with ID %d not found", incident.ID)
+    if err = tx.Commit(); err != nil {
+        log.Printf("UpdateIncidentStatus: Error committing transaction: %v", err)
+        return err
     }
 
-    log.Printf("UpdateIncidentStatus: Successfully updated status for incident ID %d", incident.ID)
+    log.Printf("UpdateIncidentStatus: Successfully updated status for incident ID %d", transition.ID)
     return nil
 }
 
 
-func (r *incidentRepository) UpdateIncidentSeverity(incident *models.IncidentSeverity) error {
-    log.Printf("UpdateIncidentSeverity: Updating severity for incident ID %d", incident.ID)
-
-    query := `UPDATE incidents SET severity = $1 WHERE id = $2`
-
-    result, err := r.db.Exec(query, incident.Severity, incident.ID)
+// applyStatusTransition moves an incident to a new status inside the caller's
+// transaction, stamping the lifecycle timestamps and recording the event.
+func applyStatusTransition(tx *sqlx.Tx, transition *models.IncidentStatusTransition, actorID int) error {
+    // Lock the row so a concurrent transition cannot slip in between the
+    // check and the update.
+    var current string
+    err := tx.Get(&current, `SELECT status FROM incidents WHERE id = $1 FOR UPDATE`, transition.ID)
+    if errors.Is(err, sql.ErrNoRows) {
+        log.Printf("applyStatusTransition: Incident with ID %d not found", transition.ID)
+        return &customErrors.NotFoundError{Msg: fmt.Sprintf("incident with ID %d not found", transition.ID)}
+    }
     if err != nil {
-        log.Printf("UpdateIncidentSeverity: Error executing update query: %v", err)
+        log.Printf("applyStatusTransition: Error locking incident: %v", err)
         return err
     }
 
-    rowsAffected, err := result.RowsAffected()
-    if err != nil {
-        log.Printf("UpdateIncidentSeverity: Error getting rows affected: %v", err)
+    if current != transition.From {
+        log.Printf("applyStatusTransition: Status changed concurrently to %q", current)
+        return &customErrors.InvalidTransitionError{From: current, To: transition.To}
+    }
+
+    // Counted under the lock, which action item writes also take, so an item
+    // cannot open or become blocking while the incident closes.
+    if transition.CheckBlockingActionItems {
+        blocking, err := countOpenBlockingActionItems(tx, transition.ID)
+        if err != nil {
+            log.Printf("applyStatusTransition: Error counting blocking action items: %v", err)
+            return err
+        }
+
+        if blocking > 0 {
+            log.Printf("applyStatusTransition: Incident ID %d has %d open blocking action items", transition.ID, blocking)
+            return &customErrors.ConflictError{Msg: fmt.Sprintf("incident cannot be closed while %d blocking action items are open", blocking)}
+        }
+    }
+
+    setFields := []string{"status = $1"}
+    args := []interface{}{transition.To, transition.At}
+
+    // Keep the first time each lifecycle stage was reached so reopened
+    // incidents do not distort MTTR.
+    for _, column := range transition.Timestamps {
+        setFields = append(setFields, fmt.Sprintf("%s = COALESCE(%s, $2)", column, column))
+    }
+
+    query := fmt.Sprintf(`UPDATE incidents SET %s WHERE id = $3`, strings.Join(setFields, ", "))
+    args = append(args, transition.ID)
+
+    if _, err = tx.Exec(query, args...); err != nil {
+        log.Printf("applyStatusTransition: Error executing update query: %v", err)
         return err
     }
 
-    if rowsAffected == 0 {
-        log.Printf("UpdateIncidentSeverity: No rows affected. Incident with ID %d not found", incident.ID)
-        return fmt.Errorf("incident with ID %d not found", incident.ID)
+    event := newIncidentEvent(transition.ID, actorID, "status", stringValue(current), stringValue(transition.To))
+    return insertIncidentEvents(tx, event)
+}
+
+func (r *incidentRepository) UpdateIncidentSeverity(incident *models.IncidentSeverity, actorID int) error {
+    log.Printf("UpdateIncidentSeverity: Updating severity for incident ID %d", incident.ID)
+
+    if err := r.updateIncidentField(incident.ID, actorID, "severity", incident.Severity); err != nil {
+        log.Printf("UpdateIncidentSeverity: Error updating severity: %v", err)
+        return err
     }
 
     log.Printf("UpdateIncidentSeverity: Successfully updated severity for incident ID %d", incident.ID)
     return nil
 }
 
-func (r *incidentRepository) UpdateIncidentType(incident *models.IncidentType) error {
+func (r *incidentRepository) UpdateIncidentType(incident *models.IncidentType, actorID int) error {
     log.Printf("UpdateIncidentType: Updating type for incident ID %d", incident.ID)
 
-    query := `UPDATE incidents SET type = $1 WHERE id = $2`
+    if err := r.updateIncidentField(incident.ID, actorID, "type", incident.Type); err != nil {
+        log.Printf("UpdateIncidentType: Error updating type: %v", err)
+        return err
+    }
+
+    log.Printf("UpdateIncidentType: Successfully updated type for incident ID %d", incident.ID)
+    return nil
+}
 
-    result, err := r.db.Exec(query, incident.Type, incident.ID)
+// updateIncidentField sets a single text column and records the change on the
+// incident timeline within the same transaction. column must be a trusted
+// identifier, never user input.
+func (r *incidentRepository) updateIncidentField(id, actorID int, column, value string) error {
+    tx, err := r.db.Beginx()
     if err != nil {
-        log.Printf("UpdateIncidentType: Error executing update query: %v", err)
-        return err
+        return fmt.Errorf("error starting transaction: %v", err)
     }
+    defer tx.Rollback()
 
-    rowsAffected, err := result.RowsAffected()
+    var previous *string
+    err = tx.Get(&previous, fmt.Sprintf(`SELECT %s FROM incidents WHERE id = $1 FOR UPDATE`, column), id)
+    if errors.Is(err, sql.ErrNoRows) {
+        return &customErrors.NotFoundError{Msg: fmt.Sprintf("incident with ID %d not found", id)}
+    }
     if err != nil {
-        log.Printf("UpdateIncidentType: Error getting rows affected: %v", err)
         return err
     }
 
-    if rowsAffected == 0 {
-        log.Printf("UpdateIncidentType: No rows affected. Incident with ID %d not found", incident.ID)
-        return fmt.Errorf("incident with ID %d not found", incident.ID)
+    if _, err = tx.Exec(fmt.Sprintf(`UPDATE incidents SET %s = $1 WHERE id = $2`, column), value, id); err != nil {
+        return err
+    }
+
+    if !sameValue(previous, &value) {
+        if err = insertIncidentEvents(tx, newIncidentEvent(id, actorID, column, previous, stringValue(value))); err != nil {
+            return err
+        }
     }
 
-    log.Printf("UpdateIncidentType: Successfully updated type for incident ID %d", incident.ID)
-    return nil
+    return tx.Commit()
 }
 
-func (r *incidentRepository) UpdateIncidentRoles(incident *models.IncidentRoles) error {
+
+func (r *incidentRepository) UpdateIncidentRoles(incident *models.IncidentRoles, actorID int) error {
     if incident == nil {
         return fmt.Errorf("UpdateIncidentRoles: incident cannot be nil")
     }
@@ -363,27 +660,52 @@ func (r *incidentRepository) UpdateIncidentRoles(incident *models.IncidentRoles)
 
     log.Printf("UpdateIncidentRoles: Updating roles for incident ID %d", incident.ID)
 
+    // If no fields to update, return early
+    if incident.Lead == nil && incident.QE == nil {
+        log.Printf("UpdateIncidentRoles: No fields to update for incident ID %d", incident.ID)
+        return nil
+    }
+
+    tx, err := r.db.Beginx()
+    if err != nil {
+        log.Printf("UpdateIncidentRoles: Error starting transaction: %v", err)
+        return err
+    }
+    defer tx.Rollback()
+
+    var previous models.IncidentRoles
+    err = tx.Get(&previous, `SELECT id, lead, qe FROM incidents WHERE id = $1 FOR UPDATE`, incident.ID)
+    if errors.Is(err, sql.ErrNoRows) {
+        log.Printf("UpdateIncidentRoles: Incident with ID %d not found", incident.ID)
+        return &customErrors.NotFoundError{Msg: fmt.Sprintf("incident with ID %d not found", incident.ID)}
+    }
+    if err != nil {
+        log.Printf("UpdateIncidentRoles: Error locking incident: %v", err)
+        return err
+    }
+
     // Start building the query
     query := "UPDATE incidents SET "
     var args []interface{}
     var setFields []string
+    var events []*models.IncidentEvent
 
     // Check if Lead is provided
     if incident.Lead != nil {
         setFields = append(setFields, "lead = ?")
         args = append(args, *incident.Lead)
+        if !sameValue(intValue(previous.Lead), intValue(incident.Lead)) {
+            events = append(events, newIncidentEvent(incident.ID, actorID, "lead", intValue(previous.Lead), intValue(incident.Lead)))
+        }
     }
 
     // Check if QE is provided
     if incident.QE != nil {
         setFields = append(setFields, "qe = ?")
         args = append(args, *incident.QE)
-    }
-
-    // If no fields to update, return early
-    if len(setFields) == 0 {
-        log.Printf("UpdateIncidentRoles: No fields to update for incident ID %d", incident.ID)
-        return nil
+        if !sameValue(intValue(previous.QE), intValue(incident.QE)) {
+            events = append(events, newIncidentEvent(incident.ID, actorID, "qe", intValue(previous.QE), intValue(incident.QE)))
+        }
     }
 
     // Complete the query
@@ -392,28 +714,26 @@ func (r *incidentRepository) UpdateIncidentRoles(incident *models.IncidentRoles)
     args = append(args, incident.ID)
 
     // Use sqlx for easier query building
-    query = r.db.Rebind(query)
-    result, err := r.db.Exec(query, args...)
-    if err != nil {
+    query = tx.Rebind(query)
+    if _, err = tx.Exec(query, args...); err != nil {
         log.Printf("UpdateIncidentRoles: Error executing update query: %v", err)
         return err
     }
 
-    rowsAffected, err := result.RowsAffected()
-    if err != nil {
-        log.Printf("UpdateIncidentRoles: Error getting rows affected: %v", err)
+    if err = insertIncidentEvents(tx, events...); err != nil {
         return err
     }
 
-    if rowsAffected == 0 {
-        log.Printf("UpdateIncidentRoles: No rows affected. Incident with ID %d not found", incident.ID)
-        return fmt.Errorf("incident with ID %d not found", incident.ID)
+    if err = tx.Commit(); err != nil {
+        log.Printf("UpdateIncidentRoles: Error committing transaction: %v", err)
+        return err
     }
 
     log.Printf("UpdateIncidentRoles: Successfully updated roles for incident ID %d", incident.ID)
     return nil
 }
 
+
 func (r *incidentRepository) getRelatedData(incident *models.IncidentOutput) error {
     relatedTables := []struct {
         query    string
@@ -484,13 +804,28 @@ func (r *incidentRepository) getRelatedData(incident *models.IncidentOutput) err
     return nil
 }
 
-func (r *incidentRepository) UpdateIncidentCustomFields(incident *models.IncidentCustomFieldsUpdate) error {
+func (r *incidentRepository) UpdateIncidentCustomFields(incident *models.IncidentCustomFieldsUpdate, actorID int) error {
     tx, err := r.db.Beginx()
     if err != nil {
         return fmt.Errorf("error starting transaction: %v", err)
     }
     defer tx.Rollback()
 
+    var previous struct {
+        Impact    *string `db:"impact"`
+        Treatment *string `db:"treatment"`
+        Mitigator *string `db:"mitigator"`
+    }
+    err = tx.Get(&previous, `SELECT impact, treatment, mitigator FROM incidents WHERE id = $1 FOR UPDATE`, incident.ID)
+    if errors.Is(err, sql.ErrNoRows) {
+        return &customErrors.NotFoundError{Msg: fmt.Sprintf("incident with ID %d not found", incident.ID)}
+    }
+    if err != nil {
+        return fmt.Errorf("error locking incident: %v", err)
+    }
+
+    var events []*models.IncidentEvent
+
     // Update fields in the incidents table
     updateQuery := "UPDATE incidents SET"
     updateParams := []interface{}{}
@@ -500,16 +835,25 @@ func (r *incidentRepository) UpdateIncidentCustomFields(incident *models.Inciden
         updateQuery += fmt.Sprintf(" impact = $%d,", paramCount)
         updateParams = append(updateParams, *incident.Impact)
         paramCount++
+        if !sameValue(previous.Impact, incident.Impact) {
+            events = append(events, newIncidentEvent(incident.ID, actorID, "impact", previous.Impact, incident.Impact))
+        }
     }
     if incident.Treatment != nil {
         updateQuery += fmt.Sprintf(" treatment = $%d,", paramCount)
         updateParams = append(updateParams, *incident.Treatment)
         paramCount++
+        if !sameValue(previous.Treatment, incident.Treatment) {
+            events = append(events, newIncidentEvent(incident.ID, actorID, "treatment", previous.Treatment, incident.Treatment))
+        }
     }
     if incident.Mitigator != nil {
         updateQuery += fmt.Sprintf(" mitigator = $%d,", paramCount)
         updateParams = append(updateParams, *incident.Mitigator)
         paramCount++
+        if !sameValue(previous.Mitigator, incident.Mitigator) {
+            events = append(events, newIncidentEvent(incident.ID, actorID, "mitigator", previous.Mitigator, incident.Mitigator))
+        }
     }
 
     // Remove trailing comma
@@ -529,15 +873,22 @@ func (r *incidentRepository) UpdateIncidentCustomFields(incident *models.Inciden
         items    []int
         table    string
         idColumn string
+        field    string
     }{
-        {incident.Products, "incident_products", "product_id"},
-        {incident.Areas, "incident_areas", "area_id"},
-        {incident.Causes, "incident_causes", "cause_id"},
-        {incident.FaultySystems, "incident_faulty_systems", "faulty_system_id"},
-        {incident.PerformanceIndicators, "incident_performance_indicators", "performance_indicator_id"},
+        {incident.Products, "incident_products", "product_id", "products"},
+        {incident.Areas, "incident_areas", "area_id", "areas"},
+        {incident.Causes, "incident_causes", "cause_id", "causes"},
+        {incident.FaultySystems, "incident_faulty_systems", "faulty_system_id", "faultySystems"},
+        {incident.PerformanceIndicators, "incident_performance_indicators", "performance_indicator_id", "performanceIndicators"},
     }
 
     for _, item := range relatedItems {
+            var previousIDs []int
+            err = tx.Select(&previousIDs, fmt.Sprintf("SELECT %s FROM %s WHERE incident_id = $1 ORDER BY %s", item.idColumn, item.table, item.idColumn), incident.ID)
+            if err != nil {
+                return fmt.Errorf("error reading existing %s: %v", item.table, err)
+            }
+
             // Delete existing relations
             _, err = tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE incident_id = $1", item.table), incident.ID)
             if err != nil {
@@ -552,7 +903,233 @@ func (r *incidentRepository) UpdateIncidentCustomFields(incident *models.Inciden
                     return fmt.Errorf("error inserting new %s: %v", item.table, err)
                 }
             }
+
+            oldValue, newValue := joinIDs(previousIDs), joinIDs(item.items)
+            if !sameValue(oldValue, newValue) {
+                events = append(events, newIncidentEvent(incident.ID, actorID, item.field, oldValue, newValue))
+            }
+    }
+
+    if err = insertIncidentEvents(tx, events...); err != nil {
+        return fmt.Errorf("error recording incident events: %v", err)
     }
 
     return tx.Commit()
 }
+
+// joinIDs renders a set of related IDs as a sorted, comma separated list so
+// timeline entries compare equal regardless of input order.
+func joinIDs(ids []int) *string {
+    if len(ids) == 0 {
+        return nil
+    }
+
+    sorted := append([]int(nil), ids...)
+    sort.Ints(sorted)
+
+    parts := make([]string, len(sorted))
+    for i, id := range sorted {
+        parts[i] = strconv.Itoa(id)
+    }
+
+    joined := strings.Join(parts, ",")
+    return &joined
+}
+
+// GetTriageQueue lists the incidents waiting in triage, oldest first.
+func (r *incidentRepository) GetTriageQueue(triageStatus string) ([]*models.TriageQueueItem, error) {
+	query := `
+		SELECT i.id, i.title, i.type, i.severity, i.summary, i.status, i.reporter,
+			u.name AS reporter_name, u.avatar_url AS reporter_avatar, i.reported_at, i.impact_started_at,
+			(SELECT COUNT(*) FROM incident_alerts ia WHERE ia.incident_id = i.id AND ia.status = 'firing') AS firing_alerts
+		FROM incidents i
+		LEFT JOIN users u ON i.reporter = u.id
+		WHERE LOWER(i.status) = $1
+		ORDER BY i.reported_at ASC NULLS LAST, i.id ASC`
+
+	queue := []*models.TriageQueueItem{}
+	if err := r.db.Select(&queue, query, triageStatus); err != nil {
+		log.Printf("GetTriageQueue: Error executing query: %v", err)
+		return nil, err
+	}
+
+	return queue, nil
+}
+
+// AcceptIncident moves an incident out of triage and assigns its lead.
+func (r *incidentRepository) AcceptIncident(transition *models.IncidentStatusTransition, lead, actorID int) error {
+	log.Printf("AcceptIncident: Accepting incident ID %d with lead %d", transition.ID, lead)
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		log.Printf("AcceptIncident: Error starting transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = applyStatusTransition(tx, transition, actorID); err != nil {
+		return err
+	}
+
+	var previous *int
+	if err = tx.Get(&previous, `SELECT lead FROM incidents WHERE id = $1`, transition.ID); err != nil {
+		log.Printf("AcceptIncident: Error reading current lead: %v", err)
+		return err
+	}
+
+	if _, err = tx.Exec(`UPDATE incidents SET lead = $1, triaged_by = $2 WHERE id = $3`, lead, actorID, transition.ID); err != nil {
+		log.Printf("AcceptIncident: Error executing update query: %v", err)
+		return err
+	}
+
+	if !sameValue(intValue(previous), intValue(&lead)) {
+		if err = insertIncidentEvents(tx, newIncidentEvent(transition.ID, actorID, "lead", intValue(previous), intValue(&lead))); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("AcceptIncident: Error committing transaction: %v", err)
+		return err
+	}
+
+	log.Printf("AcceptIncident: Successfully accepted incident ID %d", transition.ID)
+	return nil
+}
+
+// DeclineIncident closes a triaged incident, keeping the reason it needed no
+// response.
+func (r *incidentRepository) DeclineIncident(transition *models.IncidentStatusTransition, reason string, actorID int) error {
+	log.Printf("DeclineIncident: Declining incident ID %d", transition.ID)
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		log.Printf("DeclineIncident: Error starting transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = applyStatusTransition(tx, transition, actorID); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(`UPDATE incidents SET decline_reason = $1, triaged_by = $2 WHERE id = $3`, reason, actorID, transition.ID); err != nil {
+		log.Printf("DeclineIncident: Error executing update query: %v", err)
+		return err
+	}
+
+	if err = insertIncidentEvents(tx, newIncidentEvent(transition.ID, actorID, "decline_reason", nil, stringValue(reason))); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("DeclineIncident: Error committing transaction: %v", err)
+		return err
+	}
+
+	log.Printf("DeclineIncident: Successfully declined incident ID %d", transition.ID)
+	return nil
+}
+
+// mergedLinks are the option links a merged incident hands to its target.
+var mergedLinks = []struct {
+	table    string
+	idColumn string
+}{
+	{"incident_products", "product_id"},
+	{"incident_areas", "area_id"},
+	{"incident_causes", "cause_id"},
+	{"incident_faulty_systems", "faulty_system_id"},
+	{"incident_performance_indicators", "performance_indicator_id"},
+}
+
+// MergeIncident folds a duplicate into the incident tracking the same
+// problem: its comments, attachments, alerts and option links move to the
+// target, which must still be open, and it points at the target through
+// related_incident.
+func (r *incidentRepository) MergeIncident(transition *models.IncidentStatusTransition, into int, closedStatuses []string, actorID int) error {
+	log.Printf("MergeIncident: Merging incident ID %d into %d", transition.ID, into)
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		log.Printf("MergeIncident: Error starting transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock both incidents in ID order so opposite merges cannot deadlock
+	var locked []struct {
+		ID     int    `db:"id"`
+		Status string `db:"status"`
+	}
+	err = tx.Select(&locked, `SELECT id, status FROM incidents WHERE id IN ($1, $2) ORDER BY id FOR UPDATE`, transition.ID, into)
+	if err != nil {
+		log.Printf("MergeIncident: Error locking incidents: %v", err)
+		return err
+	}
+
+	target := -1
+	for i, incident := range locked {
+		if incident.ID == into {
+			target = i
+		}
+	}
+	if target < 0 {
+		return &customErrors.NotFoundError{Msg: fmt.Sprintf("incident with ID %d not found", into)}
+	}
+	for _, status := range closedStatuses {
+		if strings.EqualFold(locked[target].Status, status) {
+			return &customErrors.ConflictError{Msg: fmt.Sprintf("cannot merge into incident %d, which is %s", into, locked[target].Status)}
+		}
+	}
+
+	if err = applyStatusTransition(tx, transition, actorID); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(`UPDATE incidents SET related_incident = $1, triaged_by = $2 WHERE id = $3`, into, actorID, transition.ID); err != nil {
+		log.Printf("MergeIncident: Error executing update query: %v", err)
+		return err
+	}
+
+	moves := []string{
+		`UPDATE incident_updates SET incident_id = $1 WHERE incident_id = $2`,
+		`UPDATE incident_attachments SET incident_id = $1 WHERE incident_id = $2`,
+		// An alert both incidents track stays with the target's copy
+		`UPDATE incident_alerts a SET incident_id = $1 WHERE a.incident_id = $2
+			AND NOT EXISTS (SELECT 1 FROM incident_alerts b WHERE b.incident_id = $1 AND b.source = a.source AND b.fingerprint = a.fingerprint)`,
+	}
+	for _, link := range mergedLinks {
+		moves = append(moves, fmt.Sprintf(`INSERT INTO %[1]s (incident_id, %[2]s) SELECT $1, %[2]s FROM %[1]s WHERE incident_id = $2
+			AND %[2]s NOT IN (SELECT %[2]s FROM %[1]s WHERE incident_id = $1)`, link.table, link.idColumn))
+	}
+	for _, move := range moves {
+		if _, err = tx.Exec(move, into, transition.ID); err != nil {
+			log.Printf("MergeIncident: Error moving incident data: %v", err)
+			return err
+		}
+	}
+
+	for _, link := range mergedLinks {
+		if _, err = tx.Exec(fmt.Sprintf(`DELETE FROM %s WHERE incident_id = $1`, link.table), transition.ID); err != nil {
+			log.Printf("MergeIncident: Error removing moved %s: %v", link.table, err)
+			return err
+		}
+	}
+
+	err = insertIncidentEvents(tx,
+		newIncidentEvent(transition.ID, actorID, "related_incident", nil, intValue(&into)),
+		newIncidentEvent(into, actorID, "merged", nil, intValue(&transition.ID)),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("MergeIncident: Error committing transaction: %v", err)
+		return err
+	}
+
+	log.Printf("MergeIncident: Successfully merged incident ID %d into %d", transition.ID, into)
+	return nil
+}
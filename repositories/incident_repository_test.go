@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIncidentFilters(t *testing.T) {
+	reportedFrom := "2024-03-01T00:00:00Z"
+
+	tests := []struct {
+		name           string
+		params         *models.IncidentQueryParams
+		expectedWhere  string
+		expectedParams map[string]interface{}
+	}{
+		{
+			name:           "No Filters",
+			params:         &models.IncidentQueryParams{},
+			expectedWhere:  "",
+			expectedParams: map[string]interface{}{},
+		},
+		{
+			name: "Combined Filters",
+			params: &models.IncidentQueryParams{
+				Status:       []string{"Investigating", "Fixing"},
+				Severity:     []string{"SEV1", "SEV2"},
+				Products:     []int{3},
+				ReportedFrom: &reportedFrom,
+			},
+			expectedWhere: " WHERE i.status IN (:status)" +
+				" AND i.severity IN (:severity)" +
+				" AND EXISTS (SELECT 1 FROM incident_products rel WHERE rel.incident_id = i.id AND rel.product_id IN (:products))" +
+				" AND i.reported_at >= :reported_from",
+			expectedParams: map[string]interface{}{
+				"status":        []string{"Investigating", "Fixing"},
+				"severity":      []string{"SEV1", "SEV2"},
+				"products":      []int{3},
+				"reported_from": reportedFrom,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, params := incidentFilters(tt.params)
+
+			assert.Equal(t, tt.expectedWhere, where)
+			assert.Equal(t, tt.expectedParams, params)
+		})
+	}
+}
+
+func TestGetIncidentsOrdering(t *testing.T) {
+	tests := []struct {
+		name          string
+		params        *models.IncidentQueryParams
+		expectedOrder string
+		expectedArgs  []driver.Value
+	}{
+		{
+			name:          "Whitelisted Sort",
+			params:        &models.IncidentQueryParams{Sort: "severity", Order: "asc", Page: 3, Limit: 10},
+			expectedOrder: "ORDER BY i.severity ASC NULLS LAST, i.id ASC LIMIT $1 OFFSET $2",
+			expectedArgs:  []driver.Value{int64(10), int64(20)},
+		},
+		{
+			name:          "Unknown Sort Falls Back",
+			params:        &models.IncidentQueryParams{Sort: "title; DROP TABLE incidents", Page: 1, Limit: 20},
+			expectedOrder: "ORDER BY i.reported_at DESC NULLS LAST, i.id DESC LIMIT $1 OFFSET $2",
+			expectedArgs:  []driver.Value{int64(20), int64(0)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, db := newRecordingIncidentRepository(map[string]cannedRow{
+				"SELECT COUNT(*)": {[]string{"count"}, []driver.Value{int64(45)}},
+			})
+
+			incidents, total, err := repo.GetIncidents(tt.params)
+			require.NoError(t, err)
+			assert.Empty(t, incidents)
+			assert.Equal(t, 45, total)
+
+			require.Len(t, db.queries, 2)
+			assert.Contains(t, db.queries[1].query, tt.expectedOrder)
+			assert.Equal(t, tt.expectedArgs, db.queries[1].args)
+		})
+	}
+}
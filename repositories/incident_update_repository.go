@@ -0,0 +1,135 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+)
+
+type IncidentUpdateRepository interface {
+	CreateIncidentUpdate(update *models.IncidentUpdateInput, authorID int) (int, error)
+	GetIncidentUpdates(incidentID int) ([]*models.IncidentUpdate, error)
+	GetIncidentUpdateByID(incidentID, updateID int) (*models.IncidentUpdate, error)
+	EditIncidentUpdate(update *models.IncidentUpdateInput) error
+	DeleteIncidentUpdate(incidentID, updateID int) error
+}
+
+type incidentUpdateRepository struct {
+	db *sqlx.DB
+}
+
+func NewIncidentUpdateRepository(db *sqlx.DB) IncidentUpdateRepository {
+	return &incidentUpdateRepository{db: db}
+}
+
+const incidentUpdateSelect = `
+	SELECT
+		iu.id, iu.incident_id, iu.author_id, iu.body, iu.created_at, iu.updated_at,
+		author.name AS author_name,
+		author.avatar_url AS author_avatar
+	FROM
+		incident_updates iu
+	JOIN
+		users author ON iu.author_id = author.id
+	`
+
+func (r *incidentUpdateRepository) CreateIncidentUpdate(update *models.IncidentUpdateInput, authorID int) (int, error) {
+	log.Printf("CreateIncidentUpdate: Creating update for incident ID %d", update.IncidentID)
+
+	query := `INSERT INTO incident_updates (incident_id, author_id, body) VALUES ($1, $2, $3) RETURNING id`
+
+	var updateID int
+	if err := r.db.Get(&updateID, query, update.IncidentID, authorID, update.Body); err != nil {
+		log.Printf("CreateIncidentUpdate: Error executing query: %v", err)
+		return 0, err
+	}
+
+	log.Printf("CreateIncidentUpdate: Update created with ID %d", updateID)
+	return updateID, nil
+}
+
+func (r *incidentUpdateRepository) GetIncidentUpdates(incidentID int) ([]*models.IncidentUpdate, error) {
+	log.Printf("GetIncidentUpdates: Retrieving updates for incident ID %d", incidentID)
+
+	query := incidentUpdateSelect + `WHERE iu.incident_id = $1 ORDER BY iu.created_at DESC, iu.id DESC`
+
+	updates := []*models.IncidentUpdate{}
+	if err := r.db.Select(&updates, query, incidentID); err != nil {
+		log.Printf("GetIncidentUpdates: Error executing query: %v", err)
+		return nil, err
+	}
+
+	log.Printf("GetIncidentUpdates: Successfully retrieved %d updates", len(updates))
+	return updates, nil
+}
+
+func (r *incidentUpdateRepository) GetIncidentUpdateByID(incidentID, updateID int) (*models.IncidentUpdate, error) {
+	log.Printf("GetIncidentUpdateByID: Retrieving update %d of incident ID %d", updateID, incidentID)
+
+	query := incidentUpdateSelect + `WHERE iu.incident_id = $1 AND iu.id = $2`
+
+	update := new(models.IncidentUpdate)
+	err := r.db.Get(update, query, incidentID, updateID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &customErrors.NotFoundError{Msg: fmt.Sprintf("update with ID %d not found", updateID)}
+	}
+	if err != nil {
+		log.Printf("GetIncidentUpdateByID: Error executing query: %v", err)
+		return nil, err
+	}
+
+	return update, nil
+}
+
+func (r *incidentUpdateRepository) EditIncidentUpdate(update *models.IncidentUpdateInput) error {
+	log.Printf("EditIncidentUpdate: Editing update %d of incident ID %d", update.ID, update.IncidentID)
+
+	query := `UPDATE incident_updates SET body = $1, updated_at = NOW() WHERE id = $2 AND incident_id = $3`
+
+	result, err := r.db.Exec(query, update.Body, update.ID, update.IncidentID)
+	if err != nil {
+		log.Printf("EditIncidentUpdate: Error executing update query: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("EditIncidentUpdate: Error getting rows affected: %v", err)
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return &customErrors.NotFoundError{Msg: fmt.Sprintf("update with ID %d not found", update.ID)}
+	}
+
+	log.Printf("EditIncidentUpdate: Successfully edited update %d", update.ID)
+	return nil
+}
+
+func (r *incidentUpdateRepository) DeleteIncidentUpdate(incidentID, updateID int) error {
+	log.Printf("DeleteIncidentUpdate: Deleting update %d of incident ID %d", updateID, incidentID)
+
+	result, err := r.db.Exec(`DELETE FROM incident_updates WHERE id = $1 AND incident_id = $2`, updateID, incidentID)
+	if err != nil {
+		log.Printf("DeleteIncidentUpdate: Error executing delete query: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("DeleteIncidentUpdate: Error getting rows affected: %v", err)
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return &customErrors.NotFoundError{Msg: fmt.Sprintf("update with ID %d not found", updateID)}
+	}
+
+	log.Printf("DeleteIncidentUpdate: Successfully deleted update %d", updateID)
+	return nil
+}
@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// LoginAttemptRepository tracks failed logins per scope and subject, e.g.
+// ("account", "john@example.com") or ("ip", "10.0.0.1").
+type LoginAttemptRepository interface {
+	GetLockedUntil(scope, subject string) (*time.Time, error)
+	RecordFailure(scope, subject string, window time.Duration) (int, error)
+	LockUntil(scope, subject string, until time.Time) error
+	ClearFailures(scope, subject string) error
+}
+
+type loginAttemptRepository struct {
+	db *sqlx.DB
+}
+
+func NewLoginAttemptRepository(db *sqlx.DB) LoginAttemptRepository {
+	return &loginAttemptRepository{db: db}
+}
+
+func (r *loginAttemptRepository) GetLockedUntil(scope, subject string) (*time.Time, error) {
+	var lockedUntil sql.NullTime
+	err := r.db.Get(&lockedUntil, `SELECT locked_until FROM login_failures WHERE scope = $1 AND subject = $2`, scope, subject)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !lockedUntil.Valid) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("GetLockedUntil: Error executing query: %v", err)
+		return nil, err
+	}
+
+	return &lockedUntil.Time, nil
+}
+
+// RecordFailure adds a failure and returns the running count. Failures older
+// than window no longer count, so the counter starts over.
+func (r *loginAttemptRepository) RecordFailure(scope, subject string, window time.Duration) (int, error) {
+	query := `
+	INSERT INTO login_failures (scope, subject, failures, last_failure_at)
+	VALUES ($1, $2, 1, NOW())
+	ON CONFLICT (scope, subject) DO UPDATE SET
+		failures = CASE
+			WHEN login_failures.last_failure_at < NOW() - make_interval(secs => $3) THEN 1
+			ELSE login_failures.failures + 1
+		END,
+		last_failure_at = NOW()
+	RETURNING failures
+	`
+
+	var failures int
+	if err := r.db.Get(&failures, query, scope, subject, window.Seconds()); err != nil {
+		log.Printf("RecordFailure: Error executing query: %v", err)
+		return 0, err
+	}
+
+	return failures, nil
+}
+
+func (r *loginAttemptRepository) LockUntil(scope, subject string, until time.Time) error {
+	if _, err := r.db.Exec(`UPDATE login_failures SET locked_until = $3 WHERE scope = $1 AND subject = $2`, scope, subject, until); err != nil {
+		log.Printf("LockUntil: Error executing query: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *loginAttemptRepository) ClearFailures(scope, subject string) error {
+	if _, err := r.db.Exec(`DELETE FROM login_failures WHERE scope = $1 AND subject = $2`, scope, subject); err != nil {
+		log.Printf("ClearFailures: Error executing query: %v", err)
+		return err
+	}
+
+	return nil
+}
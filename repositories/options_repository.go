@@ -1,9 +1,13 @@
 package repositories
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"log"
 
 	"github.com/jmoiron/sqlx"
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
 	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
 )
 
@@ -17,6 +21,15 @@ type OptionsRepository interface {
 	GetPerformanceIndicators() ([]*models.PerformanceIndicator, error)
 	GetFaultySystems() ([]*models.FaultySystem, error)
 	GetCauses() ([]*models.Cause, error)
+	GetSources() ([]*models.Source, error)
+	ListOptions(taxonomy string) ([]*models.Option, error)
+	GetOptionByID(taxonomy string, id int) (*models.Option, error)
+	OptionNameExists(taxonomy, name string, excludeID int) (bool, error)
+	CreateOption(taxonomy, name string) (int, error)
+	RenameOption(taxonomy string, id int, name string) error
+	ReorderOptions(taxonomy string, ids []int) error
+	SetOptionActive(taxonomy string, id int, active bool) error
+	CountOpenIncidentsUsingOption(taxonomy string, id int, closedStatuses []string) (int, error)
 }
 
 type optionsRepository struct {
@@ -29,7 +42,7 @@ func NewOptionsRepository(db *sqlx.DB) OptionsRepository {
 
 
 func (r *optionsRepository) GetTypes() ([]*models.Type, error) {
-	query := "SELECT id, name FROM types WHERE active = true"
+	query := "SELECT id, name FROM types WHERE active = true ORDER BY position, name"
 
 	rows, err := r.db.Queryx(query)
 	if err != nil {
@@ -56,7 +69,7 @@ func (r *optionsRepository) GetTypes() ([]*models.Type, error) {
 }
 
 func (r *optionsRepository) GetStatuses() ([]*models.Status, error) {
-	query := "SELECT id, name FROM statuses WHERE active = true"
+	query := "SELECT id, name FROM statuses WHERE active = true ORDER BY position, name"
 
 	rows, err := r.db.Queryx(query)
 	if err != nil {
@@ -83,7 +96,7 @@ func (r *optionsRepository) GetStatuses() ([]*models.Status, error) {
 }
 
 func (r *optionsRepository) GetSeverities() ([]*models.Severity, error) {
-	query := "SELECT id, name FROM severities WHERE active = true"
+	query := "SELECT id, name FROM severities WHERE active = true ORDER BY position, name"
 
 	rows, err := r.db.Queryx(query)
 	if err != nil {
@@ -110,7 +123,7 @@ func (r *optionsRepository) GetSeverities() ([]*models.Severity, error) {
 }
 
 func (r *optionsRepository) GetProducts() ([]*models.Product, error) {
-	query := "SELECT id, name FROM products WHERE active = true"
+	query := "SELECT id, name FROM products WHERE active = true ORDER BY position, name"
 
 	rows, err := r.db.Queryx(query)
 	if err != nil {
@@ -137,7 +150,7 @@ func (r *optionsRepository) GetProducts() ([]*models.Product, error) {
 }
 
 func (r *optionsRepository) GetAreas() ([]*models.Area, error) {
-	query := "SELECT id, name FROM areas WHERE active = true"
+	query := "SELECT id, name FROM areas WHERE active = true ORDER BY position, name"
 
 	rows, err := r.db.Queryx(query)
 	if err != nil {
@@ -164,7 +177,7 @@ func (r *optionsRepository) GetAreas() ([]*models.Area, error) {
 }
 
 func (r *optionsRepository) GetPerformanceIndicators() ([]*models.PerformanceIndicator, error) {
-	query := "SELECT id, name FROM performance_indicators WHERE active = true"
+	query := "SELECT id, name FROM performance_indicators WHERE active = true ORDER BY position, name"
 
 	rows, err := r.db.Queryx(query)
 	if err != nil {
@@ -191,7 +204,7 @@ func (r *optionsRepository) GetPerformanceIndicators() ([]*models.PerformanceInd
 }
 
 func (r *optionsRepository) GetFaultySystems() ([]*models.FaultySystem, error) {
-	query := "SELECT id, name FROM faulty_systems WHERE active = true"
+	query := "SELECT id, name FROM faulty_systems WHERE active = true ORDER BY position, name"
 
 	rows, err := r.db.Queryx(query)
 	if err != nil {
@@ -218,7 +231,7 @@ func (r *optionsRepository) GetFaultySystems() ([]*models.FaultySystem, error) {
 }
 
 func (r *optionsRepository) GetCauses() ([]*models.Cause, error) {
-	query := "SELECT id, name FROM causes WHERE active = true"
+	query := "SELECT id, name FROM causes WHERE active = true ORDER BY position, name"
 
 	rows, err := r.db.Queryx(query)
 	if err != nil {
@@ -243,3 +256,248 @@ func (r *optionsRepository) GetCauses() ([]*models.Cause, error) {
 
 	return causes, nil
 }
+
+func (r *optionsRepository) GetSources() ([]*models.Source, error) {
+	sources := []*models.Source{}
+	if err := r.db.Select(&sources, "SELECT id, name FROM sources WHERE active = true ORDER BY position, name"); err != nil {
+		log.Printf("GetSources: Error executing query: %v", err)
+		return nil, err
+	}
+
+	return sources, nil
+}
+
+// optionTaxonomy describes where a taxonomy is stored and how incidents
+// reference it. Taxonomies are stored on incidents either by name in
+// incidentColumn or by ID through joinTable.
+type optionTaxonomy struct {
+	table          string
+	incidentColumn string
+	joinTable      string
+	joinColumn     string
+}
+
+// optionTaxonomies is keyed by the slug used in the public options routes.
+var optionTaxonomies = map[string]optionTaxonomy{
+	"types":                  {table: "types", incidentColumn: "type"},
+	"status":                 {table: "statuses", incidentColumn: "status"},
+	"severity":               {table: "severities", incidentColumn: "severity"},
+	"sources":                {table: "sources", incidentColumn: "incident_source"},
+	"products":               {table: "products", joinTable: "incident_products", joinColumn: "product_id"},
+	"areas":                  {table: "areas", joinTable: "incident_areas", joinColumn: "area_id"},
+	"performance-indicators": {table: "performance_indicators", joinTable: "incident_performance_indicators", joinColumn: "performance_indicator_id"},
+	"faulty-systems":         {table: "faulty_systems", joinTable: "incident_faulty_systems", joinColumn: "faulty_system_id"},
+	"causes":                 {table: "causes", joinTable: "incident_causes", joinColumn: "cause_id"},
+}
+
+func lookupTaxonomy(taxonomy string) (optionTaxonomy, error) {
+	definition, ok := optionTaxonomies[taxonomy]
+	if !ok {
+		return optionTaxonomy{}, &customErrors.NotFoundError{Msg: fmt.Sprintf("unknown option taxonomy %q", taxonomy)}
+	}
+	return definition, nil
+}
+
+func (r *optionsRepository) ListOptions(taxonomy string) ([]*models.Option, error) {
+	definition, err := lookupTaxonomy(taxonomy)
+	if err != nil {
+		return nil, err
+	}
+
+	options := []*models.Option{}
+	query := fmt.Sprintf("SELECT id, name, position, active FROM %s ORDER BY position, name", definition.table)
+	if err := r.db.Select(&options, query); err != nil {
+		log.Printf("ListOptions: Error executing query: %v", err)
+		return nil, err
+	}
+
+	return options, nil
+}
+
+func (r *optionsRepository) GetOptionByID(taxonomy string, id int) (*models.Option, error) {
+	definition, err := lookupTaxonomy(taxonomy)
+	if err != nil {
+		return nil, err
+	}
+
+	option := new(models.Option)
+	query := fmt.Sprintf("SELECT id, name, position, active FROM %s WHERE id = $1", definition.table)
+	err = r.db.Get(option, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &customErrors.NotFoundError{Msg: fmt.Sprintf("option with ID %d not found", id)}
+	}
+	if err != nil {
+		log.Printf("GetOptionByID: Error executing query: %v", err)
+		return nil, err
+	}
+
+	return option, nil
+}
+
+func (r *optionsRepository) OptionNameExists(taxonomy, name string, excludeID int) (bool, error) {
+	definition, err := lookupTaxonomy(taxonomy)
+	if err != nil {
+		return false, err
+	}
+
+	var exists bool
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE LOWER(name) = LOWER($1) AND id <> $2)", definition.table)
+	if err := r.db.Get(&exists, query, name, excludeID); err != nil {
+		log.Printf("OptionNameExists: Error executing query: %v", err)
+		return false, err
+	}
+
+	return exists, nil
+}
+
+func (r *optionsRepository) CreateOption(taxonomy, name string) (int, error) {
+	definition, err := lookupTaxonomy(taxonomy)
+	if err != nil {
+		return 0, err
+	}
+
+	// New values are appended to the end of the list.
+	query := fmt.Sprintf(
+		"INSERT INTO %s (name, active, position) VALUES ($1, true, (SELECT COALESCE(MAX(position), 0) + 1 FROM %s)) RETURNING id",
+		definition.table, definition.table,
+	)
+
+	var id int
+	if err := r.db.Get(&id, query, name); err != nil {
+		log.Printf("CreateOption: Error executing query: %v", err)
+		return 0, err
+	}
+
+	log.Printf("CreateOption: Created %s option %d", taxonomy, id)
+	return id, nil
+}
+
+func (r *optionsRepository) RenameOption(taxonomy string, id int, name string) error {
+	definition, err := lookupTaxonomy(taxonomy)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var previous string
+	err = tx.Get(&previous, fmt.Sprintf("SELECT name FROM %s WHERE id = $1 FOR UPDATE", definition.table), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &customErrors.NotFoundError{Msg: fmt.Sprintf("option with ID %d not found", id)}
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(fmt.Sprintf("UPDATE %s SET name = $1 WHERE id = $2", definition.table), name, id); err != nil {
+		log.Printf("RenameOption: Error renaming option: %v", err)
+		return err
+	}
+
+	// Incidents reference these taxonomies by name, so keep them in sync.
+	if definition.incidentColumn != "" {
+		query := fmt.Sprintf("UPDATE incidents SET %s = $1 WHERE %s = $2", definition.incidentColumn, definition.incidentColumn)
+		if _, err = tx.Exec(query, name, previous); err != nil {
+			log.Printf("RenameOption: Error renaming option on incidents: %v", err)
+			return err
+		}
+	}
+
+	log.Printf("RenameOption: Renamed %s option %d from %q to %q", taxonomy, id, previous, name)
+	return tx.Commit()
+}
+
+func (r *optionsRepository) ReorderOptions(taxonomy string, ids []int) error {
+	definition, err := lookupTaxonomy(taxonomy)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf("UPDATE %s SET position = $1 WHERE id = $2", definition.table)
+	for position, id := range ids {
+		result, err := tx.Exec(query, position+1, id)
+		if err != nil {
+			log.Printf("ReorderOptions: Error updating position: %v", err)
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return &customErrors.NotFoundError{Msg: fmt.Sprintf("option with ID %d not found", id)}
+		}
+	}
+
+	log.Printf("ReorderOptions: Reordered %d %s options", len(ids), taxonomy)
+	return tx.Commit()
+}
+
+func (r *optionsRepository) SetOptionActive(taxonomy string, id int, active bool) error {
+	definition, err := lookupTaxonomy(taxonomy)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.Exec(fmt.Sprintf("UPDATE %s SET active = $1 WHERE id = $2", definition.table), active, id)
+	if err != nil {
+		log.Printf("SetOptionActive: Error executing query: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return &customErrors.NotFoundError{Msg: fmt.Sprintf("option with ID %d not found", id)}
+	}
+
+	return nil
+}
+
+// CountOpenIncidentsUsingOption counts incidents referencing the option whose
+// status is not one of closedStatuses (compared case-insensitively).
+func (r *optionsRepository) CountOpenIncidentsUsingOption(taxonomy string, id int, closedStatuses []string) (int, error) {
+	definition, err := lookupTaxonomy(taxonomy)
+	if err != nil {
+		return 0, err
+	}
+
+	var query string
+	if definition.incidentColumn != "" {
+		query = fmt.Sprintf(
+			"SELECT COUNT(*) FROM incidents i JOIN %s o ON i.%s = o.name WHERE o.id = ? AND LOWER(i.status) NOT IN (?)",
+			definition.table, definition.incidentColumn,
+		)
+	} else {
+		query = fmt.Sprintf(
+			"SELECT COUNT(*) FROM incidents i JOIN %s rel ON rel.incident_id = i.id WHERE rel.%s = ? AND LOWER(i.status) NOT IN (?)",
+			definition.joinTable, definition.joinColumn,
+		)
+	}
+
+	query, args, err := sqlx.In(query, id, closedStatuses)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	if err := r.db.Get(&count, r.db.Rebind(query), args...); err != nil {
+		log.Printf("CountOpenIncidentsUsingOption: Error executing query: %v", err)
+		return 0, err
+	}
+
+	return count, nil
+}
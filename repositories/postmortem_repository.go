@@ -0,0 +1,484 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+)
+
+const (
+	postmortemEventField       = "postmortem"
+	postmortemReviewEventField = "postmortem_review"
+)
+
+type PostmortemRepository interface {
+	GetPostmortem(incidentID int) (*models.Postmortem, error)
+	CreatePostmortem(postmortem *models.Postmortem, transitions []*models.IncidentStatusTransition) error
+	UpdatePostmortemSections(incidentID int, sections *models.PostmortemSections) error
+	SubmitPostmortem(incidentID int, reviewers []int, transitions []*models.IncidentStatusTransition, actorID int) error
+	ApprovePostmortem(incidentID, reviewerID int, at *models.CustomTime) (bool, error)
+	RequestPostmortemChanges(request *models.PostmortemChangeRequest, transitions []*models.IncidentStatusTransition, actorID int) error
+	GetPostmortemTemplates() ([]*models.PostmortemTemplate, error)
+	GetPostmortemTemplateForType(typeName string) (*models.PostmortemTemplate, error)
+	SavePostmortemTemplate(template *models.PostmortemTemplateInput) error
+	DeletePostmortemTemplate(typeID int) error
+}
+
+type postmortemRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostmortemRepository(db *sqlx.DB) PostmortemRepository {
+	return &postmortemRepository{db: db}
+}
+
+const postmortemSectionColumns = `summary, timeline, root_cause, contributing_factors, went_well, went_badly, lessons`
+
+func (r *postmortemRepository) GetPostmortem(incidentID int) (*models.Postmortem, error) {
+	log.Printf("GetPostmortem: Retrieving postmortem of incident ID %d", incidentID)
+
+	query := `
+	SELECT
+		p.incident_id, p.status, p.summary, p.timeline, p.root_cause, p.contributing_factors,
+		p.went_well, p.went_badly, p.lessons, p.author_id, p.created_at, p.updated_at,
+		p.submitted_at, p.approved_at,
+		author.name AS author_name
+	FROM
+		postmortems p
+	JOIN
+		users author ON p.author_id = author.id
+	WHERE
+		p.incident_id = $1
+	`
+
+	postmortem := new(models.Postmortem)
+	err := r.db.Get(postmortem, query, incidentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &customErrors.NotFoundError{Msg: fmt.Sprintf("incident with ID %d has no postmortem", incidentID)}
+	}
+	if err != nil {
+		log.Printf("GetPostmortem: Error executing query: %v", err)
+		return nil, err
+	}
+
+	reviewersQuery := `
+	SELECT r.user_id, r.approved_at, u.name, u.avatar_url
+	FROM postmortem_reviewers r
+	JOIN users u ON r.user_id = u.id
+	WHERE r.incident_id = $1
+	ORDER BY u.name
+	`
+
+	postmortem.Reviewers = []*models.PostmortemReviewer{}
+	if err := r.db.Select(&postmortem.Reviewers, reviewersQuery, incidentID); err != nil {
+		log.Printf("GetPostmortem: Error retrieving reviewers: %v", err)
+		return nil, err
+	}
+
+	return postmortem, nil
+}
+
+// CreatePostmortem starts the draft and moves the incident into
+// documentation when it is ready for it.
+func (r *postmortemRepository) CreatePostmortem(postmortem *models.Postmortem, transitions []*models.IncidentStatusTransition) error {
+	log.Printf("CreatePostmortem: Starting postmortem of incident ID %d", postmortem.IncidentID)
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		log.Printf("CreatePostmortem: Error starting transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO postmortems (incident_id, author_id, ` + postmortemSectionColumns + `)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	ON CONFLICT (incident_id) DO NOTHING
+	`
+
+	sections := postmortem.PostmortemSections
+	result, err := tx.Exec(query, postmortem.IncidentID, postmortem.AuthorID, sections.Summary, sections.Timeline, sections.RootCause,
+		sections.ContributingFactors, sections.WentWell, sections.WentBadly, sections.Lessons)
+	if err != nil {
+		log.Printf("CreatePostmortem: Error executing query: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("CreatePostmortem: Error getting rows affected: %v", err)
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return &customErrors.ConflictError{Msg: fmt.Sprintf("incident with ID %d already has a postmortem", postmortem.IncidentID)}
+	}
+
+	if err := setRenderedPostmortem(tx, postmortem.IncidentID, &sections); err != nil {
+		return err
+	}
+
+	event := newIncidentEvent(postmortem.IncidentID, postmortem.AuthorID, postmortemEventField, nil, stringValue(models.PostmortemDraft))
+	if err := insertIncidentEvents(tx, event); err != nil {
+		return err
+	}
+
+	for _, transition := range transitions {
+		if err := applyStatusTransition(tx, transition, postmortem.AuthorID); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("CreatePostmortem: Error committing transaction: %v", err)
+		return err
+	}
+
+	log.Printf("CreatePostmortem: Postmortem of incident ID %d created", postmortem.IncidentID)
+	return nil
+}
+
+func (r *postmortemRepository) UpdatePostmortemSections(incidentID int, sections *models.PostmortemSections) error {
+	log.Printf("UpdatePostmortemSections: Editing postmortem of incident ID %d", incidentID)
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		log.Printf("UpdatePostmortemSections: Error starting transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockPostmortem(tx, incidentID, models.PostmortemDraft); err != nil {
+		return err
+	}
+
+	query := `
+	UPDATE postmortems
+	SET summary = $1, timeline = $2, root_cause = $3, contributing_factors = $4,
+		went_well = $5, went_badly = $6, lessons = $7, updated_at = NOW()
+	WHERE incident_id = $8
+	`
+
+	_, err = tx.Exec(query, sections.Summary, sections.Timeline, sections.RootCause, sections.ContributingFactors,
+		sections.WentWell, sections.WentBadly, sections.Lessons, incidentID)
+	if err != nil {
+		log.Printf("UpdatePostmortemSections: Error executing update query: %v", err)
+		return err
+	}
+
+	if err := setRenderedPostmortem(tx, incidentID, sections); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("UpdatePostmortemSections: Error committing transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// SubmitPostmortem replaces the reviewers, clearing earlier approvals, and
+// moves the incident into review.
+func (r *postmortemRepository) SubmitPostmortem(incidentID int, reviewers []int, transitions []*models.IncidentStatusTransition, actorID int) error {
+	log.Printf("SubmitPostmortem: Submitting postmortem of incident ID %d to %d reviewers", incidentID, len(reviewers))
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		log.Printf("SubmitPostmortem: Error starting transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockPostmortem(tx, incidentID, models.PostmortemDraft); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE postmortems SET status = $1, submitted_at = NOW(), updated_at = NOW() WHERE incident_id = $2`, models.PostmortemInReview, incidentID)
+	if err != nil {
+		log.Printf("SubmitPostmortem: Error updating postmortem: %v", err)
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM postmortem_reviewers WHERE incident_id = $1`, incidentID); err != nil {
+		log.Printf("SubmitPostmortem: Error clearing reviewers: %v", err)
+		return err
+	}
+
+	for _, reviewerID := range reviewers {
+		if _, err := tx.Exec(`INSERT INTO postmortem_reviewers (incident_id, user_id) VALUES ($1, $2)`, incidentID, reviewerID); err != nil {
+			log.Printf("SubmitPostmortem: Error adding reviewer %d: %v", reviewerID, err)
+			return err
+		}
+	}
+
+	event := newIncidentEvent(incidentID, actorID, postmortemEventField, stringValue(models.PostmortemDraft), stringValue(models.PostmortemInReview))
+	if err := insertIncidentEvents(tx, event); err != nil {
+		return err
+	}
+
+	for _, transition := range transitions {
+		if err := applyStatusTransition(tx, transition, actorID); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("SubmitPostmortem: Error committing transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// ApprovePostmortem records one reviewer's approval and reports whether it
+// was the last one missing. The final approval stamps the incident's
+// documented and reviewed timestamps.
+func (r *postmortemRepository) ApprovePostmortem(incidentID, reviewerID int, at *models.CustomTime) (bool, error) {
+	log.Printf("ApprovePostmortem: Reviewer %d approving postmortem of incident ID %d", reviewerID, incidentID)
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		log.Printf("ApprovePostmortem: Error starting transaction: %v", err)
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if err := lockPostmortem(tx, incidentID, models.PostmortemInReview); err != nil {
+		return false, err
+	}
+
+	result, err := tx.Exec(`UPDATE postmortem_reviewers SET approved_at = $1 WHERE incident_id = $2 AND user_id = $3 AND approved_at IS NULL`, at, incidentID, reviewerID)
+	if err != nil {
+		log.Printf("ApprovePostmortem: Error recording approval: %v", err)
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("ApprovePostmortem: Error getting rows affected: %v", err)
+		return false, err
+	}
+
+	if rowsAffected == 0 {
+		return false, &customErrors.ConflictError{Msg: "you are not a pending reviewer of this postmortem"}
+	}
+
+	var pending int
+	if err := tx.Get(&pending, `SELECT COUNT(*) FROM postmortem_reviewers WHERE incident_id = $1 AND approved_at IS NULL`, incidentID); err != nil {
+		log.Printf("ApprovePostmortem: Error counting pending reviewers: %v", err)
+		return false, err
+	}
+
+	approved := pending == 0
+	if approved {
+		_, err = tx.Exec(`UPDATE postmortems SET status = $1, approved_at = $2, updated_at = NOW() WHERE incident_id = $3`, models.PostmortemApproved, at, incidentID)
+		if err != nil {
+			log.Printf("ApprovePostmortem: Error approving postmortem: %v", err)
+			return false, err
+		}
+
+		// Keep the first time, like status transitions do
+		_, err = tx.Exec(`UPDATE incidents SET documented_at = COALESCE(documented_at, $1), reviewed_at = COALESCE(reviewed_at, $1) WHERE id = $2`, at, incidentID)
+		if err != nil {
+			log.Printf("ApprovePostmortem: Error stamping incident: %v", err)
+			return false, err
+		}
+
+		event := newIncidentEvent(incidentID, reviewerID, postmortemEventField, stringValue(models.PostmortemInReview), stringValue(models.PostmortemApproved))
+		if err := insertIncidentEvents(tx, event); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("ApprovePostmortem: Error committing transaction: %v", err)
+		return false, err
+	}
+
+	return approved, nil
+}
+
+// RequestPostmortemChanges sends the postmortem back to draft, dropping the
+// approvals given so far, and the incident back to documentation.
+func (r *postmortemRepository) RequestPostmortemChanges(request *models.PostmortemChangeRequest, transitions []*models.IncidentStatusTransition, actorID int) error {
+	log.Printf("RequestPostmortemChanges: Returning postmortem of incident ID %d to draft", request.IncidentID)
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		log.Printf("RequestPostmortemChanges: Error starting transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockPostmortem(tx, request.IncidentID, models.PostmortemInReview); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE postmortems SET status = $1, submitted_at = NULL, updated_at = NOW() WHERE incident_id = $2`, models.PostmortemDraft, request.IncidentID)
+	if err != nil {
+		log.Printf("RequestPostmortemChanges: Error updating postmortem: %v", err)
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE postmortem_reviewers SET approved_at = NULL WHERE incident_id = $1`, request.IncidentID); err != nil {
+		log.Printf("RequestPostmortemChanges: Error clearing approvals: %v", err)
+		return err
+	}
+
+	events := []*models.IncidentEvent{
+		newIncidentEvent(request.IncidentID, actorID, postmortemEventField, stringValue(models.PostmortemInReview), stringValue(models.PostmortemDraft)),
+		newIncidentEvent(request.IncidentID, actorID, postmortemReviewEventField, nil, stringValue(request.Reason)),
+	}
+	if err := insertIncidentEvents(tx, events...); err != nil {
+		return err
+	}
+
+	for _, transition := range transitions {
+		if err := applyStatusTransition(tx, transition, actorID); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("RequestPostmortemChanges: Error committing transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+const postmortemTemplateSelect = `
+	SELECT
+		t.type_id, t.summary, t.timeline, t.root_cause, t.contributing_factors,
+		t.went_well, t.went_badly, t.lessons, t.updated_at,
+		ty.name AS type_name
+	FROM
+		postmortem_templates t
+	JOIN
+		types ty ON t.type_id = ty.id
+	`
+
+func (r *postmortemRepository) GetPostmortemTemplates() ([]*models.PostmortemTemplate, error) {
+	log.Println("GetPostmortemTemplates: Retrieving postmortem templates")
+
+	templates := []*models.PostmortemTemplate{}
+	if err := r.db.Select(&templates, postmortemTemplateSelect+`ORDER BY ty.position, ty.name`); err != nil {
+		log.Printf("GetPostmortemTemplates: Error executing query: %v", err)
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+func (r *postmortemRepository) GetPostmortemTemplateForType(typeName string) (*models.PostmortemTemplate, error) {
+	log.Printf("GetPostmortemTemplateForType: Retrieving template for type %q", typeName)
+
+	template := new(models.PostmortemTemplate)
+	err := r.db.Get(template, postmortemTemplateSelect+`WHERE LOWER(ty.name) = LOWER($1)`, typeName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &customErrors.NotFoundError{Msg: fmt.Sprintf("no postmortem template for type %q", typeName)}
+	}
+	if err != nil {
+		log.Printf("GetPostmortemTemplateForType: Error executing query: %v", err)
+		return nil, err
+	}
+
+	return template, nil
+}
+
+func (r *postmortemRepository) SavePostmortemTemplate(template *models.PostmortemTemplateInput) error {
+	log.Printf("SavePostmortemTemplate: Saving template for type ID %d", template.TypeID)
+
+	var exists bool
+	if err := r.db.Get(&exists, `SELECT EXISTS (SELECT 1 FROM types WHERE id = $1)`, template.TypeID); err != nil {
+		log.Printf("SavePostmortemTemplate: Error checking type: %v", err)
+		return err
+	}
+
+	if !exists {
+		return &customErrors.NotFoundError{Msg: fmt.Sprintf("type with ID %d not found", template.TypeID)}
+	}
+
+	query := `
+	INSERT INTO postmortem_templates (type_id, ` + postmortemSectionColumns + `)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	ON CONFLICT (type_id) DO UPDATE SET
+		summary = EXCLUDED.summary,
+		timeline = EXCLUDED.timeline,
+		root_cause = EXCLUDED.root_cause,
+		contributing_factors = EXCLUDED.contributing_factors,
+		went_well = EXCLUDED.went_well,
+		went_badly = EXCLUDED.went_badly,
+		lessons = EXCLUDED.lessons,
+		updated_at = NOW()
+	`
+
+	sections := template.PostmortemSections
+	_, err := r.db.Exec(query, template.TypeID, sections.Summary, sections.Timeline, sections.RootCause,
+		sections.ContributingFactors, sections.WentWell, sections.WentBadly, sections.Lessons)
+	if err != nil {
+		log.Printf("SavePostmortemTemplate: Error executing query: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *postmortemRepository) DeletePostmortemTemplate(typeID int) error {
+	log.Printf("DeletePostmortemTemplate: Deleting template for type ID %d", typeID)
+
+	result, err := r.db.Exec(`DELETE FROM postmortem_templates WHERE type_id = $1`, typeID)
+	if err != nil {
+		log.Printf("DeletePostmortemTemplate: Error executing delete query: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("DeletePostmortemTemplate: Error getting rows affected: %v", err)
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return &customErrors.NotFoundError{Msg: fmt.Sprintf("no postmortem template for type ID %d", typeID)}
+	}
+
+	return nil
+}
+
+// lockPostmortem locks the postmortem row for the rest of the transaction and
+// checks it is still in the expected state.
+func lockPostmortem(tx *sqlx.Tx, incidentID int, expected string) error {
+	var status string
+	err := tx.Get(&status, `SELECT status FROM postmortems WHERE incident_id = $1 FOR UPDATE`, incidentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &customErrors.NotFoundError{Msg: fmt.Sprintf("incident with ID %d has no postmortem", incidentID)}
+	}
+	if err != nil {
+		log.Printf("lockPostmortem: Error locking postmortem: %v", err)
+		return err
+	}
+
+	if status != expected {
+		return &customErrors.ConflictError{Msg: fmt.Sprintf("postmortem is %s, not %s", strings.ReplaceAll(status, "_", " "), strings.ReplaceAll(expected, "_", " "))}
+	}
+
+	return nil
+}
+
+// setRenderedPostmortem keeps incidents.post_mortem in step with the
+// structured document.
+func setRenderedPostmortem(tx *sqlx.Tx, incidentID int, sections *models.PostmortemSections) error {
+	if _, err := tx.Exec(`UPDATE incidents SET post_mortem = $1 WHERE id = $2`, sections.Markdown(), incidentID); err != nil {
+		log.Printf("setRenderedPostmortem: Error updating incident: %v", err)
+		return err
+	}
+	return nil
+}
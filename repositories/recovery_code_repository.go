@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"log"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// RecoveryCodeRepository stores the hashes of two-factor recovery codes.
+type RecoveryCodeRepository interface {
+	ReplaceRecoveryCodes(userID int, codeHashes []string) error
+	ConsumeRecoveryCode(userID int, codeHash string) (bool, error)
+	DeleteRecoveryCodes(userID int) error
+}
+
+type recoveryCodeRepository struct {
+	db *sqlx.DB
+}
+
+func NewRecoveryCodeRepository(db *sqlx.DB) RecoveryCodeRepository {
+	return &recoveryCodeRepository{db: db}
+}
+
+// ReplaceRecoveryCodes drops every code of the user and stores the new set.
+func (r *recoveryCodeRepository) ReplaceRecoveryCodes(userID int, codeHashes []string) error {
+	log.Printf("ReplaceRecoveryCodes: Storing %d recovery codes for user ID %d", len(codeHashes), userID)
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		log.Printf("ReplaceRecoveryCodes: Error starting transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		log.Printf("ReplaceRecoveryCodes: Error deleting previous codes: %v", err)
+		return err
+	}
+
+	for _, codeHash := range codeHashes {
+		if _, err := tx.Exec(`INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, codeHash); err != nil {
+			log.Printf("ReplaceRecoveryCodes: Error inserting code: %v", err)
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ConsumeRecoveryCode marks an unused code as used and reports whether one
+// matched.
+func (r *recoveryCodeRepository) ConsumeRecoveryCode(userID int, codeHash string) (bool, error) {
+	result, err := r.db.Exec(`UPDATE user_recovery_codes SET used_at = NOW() WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`, userID, codeHash)
+	if err != nil {
+		log.Printf("ConsumeRecoveryCode: Error executing query: %v", err)
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (r *recoveryCodeRepository) DeleteRecoveryCodes(userID int) error {
+	if _, err := r.db.Exec(`DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		log.Printf("DeleteRecoveryCodes: Error executing query: %v", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"log"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+)
+
+type SecurityEventRepository interface {
+	CreateSecurityEvent(event *models.SecurityEvent) error
+	GetSecurityEvents(qp *models.SecurityEventQueryParams) ([]*models.SecurityEvent, int, error)
+}
+
+type securityEventRepository struct {
+	db *sqlx.DB
+}
+
+func NewSecurityEventRepository(db *sqlx.DB) SecurityEventRepository {
+	return &securityEventRepository{db: db}
+}
+
+func (r *securityEventRepository) CreateSecurityEvent(event *models.SecurityEvent) error {
+	log.Printf("CreateSecurityEvent: Recording %s event", event.Type)
+
+	query := `
+	INSERT INTO security_events (type, user_id, actor_id, email, ip, detail)
+	VALUES (:type, :user_id, :actor_id, :email, :ip, :detail)
+	`
+
+	if _, err := r.db.NamedExec(query, event); err != nil {
+		log.Printf("CreateSecurityEvent: Error executing query: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *securityEventRepository) GetSecurityEvents(qp *models.SecurityEventQueryParams) ([]*models.SecurityEvent, int, error) {
+	log.Println("GetSecurityEvents: Retrieving security events")
+
+	where := ` WHERE ($1::text IS NULL OR type = $1) AND ($2::int IS NULL OR user_id = $2)`
+
+	var total int
+	if err := r.db.Get(&total, `SELECT COUNT(*) FROM security_events`+where, qp.Type, qp.UserID); err != nil {
+		log.Printf("GetSecurityEvents: Error counting events: %v", err)
+		return nil, 0, err
+	}
+
+	query := `SELECT id, type, user_id, actor_id, email, ip, detail, created_at FROM security_events` + where +
+		` ORDER BY created_at DESC, id DESC LIMIT $3 OFFSET $4`
+
+	events := []*models.SecurityEvent{}
+	if err := r.db.Select(&events, query, qp.Type, qp.UserID, qp.Limit, qp.Offset()); err != nil {
+		log.Printf("GetSecurityEvents: Error executing query: %v", err)
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
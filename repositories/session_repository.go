@@ -0,0 +1,180 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+)
+
+type SessionRepository interface {
+	CreateSession(session *models.Session, tokenHash string) (int, error)
+	GetSessionByTokenHash(tokenHash string) (*models.Session, error)
+	GetSessionByPreviousTokenHash(tokenHash string) (*models.Session, error)
+	RotateSessionToken(id int, oldHash, newHash string, expiresAt time.Time) error
+	ListActiveSessions(userID int) ([]*models.Session, error)
+	RevokeSession(id int) error
+	RevokeUserSession(userID, id int) error
+	RevokeAllUserSessions(userID int) error
+	RevokeOtherUserSessions(userID, keepID int) error
+	IsSessionActive(id int) (bool, error)
+}
+
+type sessionRepository struct {
+	db *sqlx.DB
+}
+
+func NewSessionRepository(db *sqlx.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+const sessionColumns = `id, user_id, user_agent, ip, created_at, last_used_at, expires_at, revoked_at`
+
+func (r *sessionRepository) CreateSession(session *models.Session, tokenHash string) (int, error) {
+	log.Printf("CreateSession: Creating session for user ID %d", session.UserID)
+
+	query := `INSERT INTO user_sessions (user_id, token_hash, user_agent, ip, expires_at) VALUES ($1, $2, $3, $4, $5) RETURNING id`
+
+	var id int
+	if err := r.db.Get(&id, query, session.UserID, tokenHash, session.UserAgent, session.IP, session.ExpiresAt); err != nil {
+		log.Printf("CreateSession: Error executing query: %v", err)
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *sessionRepository) GetSessionByTokenHash(tokenHash string) (*models.Session, error) {
+	return r.getSession(`SELECT `+sessionColumns+` FROM user_sessions WHERE token_hash = $1`, tokenHash)
+}
+
+func (r *sessionRepository) GetSessionByPreviousTokenHash(tokenHash string) (*models.Session, error) {
+	return r.getSession(`SELECT `+sessionColumns+` FROM user_sessions WHERE previous_token_hash = $1`, tokenHash)
+}
+
+func (r *sessionRepository) getSession(query string, args ...interface{}) (*models.Session, error) {
+	session := new(models.Session)
+	err := r.db.Get(session, query, args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &customErrors.NotFoundError{Msg: "session not found"}
+	}
+	if err != nil {
+		log.Printf("getSession: Error executing query: %v", err)
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// RotateSessionToken swaps the refresh token only if oldHash is still the
+// current one, so two concurrent refreshes cannot both succeed.
+func (r *sessionRepository) RotateSessionToken(id int, oldHash, newHash string, expiresAt time.Time) error {
+	log.Printf("RotateSessionToken: Rotating refresh token of session %d", id)
+
+	query := `
+	UPDATE user_sessions
+	SET token_hash = $1, previous_token_hash = $2, expires_at = $3, last_used_at = NOW()
+	WHERE id = $4 AND token_hash = $2 AND revoked_at IS NULL
+	`
+
+	result, err := r.db.Exec(query, newHash, oldHash, expiresAt, id)
+	if err != nil {
+		log.Printf("RotateSessionToken: Error executing query: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return &customErrors.AuthenticationError{Msg: "refresh token is no longer valid"}
+	}
+
+	return nil
+}
+
+func (r *sessionRepository) ListActiveSessions(userID int) ([]*models.Session, error) {
+	log.Printf("ListActiveSessions: Retrieving sessions for user ID %d", userID)
+
+	query := `SELECT ` + sessionColumns + ` FROM user_sessions WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW() ORDER BY last_used_at DESC`
+
+	sessions := []*models.Session{}
+	if err := r.db.Select(&sessions, query, userID); err != nil {
+		log.Printf("ListActiveSessions: Error executing query: %v", err)
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (r *sessionRepository) RevokeSession(id int) error {
+	log.Printf("RevokeSession: Revoking session %d", id)
+
+	if _, err := r.db.Exec(`UPDATE user_sessions SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, id); err != nil {
+		log.Printf("RevokeSession: Error executing query: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *sessionRepository) RevokeUserSession(userID, id int) error {
+	log.Printf("RevokeUserSession: Revoking session %d of user ID %d", id, userID)
+
+	result, err := r.db.Exec(`UPDATE user_sessions SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`, id, userID)
+	if err != nil {
+		log.Printf("RevokeUserSession: Error executing query: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return &customErrors.NotFoundError{Msg: fmt.Sprintf("session with ID %d not found", id)}
+	}
+
+	return nil
+}
+
+func (r *sessionRepository) RevokeAllUserSessions(userID int) error {
+	log.Printf("RevokeAllUserSessions: Revoking every session of user ID %d", userID)
+
+	if _, err := r.db.Exec(`UPDATE user_sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, userID); err != nil {
+		log.Printf("RevokeAllUserSessions: Error executing query: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *sessionRepository) RevokeOtherUserSessions(userID, keepID int) error {
+	log.Printf("RevokeOtherUserSessions: Revoking sessions of user ID %d except %d", userID, keepID)
+
+	if _, err := r.db.Exec(`UPDATE user_sessions SET revoked_at = NOW() WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL`, userID, keepID); err != nil {
+		log.Printf("RevokeOtherUserSessions: Error executing query: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *sessionRepository) IsSessionActive(id int) (bool, error) {
+	var active bool
+	query := `SELECT EXISTS (SELECT 1 FROM user_sessions WHERE id = $1 AND revoked_at IS NULL AND expires_at > NOW())`
+	if err := r.db.Get(&active, query, id); err != nil {
+		log.Printf("IsSessionActive: Error executing query: %v", err)
+		return false, err
+	}
+
+	return active, nil
+}
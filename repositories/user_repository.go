@@ -1,17 +1,40 @@
 package repositories
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
 	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
 )
 
 
 type UserRepository interface {
-	CreateUser(user *models.Register) error
+	CreateUser(user *models.Register) (int, error)
 	GetUserByEmail(email string) (*models.User, error)
-	GetAllUsersPublicData() ([]*models.UserPublicData, error)
+	GetUserByID(id int) (*models.User, error)
+	GetUsers(qp *models.UserQueryParams) ([]*models.UserPublicData, int, error)
+	UpdateProfile(id int, update *models.ProfileUpdate) error
+	SetUserDeactivated(id int, deactivated bool) error
+	UpdateUserAvatar(id int, avatarURL string) error
+	UpdateUserRole(id int, role string) error
+	UpdateUserTeam(id int, team string) error
+	UpdateUserPassword(id int, passwordHash string) error
+	MarkEmailVerified(id int) error
+	SetTOTPSecret(id int, secret string) error
+	EnableTOTP(id int) error
+	DisableTOTP(id int) error
+	ClaimTOTPStep(id int, step int64) (bool, error)
+	CreateServiceAccount(name, email, passwordHash, role string) (int, error)
+	GetServiceAccounts() ([]*models.ServiceAccount, error)
+	GetUserByOIDCSubject(issuer, subject string) (*models.User, error)
+	LinkOIDCIdentity(id int, issuer, subject string) error
+	CreateOIDCUser(name, email, passwordHash, issuer, subject string) (int, error)
 }
 
 type userRepository struct {
@@ -22,19 +45,22 @@ func NewUserRepository(db *sqlx.DB) UserRepository {
     return &userRepository{db: db}
 }
 
-func (r *userRepository) CreateUser(user *models.Register) error {
+const userColumns = `id, name, email, password, team, role, avatar_url, email_verified_at, totp_secret, totp_enabled_at, totp_last_step, is_service_account, deactivated_at`
+
+func (r *userRepository) CreateUser(user *models.Register) (int, error) {
 	log.Println("CreateUser: Starting user creation process")
-	query := `INSERT INTO users (name, email, password, role, team) values ($1, $2, $3, $4, $5)`
+	query := `INSERT INTO users (name, email, password, role, team) values ($1, $2, $3, $4, $5) RETURNING id`
 
-	log.Printf("CreateUser: Executing query with name: %s, email: %s, role: Viewer, team: Cloudwalk", user.Name, user.Email)
-	_, err := r.db.Exec(query, user.Name, user.Email, user.Password, "Viewer", "Cloudwalk")
+	log.Printf("CreateUser: Executing query with name: %s, email: %s, role: %s, team: Cloudwalk", user.Name, user.Email, models.RoleViewer)
+	var id int
+	err := r.db.Get(&id, query, user.Name, user.Email, user.Password, models.RoleViewer, "Cloudwalk")
 	if err != nil {
 		log.Printf("CreateUser: Error executing query: %v", err)
-		return err
+		return 0, err
 	}
 
 	log.Println("CreateUser: User created successfully")
-	return nil 
+	return id, nil
 }
 
 
@@ -42,10 +68,13 @@ func (r *userRepository) GetUserByEmail(email string) (*models.User, error) {
 	log.Printf("GetUserByEmail: Retrieving user with email: %s", email)
 	
 	user := models.User{}
-	query := `SELECT id, name, email, password, team, role, avatar_url FROM users where email = $1`
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = $1`
 
 	log.Println("GetUserByEmail: Executing query")
 	err := r.db.Get(&user, query, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &customErrors.NotFoundError{Msg: "user not found"}
+	}
 	if err != nil {
 		log.Printf("GetUserByEmail: Error executing query: %v", err)
 		return nil, err
@@ -56,34 +85,282 @@ func (r *userRepository) GetUserByEmail(email string) (*models.User, error) {
 
 }
 
-func (r *userRepository) GetAllUsersPublicData() ([]*models.UserPublicData, error) {
-	log.Println("GetAllUsersPublicData: Starting retrieval of all users' public data")
+func (r *userRepository) GetUserByID(id int) (*models.User, error) {
+	log.Printf("GetUserByID: Retrieving user with ID: %d", id)
 
-	query := `SELECT id, name, avatar_url FROM users`
+	user := models.User{}
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
 
-	rows, err := r.db.Queryx(query)
+	err := r.db.Get(&user, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &customErrors.NotFoundError{Msg: fmt.Sprintf("user with ID %d not found", id)}
+	}
 	if err != nil {
-		log.Printf("GetAllUsersPublicData: Error executing query: %v", err)
+		log.Printf("GetUserByID: Error executing query: %v", err)
 		return nil, err
 	}
-	defer rows.Close()
 
-	var users []*models.UserPublicData
+	return &user, nil
+}
+
+func (r *userRepository) GetUsers(qp *models.UserQueryParams) ([]*models.UserPublicData, int, error) {
+	log.Println("GetUsers: Retrieving user directory")
+
+	where := `
+	WHERE NOT is_service_account
+	AND ($1::text IS NULL OR name ILIKE '%' || $1 || '%' ESCAPE '\' OR email ILIKE '%' || $1 || '%' ESCAPE '\')
+	AND ($2::text IS NULL OR team = $2)
+	AND ($3::text IS NULL OR role = $3)
+	AND ($4::boolean OR deactivated_at IS NULL)
+	`
+
+	var search *string
+	if qp.Search != nil {
+		escaped := escapeLike(*qp.Search)
+		search = &escaped
+	}
+
+	var total int
+	if err := r.db.Get(&total, `SELECT COUNT(*) FROM users`+where, search, qp.Team, qp.Role, qp.IncludeInactive); err != nil {
+		log.Printf("GetUsers: Error counting users: %v", err)
+		return nil, 0, err
+	}
+
+	query := `SELECT id, name, avatar_url, team, role FROM users` + where + ` ORDER BY name, id LIMIT $5 OFFSET $6`
+
+	users := []*models.UserPublicData{}
+	if err := r.db.Select(&users, query, search, qp.Team, qp.Role, qp.IncludeInactive, qp.Limit, qp.Offset()); err != nil {
+		log.Printf("GetUsers: Error executing query: %v", err)
+		return nil, 0, err
+	}
+
+	log.Printf("GetUsers: Retrieved %d of %d users", len(users), total)
+	return users, total, nil
+}
+
+// escapeLike makes a search term match literally inside a LIKE pattern
+// using '\' as the escape character.
+func escapeLike(term string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(term)
+}
+
+// UpdateProfile sets the given fields, keeping the current value of any nil
+// one.
+func (r *userRepository) UpdateProfile(id int, update *models.ProfileUpdate) error {
+	log.Printf("UpdateProfile: Updating profile of user ID %d", id)
+
+	query := `
+	UPDATE users
+	SET name = COALESCE($1, name)
+	WHERE id = $2
+	`
+
+	result, err := r.db.Exec(query, update.Name, id)
+	if err != nil {
+		log.Printf("UpdateProfile: Error executing query: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return &customErrors.NotFoundError{Msg: fmt.Sprintf("user with ID %d not found", id)}
+	}
+
+	return nil
+}
+
+func (r *userRepository) SetUserDeactivated(id int, deactivated bool) error {
+	log.Printf("SetUserDeactivated: Setting deactivated=%t for user ID %d", deactivated, id)
+
+	var deactivatedAt *time.Time
+	if deactivated {
+		now := time.Now()
+		deactivatedAt = &now
+	}
+	return r.updateUserColumn(id, "deactivated_at", deactivatedAt)
+}
+
+func (r *userRepository) UpdateUserRole(id int, role string) error {
+	log.Printf("UpdateUserRole: Setting role for user ID %d", id)
+	return r.updateUserColumn(id, "role", role)
+}
+
+func (r *userRepository) UpdateUserTeam(id int, team string) error {
+	log.Printf("UpdateUserTeam: Setting team for user ID %d", id)
+	return r.updateUserColumn(id, "team", team)
+}
+
+func (r *userRepository) UpdateUserAvatar(id int, avatarURL string) error {
+	log.Printf("UpdateUserAvatar: Setting avatar for user ID %d", id)
+	return r.updateUserColumn(id, "avatar_url", avatarURL)
+}
+
+func (r *userRepository) UpdateUserPassword(id int, passwordHash string) error {
+	log.Printf("UpdateUserPassword: Setting password for user ID %d", id)
+	return r.updateUserColumn(id, "password", passwordHash)
+}
+
+func (r *userRepository) MarkEmailVerified(id int) error {
+	log.Printf("MarkEmailVerified: Marking email of user ID %d as verified", id)
+	return r.updateUserColumn(id, "email_verified_at", models.NewCustomTimeNow())
+}
+
+// SetTOTPSecret stores a pending secret; 2FA stays off until EnableTOTP.
+func (r *userRepository) SetTOTPSecret(id int, secret string) error {
+	log.Printf("SetTOTPSecret: Storing pending TOTP secret for user ID %d", id)
+
+	_, err := r.db.Exec(`UPDATE users SET totp_secret = $1, totp_enabled_at = NULL, totp_last_step = NULL WHERE id = $2`, secret, id)
+	if err != nil {
+		log.Printf("SetTOTPSecret: Error executing query: %v", err)
+	}
+	return err
+}
+
+func (r *userRepository) EnableTOTP(id int) error {
+	log.Printf("EnableTOTP: Enabling TOTP for user ID %d", id)
+	return r.updateUserColumn(id, "totp_enabled_at", models.NewCustomTimeNow())
+}
+
+func (r *userRepository) DisableTOTP(id int) error {
+	log.Printf("DisableTOTP: Disabling TOTP for user ID %d", id)
+
+	_, err := r.db.Exec(`UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL WHERE id = $1`, id)
+	if err != nil {
+		log.Printf("DisableTOTP: Error executing query: %v", err)
+	}
+	return err
+}
+
+// ClaimTOTPStep records the time step of an accepted code and reports false
+// if that step, or a later one, was already used.
+func (r *userRepository) ClaimTOTPStep(id int, step int64) (bool, error) {
+	result, err := r.db.Exec(`UPDATE users SET totp_last_step = $1 WHERE id = $2 AND (totp_last_step IS NULL OR totp_last_step < $1)`, step, id)
+	if err != nil {
+		log.Printf("ClaimTOTPStep: Error executing query: %v", err)
+		return false, err
+	}
 
-	for rows.Next() {
-		var user models.UserPublicData
-		if err := rows.StructScan(&user); err != nil {
-			log.Printf("GetAllUsersPublicData: Error scanning row: %v", err)
-			return nil, err
-		}
-		users = append(users, &user)
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
 	}
 
-	if err = rows.Err(); err != nil {
-		log.Printf("GetAllUsersPublicData: Error after scanning all rows: %v", err)
+	return rowsAffected == 1, nil
+}
+
+func (r *userRepository) CreateServiceAccount(name, email, passwordHash, role string) (int, error) {
+	log.Printf("CreateServiceAccount: Creating service account %s with role %s", name, role)
+
+	query := `INSERT INTO users (name, email, password, role, team, is_service_account) VALUES ($1, $2, $3, $4, $5, TRUE) RETURNING id`
+
+	var id int
+	if err := r.db.Get(&id, query, name, email, passwordHash, role, "Service Accounts"); err != nil {
+		log.Printf("CreateServiceAccount: Error executing query: %v", err)
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *userRepository) GetServiceAccounts() ([]*models.ServiceAccount, error) {
+	log.Println("GetServiceAccounts: Retrieving service accounts")
+
+	accounts := []*models.ServiceAccount{}
+	if err := r.db.Select(&accounts, `SELECT id, name, role FROM users WHERE is_service_account ORDER BY name`); err != nil {
+		log.Printf("GetServiceAccounts: Error executing query: %v", err)
 		return nil, err
 	}
 
-	log.Printf("GetAllUsersPublicData: Successfully retrieved public data for %d users", len(users))
-	return users, nil
+	return accounts, nil
+}
+
+func (r *userRepository) GetUserByOIDCSubject(issuer, subject string) (*models.User, error) {
+	log.Printf("GetUserByOIDCSubject: Retrieving user linked to subject %s", subject)
+
+	user := models.User{}
+	query := `SELECT ` + userColumns + ` FROM users WHERE oidc_issuer = $1 AND oidc_subject = $2`
+
+	err := r.db.Get(&user, query, issuer, subject)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &customErrors.NotFoundError{Msg: "no user is linked to this identity"}
+	}
+	if err != nil {
+		log.Printf("GetUserByOIDCSubject: Error executing query: %v", err)
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// LinkOIDCIdentity attaches a provider identity to a user that has none yet.
+// The provider vouched for the email address, so it counts as verified.
+func (r *userRepository) LinkOIDCIdentity(id int, issuer, subject string) error {
+	log.Printf("LinkOIDCIdentity: Linking subject %s to user ID %d", subject, id)
+
+	query := `
+	UPDATE users
+	SET oidc_issuer = $1, oidc_subject = $2, email_verified_at = COALESCE(email_verified_at, NOW())
+	WHERE id = $3 AND oidc_subject IS NULL
+	`
+
+	result, err := r.db.Exec(query, issuer, subject, id)
+	if err != nil {
+		log.Printf("LinkOIDCIdentity: Error executing query: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return &customErrors.ConflictError{Msg: "this account is already linked to another single sign-on identity"}
+	}
+
+	return nil
+}
+
+func (r *userRepository) CreateOIDCUser(name, email, passwordHash, issuer, subject string) (int, error) {
+	log.Printf("CreateOIDCUser: Provisioning user %s for subject %s", email, subject)
+
+	query := `
+	INSERT INTO users (name, email, password, role, team, email_verified_at, oidc_issuer, oidc_subject)
+	VALUES ($1, $2, $3, $4, $5, NOW(), $6, $7)
+	RETURNING id
+	`
+
+	var id int
+	if err := r.db.Get(&id, query, name, email, passwordHash, models.RoleViewer, "Cloudwalk", issuer, subject); err != nil {
+		log.Printf("CreateOIDCUser: Error executing query: %v", err)
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// updateUserColumn sets a single column of a user. column must be a trusted
+// identifier, never user input.
+func (r *userRepository) updateUserColumn(id int, column string, value interface{}) error {
+	result, err := r.db.Exec(fmt.Sprintf(`UPDATE users SET %s = $1 WHERE id = $2`, column), value, id)
+	if err != nil {
+		log.Printf("updateUserColumn: Error updating %s: %v", column, err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("updateUserColumn: Error getting rows affected: %v", err)
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return &customErrors.NotFoundError{Msg: fmt.Sprintf("user with ID %d not found", id)}
+	}
+
+	return nil
 }
@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetUsersSearchesLiterally(t *testing.T) {
+	db := &recordingConnector{rows: map[string]cannedRow{"SELECT COUNT(*)": {[]string{"count"}, []driver.Value{int64(0)}}}}
+	repo := &userRepository{db: sqlx.NewDb(sql.OpenDB(db), "pgx")}
+	search := `50%_off\`
+
+	_, _, err := repo.GetUsers(&models.UserQueryParams{Search: &search, Page: 1, Limit: 20})
+	require.NoError(t, err)
+
+	require.Len(t, db.queries, 2)
+	for _, query := range db.queries {
+		assert.Contains(t, query.query, `ESCAPE '\'`)
+		assert.Equal(t, `50\%\_off\\`, query.args[0], "wildcards in the search match themselves")
+	}
+}
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+)
+
+// UserTokenRepository stores the hashes of single-use tokens emailed to
+// users, such as password reset and email verification links.
+type UserTokenRepository interface {
+	CreateUserToken(userID int, purpose, tokenHash string, expiresAt time.Time) error
+	GetUserToken(purpose, tokenHash string) (int, error)
+	ConsumeUserToken(purpose, tokenHash string) (int, error)
+	InvalidateUserTokens(userID int, purpose string) error
+}
+
+type userTokenRepository struct {
+	db *sqlx.DB
+}
+
+func NewUserTokenRepository(db *sqlx.DB) UserTokenRepository {
+	return &userTokenRepository{db: db}
+}
+
+func (r *userTokenRepository) CreateUserToken(userID int, purpose, tokenHash string, expiresAt time.Time) error {
+	log.Printf("CreateUserToken: Creating %s token for user ID %d", purpose, userID)
+
+	query := `INSERT INTO user_tokens (user_id, purpose, token_hash, expires_at) VALUES ($1, $2, $3, $4)`
+	if _, err := r.db.Exec(query, userID, purpose, tokenHash, expiresAt); err != nil {
+		log.Printf("CreateUserToken: Error executing query: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetUserToken returns the user of an unused, unexpired token without using
+// it up.
+func (r *userTokenRepository) GetUserToken(purpose, tokenHash string) (int, error) {
+	query := `SELECT user_id FROM user_tokens WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > NOW()`
+
+	var userID int
+	err := r.db.Get(&userID, query, tokenHash, purpose)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, &customErrors.NotFoundError{Msg: "token not found"}
+	}
+	if err != nil {
+		log.Printf("GetUserToken: Error executing query: %v", err)
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+// ConsumeUserToken marks an unused, unexpired token as used and returns its
+// user. Doing both in one statement keeps the token single-use under
+// concurrent requests.
+func (r *userTokenRepository) ConsumeUserToken(purpose, tokenHash string) (int, error) {
+	log.Printf("ConsumeUserToken: Consuming %s token", purpose)
+
+	query := `
+	UPDATE user_tokens
+	SET used_at = NOW()
+	WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > NOW()
+	RETURNING user_id
+	`
+
+	var userID int
+	err := r.db.Get(&userID, query, tokenHash, purpose)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, &customErrors.NotFoundError{Msg: "token not found"}
+	}
+	if err != nil {
+		log.Printf("ConsumeUserToken: Error executing query: %v", err)
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+func (r *userTokenRepository) InvalidateUserTokens(userID int, purpose string) error {
+	log.Printf("InvalidateUserTokens: Invalidating %s tokens of user ID %d", purpose, userID)
+
+	query := `UPDATE user_tokens SET used_at = NOW() WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL`
+	if _, err := r.db.Exec(query, userID, purpose); err != nil {
+		log.Printf("InvalidateUserTokens: Error executing query: %v", err)
+		return err
+	}
+
+	return nil
+}
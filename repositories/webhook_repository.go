@@ -0,0 +1,279 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+)
+
+type WebhookRepository interface {
+	CreateSubscription(subscription *models.WebhookSubscription) (int, error)
+	GetSubscriptions() ([]*models.WebhookSubscription, error)
+	GetSubscriptionByID(id int) (*models.WebhookSubscription, error)
+	UpdateSubscription(subscription *models.WebhookSubscription) error
+	UpdateSubscriptionSecret(id int, secret string) error
+	DeleteSubscription(id int) error
+	EnqueueDeliveries(eventID, eventType string, payload models.WebhookPayload) (int, error)
+	ClaimDueDeliveries(limit int, lease time.Duration) ([]*models.ClaimedWebhookDelivery, error)
+	RecordAttempt(attempt *models.WebhookAttempt) error
+	GetDeliveries(qp *models.WebhookDeliveryQueryParams) ([]*models.WebhookDelivery, int, error)
+	GetDeliveryByID(subscriptionID, deliveryID int) (*models.WebhookDelivery, error)
+	Redeliver(delivery *models.WebhookDelivery) (int, error)
+}
+
+type webhookRepository struct {
+	db *sqlx.DB
+}
+
+func NewWebhookRepository(db *sqlx.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+const webhookSubscriptionColumns = `id, name, url, secret, events, active, created_by, created_at, updated_at`
+
+const webhookDeliveryColumns = `
+	id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_attempt_at,
+	response_status, response_body, error, redelivery_of, created_at, delivered_at
+	`
+
+func (r *webhookRepository) CreateSubscription(subscription *models.WebhookSubscription) (int, error) {
+	log.Printf("CreateSubscription: Creating webhook subscription %q", subscription.Name)
+
+	query := `
+	INSERT INTO webhook_subscriptions (name, url, secret, events, created_by)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING id
+	`
+
+	var id int
+	err := r.db.Get(&id, query, subscription.Name, subscription.URL, subscription.Secret, subscription.Events, subscription.CreatedBy)
+	if err != nil {
+		log.Printf("CreateSubscription: Error executing query: %v", err)
+		return 0, err
+	}
+
+	log.Printf("CreateSubscription: Webhook subscription created with ID %d", id)
+	return id, nil
+}
+
+func (r *webhookRepository) GetSubscriptions() ([]*models.WebhookSubscription, error) {
+	log.Println("GetSubscriptions: Retrieving webhook subscriptions")
+
+	subscriptions := []*models.WebhookSubscription{}
+	if err := r.db.Select(&subscriptions, `SELECT `+webhookSubscriptionColumns+` FROM webhook_subscriptions ORDER BY name, id`); err != nil {
+		log.Printf("GetSubscriptions: Error executing query: %v", err)
+		return nil, err
+	}
+
+	return subscriptions, nil
+}
+
+func (r *webhookRepository) GetSubscriptionByID(id int) (*models.WebhookSubscription, error) {
+	subscription := new(models.WebhookSubscription)
+	err := r.db.Get(subscription, `SELECT `+webhookSubscriptionColumns+` FROM webhook_subscriptions WHERE id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &customErrors.NotFoundError{Msg: fmt.Sprintf("webhook subscription with ID %d not found", id)}
+	}
+	if err != nil {
+		log.Printf("GetSubscriptionByID: Error executing query: %v", err)
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+func (r *webhookRepository) UpdateSubscription(subscription *models.WebhookSubscription) error {
+	log.Printf("UpdateSubscription: Updating webhook subscription %d", subscription.ID)
+
+	query := `
+	UPDATE webhook_subscriptions
+	SET name = $1, url = $2, events = $3, active = $4, updated_at = NOW()
+	WHERE id = $5
+	`
+
+	result, err := r.db.Exec(query, subscription.Name, subscription.URL, subscription.Events, subscription.Active, subscription.ID)
+	if err != nil {
+		log.Printf("UpdateSubscription: Error executing update query: %v", err)
+		return err
+	}
+
+	return expectSubscriptionRow(result, subscription.ID)
+}
+
+func (r *webhookRepository) UpdateSubscriptionSecret(id int, secret string) error {
+	log.Printf("UpdateSubscriptionSecret: Rotating secret of webhook subscription %d", id)
+
+	result, err := r.db.Exec(`UPDATE webhook_subscriptions SET secret = $1, updated_at = NOW() WHERE id = $2`, secret, id)
+	if err != nil {
+		log.Printf("UpdateSubscriptionSecret: Error executing update query: %v", err)
+		return err
+	}
+
+	return expectSubscriptionRow(result, id)
+}
+
+func (r *webhookRepository) DeleteSubscription(id int) error {
+	log.Printf("DeleteSubscription: Deleting webhook subscription %d", id)
+
+	result, err := r.db.Exec(`DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		log.Printf("DeleteSubscription: Error executing delete query: %v", err)
+		return err
+	}
+
+	return expectSubscriptionRow(result, id)
+}
+
+// EnqueueDeliveries queues the event for every active subscription whose
+// filter matches it and returns how many were queued.
+func (r *webhookRepository) EnqueueDeliveries(eventID, eventType string, payload models.WebhookPayload) (int, error) {
+	query := `
+	INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload)
+	SELECT id, $1, $2, $3
+	FROM webhook_subscriptions
+	WHERE active AND (' ' || events || ' ' LIKE '% ' || $2 || ' %' OR ' ' || events || ' ' LIKE '% * %')
+	`
+
+	result, err := r.db.Exec(query, eventID, eventType, payload)
+	if err != nil {
+		log.Printf("EnqueueDeliveries: Error executing query: %v", err)
+		return 0, err
+	}
+
+	queued, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(queued), nil
+}
+
+// ClaimDueDeliveries leases up to limit due deliveries by pushing their next
+// attempt past the lease, so other workers skip them while they are sent. A
+// worker that dies mid-send leaves them to be retried once the lease expires.
+func (r *webhookRepository) ClaimDueDeliveries(limit int, lease time.Duration) ([]*models.ClaimedWebhookDelivery, error) {
+	query := `
+	WITH due AS (
+		SELECT d.id
+		FROM webhook_deliveries d
+		JOIN webhook_subscriptions s ON d.subscription_id = s.id
+		WHERE d.status = 'pending' AND d.next_attempt_at <= NOW() AND s.active
+		ORDER BY d.next_attempt_at
+		LIMIT $1
+		FOR UPDATE OF d SKIP LOCKED
+	)
+	UPDATE webhook_deliveries d
+	SET next_attempt_at = NOW() + make_interval(secs => $2)
+	FROM due, webhook_subscriptions s
+	WHERE d.id = due.id AND s.id = d.subscription_id
+	RETURNING d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.attempts, s.url, s.secret
+	`
+
+	deliveries := []*models.ClaimedWebhookDelivery{}
+	if err := r.db.Select(&deliveries, query, limit, lease.Seconds()); err != nil {
+		log.Printf("ClaimDueDeliveries: Error executing query: %v", err)
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (r *webhookRepository) RecordAttempt(attempt *models.WebhookAttempt) error {
+	query := `
+	UPDATE webhook_deliveries
+	SET status = $1,
+		attempts = attempts + 1,
+		last_attempt_at = NOW(),
+		next_attempt_at = COALESCE($2, next_attempt_at),
+		response_status = $3,
+		response_body = $4,
+		error = $5,
+		delivered_at = CASE WHEN $1 = 'succeeded' THEN NOW() END
+	WHERE id = $6
+	`
+
+	_, err := r.db.Exec(query, attempt.Status, attempt.NextAttemptAt, attempt.ResponseStatus, attempt.ResponseBody, attempt.Error, attempt.DeliveryID)
+	if err != nil {
+		log.Printf("RecordAttempt: Error executing update query: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetDeliveries returns a page of a subscription's delivery log, newest first.
+func (r *webhookRepository) GetDeliveries(qp *models.WebhookDeliveryQueryParams) ([]*models.WebhookDelivery, int, error) {
+	log.Printf("GetDeliveries: Retrieving deliveries of webhook subscription %d", qp.SubscriptionID)
+
+	where := ` WHERE subscription_id = $1 AND ($2::text IS NULL OR status = $2)`
+
+	var total int
+	if err := r.db.Get(&total, `SELECT COUNT(*) FROM webhook_deliveries`+where, qp.SubscriptionID, qp.Status); err != nil {
+		log.Printf("GetDeliveries: Error counting deliveries: %v", err)
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries` + where + ` ORDER BY created_at DESC, id DESC LIMIT $3 OFFSET $4`
+
+	deliveries := []*models.WebhookDelivery{}
+	if err := r.db.Select(&deliveries, query, qp.SubscriptionID, qp.Status, qp.Limit, qp.Offset()); err != nil {
+		log.Printf("GetDeliveries: Error executing query: %v", err)
+		return nil, 0, err
+	}
+
+	return deliveries, total, nil
+}
+
+func (r *webhookRepository) GetDeliveryByID(subscriptionID, deliveryID int) (*models.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE subscription_id = $1 AND id = $2`
+
+	delivery := new(models.WebhookDelivery)
+	err := r.db.Get(delivery, query, subscriptionID, deliveryID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &customErrors.NotFoundError{Msg: fmt.Sprintf("webhook delivery with ID %d not found", deliveryID)}
+	}
+	if err != nil {
+		log.Printf("GetDeliveryByID: Error executing query: %v", err)
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// Redeliver queues a fresh copy of a delivery, keeping the original in the
+// log as it was.
+func (r *webhookRepository) Redeliver(delivery *models.WebhookDelivery) (int, error) {
+	log.Printf("Redeliver: Queueing redelivery of webhook delivery %d", delivery.ID)
+
+	query := `
+	INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, redelivery_of)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING id
+	`
+
+	var id int
+	if err := r.db.Get(&id, query, delivery.SubscriptionID, delivery.EventID, delivery.EventType, delivery.Payload, delivery.ID); err != nil {
+		log.Printf("Redeliver: Error executing query: %v", err)
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func expectSubscriptionRow(result sql.Result, id int) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return &customErrors.NotFoundError{Msg: fmt.Sprintf("webhook subscription with ID %d not found", id)}
+	}
+	return nil
+}
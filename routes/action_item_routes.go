@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/handlers"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/middlewares"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/services"
+)
+
+// SetupActionItemRoutes registers the cross-incident views; per-incident
+// routes live with the incident routes.
+func SetupActionItemRoutes(app *fiber.App, services *services.Services) {
+	actionItemHandler := handlers.NewActionItemHandler(services.ActionItemService)
+
+	api := app.Group("/api/v1/action-items")
+	api.Use(middlewares.JWTMiddleware(services.SessionService, services.APIKeyService))
+	api.Use(middlewares.RequirePermission(models.PermissionIncidentsRead))
+
+	api.Get("/mine", actionItemHandler.GetMyActionItems)
+	api.Get("/overdue", actionItemHandler.GetOverdueActionItems)
+}
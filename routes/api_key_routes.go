@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/handlers"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/middlewares"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/services"
+)
+
+func SetupAPIKeyRoutes(app *fiber.App, services *services.Services) {
+	apiKeyHandler := handlers.NewAPIKeyHandler(services.APIKeyService)
+	jwt := middlewares.JWTMiddleware(services.SessionService, services.APIKeyService)
+
+	// Personal keys
+	keys := app.Group("/api/v1/api-keys")
+	keys.Use(jwt)
+	keys.Get("/", apiKeyHandler.ListAPIKeys)
+	keys.Post("/", apiKeyHandler.CreateAPIKey)
+	keys.Delete("/:keyId", apiKeyHandler.RevokeAPIKey)
+
+	// Admin routes
+	accounts := app.Group("/api/v1/service-accounts")
+	accounts.Use(jwt)
+	accounts.Use(middlewares.RequirePermission(models.PermissionUsersManage))
+	accounts.Get("/", apiKeyHandler.GetServiceAccounts)
+	accounts.Post("/", apiKeyHandler.CreateServiceAccount)
+	accounts.Get("/:id/api-keys", apiKeyHandler.ListAPIKeys)
+	accounts.Post("/:id/api-keys", apiKeyHandler.CreateAPIKey)
+	accounts.Delete("/:id/api-keys/:keyId", apiKeyHandler.RevokeAPIKey)
+}
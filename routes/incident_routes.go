@@ -4,23 +4,63 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/pamateus-henrique/infinitepay-firewatchers-api/handlers"
 	"github.com/pamateus-henrique/infinitepay-firewatchers-api/middlewares"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
 	"github.com/pamateus-henrique/infinitepay-firewatchers-api/services"
 )
 
 func SetupIncidentRoutes(app *fiber.App, services *services.Services) {
 	incidentHandler := handlers.NewIncidentHandler(services.IncidentService)
+	incidentUpdateHandler := handlers.NewIncidentUpdateHandler(services.IncidentUpdateService)
+	attachmentHandler := handlers.NewIncidentAttachmentHandler(services.IncidentAttachmentService)
+	postmortemHandler := handlers.NewPostmortemHandler(services.PostmortemService)
+	actionItemHandler := handlers.NewActionItemHandler(services.ActionItemService)
+	linkHandler := handlers.NewIncidentLinkHandler(services.IncidentLinkService)
 
     // Protected routes
     api := app.Group("/api/v1/incidents")
-	api.Use(middlewares.JWTMiddleware())
-    api.Post("/create", incidentHandler.CreateIncident)
-	api.Post("/update/summary", incidentHandler.UpdateIncidentSummary)
-	api.Post("/update/status", incidentHandler.UpdateIncidentStatus)
-	api.Post("/update/severity", incidentHandler.UpdateIncidentSeverity)
-	api.Post("/update/type", incidentHandler.UpdateIncidentType)
-	api.Post("/update/roles", incidentHandler.UpdateIncidentRoles)
-	api.Get("/", incidentHandler.GetIncidents)
-	api.Get("/:id", incidentHandler.GetSingleIncident)
+	api.Use(middlewares.JWTMiddleware(services.SessionService, services.APIKeyService))
+
+	canRead := middlewares.RequirePermission(models.PermissionIncidentsRead)
+	canCreate := middlewares.RequirePermission(models.PermissionIncidentsCreate)
+	canUpdate := middlewares.RequirePermission(models.PermissionIncidentsUpdate)
+	canManage := middlewares.RequirePermission(models.PermissionIncidentsManage)
+
+    api.Post("/create", canCreate, incidentHandler.CreateIncident)
+	api.Post("/update/summary", canUpdate, incidentHandler.UpdateIncidentSummary)
+	api.Post("/update/status", canUpdate, incidentHandler.UpdateIncidentStatus)
+	api.Post("/update/severity", canManage, incidentHandler.UpdateIncidentSeverity)
+	api.Post("/update/type", canUpdate, incidentHandler.UpdateIncidentType)
+	api.Post("/update/roles", canManage, incidentHandler.UpdateIncidentRoles)
+	api.Get("/", canRead, incidentHandler.GetIncidents)
+	api.Get("/triage", canRead, incidentHandler.GetTriageQueue)
+	api.Get("/:id", canRead, incidentHandler.GetSingleIncident)
+	api.Get("/:id/timeline", canRead, incidentHandler.GetIncidentTimeline)
+	api.Post("/:id/accept", canManage, incidentHandler.AcceptIncident)
+	api.Post("/:id/decline", canManage, incidentHandler.DeclineIncident)
+	api.Post("/:id/merge", canManage, incidentHandler.MergeIncident)
+	api.Get("/:id/updates", canRead, incidentUpdateHandler.GetIncidentUpdates)
+	api.Post("/:id/updates", canUpdate, incidentUpdateHandler.CreateIncidentUpdate)
+	api.Patch("/:id/updates/:updateId", canUpdate, incidentUpdateHandler.EditIncidentUpdate)
+	api.Delete("/:id/updates/:updateId", canUpdate, incidentUpdateHandler.DeleteIncidentUpdate)
+	api.Get("/:id/attachments", canRead, attachmentHandler.GetAttachments)
+	api.Post("/:id/attachments", canUpdate, attachmentHandler.UploadAttachment)
+	api.Get("/:id/attachments/:attachmentId", canRead, attachmentHandler.DownloadAttachment)
+	api.Delete("/:id/attachments/:attachmentId", canUpdate, attachmentHandler.DeleteAttachment)
+	api.Get("/:id/postmortem", canRead, postmortemHandler.GetPostmortem)
+	api.Post("/:id/postmortem", canUpdate, postmortemHandler.StartPostmortem)
+	api.Patch("/:id/postmortem", canUpdate, postmortemHandler.EditPostmortem)
+	api.Post("/:id/postmortem/submit", canUpdate, postmortemHandler.SubmitPostmortem)
+	// Reviewers are checked by name, so any reader may be asked to review
+	api.Post("/:id/postmortem/approve", canRead, postmortemHandler.ApprovePostmortem)
+	api.Post("/:id/postmortem/request-changes", canRead, postmortemHandler.RequestPostmortemChanges)
+	api.Get("/:id/action-items", canRead, actionItemHandler.GetActionItems)
+	api.Post("/:id/action-items", canUpdate, actionItemHandler.CreateActionItem)
+	api.Get("/:id/action-items/:itemId", canRead, actionItemHandler.GetActionItem)
+	api.Patch("/:id/action-items/:itemId", canUpdate, actionItemHandler.UpdateActionItem)
+	api.Delete("/:id/action-items/:itemId", canUpdate, actionItemHandler.DeleteActionItem)
+	api.Get("/:id/links", canRead, linkHandler.GetIncidentLinks)
+	api.Post("/:id/links", canUpdate, linkHandler.CreateIncidentLink)
+	api.Delete("/:id/links/:linkId", canUpdate, linkHandler.DeleteIncidentLink)
 	
-	api.Post("/custom-fields", incidentHandler.UpdateIncidentCustomFields)
+	api.Post("/custom-fields", canUpdate, incidentHandler.UpdateIncidentCustomFields)
 }
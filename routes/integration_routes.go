@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/handlers"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/middlewares"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/services"
+)
+
+// SetupIntegrationRoutes registers the endpoints monitoring systems post
+// alerts to. /alerts takes the ingestion token of an alert integration;
+// /alertmanager takes an API key of a user allowed to create incidents, who
+// becomes the reporter of the incidents it opens.
+func SetupIntegrationRoutes(app *fiber.App, services *services.Services) {
+	alertHandler := handlers.NewAlertHandler(services.AlertService)
+
+	api := app.Group("/api/v1/integrations")
+
+	authenticated := middlewares.JWTMiddleware(services.SessionService, services.APIKeyService)
+	canCreate := middlewares.RequirePermission(models.PermissionIncidentsCreate)
+	canManageOptions := middlewares.RequirePermission(models.PermissionOptionsManage)
+	canManageIntegrations := middlewares.RequirePermission(models.PermissionIntegrationsManage)
+
+	api.Post("/alerts", middlewares.AlertTokenMiddleware(services.AlertService), alertHandler.IngestAlerts)
+	api.Post("/alertmanager", authenticated, canCreate, alertHandler.IngestAlertmanager)
+	api.Get("/alert-rules", authenticated, canManageOptions, alertHandler.GetMappingRules)
+	api.Post("/alert-rules", authenticated, canManageOptions, alertHandler.CreateMappingRule)
+	api.Put("/alert-rules/:id", authenticated, canManageOptions, alertHandler.UpdateMappingRule)
+	api.Delete("/alert-rules/:id", authenticated, canManageOptions, alertHandler.DeleteMappingRule)
+	api.Get("/sources", authenticated, canManageIntegrations, alertHandler.GetIntegrations)
+	api.Post("/sources", authenticated, canManageIntegrations, alertHandler.CreateIntegration)
+	api.Get("/sources/:id", authenticated, canManageIntegrations, alertHandler.GetIntegration)
+	api.Patch("/sources/:id", authenticated, canManageIntegrations, alertHandler.UpdateIntegration)
+	api.Delete("/sources/:id", authenticated, canManageIntegrations, alertHandler.DeleteIntegration)
+	api.Post("/sources/:id/rotate-token", authenticated, canManageIntegrations, alertHandler.RotateIntegrationToken)
+}
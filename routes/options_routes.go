@@ -3,6 +3,8 @@ package routes
 import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/pamateus-henrique/infinitepay-firewatchers-api/handlers"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/middlewares"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
 
 	"github.com/pamateus-henrique/infinitepay-firewatchers-api/services"
 )
@@ -20,4 +22,15 @@ func SetupOptionsRoutes(app *fiber.App, services *services.Services) {
 	api.Get("/faulty-systems", optionsHandler.GetFaultySystems)
 	api.Get("/causes", optionsHandler.GetCauses)
 	api.Get("/sources", optionsHandler.GetSources)
+
+	// Admin routes
+	manage := app.Group("/api/v1/options/manage")
+	manage.Use(middlewares.JWTMiddleware(services.SessionService, services.APIKeyService))
+	manage.Use(middlewares.RequirePermission(models.PermissionOptionsManage))
+	manage.Get("/:taxonomy", optionsHandler.ListOptions)
+	manage.Post("/:taxonomy", optionsHandler.CreateOption)
+	manage.Post("/:taxonomy/reorder", optionsHandler.ReorderOptions)
+	manage.Patch("/:taxonomy/:id", optionsHandler.RenameOption)
+	manage.Post("/:taxonomy/:id/activate", optionsHandler.ActivateOption)
+	manage.Post("/:taxonomy/:id/deactivate", optionsHandler.DeactivateOption)
 }
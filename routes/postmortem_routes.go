@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/handlers"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/middlewares"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/services"
+)
+
+func SetupPostmortemRoutes(app *fiber.App, services *services.Services) {
+	postmortemHandler := handlers.NewPostmortemHandler(services.PostmortemService)
+
+	api := app.Group("/api/v1/postmortem-templates")
+	api.Use(middlewares.JWTMiddleware(services.SessionService, services.APIKeyService))
+
+	canManageOptions := middlewares.RequirePermission(models.PermissionOptionsManage)
+
+	api.Get("/", middlewares.RequirePermission(models.PermissionIncidentsRead), postmortemHandler.GetPostmortemTemplates)
+	api.Put("/:typeId", canManageOptions, postmortemHandler.SavePostmortemTemplate)
+	api.Delete("/:typeId", canManageOptions, postmortemHandler.DeletePostmortemTemplate)
+}
@@ -11,5 +11,11 @@ func SetupRoutes(app *fiber.App, services *services.Services) {
     SetupUserRoutes(app, services)
     SetupIncidentRoutes(app, services)
     SetupOptionsRoutes(app,services)
+    SetupSecurityRoutes(app, services)
+    SetupAPIKeyRoutes(app, services)
+    SetupPostmortemRoutes(app, services)
+    SetupActionItemRoutes(app, services)
+    SetupWebhookRoutes(app, services)
+    SetupIntegrationRoutes(app, services)
     // Setup more routes here (e.g., product routes)
 }
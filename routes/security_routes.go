@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/handlers"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/middlewares"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/services"
+)
+
+func SetupSecurityRoutes(app *fiber.App, services *services.Services) {
+	securityEventHandler := handlers.NewSecurityEventHandler(services.SecurityEventService)
+
+	// Admin routes
+	api := app.Group("/api/v1/security")
+	api.Use(middlewares.JWTMiddleware(services.SessionService, services.APIKeyService))
+	api.Use(middlewares.RequirePermission(models.PermissionUsersManage))
+	api.Get("/events", securityEventHandler.GetSecurityEvents)
+}
@@ -5,20 +5,66 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/pamateus-henrique/infinitepay-firewatchers-api/handlers"
 	"github.com/pamateus-henrique/infinitepay-firewatchers-api/middlewares"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
 
 	"github.com/pamateus-henrique/infinitepay-firewatchers-api/services"
 )
 
 func SetupUserRoutes(app *fiber.App, services *services.Services) {
-    userHandler := handlers.NewUserHandler(services.UserService)
+    userHandler := handlers.NewUserHandler(services.UserService, services.SessionService, services.TwoFactorService)
+    twoFactorHandler := handlers.NewTwoFactorHandler(services.TwoFactorService, services.SessionService)
+    oidcHandler := handlers.NewOIDCHandler(services.OIDCService, services.SessionService, services.TwoFactorService)
+    avatarHandler := handlers.NewAvatarHandler(services.AvatarService)
+    jwt := middlewares.JWTMiddleware(services.SessionService, services.APIKeyService)
+    sessionOnly := middlewares.RequireSession()
 
     // Public routes
     app.Post("/api/v1/auth/register", userHandler.Register)
     app.Post("/api/v1/auth/login", userHandler.Login)
+    app.Post("/api/v1/auth/refresh", userHandler.Refresh)
+    app.Post("/api/v1/auth/logout", userHandler.Logout)
+    app.Post("/api/v1/auth/forgot-password", userHandler.ForgotPassword)
+    app.Post("/api/v1/auth/reset-password", userHandler.ResetPassword)
+    app.Post("/api/v1/auth/verify-email", userHandler.VerifyEmail)
+    app.Post("/api/v1/auth/verify-email/resend", jwt, userHandler.ResendVerificationEmail)
+
+    // Two-factor authentication
+    app.Post("/api/v1/auth/2fa/verify", twoFactorHandler.Verify)
+    app.Post("/api/v1/auth/2fa/enroll", twoFactorHandler.EnrollWithChallenge)
+    app.Post("/api/v1/auth/2fa/setup", jwt, sessionOnly, twoFactorHandler.Setup)
+    app.Post("/api/v1/auth/2fa/enable", jwt, sessionOnly, twoFactorHandler.Enable)
+    app.Post("/api/v1/auth/2fa/disable", jwt, sessionOnly, twoFactorHandler.Disable)
+    app.Post("/api/v1/auth/2fa/recovery-codes", jwt, sessionOnly, twoFactorHandler.RegenerateRecoveryCodes)
+
+    // Single sign-on
+    app.Get("/api/v1/auth/oidc/login", oidcHandler.Login)
+    app.Get("/api/v1/auth/oidc/callback", oidcHandler.Callback)
+
+    // Session management
+    sessions := app.Group("/api/v1/auth/sessions")
+    sessions.Use(jwt, sessionOnly)
+    sessions.Get("/", userHandler.ListSessions)
+    sessions.Delete("/:id", userHandler.RevokeSession)
+
+    // Avatars are loaded by <img> tags, so they are served without a token
+    app.Get("/api/v1/users/:id/avatar", avatarHandler.GetAvatar)
 
     // Protected routes
     api := app.Group("/api/v1/users")
     
-    api.Use(middlewares.JWTMiddleware())
-    api.Get("/", userHandler.GetAllUsersPublicData)
+    api.Use(jwt)
+    api.Get("/", userHandler.GetUsers)
+    api.Get("/me", userHandler.GetProfile)
+    api.Patch("/me", sessionOnly, userHandler.UpdateProfile)
+    api.Post("/me/password", sessionOnly, userHandler.ChangePassword)
+    api.Post("/me/avatar", sessionOnly, avatarHandler.UploadAvatar)
+    api.Get("/:id", userHandler.GetUser)
+
+    // Admin routes
+    canManageUsers := middlewares.RequirePermission(models.PermissionUsersManage)
+    api.Patch("/:id/role", canManageUsers, userHandler.UpdateUserRole)
+    api.Patch("/:id/team", canManageUsers, userHandler.UpdateUserTeam)
+    api.Post("/:id/unlock", canManageUsers, userHandler.UnlockUser)
+    api.Post("/:id/deactivate", canManageUsers, userHandler.DeactivateUser)
+    api.Post("/:id/reactivate", canManageUsers, userHandler.ReactivateUser)
 }
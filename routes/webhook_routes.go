@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/handlers"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/middlewares"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/services"
+)
+
+func SetupWebhookRoutes(app *fiber.App, services *services.Services) {
+	webhookHandler := handlers.NewWebhookHandler(services.WebhookService)
+
+	api := app.Group("/api/v1/webhooks")
+	api.Use(middlewares.JWTMiddleware(services.SessionService, services.APIKeyService))
+	api.Use(middlewares.RequirePermission(models.PermissionWebhooksManage))
+
+	api.Get("/", webhookHandler.GetSubscriptions)
+	api.Post("/", webhookHandler.CreateSubscription)
+	api.Get("/:id", webhookHandler.GetSubscription)
+	api.Patch("/:id", webhookHandler.UpdateSubscription)
+	api.Delete("/:id", webhookHandler.DeleteSubscription)
+	api.Post("/:id/rotate-secret", webhookHandler.RotateSecret)
+	api.Get("/:id/deliveries", webhookHandler.GetDeliveries)
+	api.Post("/:id/deliveries/:deliveryId/redeliver", webhookHandler.Redeliver)
+}
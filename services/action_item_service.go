@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/repositories"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/validators"
+)
+
+type ActionItemService interface {
+	CreateActionItem(ctx context.Context, input *models.ActionItemInput) (*models.ActionItem, error)
+	GetActionItems(incidentID int) ([]*models.ActionItem, error)
+	GetActionItem(incidentID, itemID int) (*models.ActionItem, error)
+	UpdateActionItem(ctx context.Context, update *models.ActionItemUpdate) (*models.ActionItem, error)
+	DeleteActionItem(ctx context.Context, incidentID, itemID int) error
+	GetMyActionItems(ctx context.Context, queryParams *models.ActionItemQueryParams) ([]*models.ActionItem, *models.Pagination, error)
+	GetOverdueActionItems(queryParams *models.ActionItemQueryParams) ([]*models.ActionItem, *models.Pagination, error)
+}
+
+type actionItemService struct {
+	incidentRepository   repositories.IncidentRepository
+	actionItemRepository repositories.ActionItemRepository
+	userRepository       repositories.UserRepository
+}
+
+func NewActionItemService(incidentRepository repositories.IncidentRepository, actionItemRepository repositories.ActionItemRepository, userRepository repositories.UserRepository) ActionItemService {
+	return &actionItemService{
+		incidentRepository:   incidentRepository,
+		actionItemRepository: actionItemRepository,
+		userRepository:       userRepository,
+	}
+}
+
+func (s *actionItemService) CreateActionItem(ctx context.Context, input *models.ActionItemInput) (*models.ActionItem, error) {
+	log.Printf("CreateActionItem: Starting creation process for incident ID %d", input.IncidentID)
+
+	if err := validators.ValidateStruct(input); err != nil {
+		log.Printf("CreateActionItem: Validation error: %v", err)
+		return nil, &validators.ValidationError{Err: err}
+	}
+
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.incidentRepository.GetIncidentStatus(input.IncidentID); err != nil {
+		log.Printf("CreateActionItem: Error retrieving incident: %v", err)
+		return nil, err
+	}
+
+	if err := s.validateOwner(input.OwnerID); err != nil {
+		return nil, err
+	}
+
+	item := &models.ActionItem{
+		IncidentID: input.IncidentID,
+		Title:      input.Title,
+		OwnerID:    input.OwnerID,
+		DueAt:      input.DueAt,
+		Priority:   input.Priority,
+		Status:     models.ActionItemOpen,
+		Blocking:   input.Blocking,
+		TicketURL:  input.TicketURL,
+		CreatedBy:  actorID,
+	}
+	if item.Priority == "" {
+		item.Priority = "medium"
+	}
+
+	itemID, err := s.actionItemRepository.CreateActionItem(item)
+	if err != nil {
+		log.Printf("CreateActionItem: Error creating action item: %v", err)
+		return nil, err
+	}
+
+	log.Printf("CreateActionItem: Successfully created action item %d", itemID)
+	return s.actionItemRepository.GetActionItemByID(input.IncidentID, itemID)
+}
+
+func (s *actionItemService) GetActionItems(incidentID int) ([]*models.ActionItem, error) {
+	if _, err := s.incidentRepository.GetIncidentStatus(incidentID); err != nil {
+		log.Printf("GetActionItems: Error retrieving incident: %v", err)
+		return nil, err
+	}
+
+	return s.actionItemRepository.GetActionItems(incidentID)
+}
+
+func (s *actionItemService) GetActionItem(incidentID, itemID int) (*models.ActionItem, error) {
+	return s.actionItemRepository.GetActionItemByID(incidentID, itemID)
+}
+
+func (s *actionItemService) UpdateActionItem(ctx context.Context, update *models.ActionItemUpdate) (*models.ActionItem, error) {
+	log.Printf("UpdateActionItem: Starting update process for action item %d", update.ID)
+
+	if err := validators.ValidateStruct(update); err != nil {
+		log.Printf("UpdateActionItem: Validation error: %v", err)
+		return nil, &validators.ValidationError{Err: err}
+	}
+
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := s.actionItemRepository.GetActionItemByID(update.IncidentID, update.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validateOwner(update.OwnerID); err != nil {
+		return nil, err
+	}
+
+	item := *previous
+	if update.Title != nil {
+		item.Title = *update.Title
+	}
+	if update.OwnerID != nil {
+		item.OwnerID = update.OwnerID
+	}
+	if update.DueAt != nil {
+		item.DueAt = update.DueAt
+	}
+	if update.Priority != nil {
+		item.Priority = *update.Priority
+	}
+	if update.Blocking != nil {
+		item.Blocking = *update.Blocking
+	}
+	if update.TicketURL != nil {
+		item.TicketURL = update.TicketURL
+	}
+	if update.Status != nil {
+		item.Status = *update.Status
+		switch {
+		case item.Resolved() && !previous.Resolved():
+			item.CompletedAt = models.NewCustomTimeNow()
+		case !item.Resolved():
+			item.CompletedAt = nil
+		}
+	}
+
+	if err := s.actionItemRepository.UpdateActionItem(previous, &item, actorID); err != nil {
+		log.Printf("UpdateActionItem: Error updating action item: %v", err)
+		return nil, err
+	}
+
+	log.Printf("UpdateActionItem: Successfully updated action item %d", update.ID)
+	return s.actionItemRepository.GetActionItemByID(update.IncidentID, update.ID)
+}
+
+func (s *actionItemService) DeleteActionItem(ctx context.Context, incidentID, itemID int) error {
+	log.Printf("DeleteActionItem: Starting delete process for action item %d", itemID)
+
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	item, err := s.actionItemRepository.GetActionItemByID(incidentID, itemID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.actionItemRepository.DeleteActionItem(item, actorID); err != nil {
+		log.Printf("DeleteActionItem: Error deleting action item: %v", err)
+		return err
+	}
+
+	log.Printf("DeleteActionItem: Successfully deleted action item %d", itemID)
+	return nil
+}
+
+// GetMyActionItems lists the action items owned by the caller.
+func (s *actionItemService) GetMyActionItems(ctx context.Context, queryParams *models.ActionItemQueryParams) ([]*models.ActionItem, *models.Pagination, error) {
+	ownerID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	queryParams.OwnerID = &ownerID
+	queryParams.Overdue = false
+	return s.listActionItems(queryParams)
+}
+
+// GetOverdueActionItems lists unresolved action items past their due date.
+func (s *actionItemService) GetOverdueActionItems(queryParams *models.ActionItemQueryParams) ([]*models.ActionItem, *models.Pagination, error) {
+	queryParams.Overdue = true
+	return s.listActionItems(queryParams)
+}
+
+func (s *actionItemService) listActionItems(queryParams *models.ActionItemQueryParams) ([]*models.ActionItem, *models.Pagination, error) {
+	if err := validators.ValidateStruct(queryParams); err != nil {
+		log.Printf("listActionItems: Validation error: %v", err)
+		return nil, nil, &validators.ValidationError{Err: err}
+	}
+
+	if queryParams.Page == 0 {
+		queryParams.Page = 1
+	}
+	if queryParams.Limit == 0 {
+		queryParams.Limit = models.DefaultPageLimit
+	}
+
+	items, total, err := s.actionItemRepository.ListActionItems(queryParams)
+	if err != nil {
+		log.Printf("listActionItems: Error retrieving action items: %v", err)
+		return nil, nil, err
+	}
+
+	return items, models.NewPagination(queryParams.Page, queryParams.Limit, total), nil
+}
+
+// validateOwner requires owners to be active people.
+func (s *actionItemService) validateOwner(ownerID *int) error {
+	if ownerID == nil {
+		return nil
+	}
+
+	user, err := s.userRepository.GetUserByID(*ownerID)
+	var notFound *customErrors.NotFoundError
+	if errors.As(err, &notFound) {
+		return &validators.ValidationError{Messages: []string{fmt.Sprintf("Owner %d does not exist", *ownerID)}}
+	}
+	if err != nil {
+		return err
+	}
+
+	if user.IsServiceAccount || user.DeactivatedAt != nil {
+		return &validators.ValidationError{Messages: []string{fmt.Sprintf("User %d cannot own action items", *ownerID)}}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubActionItemRepository keeps action items in memory; listing is unused.
+type stubActionItemRepository struct {
+	repositories.ActionItemRepository
+	items map[int]*models.ActionItem
+}
+
+func (r *stubActionItemRepository) GetActionItemByID(incidentID, itemID int) (*models.ActionItem, error) {
+	item, ok := r.items[itemID]
+	if !ok || item.IncidentID != incidentID {
+		return nil, &customErrors.NotFoundError{Msg: "action item not found"}
+	}
+	copied := *item
+	return &copied, nil
+}
+
+func (r *stubActionItemRepository) UpdateActionItem(previous, item *models.ActionItem, actorID int) error {
+	r.items[item.ID] = item
+	return nil
+}
+
+func (r *stubActionItemRepository) countOpenBlocking(incidentID int) int {
+	count := 0
+	for _, item := range r.items {
+		if item.IncidentID == incidentID && item.Blocking && !item.Resolved() {
+			count++
+		}
+	}
+	return count
+}
+
+// stubTransitionIncidentRepository records status changes of one incident,
+// checking blocking action items when asked to as the repository does.
+type stubTransitionIncidentRepository struct {
+	repositories.IncidentRepository
+	status string
+	items  *stubActionItemRepository
+}
+
+func (r *stubTransitionIncidentRepository) GetIncidentStatus(id int) (string, error) {
+	return r.status, nil
+}
+
+func (r *stubTransitionIncidentRepository) GetIncidentByID(id int) (*models.IncidentOutput, error) {
+	return &models.IncidentOutput{ID: id, Status: r.status}, nil
+}
+
+func (r *stubTransitionIncidentRepository) UpdateIncidentStatus(transition *models.IncidentStatusTransition, actorID int) error {
+	if transition.CheckBlockingActionItems && r.items != nil && r.items.countOpenBlocking(transition.ID) > 0 {
+		return &customErrors.ConflictError{Msg: "incident cannot be closed while blocking action items are open"}
+	}
+	r.status = transition.To
+	return nil
+}
+
+func TestBlockingActionItemsPreventClosing(t *testing.T) {
+	items := &stubActionItemRepository{items: map[int]*models.ActionItem{
+		1: {ID: 1, IncidentID: 3, Title: "Add alerting", Status: models.ActionItemOpen, Blocking: true},
+		2: {ID: 2, IncidentID: 3, Title: "Write runbook", Status: models.ActionItemOpen},
+	}}
+	incidents := &stubTransitionIncidentRepository{status: "In Review", items: items}
+	incidentService := NewIncidentService(incidents, nil, &stubOptionsService{active: map[string][]*models.Option{
+		"status": {{Name: "In Review", Active: true}, {Name: "Closed", Active: true}},
+	}}, nil)
+	actionItemService := NewActionItemService(incidents, items, nil)
+	ctx := context.WithValue(context.Background(), "user_id", 7)
+
+	var conflict *customErrors.ConflictError
+	err := incidentService.UpdateIncidentStatus(ctx, &models.IncidentStatus{ID: 3, Status: "Closed"})
+	assert.ErrorAs(t, err, &conflict)
+	assert.Equal(t, "In Review", incidents.status)
+
+	done := models.ActionItemDone
+	item, err := actionItemService.UpdateActionItem(ctx, &models.ActionItemUpdate{IncidentID: 3, ID: 1, Status: &done})
+	require.NoError(t, err)
+	assert.NotNil(t, item.CompletedAt)
+
+	assert.NoError(t, incidentService.UpdateIncidentStatus(ctx, &models.IncidentStatus{ID: 3, Status: "Closed"}), "non-blocking items do not hold the incident")
+	assert.Equal(t, "Closed", incidents.status)
+
+	reopened := models.ActionItemOpen
+	item, err = actionItemService.UpdateActionItem(ctx, &models.ActionItemUpdate{IncidentID: 3, ID: 1, Status: &reopened})
+	require.NoError(t, err)
+	assert.Nil(t, item.CompletedAt)
+}
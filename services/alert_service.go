@@ -0,0 +1,567 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/alerts"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/config"
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/repositories"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/utils"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/validators"
+)
+
+type AlertService interface {
+	IngestAlertmanager(ctx context.Context, body []byte) (*models.AlertIngestResult, error)
+	Ingest(ctx context.Context, integration *models.AlertIntegration, body []byte) (*models.AlertIngestResult, error)
+	GetMappingRules() ([]*models.AlertMappingRule, error)
+	CreateMappingRule(input *models.AlertMappingRuleInput) (*models.AlertMappingRule, error)
+	UpdateMappingRule(input *models.AlertMappingRuleInput) (*models.AlertMappingRule, error)
+	DeleteMappingRule(id int) error
+	CreateIntegration(ctx context.Context, input *models.AlertIntegrationInput) (*models.CreatedAlertIntegration, error)
+	GetIntegrations() ([]*models.AlertIntegration, error)
+	GetIntegration(id int) (*models.AlertIntegration, error)
+	UpdateIntegration(update *models.AlertIntegrationUpdate) (*models.AlertIntegration, error)
+	RotateIntegrationToken(id int) (*models.CreatedAlertIntegration, error)
+	DeleteIntegration(id int) error
+	AuthenticateIntegration(token string) (*models.AlertIntegration, error)
+}
+
+// alertTokenPrefix starts every ingestion token, telling them apart from
+// API keys.
+const alertTokenPrefix = "fwa"
+
+type alertService struct {
+	alertRepository repositories.AlertRepository
+	userRepository  repositories.UserRepository
+	incidentService IncidentService
+	optionsService  OptionsService
+	defaultType     string
+	defaultSeverity string
+}
+
+// alertIngestAttempts bounds how often ingestion starts over after another
+// notification opened an incident for the same alerts first.
+const alertIngestAttempts = 3
+
+func NewAlertService(alertRepository repositories.AlertRepository, userRepository repositories.UserRepository, incidentService IncidentService, optionsService OptionsService) AlertService {
+	cfg := config.GetConfig()
+	return &alertService{
+		alertRepository: alertRepository,
+		userRepository:  userRepository,
+		incidentService: incidentService,
+		optionsService:  optionsService,
+		defaultType:     cfg.AlertDefaultType,
+		defaultSeverity: cfg.AlertDefaultSeverity,
+	}
+}
+
+func (s *alertService) IngestAlertmanager(ctx context.Context, body []byte) (*models.AlertIngestResult, error) {
+	log.Println("IngestAlertmanager: Parsing Alertmanager notification")
+
+	parsed, err := alerts.NewAlertmanagerSource().Parse(body)
+	if err != nil {
+		log.Printf("IngestAlertmanager: Error parsing notification: %v", err)
+		return nil, err
+	}
+
+	return s.ingest(ctx, parsed)
+}
+
+func (s *alertService) Ingest(ctx context.Context, integration *models.AlertIntegration, body []byte) (*models.AlertIngestResult, error) {
+	log.Printf("Ingest: Parsing notification of alert integration %d", integration.ID)
+
+	source, err := alerts.NewAlertSource(integration)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := source.Parse(body)
+	if err != nil {
+		log.Printf("Ingest: Error parsing notification of alert integration %d: %v", integration.ID, err)
+		return nil, err
+	}
+
+	return s.ingest(ctx, parsed)
+}
+
+// ingest folds each alert into the open incident already tracking it. Firing
+// alerts nobody tracks open one new triage incident between them; resolved
+// alerts nobody tracks are dropped.
+func (s *alertService) ingest(ctx context.Context, batch []*models.Alert) (*models.AlertIngestResult, error) {
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.AlertIngestResult{Created: []int{}, Updated: []int{}}
+	pending := batch
+
+	for attempt := 1; ; attempt++ {
+		untracked, err := s.recordTrackedAlerts(pending, actorID, result)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(untracked) == 0 {
+			return result, nil
+		}
+
+		incidentID, err := s.openIncident(ctx, untracked)
+		var conflict *customErrors.ConflictError
+		if errors.As(err, &conflict) && attempt < alertIngestAttempts {
+			// A concurrent notification tracked some of the alerts first, so
+			// they now belong to its incident.
+			log.Printf("ingest: Retrying untracked alerts: %v", err)
+			pending = untracked
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		result.Created = append(result.Created, incidentID)
+		log.Printf("ingest: Opened incident %d for %d alerts", incidentID, len(untracked))
+		return result, nil
+	}
+}
+
+// recordTrackedAlerts records the alerts an open incident already tracks and
+// returns the firing ones no incident tracks.
+func (s *alertService) recordTrackedAlerts(batch []*models.Alert, actorID int, result *models.AlertIngestResult) ([]*models.Alert, error) {
+	var untracked []*models.Alert
+
+	for _, alert := range batch {
+		tracked, err := s.alertRepository.FindOpenIncidentAlert(alert.Source, alert.Fingerprint, terminalStatuses)
+		var notFound *customErrors.NotFoundError
+		switch {
+		case errors.As(err, &notFound):
+			if alert.Status == models.AlertFiring {
+				untracked = append(untracked, alert)
+			} else {
+				result.Ignored++
+			}
+			continue
+		case err != nil:
+			return nil, err
+		}
+
+		annotated, err := s.alertRepository.RecordIncidentAlert(tracked.IncidentID, alert, actorID)
+		if err != nil {
+			return nil, err
+		}
+		if annotated && alert.Status == models.AlertResolved {
+			result.Resolved++
+		}
+		result.Updated = appendUnique(result.Updated, tracked.IncidentID)
+	}
+
+	return untracked, nil
+}
+
+// openIncident creates a triage incident describing the alerts, with fields
+// set by the mapping rules or, failing that, the configured defaults.
+func (s *alertService) openIncident(ctx context.Context, batch []*models.Alert) (int, error) {
+	triage, err := s.triageStatus()
+	if err != nil {
+		return 0, err
+	}
+
+	input := &models.IncidentInput{
+		Title:    truncateRunes(alertTitle(batch), 255),
+		Summary:  truncateRunes(alertSummary(batch), 10000),
+		Status:   triage,
+		Type:     s.defaultType,
+		Severity: s.defaultSeverity,
+	}
+
+	var startedAt time.Time
+	for _, alert := range batch {
+		if !alert.StartsAt.IsZero() && (startedAt.IsZero() || alert.StartsAt.Before(startedAt)) {
+			startedAt = alert.StartsAt
+		}
+	}
+	if !startedAt.IsZero() {
+		input.ImpactStartedAt = models.NewCustomTime(startedAt.UTC())
+	}
+
+	if err := s.applyMappingRules(input, batch); err != nil {
+		return 0, err
+	}
+
+	return s.incidentService.CreateAlertIncident(ctx, input, batch)
+}
+
+// applyMappingRules sets incident fields from the rules matching any of the
+// alerts. Single valued fields take the first matching rule; products and
+// areas collect every match. Rules pointing at inactive options are skipped.
+func (s *alertService) applyMappingRules(input *models.IncidentInput, batch []*models.Alert) error {
+	rules, err := s.alertRepository.GetMappingRules()
+	if err != nil {
+		return err
+	}
+
+	mapped := map[string]bool{}
+	for _, rule := range rules {
+		if mapped[rule.Field] {
+			continue
+		}
+
+		matched := false
+		for _, alert := range batch {
+			if rule.Matches(alert.Labels) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		option, err := s.activeOption(rule.Field, rule.OptionID)
+		if err != nil {
+			return err
+		}
+		if option == nil {
+			log.Printf("applyMappingRules: Skipping rule %d, option %d is not active", rule.ID, rule.OptionID)
+			continue
+		}
+
+		switch rule.Field {
+		case models.AlertFieldSeverity:
+			input.Severity = option.Name
+			mapped[rule.Field] = true
+		case models.AlertFieldType:
+			input.Type = option.Name
+			mapped[rule.Field] = true
+		case models.AlertFieldSource:
+			input.Source = &option.Name
+			mapped[rule.Field] = true
+		case models.AlertFieldProduct:
+			input.Products = appendUnique(input.Products, option.ID)
+		case models.AlertFieldArea:
+			input.Areas = appendUnique(input.Areas, option.ID)
+		}
+	}
+
+	return nil
+}
+
+func (s *alertService) activeOption(field string, id int) (*models.Option, error) {
+	options, err := s.optionsService.GetActiveOptions(models.AlertFieldTaxonomy(field))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, option := range options {
+		if option.ID == id {
+			return option, nil
+		}
+	}
+	return nil, nil
+}
+
+// triageStatus returns the configured name of the triage status.
+func (s *alertService) triageStatus() (string, error) {
+	statuses, err := s.optionsService.GetActiveOptions("status")
+	if err != nil {
+		return "", err
+	}
+
+	for _, status := range statuses {
+		if normalizeStatus(status.Name) == StatusTriage {
+			return status.Name, nil
+		}
+	}
+	return "", &validators.ValidationError{Messages: []string{"no active triage status to open alert incidents in"}}
+}
+
+func (s *alertService) GetMappingRules() ([]*models.AlertMappingRule, error) {
+	return s.alertRepository.GetMappingRules()
+}
+
+func (s *alertService) CreateMappingRule(input *models.AlertMappingRuleInput) (*models.AlertMappingRule, error) {
+	log.Printf("CreateMappingRule: Mapping label %s=%s to %s", input.Label, input.Value, input.Field)
+
+	if err := s.validateMappingRule(input); err != nil {
+		return nil, err
+	}
+
+	id, err := s.alertRepository.CreateMappingRule(input)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.alertRepository.GetMappingRuleByID(id)
+}
+
+func (s *alertService) UpdateMappingRule(input *models.AlertMappingRuleInput) (*models.AlertMappingRule, error) {
+	log.Printf("UpdateMappingRule: Updating alert mapping rule %d", input.ID)
+
+	if err := s.validateMappingRule(input); err != nil {
+		return nil, err
+	}
+
+	if err := s.alertRepository.UpdateMappingRule(input); err != nil {
+		return nil, err
+	}
+
+	return s.alertRepository.GetMappingRuleByID(input.ID)
+}
+
+func (s *alertService) DeleteMappingRule(id int) error {
+	return s.alertRepository.DeleteMappingRule(id)
+}
+
+func (s *alertService) validateMappingRule(input *models.AlertMappingRuleInput) error {
+	if err := validators.ValidateStruct(input); err != nil {
+		log.Printf("validateMappingRule: Validation error: %v", err)
+		return &validators.ValidationError{Err: err}
+	}
+
+	option, err := s.activeOption(input.Field, input.OptionID)
+	if err != nil {
+		return err
+	}
+	if option == nil {
+		return &validators.ValidationError{Messages: []string{
+			fmt.Sprintf("OptionID %d is not an active %s option", input.OptionID, input.Field),
+		}}
+	}
+	return nil
+}
+
+func (s *alertService) CreateIntegration(ctx context.Context, input *models.AlertIntegrationInput) (*models.CreatedAlertIntegration, error) {
+	log.Printf("CreateIntegration: Creating %s alert integration %q", input.Kind, input.Name)
+
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validators.ValidateStruct(input); err != nil {
+		log.Printf("CreateIntegration: Validation error: %v", err)
+		return nil, &validators.ValidationError{Err: err}
+	}
+
+	integration := &models.AlertIntegration{
+		Name:       input.Name,
+		Kind:       input.Kind,
+		ReporterID: input.ReporterID,
+		Active:     true,
+		CreatedBy:  &actorID,
+	}
+	if integration.ReporterID == 0 {
+		integration.ReporterID = actorID
+	}
+	if integration.Kind == models.AlertKindGeneric {
+		integration.Mapping = input.Mapping
+	}
+
+	if err := s.validateIntegration(integration); err != nil {
+		return nil, err
+	}
+
+	token, tokenHash, err := newAlertToken(integration)
+	if err != nil {
+		return nil, err
+	}
+
+	if integration.ID, err = s.alertRepository.CreateIntegration(integration, tokenHash); err != nil {
+		return nil, err
+	}
+
+	created, err := s.alertRepository.GetIntegrationByID(integration.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("CreateIntegration: Alert integration %d created", created.ID)
+	return &models.CreatedAlertIntegration{Integration: created, Token: token}, nil
+}
+
+func (s *alertService) GetIntegrations() ([]*models.AlertIntegration, error) {
+	return s.alertRepository.GetIntegrations()
+}
+
+func (s *alertService) GetIntegration(id int) (*models.AlertIntegration, error) {
+	return s.alertRepository.GetIntegrationByID(id)
+}
+
+func (s *alertService) UpdateIntegration(update *models.AlertIntegrationUpdate) (*models.AlertIntegration, error) {
+	log.Printf("UpdateIntegration: Updating alert integration %d", update.ID)
+
+	if err := validators.ValidateStruct(update); err != nil {
+		log.Printf("UpdateIntegration: Validation error: %v", err)
+		return nil, &validators.ValidationError{Err: err}
+	}
+
+	integration, err := s.alertRepository.GetIntegrationByID(update.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Name != nil {
+		integration.Name = *update.Name
+	}
+	if update.ReporterID != nil {
+		integration.ReporterID = *update.ReporterID
+	}
+	if update.Active != nil {
+		integration.Active = *update.Active
+	}
+	if update.Mapping != nil {
+		if integration.Kind != models.AlertKindGeneric {
+			return nil, &validators.ValidationError{Messages: []string{"Mapping only applies to generic integrations"}}
+		}
+		integration.Mapping = update.Mapping
+	}
+
+	if err := s.validateIntegration(integration); err != nil {
+		return nil, err
+	}
+
+	if err := s.alertRepository.UpdateIntegration(integration); err != nil {
+		return nil, err
+	}
+
+	return s.alertRepository.GetIntegrationByID(integration.ID)
+}
+
+func (s *alertService) RotateIntegrationToken(id int) (*models.CreatedAlertIntegration, error) {
+	log.Printf("RotateIntegrationToken: Rotating token of alert integration %d", id)
+
+	integration, err := s.alertRepository.GetIntegrationByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	token, tokenHash, err := newAlertToken(integration)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.alertRepository.UpdateIntegrationToken(id, integration.TokenPrefix, tokenHash); err != nil {
+		return nil, err
+	}
+
+	return &models.CreatedAlertIntegration{Integration: integration, Token: token}, nil
+}
+
+func (s *alertService) DeleteIntegration(id int) error {
+	return s.alertRepository.DeleteIntegration(id)
+}
+
+func (s *alertService) AuthenticateIntegration(token string) (*models.AlertIntegration, error) {
+	integration, err := s.alertRepository.GetIntegrationByToken(utils.HashToken(token))
+
+	var notFound *customErrors.NotFoundError
+	if errors.As(err, &notFound) {
+		return nil, &customErrors.AuthenticationError{Msg: "invalid ingestion token"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.alertRepository.TouchIntegration(integration.ID); err != nil {
+		log.Printf("AuthenticateIntegration: Error recording integration use: %v", err)
+	}
+
+	return integration, nil
+}
+
+// validateIntegration checks the reporter is an active user and, for
+// generic integrations, that every JSONPath of the mapping compiles.
+func (s *alertService) validateIntegration(integration *models.AlertIntegration) error {
+	reporter, err := s.userRepository.GetUserByID(integration.ReporterID)
+	var notFound *customErrors.NotFoundError
+	switch {
+	case errors.As(err, &notFound):
+		return &validators.ValidationError{Messages: []string{fmt.Sprintf("Reporter %d does not exist", integration.ReporterID)}}
+	case err != nil:
+		return err
+	case reporter.DeactivatedAt != nil:
+		return &validators.ValidationError{Messages: []string{fmt.Sprintf("Reporter %d is deactivated", integration.ReporterID)}}
+	}
+
+	if integration.Kind != models.AlertKindGeneric {
+		return nil
+	}
+
+	if err := validators.ValidateStruct(integration.Mapping); err != nil {
+		return &validators.ValidationError{Err: err}
+	}
+	if _, err := alerts.NewAlertSource(integration); err != nil {
+		return &validators.ValidationError{Messages: []string{"Mapping " + err.Error()}}
+	}
+	return nil
+}
+
+// newAlertToken issues a fresh ingestion token, setting the integration's
+// prefix, and returns it with the hash to store.
+func newAlertToken(integration *models.AlertIntegration) (string, string, error) {
+	prefix, err := randomHex(4)
+	if err != nil {
+		return "", "", err
+	}
+
+	secret, err := utils.GenerateToken(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	integration.TokenPrefix = prefix
+	token := fmt.Sprintf("%s_%s_%s", alertTokenPrefix, prefix, secret)
+	return token, utils.HashToken(token), nil
+}
+
+func alertTitle(batch []*models.Alert) string {
+	title := batch[0].Annotations["summary"]
+	if title == "" {
+		title = batch[0].Name
+	}
+	if len(batch) > 1 {
+		title = fmt.Sprintf("%s (+%d more)", title, len(batch)-1)
+	}
+	return title
+}
+
+func alertSummary(batch []*models.Alert) string {
+	lines := make([]string, 0, len(batch))
+	for _, alert := range batch {
+		line := alert.Name
+		if description := alert.Annotations["description"]; description != "" {
+			line += ": " + description
+		} else if summary := alert.Annotations["summary"]; summary != "" {
+			line += ": " + summary
+		}
+		if alert.GeneratorURL != "" {
+			line += " (" + alert.GeneratorURL + ")"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func truncateRunes(value string, limit int) string {
+	if utf8.RuneCountInString(value) <= limit {
+		return value
+	}
+	return string([]rune(value)[:limit])
+}
+
+func appendUnique(ids []int, id int) []int {
+	for _, existing := range ids {
+		if existing == id {
+			return ids
+		}
+	}
+	return append(ids, id)
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubAlertRepository tracks alert links in memory; every linked incident
+// counts as open.
+type stubAlertRepository struct {
+	repositories.AlertRepository
+	rules  []*models.AlertMappingRule
+	links  map[string]*models.IncidentAlert
+	events []string
+}
+
+func (r *stubAlertRepository) GetMappingRules() ([]*models.AlertMappingRule, error) {
+	return r.rules, nil
+}
+
+func (r *stubAlertRepository) FindOpenIncidentAlert(source, fingerprint string, closedStatuses []string) (*models.IncidentAlert, error) {
+	link, ok := r.links[fingerprint]
+	if !ok {
+		return nil, &customErrors.NotFoundError{Msg: "no open incident for alert"}
+	}
+	return link, nil
+}
+
+func (r *stubAlertRepository) RecordIncidentAlert(incidentID int, alert *models.Alert, actorID int) (bool, error) {
+	link, ok := r.links[alert.Fingerprint]
+	if ok && link.Status == alert.Status {
+		return false, nil
+	}
+	r.links[alert.Fingerprint] = &models.IncidentAlert{IncidentID: incidentID, Fingerprint: alert.Fingerprint, Status: alert.Status}
+	r.events = append(r.events, alert.Status+": "+alert.Name)
+	return true, nil
+}
+
+// stubCreateIncidentService records the incidents it is asked to create and
+// links their alerts, refusing alerts already linked as the repository does.
+type stubCreateIncidentService struct {
+	IncidentService
+	alerts  *stubAlertRepository
+	created []*models.IncidentInput
+	// concurrent runs before the check, standing in for another notification
+	concurrent func()
+}
+
+func (s *stubCreateIncidentService) CreateAlertIncident(ctx context.Context, input *models.IncidentInput, alerts []*models.Alert) (int, error) {
+	if s.concurrent != nil {
+		s.concurrent()
+		s.concurrent = nil
+	}
+
+	for _, alert := range alerts {
+		if link, ok := s.alerts.links[alert.Fingerprint]; ok {
+			return 0, &customErrors.ConflictError{Msg: fmt.Sprintf("alert %s is already tracked by incident %d", alert.Fingerprint, link.IncidentID)}
+		}
+	}
+
+	s.created = append(s.created, input)
+	incidentID := 100 + len(s.created)
+	for _, alert := range alerts {
+		if _, err := s.alerts.RecordIncidentAlert(incidentID, alert, input.Reporter); err != nil {
+			return 0, err
+		}
+	}
+	return incidentID, nil
+}
+
+func TestIngestAlertmanager(t *testing.T) {
+	alerts := &stubAlertRepository{
+		rules: []*models.AlertMappingRule{
+			{ID: 1, Label: "severity", Value: "critical", Field: models.AlertFieldSeverity, OptionID: 1},
+			{ID: 2, Label: "severity", Value: "warning", Field: models.AlertFieldSeverity, OptionID: 2},
+			{ID: 3, Label: "service", Value: "pix", Field: models.AlertFieldProduct, OptionID: 10},
+			{ID: 4, Label: "service", Value: "boleto", Field: models.AlertFieldProduct, OptionID: 11},
+		},
+		links: map[string]*models.IncidentAlert{
+			"known": {IncidentID: 7, Fingerprint: "known", Status: models.AlertFiring},
+		},
+	}
+	incidents := &stubCreateIncidentService{alerts: alerts}
+	service := &alertService{
+		alertRepository: alerts,
+		incidentService: incidents,
+		optionsService: &stubOptionsService{active: map[string][]*models.Option{
+			"status":   {{ID: 1, Name: "Triage", Active: true}, {ID: 2, Name: "Investigating", Active: true}},
+			"severity": {{ID: 1, Name: "SEV1", Active: true}, {ID: 2, Name: "SEV3", Active: true}},
+			"products": {{ID: 10, Name: "PIX", Active: true}},
+		}},
+		defaultType:     "Technical",
+		defaultSeverity: "SEV3",
+	}
+	ctx := context.WithValue(context.Background(), "user_id", 3)
+	notification := func(received []models.AlertmanagerAlert) []byte {
+		body, err := json.Marshal(&models.AlertmanagerPayload{Alerts: received})
+		require.NoError(t, err)
+		return body
+	}
+	startedAt := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+
+	t.Run("untracked firing alerts open one triage incident", func(t *testing.T) {
+		result, err := service.IngestAlertmanager(ctx, notification([]models.AlertmanagerAlert{
+			{Status: models.AlertFiring, Fingerprint: "known", Labels: models.AlertLabels{"alertname": "HighLatency"}},
+			{
+				Status: models.AlertFiring, Fingerprint: "a1", StartsAt: startedAt.Add(time.Minute),
+				Labels:      models.AlertLabels{"alertname": "PixErrors", "severity": "Critical", "service": "pix"},
+				Annotations: models.AlertLabels{"summary": "PIX error rate above 5%"},
+			},
+			{
+				Status: models.AlertFiring, Fingerprint: "a2", StartsAt: startedAt,
+				Labels: models.AlertLabels{"alertname": "BoletoErrors", "severity": "warning", "service": "boleto"},
+			},
+		}))
+		require.NoError(t, err)
+
+		assert.Equal(t, []int{101}, result.Created)
+		assert.Equal(t, []int{7}, result.Updated)
+		require.Len(t, incidents.created, 1)
+
+		created := incidents.created[0]
+		assert.Equal(t, "PIX error rate above 5% (+1 more)", created.Title)
+		assert.Equal(t, "Triage", created.Status)
+		assert.Equal(t, "SEV1", created.Severity, "the first matching rule wins")
+		assert.Equal(t, "Technical", created.Type, "unmapped fields use the default")
+		assert.Equal(t, []int{10}, created.Products, "rules on inactive options are skipped")
+		assert.Equal(t, startedAt, time.Time(*created.ImpactStartedAt))
+		assert.Equal(t, 101, alerts.links["a2"].IncidentID)
+	})
+
+	t.Run("repeated notifications are deduplicated", func(t *testing.T) {
+		result, err := service.IngestAlertmanager(ctx, notification([]models.AlertmanagerAlert{
+			{Status: models.AlertFiring, Fingerprint: "a1", Labels: models.AlertLabels{"alertname": "PixErrors"}},
+		}))
+		require.NoError(t, err)
+
+		assert.Empty(t, result.Created)
+		assert.Equal(t, []int{101}, result.Updated)
+		assert.Len(t, incidents.created, 1)
+	})
+
+	t.Run("resolved alerts annotate their incident", func(t *testing.T) {
+		alerts.events = nil
+		result, err := service.IngestAlertmanager(ctx, notification([]models.AlertmanagerAlert{
+			{Status: models.AlertResolved, Fingerprint: "a1", Labels: models.AlertLabels{"alertname": "PixErrors"}},
+			{Status: models.AlertResolved, Fingerprint: "gone", Labels: models.AlertLabels{"alertname": "Flapping"}},
+		}))
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, result.Resolved)
+		assert.Equal(t, 1, result.Ignored)
+		assert.Equal(t, []string{"resolved: PixErrors"}, alerts.events)
+		assert.Len(t, incidents.created, 1)
+	})
+
+	t.Run("alerts tracked concurrently join the other incident", func(t *testing.T) {
+		incidents.concurrent = func() {
+			alerts.links["b1"] = &models.IncidentAlert{IncidentID: 9, Fingerprint: "b1", Status: models.AlertFiring}
+		}
+
+		result, err := service.IngestAlertmanager(ctx, notification([]models.AlertmanagerAlert{
+			{Status: models.AlertFiring, Fingerprint: "b1", Labels: models.AlertLabels{"alertname": "CardDeclines"}},
+			{Status: models.AlertFiring, Fingerprint: "b2", Labels: models.AlertLabels{"alertname": "CardLatency"}},
+		}))
+		require.NoError(t, err)
+
+		assert.Equal(t, []int{9}, result.Updated)
+		assert.Equal(t, []int{102}, result.Created, "only the alert still untracked opens an incident")
+		assert.Equal(t, "CardLatency", incidents.created[1].Title)
+		assert.Equal(t, 102, alerts.links["b2"].IncidentID)
+	})
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/repositories"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/utils"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/validators"
+)
+
+// apiKeyPrefix starts every key so leaked keys are easy to spot in code
+// and logs.
+const apiKeyPrefix = "fw"
+
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, ownerID int, input *models.APIKeyInput) (*models.CreatedAPIKey, error)
+	ListAPIKeys(ctx context.Context, ownerID int) ([]*models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, ownerID, keyID int) error
+	AuthenticateAPIKey(key string) (*models.APIKeyPrincipal, error)
+	CreateServiceAccount(input *models.ServiceAccountInput) (*models.ServiceAccount, error)
+	GetServiceAccounts() ([]*models.ServiceAccount, error)
+}
+
+type apiKeyService struct {
+	apiKeyRepo repositories.APIKeyRepository
+	userRepo   repositories.UserRepository
+}
+
+func NewAPIKeyService(apiKeyRepo repositories.APIKeyRepository, userRepo repositories.UserRepository) APIKeyService {
+	return &apiKeyService{apiKeyRepo: apiKeyRepo, userRepo: userRepo}
+}
+
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, ownerID int, input *models.APIKeyInput) (*models.CreatedAPIKey, error) {
+	log.Printf("CreateAPIKey: Starting API key creation for user ID %d", ownerID)
+
+	actorID, owner, err := s.authorizeOwner(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validators.ValidateStruct(input); err != nil {
+		log.Printf("CreateAPIKey: Validation error: %v", err)
+		return nil, &validators.ValidationError{Err: err}
+	}
+
+	if err := validateAPIKeyInput(owner, input); err != nil {
+		log.Printf("CreateAPIKey: Validation error: %v", err)
+		return nil, err
+	}
+
+	prefix, err := randomHex(4)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := utils.GenerateToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey := &models.APIKey{
+		UserID:    owner.ID,
+		Name:      input.Name,
+		Prefix:    prefix,
+		Scopes:    models.Scopes(input.Scopes),
+		CreatedBy: &actorID,
+		ExpiresAt: input.ExpiresAt,
+	}
+
+	key := fmt.Sprintf("%s_%s_%s", apiKeyPrefix, prefix, secret)
+	if apiKey.ID, err = s.apiKeyRepo.CreateAPIKey(apiKey, utils.HashToken(key)); err != nil {
+		log.Printf("CreateAPIKey: Error storing API key: %v", err)
+		return nil, err
+	}
+
+	log.Printf("CreateAPIKey: API key %d created for user ID %d", apiKey.ID, owner.ID)
+	return &models.CreatedAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+func (s *apiKeyService) ListAPIKeys(ctx context.Context, ownerID int) ([]*models.APIKey, error) {
+	log.Printf("ListAPIKeys: Retrieving API keys of user ID %d", ownerID)
+
+	if _, _, err := s.authorizeOwner(ctx, ownerID); err != nil {
+		return nil, err
+	}
+
+	return s.apiKeyRepo.ListAPIKeys(ownerID)
+}
+
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, ownerID, keyID int) error {
+	log.Printf("RevokeAPIKey: Revoking API key %d of user ID %d", keyID, ownerID)
+
+	if _, _, err := s.authorizeOwner(ctx, ownerID); err != nil {
+		return err
+	}
+
+	return s.apiKeyRepo.RevokeAPIKey(ownerID, keyID)
+}
+
+func (s *apiKeyService) AuthenticateAPIKey(key string) (*models.APIKeyPrincipal, error) {
+	principal, err := s.apiKeyRepo.GetAPIKeyPrincipal(utils.HashToken(key))
+
+	var notFound *customErrors.NotFoundError
+	if errors.As(err, &notFound) {
+		return nil, &customErrors.AuthenticationError{Msg: "invalid or expired API key"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.apiKeyRepo.TouchAPIKey(principal.KeyID); err != nil {
+		log.Printf("AuthenticateAPIKey: Error recording key use: %v", err)
+	}
+
+	return principal, nil
+}
+
+func (s *apiKeyService) CreateServiceAccount(input *models.ServiceAccountInput) (*models.ServiceAccount, error) {
+	log.Printf("CreateServiceAccount: Starting creation of service account %q", input.Name)
+
+	if err := validators.ValidateStruct(input); err != nil {
+		log.Printf("CreateServiceAccount: Validation error: %v", err)
+		return nil, &validators.ValidationError{Err: err}
+	}
+
+	if !models.IsValidRole(input.Role) {
+		return nil, &validators.ValidationError{Messages: []string{"Role must be one of: " + strings.Join(models.Roles(), ", ")}}
+	}
+
+	// Service accounts never log in: the address is unroutable and the
+	// password is a random value nobody knows.
+	handle, err := randomHex(8)
+	if err != nil {
+		return nil, err
+	}
+	password, err := utils.GenerateToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	email := fmt.Sprintf("service-account-%s@firewatchers.invalid", handle)
+	id, err := s.userRepo.CreateServiceAccount(input.Name, email, utils.GeneratePassword(password), input.Role)
+	if err != nil {
+		log.Printf("CreateServiceAccount: Error creating service account: %v", err)
+		return nil, err
+	}
+
+	return &models.ServiceAccount{ID: id, Name: input.Name, Role: input.Role}, nil
+}
+
+func (s *apiKeyService) GetServiceAccounts() ([]*models.ServiceAccount, error) {
+	return s.userRepo.GetServiceAccounts()
+}
+
+// authorizeOwner lets users manage their own keys and user admins manage the
+// keys of service accounts. Keys cannot be used to manage keys.
+func (s *apiKeyService) authorizeOwner(ctx context.Context, ownerID int) (int, *models.User, error) {
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if _, viaAPIKey := ctx.Value("api_key_id").(int); viaAPIKey {
+		return 0, nil, &customErrors.ForbiddenError{Msg: "API keys cannot be managed with an API key"}
+	}
+
+	owner, err := s.userRepo.GetUserByID(ownerID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if ownerID == actorID {
+		return actorID, owner, nil
+	}
+
+	role, _ := ctx.Value("role").(string)
+	if owner.IsServiceAccount && models.HasPermission(role, models.PermissionUsersManage) {
+		return actorID, owner, nil
+	}
+
+	return 0, nil, &customErrors.ForbiddenError{Msg: "you cannot manage API keys of this user"}
+}
+
+// validateAPIKeyInput checks that every scope is a permission the owner's
+// role grants, and that the expiry is in the future.
+func validateAPIKeyInput(owner *models.User, input *models.APIKeyInput) error {
+	var messages []string
+
+	for _, scope := range input.Scopes {
+		if !models.IsValidPermission(scope) {
+			messages = append(messages, fmt.Sprintf("Scope %q is not a known permission", scope))
+		} else if !models.HasPermission(owner.Role, scope) {
+			messages = append(messages, fmt.Sprintf("Scope %q is not granted by the %s role", scope, owner.Role))
+		}
+	}
+
+	if input.ExpiresAt != nil && !time.Time(*input.ExpiresAt).After(time.Now()) {
+		messages = append(messages, "ExpiresAt must be in the future")
+	}
+
+	if len(messages) > 0 {
+		return &validators.ValidationError{Messages: messages}
+	}
+
+	return nil
+}
+
+func randomHex(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
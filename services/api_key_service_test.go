@@ -0,0 +1,35 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/validators"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateAPIKeyInput(t *testing.T) {
+	responder := &models.User{Role: models.RoleResponder}
+	past := models.NewCustomTime(time.Now().Add(-time.Hour))
+
+	assert.NoError(t, validateAPIKeyInput(responder, &models.APIKeyInput{
+		Name:   "alert bot",
+		Scopes: []string{models.PermissionIncidentsCreate, models.PermissionIncidentsUpdate},
+	}))
+
+	err := validateAPIKeyInput(responder, &models.APIKeyInput{
+		Name:      "alert bot",
+		Scopes:    []string{"incidents:delete", models.PermissionIncidentsManage},
+		ExpiresAt: past,
+	})
+
+	var validationErr *validators.ValidationError
+	if assert.ErrorAs(t, err, &validationErr) {
+		assert.Equal(t, []string{
+			`Scope "incidents:delete" is not a known permission`,
+			`Scope "incidents:manage" is not granted by the Responder role`,
+			"ExpiresAt must be in the future",
+		}, validationErr.ErrorMessages())
+	}
+}
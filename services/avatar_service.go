@@ -0,0 +1,156 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	// Decoders for the accepted avatar formats
+	_ "image/gif"
+	_ "image/jpeg"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/config"
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/repositories"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/storage"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/utils"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/validators"
+)
+
+// maxAvatarDimension bounds the decoded size of an upload, so a small file
+// cannot expand into a huge bitmap.
+const maxAvatarDimension = 4096
+
+var avatarTypes = map[string]bool{"image/png": true, "image/jpeg": true, "image/gif": true}
+
+type AvatarService interface {
+	UploadAvatar(ctx context.Context, content []byte) (*models.UserProfile, error)
+	GetAvatar(ctx context.Context, userID int, original bool) ([]byte, string, error)
+	MaxBytes() int
+}
+
+type avatarService struct {
+	userRepo  repositories.UserRepository
+	blobStore storage.BlobStore
+	apiURL    string
+	maxBytes  int
+	size      int
+}
+
+func NewAvatarService(userRepo repositories.UserRepository, blobStore storage.BlobStore) AvatarService {
+	cfg := config.GetConfig()
+
+	return &avatarService{
+		userRepo:  userRepo,
+		blobStore: blobStore,
+		apiURL:    strings.TrimRight(cfg.APIURL, "/"),
+		maxBytes:  cfg.AvatarMaxBytes,
+		size:      cfg.AvatarSize,
+	}
+}
+
+func (s *avatarService) MaxBytes() int {
+	return s.maxBytes
+}
+
+// UploadAvatar keeps the original image and a square thumbnail, then points
+// the caller's avatar_url at the thumbnail. The version parameter changes on
+// every upload so caches pick up the new picture.
+func (s *avatarService) UploadAvatar(ctx context.Context, content []byte) (*models.UserProfile, error) {
+	userID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("UploadAvatar: Starting avatar upload for user ID %d (%d bytes)", userID, len(content))
+
+	if len(content) > s.maxBytes {
+		return nil, &validators.ValidationError{Messages: []string{fmt.Sprintf("Avatar must be at most %d bytes", s.maxBytes)}}
+	}
+
+	if !avatarTypes[mimetype.Detect(content).String()] {
+		return nil, &validators.ValidationError{Messages: []string{"Avatar must be a PNG, JPEG or GIF image"}}
+	}
+
+	imageConfig, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		log.Printf("UploadAvatar: Error reading image header: %v", err)
+		return nil, &validators.ValidationError{Messages: []string{"Avatar is not a valid image"}}
+	}
+	if imageConfig.Width > maxAvatarDimension || imageConfig.Height > maxAvatarDimension {
+		return nil, &validators.ValidationError{Messages: []string{fmt.Sprintf("Avatar must be at most %dx%d pixels", maxAvatarDimension, maxAvatarDimension)}}
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		log.Printf("UploadAvatar: Error decoding image: %v", err)
+		return nil, &validators.ValidationError{Messages: []string{"Avatar is not a valid image"}}
+	}
+
+	var thumbnail bytes.Buffer
+	if err := png.Encode(&thumbnail, utils.Thumbnail(img, s.size)); err != nil {
+		log.Printf("UploadAvatar: Error encoding thumbnail: %v", err)
+		return nil, err
+	}
+
+	if err := s.blobStore.Put(ctx, avatarKey(userID, true), bytes.NewReader(content)); err != nil {
+		log.Printf("UploadAvatar: Error storing original: %v", err)
+		return nil, err
+	}
+	if err := s.blobStore.Put(ctx, avatarKey(userID, false), &thumbnail); err != nil {
+		log.Printf("UploadAvatar: Error storing thumbnail: %v", err)
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/api/v1/users/%d/avatar?v=%d", s.apiURL, userID, time.Now().Unix())
+	if err := s.userRepo.UpdateUserAvatar(userID, url); err != nil {
+		log.Printf("UploadAvatar: Error updating avatar URL: %v", err)
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("UploadAvatar: Avatar of user ID %d updated", userID)
+	return user.Profile(), nil
+}
+
+// GetAvatar returns the thumbnail, or the image as uploaded, with its MIME
+// type.
+func (s *avatarService) GetAvatar(ctx context.Context, userID int, original bool) ([]byte, string, error) {
+	blob, err := s.blobStore.Get(ctx, avatarKey(userID, original))
+	if errors.Is(err, storage.ErrBlobNotFound) {
+		return nil, "", &customErrors.NotFoundError{Msg: fmt.Sprintf("user with ID %d has no avatar", userID)}
+	}
+	if err != nil {
+		log.Printf("GetAvatar: Error reading avatar of user ID %d: %v", userID, err)
+		return nil, "", err
+	}
+	defer blob.Close()
+
+	content, err := io.ReadAll(blob)
+	if err != nil {
+		log.Printf("GetAvatar: Error reading avatar of user ID %d: %v", userID, err)
+		return nil, "", err
+	}
+
+	return content, mimetype.Detect(content).String(), nil
+}
+
+func avatarKey(userID int, original bool) string {
+	if original {
+		return fmt.Sprintf("avatars/%d/original", userID)
+	}
+	return fmt.Sprintf("avatars/%d/thumbnail.png", userID)
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/storage"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/validators"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadAvatar(t *testing.T) {
+	blobStore, err := storage.NewLocalBlobStore(t.TempDir())
+	require.NoError(t, err)
+
+	userRepo := &stubUserRepository{user: &models.User{ID: 7, Email: "john@example.com"}}
+	service := &avatarService{userRepo: userRepo, blobStore: blobStore, apiURL: "https://api.example.com", maxBytes: 1 << 20, size: 32}
+	ctx := context.WithValue(context.Background(), "user_id", 7)
+
+	img := image.NewNRGBA(image.Rect(0, 0, 120, 80))
+	for i := range img.Pix {
+		img.Pix[i] = 200
+	}
+	img.SetNRGBA(0, 0, color.NRGBA{A: 255})
+	var upload bytes.Buffer
+	require.NoError(t, png.Encode(&upload, img))
+
+	user, err := service.UploadAvatar(ctx, upload.Bytes())
+	require.NoError(t, err)
+	assert.Regexp(t, `^https://api\.example\.com/api/v1/users/7/avatar\?v=\d+$`, user.Avatar)
+
+	thumbnail, contentType, err := service.GetAvatar(ctx, 7, false)
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+	decoded, err := png.Decode(bytes.NewReader(thumbnail))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 32, 32), decoded.Bounds())
+
+	original, _, err := service.GetAvatar(ctx, 7, true)
+	require.NoError(t, err)
+	assert.Equal(t, upload.Bytes(), original)
+
+	var validationErr *validators.ValidationError
+	_, err = service.UploadAvatar(ctx, []byte("%PDF-1.4 not an image"))
+	assert.ErrorAs(t, err, &validationErr)
+
+	service.maxBytes = 10
+	_, err = service.UploadAvatar(ctx, upload.Bytes())
+	assert.ErrorAs(t, err, &validationErr)
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/config"
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/repositories"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/storage"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/utils"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/validators"
+)
+
+// mimeSniffBytes is how much of an upload is read to detect its type.
+const mimeSniffBytes = 3072
+
+type IncidentAttachmentService interface {
+	UploadAttachment(ctx context.Context, upload *models.AttachmentUpload) (*models.IncidentAttachment, error)
+	GetAttachments(incidentID int) ([]*models.IncidentAttachment, error)
+	OpenAttachment(ctx context.Context, incidentID, attachmentID int) (*models.IncidentAttachment, io.ReadCloser, error)
+	DeleteAttachment(ctx context.Context, incidentID, attachmentID int) error
+	MaxBytes() int
+}
+
+type incidentAttachmentService struct {
+	incidentRepository   repositories.IncidentRepository
+	attachmentRepository repositories.IncidentAttachmentRepository
+	blobStore            storage.BlobStore
+	maxBytes             int
+}
+
+func NewIncidentAttachmentService(incidentRepository repositories.IncidentRepository, attachmentRepository repositories.IncidentAttachmentRepository, blobStore storage.BlobStore) IncidentAttachmentService {
+	return &incidentAttachmentService{
+		incidentRepository:   incidentRepository,
+		attachmentRepository: attachmentRepository,
+		blobStore:            blobStore,
+		maxBytes:             config.GetConfig().AttachmentMaxBytes,
+	}
+}
+
+func (s *incidentAttachmentService) MaxBytes() int {
+	return s.maxBytes
+}
+
+// UploadAttachment streams the content into the blob store, detecting its
+// type and computing its checksum on the way, then records the metadata.
+func (s *incidentAttachmentService) UploadAttachment(ctx context.Context, upload *models.AttachmentUpload) (*models.IncidentAttachment, error) {
+	log.Printf("UploadAttachment: Starting upload of %q to incident ID %d", upload.Filename, upload.IncidentID)
+
+	uploaderID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if upload.Size > int64(s.maxBytes) {
+		return nil, &validators.ValidationError{Messages: []string{fmt.Sprintf("Attachments must be at most %d bytes", s.maxBytes)}}
+	}
+
+	filename := sanitizeFilename(upload.Filename)
+	if filename == "" {
+		return nil, &validators.ValidationError{Messages: []string{"Attachment must have a file name"}}
+	}
+
+	if _, err := s.incidentRepository.GetIncidentStatus(upload.IncidentID); err != nil {
+		log.Printf("UploadAttachment: Error retrieving incident: %v", err)
+		return nil, err
+	}
+
+	head := make([]byte, mimeSniffBytes)
+	n, err := io.ReadFull(upload.Content, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		log.Printf("UploadAttachment: Error reading upload: %v", err)
+		return nil, err
+	}
+	head = head[:n]
+
+	token, err := utils.GenerateToken(16)
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("incidents/%d/attachments/%s", upload.IncidentID, token)
+
+	hash := sha256.New()
+	counter := &countingWriter{}
+	content := io.TeeReader(io.LimitReader(io.MultiReader(bytes.NewReader(head), upload.Content), int64(s.maxBytes)+1), io.MultiWriter(hash, counter))
+
+	if err := s.blobStore.Put(ctx, key, content); err != nil {
+		log.Printf("UploadAttachment: Error storing content: %v", err)
+		return nil, err
+	}
+
+	// The announced size may lie; the limit is enforced on what was read
+	if counter.n > int64(s.maxBytes) {
+		s.deleteBlob(ctx, key)
+		return nil, &validators.ValidationError{Messages: []string{fmt.Sprintf("Attachments must be at most %d bytes", s.maxBytes)}}
+	}
+
+	attachment := &models.IncidentAttachment{
+		IncidentID: upload.IncidentID,
+		UploaderID: uploaderID,
+		Filename:   filename,
+		MimeType:   mimetype.Detect(head).String(),
+		Size:       counter.n,
+		Checksum:   hex.EncodeToString(hash.Sum(nil)),
+		StorageKey: key,
+	}
+
+	attachmentID, err := s.attachmentRepository.CreateAttachment(attachment)
+	if err != nil {
+		log.Printf("UploadAttachment: Error saving metadata: %v", err)
+		s.deleteBlob(ctx, key)
+		return nil, err
+	}
+
+	log.Printf("UploadAttachment: Attachment %d stored (%d bytes, %s)", attachmentID, attachment.Size, attachment.MimeType)
+	return s.attachmentRepository.GetAttachmentByID(upload.IncidentID, attachmentID)
+}
+
+func (s *incidentAttachmentService) GetAttachments(incidentID int) ([]*models.IncidentAttachment, error) {
+	log.Printf("GetAttachments: Starting retrieval for incident ID %d", incidentID)
+
+	if _, err := s.incidentRepository.GetIncidentStatus(incidentID); err != nil {
+		log.Printf("GetAttachments: Error retrieving incident: %v", err)
+		return nil, err
+	}
+
+	return s.attachmentRepository.GetAttachments(incidentID)
+}
+
+// OpenAttachment returns the metadata and content of an attachment; the
+// caller closes the content.
+func (s *incidentAttachmentService) OpenAttachment(ctx context.Context, incidentID, attachmentID int) (*models.IncidentAttachment, io.ReadCloser, error) {
+	attachment, err := s.attachmentRepository.GetAttachmentByID(incidentID, attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := s.blobStore.Get(ctx, attachment.StorageKey)
+	if errors.Is(err, storage.ErrBlobNotFound) {
+		log.Printf("OpenAttachment: Content of attachment %d is missing", attachmentID)
+		return nil, nil, &customErrors.NotFoundError{Msg: fmt.Sprintf("content of attachment %d is missing", attachmentID)}
+	}
+	if err != nil {
+		log.Printf("OpenAttachment: Error reading attachment %d: %v", attachmentID, err)
+		return nil, nil, err
+	}
+
+	return attachment, content, nil
+}
+
+// DeleteAttachment is allowed to the uploader and to incident managers.
+func (s *incidentAttachmentService) DeleteAttachment(ctx context.Context, incidentID, attachmentID int) error {
+	log.Printf("DeleteAttachment: Starting delete process for attachment %d", attachmentID)
+
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	attachment, err := s.attachmentRepository.GetAttachmentByID(incidentID, attachmentID)
+	if err != nil {
+		return err
+	}
+
+	if attachment.UploaderID != actorID && !actorCan(ctx, models.PermissionIncidentsManage) {
+		return &customErrors.ForbiddenError{Msg: "only the uploader or an incident manager can delete this attachment"}
+	}
+
+	if err := s.attachmentRepository.DeleteAttachment(attachment, actorID); err != nil {
+		log.Printf("DeleteAttachment: Error deleting attachment: %v", err)
+		return err
+	}
+
+	// The metadata is gone, so a leftover blob is only wasted space
+	s.deleteBlob(ctx, attachment.StorageKey)
+
+	log.Printf("DeleteAttachment: Successfully deleted attachment %d", attachmentID)
+	return nil
+}
+
+func (s *incidentAttachmentService) deleteBlob(ctx context.Context, key string) {
+	if err := s.blobStore.Delete(ctx, key); err != nil {
+		log.Printf("deleteBlob: Error deleting %s: %v", key, err)
+	}
+}
+
+// sanitizeFilename keeps the base name of an uploaded file without control
+// characters, so it is safe to echo back in a Content-Disposition header.
+func sanitizeFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+
+	if name == "." || name == "/" {
+		return ""
+	}
+
+	if runes := []rune(name); len(runes) > 255 {
+		name = string(runes[:255])
+	}
+	return name
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
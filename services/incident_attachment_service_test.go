@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/repositories"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/storage"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/validators"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubStatusIncidentRepository knows a single incident; other methods are unused.
+type stubStatusIncidentRepository struct {
+	repositories.IncidentRepository
+	incidentID int
+}
+
+func (r *stubStatusIncidentRepository) GetIncidentStatus(id int) (string, error) {
+	if id != r.incidentID {
+		return "", &customErrors.NotFoundError{Msg: "incident not found"}
+	}
+	return "investigating", nil
+}
+
+// stubAttachmentRepository keeps attachments in memory.
+type stubAttachmentRepository struct {
+	attachments map[int]*models.IncidentAttachment
+}
+
+func (r *stubAttachmentRepository) CreateAttachment(attachment *models.IncidentAttachment) (int, error) {
+	attachment.ID = len(r.attachments) + 1
+	r.attachments[attachment.ID] = attachment
+	return attachment.ID, nil
+}
+
+func (r *stubAttachmentRepository) GetAttachments(incidentID int) ([]*models.IncidentAttachment, error) {
+	var attachments []*models.IncidentAttachment
+	for _, attachment := range r.attachments {
+		if attachment.IncidentID == incidentID {
+			attachments = append(attachments, attachment)
+		}
+	}
+	return attachments, nil
+}
+
+func (r *stubAttachmentRepository) GetAttachmentByID(incidentID, attachmentID int) (*models.IncidentAttachment, error) {
+	attachment, ok := r.attachments[attachmentID]
+	if !ok || attachment.IncidentID != incidentID {
+		return nil, &customErrors.NotFoundError{Msg: "attachment not found"}
+	}
+	return attachment, nil
+}
+
+func (r *stubAttachmentRepository) DeleteAttachment(attachment *models.IncidentAttachment, actorID int) error {
+	delete(r.attachments, attachment.ID)
+	return nil
+}
+
+func TestIncidentAttachments(t *testing.T) {
+	blobStore, err := storage.NewLocalBlobStore(t.TempDir())
+	require.NoError(t, err)
+
+	attachments := &stubAttachmentRepository{attachments: map[int]*models.IncidentAttachment{}}
+	service := &incidentAttachmentService{
+		incidentRepository:   &stubStatusIncidentRepository{incidentID: 3},
+		attachmentRepository: attachments,
+		blobStore:            blobStore,
+		maxBytes:             64,
+	}
+	uploader := context.WithValue(context.WithValue(context.Background(), "user_id", 7), "role", models.RoleResponder)
+
+	content := "%PDF-1.4\nstack trace"
+	attachment, err := service.UploadAttachment(uploader, &models.AttachmentUpload{
+		IncidentID: 3,
+		Filename:   `../../etc/"dump".pdf`,
+		Content:    strings.NewReader(content),
+	})
+	require.NoError(t, err)
+	sum := sha256.Sum256([]byte(content))
+	assert.Equal(t, hex.EncodeToString(sum[:]), attachment.Checksum)
+	assert.Equal(t, "application/pdf", attachment.MimeType, "the type comes from the content, not the name")
+	assert.Equal(t, "dump.pdf", attachment.Filename)
+	assert.Equal(t, int64(len(content)), attachment.Size)
+
+	_, stored, err := service.OpenAttachment(uploader, 3, attachment.ID)
+	require.NoError(t, err)
+	body, err := io.ReadAll(stored)
+	stored.Close()
+	require.NoError(t, err)
+	assert.Equal(t, content, string(body))
+
+	// The announced size is not trusted
+	var validationErr *validators.ValidationError
+	_, err = service.UploadAttachment(uploader, &models.AttachmentUpload{
+		IncidentID: 3,
+		Filename:   "big.log",
+		Size:       10,
+		Content:    strings.NewReader(strings.Repeat("x", 65)),
+	})
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Len(t, attachments.attachments, 1)
+
+	var forbidden *customErrors.ForbiddenError
+	other := context.WithValue(context.WithValue(context.Background(), "user_id", 8), "role", models.RoleResponder)
+	assert.ErrorAs(t, service.DeleteAttachment(other, 3, attachment.ID), &forbidden)
+
+	manager := context.WithValue(context.WithValue(context.Background(), "user_id", 9), "role", models.RoleIncidentManager)
+	second, err := service.UploadAttachment(uploader, &models.AttachmentUpload{IncidentID: 3, Filename: "notes.txt", Content: strings.NewReader("notes")})
+	require.NoError(t, err)
+	assert.NoError(t, service.DeleteAttachment(manager, 3, second.ID))
+
+	assert.NoError(t, service.DeleteAttachment(uploader, 3, attachment.ID))
+	_, err = blobStore.Get(context.Background(), attachment.StorageKey)
+	assert.ErrorIs(t, err, storage.ErrBlobNotFound)
+}
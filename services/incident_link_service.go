@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/repositories"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/validators"
+)
+
+type IncidentLinkService interface {
+	CreateIncidentLink(ctx context.Context, input *models.IncidentLinkInput) (*models.IncidentLink, error)
+	GetIncidentLinks(incidentID int) ([]*models.LinkedIncident, error)
+	DeleteIncidentLink(ctx context.Context, incidentID, linkID int) error
+}
+
+type incidentLinkService struct {
+	incidentRepository     repositories.IncidentRepository
+	incidentLinkRepository repositories.IncidentLinkRepository
+}
+
+func NewIncidentLinkService(incidentRepository repositories.IncidentRepository, incidentLinkRepository repositories.IncidentLinkRepository) IncidentLinkService {
+	return &incidentLinkService{
+		incidentRepository:     incidentRepository,
+		incidentLinkRepository: incidentLinkRepository,
+	}
+}
+
+// CreateIncidentLink links two incidents. The link returned is in the
+// direction it is stored, which may be the inverse of the one requested.
+func (s *incidentLinkService) CreateIncidentLink(ctx context.Context, input *models.IncidentLinkInput) (*models.IncidentLink, error) {
+	log.Printf("CreateIncidentLink: Starting creation process for incident ID %d", input.IncidentID)
+
+	if err := validators.ValidateStruct(input); err != nil {
+		log.Printf("CreateIncidentLink: Validation error: %v", err)
+		return nil, &validators.ValidationError{Err: err}
+	}
+
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, incidentID := range []int{input.IncidentID, input.LinkedIncidentID} {
+		if _, err := s.incidentRepository.GetIncidentStatus(incidentID); err != nil {
+			log.Printf("CreateIncidentLink: Error retrieving incident: %v", err)
+			return nil, err
+		}
+	}
+
+	link := storedIncidentLink(input)
+	link.CreatedBy = actorID
+
+	link.ID, err = s.incidentLinkRepository.CreateIncidentLink(link)
+	if err != nil {
+		log.Printf("CreateIncidentLink: Error creating link: %v", err)
+		return nil, err
+	}
+
+	log.Printf("CreateIncidentLink: Link created with ID %d", link.ID)
+	return link, nil
+}
+
+// storedIncidentLink turns a requested link into the direction it is stored
+// in: inverse kinds swap sides, and related links put the lower ID first.
+func storedIncidentLink(input *models.IncidentLinkInput) *models.IncidentLink {
+	link := &models.IncidentLink{IncidentID: input.IncidentID, LinkedIncidentID: input.LinkedIncidentID, Kind: input.Kind}
+
+	switch input.Kind {
+	case models.IncidentLinkParentOf, models.IncidentLinkDuplicatedBy, models.IncidentLinkCauses:
+		link.IncidentID, link.LinkedIncidentID = input.LinkedIncidentID, input.IncidentID
+		link.Kind = models.InverseIncidentLinkKind(input.Kind)
+	case models.IncidentLinkRelated:
+		if link.IncidentID > link.LinkedIncidentID {
+			link.IncidentID, link.LinkedIncidentID = link.LinkedIncidentID, link.IncidentID
+		}
+	}
+
+	return link
+}
+
+func (s *incidentLinkService) GetIncidentLinks(incidentID int) ([]*models.LinkedIncident, error) {
+	log.Printf("GetIncidentLinks: Starting retrieval for incident ID %d", incidentID)
+
+	if _, err := s.incidentRepository.GetIncidentStatus(incidentID); err != nil {
+		log.Printf("GetIncidentLinks: Error retrieving incident: %v", err)
+		return nil, err
+	}
+
+	links, err := s.incidentLinkRepository.GetIncidentLinks(incidentID)
+	if err != nil {
+		log.Printf("GetIncidentLinks: Error retrieving links: %v", err)
+		return nil, err
+	}
+
+	return links, nil
+}
+
+func (s *incidentLinkService) DeleteIncidentLink(ctx context.Context, incidentID, linkID int) error {
+	log.Printf("DeleteIncidentLink: Starting deletion of link %d of incident ID %d", linkID, incidentID)
+
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	link, err := s.incidentLinkRepository.GetIncidentLinkByID(incidentID, linkID)
+	if err != nil {
+		log.Printf("DeleteIncidentLink: Error retrieving link: %v", err)
+		return err
+	}
+
+	if err := s.incidentLinkRepository.DeleteIncidentLink(link, actorID); err != nil {
+		log.Printf("DeleteIncidentLink: Error deleting link: %v", err)
+		return err
+	}
+
+	log.Printf("DeleteIncidentLink: Successfully deleted link %d", linkID)
+	return nil
+}
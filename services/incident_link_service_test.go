@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/repositories"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/validators"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubIncidentLinkRepository records the links it is asked to save.
+type stubIncidentLinkRepository struct {
+	repositories.IncidentLinkRepository
+	created []*models.IncidentLink
+}
+
+func (r *stubIncidentLinkRepository) CreateIncidentLink(link *models.IncidentLink) (int, error) {
+	r.created = append(r.created, link)
+	return len(r.created), nil
+}
+
+func TestStoredIncidentLink(t *testing.T) {
+	tests := []struct {
+		kind     string
+		expected models.IncidentLink
+	}{
+		{models.IncidentLinkChildOf, models.IncidentLink{IncidentID: 5, LinkedIncidentID: 2, Kind: models.IncidentLinkChildOf}},
+		{models.IncidentLinkParentOf, models.IncidentLink{IncidentID: 2, LinkedIncidentID: 5, Kind: models.IncidentLinkChildOf}},
+		{models.IncidentLinkDuplicatedBy, models.IncidentLink{IncidentID: 2, LinkedIncidentID: 5, Kind: models.IncidentLinkDuplicateOf}},
+		{models.IncidentLinkCauses, models.IncidentLink{IncidentID: 2, LinkedIncidentID: 5, Kind: models.IncidentLinkCausedBy}},
+		{models.IncidentLinkRelated, models.IncidentLink{IncidentID: 2, LinkedIncidentID: 5, Kind: models.IncidentLinkRelated}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			link := storedIncidentLink(&models.IncidentLinkInput{IncidentID: 5, LinkedIncidentID: 2, Kind: tt.kind})
+			assert.Equal(t, tt.expected, *link)
+		})
+	}
+}
+
+func TestCreateIncidentLink(t *testing.T) {
+	links := &stubIncidentLinkRepository{}
+	service := &incidentLinkService{
+		incidentRepository:     &stubStatusIncidentRepository{incidentID: 5},
+		incidentLinkRepository: links,
+	}
+	ctx := context.WithValue(context.Background(), "user_id", 3)
+
+	t.Run("incidents cannot link to themselves", func(t *testing.T) {
+		_, err := service.CreateIncidentLink(ctx, &models.IncidentLinkInput{IncidentID: 5, LinkedIncidentID: 5, Kind: models.IncidentLinkRelated})
+
+		var validation *validators.ValidationError
+		assert.ErrorAs(t, err, &validation)
+	})
+
+	t.Run("unknown kinds are rejected", func(t *testing.T) {
+		_, err := service.CreateIncidentLink(ctx, &models.IncidentLinkInput{IncidentID: 5, LinkedIncidentID: 6, Kind: "blocks"})
+
+		var validation *validators.ValidationError
+		assert.ErrorAs(t, err, &validation)
+	})
+
+	t.Run("both incidents must exist", func(t *testing.T) {
+		_, err := service.CreateIncidentLink(ctx, &models.IncidentLinkInput{IncidentID: 5, LinkedIncidentID: 6, Kind: models.IncidentLinkRelated})
+
+		var notFound *customErrors.NotFoundError
+		assert.ErrorAs(t, err, &notFound)
+		assert.Empty(t, links.created)
+	})
+
+	t.Run("links are saved in their stored direction", func(t *testing.T) {
+		service.incidentRepository = &stubTransitionIncidentRepository{status: "Investigating"}
+
+		link, err := service.CreateIncidentLink(ctx, &models.IncidentLinkInput{IncidentID: 5, LinkedIncidentID: 6, Kind: models.IncidentLinkParentOf})
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, link.ID)
+		assert.Equal(t, 6, link.IncidentID)
+		assert.Equal(t, models.IncidentLinkChildOf, link.Kind)
+		assert.Equal(t, 3, link.CreatedBy)
+	})
+}
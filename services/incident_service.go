@@ -4,6 +4,7 @@ import (
 	"context"
 	"log"
 
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
 	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
 	"github.com/pamateus-henrique/infinitepay-firewatchers-api/repositories"
 	"github.com/pamateus-henrique/infinitepay-firewatchers-api/validators"
@@ -11,41 +12,106 @@ import (
 
 type IncidentService interface {
 	CreateIncident(ctx context.Context, incidentInput *models.IncidentInput) (int, error)
-	GetIncidents(queryParams *models.IncidentQueryParams) ([]*models.IncidentOverviewOutput, error)
+	CreateAlertIncident(ctx context.Context, incidentInput *models.IncidentInput, alerts []*models.Alert) (int, error)
+	GetIncidents(queryParams *models.IncidentQueryParams) ([]*models.IncidentOverviewOutput, *models.Pagination, error)
 	GetSingleIncident(incidentID int) (*models.IncidentOutput, error)
-	UpdateIncidentSummary(incidentSummary *models.IncidentSummary) error
-	UpdateIncidentStatus(IncidentStatus *models.IncidentStatus) error
-	UpdateIncidentSeverity(incidentSeverity *models.IncidentSeverity) error
-	UpdateIncidentType(incidentType *models.IncidentType) error
-	UpdateIncidentRoles(incidentRoles *models.IncidentRoles) error
-	UpdateIncidentCustomFields(incident *models.IncidentCustomFieldsUpdate) error
+	UpdateIncidentSummary(ctx context.Context, incidentSummary *models.IncidentSummary) error
+	UpdateIncidentStatus(ctx context.Context, IncidentStatus *models.IncidentStatus) error
+	UpdateIncidentSeverity(ctx context.Context, incidentSeverity *models.IncidentSeverity) error
+	UpdateIncidentType(ctx context.Context, incidentType *models.IncidentType) error
+	UpdateIncidentRoles(ctx context.Context, incidentRoles *models.IncidentRoles) error
+	UpdateIncidentCustomFields(ctx context.Context, incident *models.IncidentCustomFieldsUpdate) error
+	GetIncidentTimeline(incidentID int) ([]*models.IncidentEvent, error)
+	GetTriageQueue() ([]*models.TriageQueueItem, error)
+	AcceptIncident(ctx context.Context, accept *models.IncidentAccept) error
+	DeclineIncident(ctx context.Context, decline *models.IncidentDecline) error
+	MergeIncident(ctx context.Context, merge *models.IncidentMerge) error
 }
 
 type incidentService struct {
-	incidentRepository repositories.IncidentRepository
+	incidentRepository      repositories.IncidentRepository
+	incidentEventRepository repositories.IncidentEventRepository
+	optionsService          OptionsService
+	webhooks                WebhookPublisher
 }
 
-func NewIncidentService(incidentRepository repositories.IncidentRepository) IncidentService {
-	return &incidentService{incidentRepository: incidentRepository}
+func NewIncidentService(incidentRepository repositories.IncidentRepository, incidentEventRepository repositories.IncidentEventRepository, optionsService OptionsService, webhooks WebhookPublisher) IncidentService {
+	return &incidentService{incidentRepository: incidentRepository, incidentEventRepository: incidentEventRepository, optionsService: optionsService, webhooks: webhooks}
+}
+
+// actorFromContext returns the authenticated user attached by the JWT middleware.
+func actorFromContext(ctx context.Context) (int, error) {
+	userID, ok := ctx.Value("user_id").(int)
+	if !ok {
+		return 0, &customErrors.AuthenticationError{Msg: "missing authenticated user"}
+	}
+	return userID, nil
+}
+
+// actorCan reports whether the caller holds a permission, honoring the
+// scopes of an API key the same way the RBAC middleware does.
+func actorCan(ctx context.Context, permission string) bool {
+	role, _ := ctx.Value("role").(string)
+	if !models.HasPermission(role, permission) {
+		return false
+	}
+
+	if scopes, viaAPIKey := ctx.Value("scopes").(models.Scopes); viaAPIKey {
+		return scopes.Contains(permission)
+	}
+	return true
 }
 
 func (s *incidentService) CreateIncident(ctx context.Context, incidentInput *models.IncidentInput) (int, error) {
-	userID := ctx.Value("user_id").(int)
+	return s.createIncident(ctx, incidentInput, nil)
+}
+
+// CreateAlertIncident creates an incident together with the alerts that
+// opened it. It fails with a ConflictError when an open incident already
+// tracks one of the alerts.
+func (s *incidentService) CreateAlertIncident(ctx context.Context, incidentInput *models.IncidentInput, alerts []*models.Alert) (int, error) {
+	return s.createIncident(ctx, incidentInput, alerts)
+}
 
+func (s *incidentService) createIncident(ctx context.Context, incidentInput *models.IncidentInput, alerts []*models.Alert) (int, error) {
 	log.Println("CreateIncident: Starting incident creation process")
 
+	userID, err := actorFromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
 	if err := validators.ValidateStruct(incidentInput); err != nil {
 		log.Printf("CreateIncident: Validation error: %v", err)
 		return 0, &validators.ValidationError{Err: err}
 	}
 
+	err = s.validateReferences(
+		nameReference("Type", "types", &incidentInput.Type),
+		nameReference("Severity", "severity", &incidentInput.Severity),
+		nameReference("Status", "status", &incidentInput.Status),
+		nameReference("Source", "sources", incidentInput.Source),
+		idReference("Products", "products", incidentInput.Products),
+		idReference("Areas", "areas", incidentInput.Areas),
+		idReference("Indicators", "performance-indicators", incidentInput.Indicators),
+	)
+	if err != nil {
+		log.Printf("CreateIncident: Reference validation error: %v", err)
+		return 0, err
+	}
+
 	//adding auto filled data
 	incidentInput.Reporter = userID
 	incidentInput.ReportedAt = models.NewCustomTimeNow()
 
 
 	log.Println("CreateIncident: Validation passed, creating incident")
-	incidentID, err := s.incidentRepository.CreateIncident(incidentInput)
+	var incidentID int
+	if len(alerts) > 0 {
+		incidentID, err = s.incidentRepository.CreateAlertIncident(incidentInput, alerts, terminalStatuses)
+	} else {
+		incidentID, err = s.incidentRepository.CreateIncident(incidentInput)
+	}
 
 	if err != nil {
 		log.Printf("CreateIncident: Error creating incident: %v", err)
@@ -53,27 +119,35 @@ func (s *incidentService) CreateIncident(ctx context.Context, incidentInput *mod
 	}
 
 	log.Printf("CreateIncident: Incident created successfully with ID: %d", incidentID)
+	s.publish(ctx, models.WebhookIncidentCreated, incidentID, nil)
 	return incidentID, nil
 }
 
-func (s *incidentService) GetIncidents(queryParams *models.IncidentQueryParams) ([]*models.IncidentOverviewOutput, error) {
+func (s *incidentService) GetIncidents(queryParams *models.IncidentQueryParams) ([]*models.IncidentOverviewOutput, *models.Pagination, error) {
 	log.Println("GetIncidents: Starting incidents retrieval process")
 
 	if err := validators.ValidateStruct(queryParams); err != nil {
 		log.Printf("GetIncidents: Validation error: %v", err)
-		return nil, &validators.ValidationError{Err: err}
+		return nil, nil, &validators.ValidationError{Err: err}
 	}
-	
+
+	if queryParams.Page == 0 {
+		queryParams.Page = 1
+	}
+	if queryParams.Limit == 0 {
+		queryParams.Limit = models.DefaultPageLimit
+	}
+
 	log.Println("GetIncidents: Validation passed, retrieving incidents")
-	incidents, err := s.incidentRepository.GetIncidents(queryParams)
+	incidents, total, err := s.incidentRepository.GetIncidents(queryParams)
 
 	if err != nil {
 		log.Printf("GetIncidents: Error retrieving incidents: %v", err)
-		return nil, err
+		return nil, nil, err
 	}
 
 	log.Printf("GetIncidents: Successfully retrieved %d incidents", len(incidents))
-	return incidents, err
+	return incidents, models.NewPagination(queryParams.Page, queryParams.Limit, total), nil
 }
 
 
@@ -91,7 +165,7 @@ func (s *incidentService) GetSingleIncident(incidentID int) (*models.IncidentOut
 }
 
 
-func (s *incidentService) UpdateIncidentSummary(incidentSummary *models.IncidentSummary) error {
+func (s *incidentService) UpdateIncidentSummary(ctx context.Context, incidentSummary *models.IncidentSummary) error {
 	log.Printf("UpdateIncidentSummary: Starting update process for incident ID %d", incidentSummary.ID)
 
 	if err := validators.ValidateStruct(incidentSummary); err != nil {
@@ -99,7 +173,12 @@ func (s *incidentService) UpdateIncidentSummary(incidentSummary *models.Incident
 		return &validators.ValidationError{Err: err}
 	}
 
-	err := s.incidentRepository.UpdateIncidentSummary(incidentSummary)
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = s.incidentRepository.UpdateIncidentSummary(incidentSummary, actorID)
 	if err != nil {
 		log.Printf("UpdateIncidentSummary: Error updating incident summary: %v", err)
 		return err
@@ -110,27 +189,57 @@ func (s *incidentService) UpdateIncidentSummary(incidentSummary *models.Incident
 }
 
 
-func (s *incidentService) UpdateIncidentStatus(IncidentStatus *models.IncidentStatus) error {
+func (s *incidentService) UpdateIncidentStatus(ctx context.Context, IncidentStatus *models.IncidentStatus) error {
 	log.Printf("UpdateIncidentStatus: Starting update process for incident ID %d", IncidentStatus.ID)
 
-
-
 	if err := validators.ValidateStruct(IncidentStatus); err != nil {
 		log.Printf("UpdateIncidentStatus: Validation error: %v", err)
 		return &validators.ValidationError{Err: err}
 	}
 
-	err := s.incidentRepository.UpdateIncidentStatus(IncidentStatus)
+	if err := s.validateReferences(nameReference("Status", "status", &IncidentStatus.Status)); err != nil {
+		log.Printf("UpdateIncidentStatus: Reference validation error: %v", err)
+		return err
+	}
+
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	current, err := s.incidentRepository.GetIncidentStatus(IncidentStatus.ID)
+	if err != nil {
+		log.Printf("UpdateIncidentStatus: Error retrieving current status: %v", err)
+		return err
+	}
+
+	timestamps, err := transitionTimestamps(current, IncidentStatus.Status)
 	if err != nil {
-		log.Printf("UpdateIncidentSummary: Error updating incident status: %v", err)
+		log.Printf("UpdateIncidentStatus: Rejected transition: %v", err)
 		return err
 	}
 
-	log.Printf("UpdateIncidentSummary: Successfully updated status for incident ID %d", IncidentStatus.ID)
+	transition := &models.IncidentStatusTransition{
+		ID:                       IncidentStatus.ID,
+		From:                     current,
+		To:                       IncidentStatus.Status,
+		Timestamps:               timestamps,
+		At:                       models.NewCustomTimeNow(),
+		CheckBlockingActionItems: normalizeStatus(IncidentStatus.Status) == StatusClosed,
+	}
+
+	err = s.incidentRepository.UpdateIncidentStatus(transition, actorID)
+	if err != nil {
+		log.Printf("UpdateIncidentStatus: Error updating incident status: %v", err)
+		return err
+	}
+
+	log.Printf("UpdateIncidentStatus: Successfully updated status for incident ID %d", IncidentStatus.ID)
+	s.publish(ctx, models.WebhookIncidentStatusChanged, IncidentStatus.ID, map[string]interface{}{"status": current})
 	return nil
 }
 
-func (s *incidentService) UpdateIncidentSeverity(incidentSeverity *models.IncidentSeverity) error {
+func (s *incidentService) UpdateIncidentSeverity(ctx context.Context, incidentSeverity *models.IncidentSeverity) error {
 	log.Printf("UpdateIncidentSeverity: Starting update process for incident ID %d", incidentSeverity.ID)
 
 	if err := validators.ValidateStruct(incidentSeverity); err != nil {
@@ -138,17 +247,32 @@ func (s *incidentService) UpdateIncidentSeverity(incidentSeverity *models.Incide
 		return &validators.ValidationError{Err: err}
 	}
 
-	err := s.incidentRepository.UpdateIncidentSeverity(incidentSeverity)
+	if err := s.validateReferences(nameReference("Severity", "severity", &incidentSeverity.Severity)); err != nil {
+		log.Printf("UpdateIncidentSeverity: Reference validation error: %v", err)
+		return err
+	}
+
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	previous := s.snapshot(incidentSeverity.ID)
+
+	err = s.incidentRepository.UpdateIncidentSeverity(incidentSeverity, actorID)
 	if err != nil {
 		log.Printf("UpdateIncidentSeverity: Error updating incident severity: %v", err)
 		return err
 	}
 
 	log.Printf("UpdateIncidentSeverity: Successfully updated severity for incident ID %d", incidentSeverity.ID)
+	if previous != nil && previous.Severity != incidentSeverity.Severity {
+		s.publish(ctx, models.WebhookIncidentSeverityChanged, incidentSeverity.ID, map[string]interface{}{"severity": previous.Severity})
+	}
 	return nil
 }
 
-func (s *incidentService) UpdateIncidentType(incidentType *models.IncidentType) error {
+func (s *incidentService) UpdateIncidentType(ctx context.Context, incidentType *models.IncidentType) error {
 	log.Printf("UpdateIncidentType: Updating type for incident ID %v", incidentType)
 
 	if err := validators.ValidateStruct(incidentType); err != nil {
@@ -156,7 +280,17 @@ func (s *incidentService) UpdateIncidentType(incidentType *models.IncidentType)
 		return &validators.ValidationError{Err: err}
 	}
 
-	err := s.incidentRepository.UpdateIncidentType(incidentType)
+	if err := s.validateReferences(nameReference("Type", "types", &incidentType.Type)); err != nil {
+		log.Printf("UpdateIncidentType: Reference validation error: %v", err)
+		return err
+	}
+
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = s.incidentRepository.UpdateIncidentType(incidentType, actorID)
 	if err != nil {
 		log.Printf("UpdateIncidentType: Error updating incident type: %v", err)
 		return err
@@ -166,7 +300,7 @@ func (s *incidentService) UpdateIncidentType(incidentType *models.IncidentType)
 	return nil
 }
 
-func (s *incidentService) UpdateIncidentRoles(incidentRoles *models.IncidentRoles) error {
+func (s *incidentService) UpdateIncidentRoles(ctx context.Context, incidentRoles *models.IncidentRoles) error {
 	log.Printf("UpdateIncidentRoles: Starting update process for incident ID %d", incidentRoles.ID)
 
 	if err := validators.ValidateStruct(incidentRoles); err != nil {
@@ -174,17 +308,30 @@ func (s *incidentService) UpdateIncidentRoles(incidentRoles *models.IncidentRole
 		return &validators.ValidationError{Err: err}
 	}
 
-	err := s.incidentRepository.UpdateIncidentRoles(incidentRoles)
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	var previous *models.IncidentOutput
+	if incidentRoles.Lead != nil {
+		previous = s.snapshot(incidentRoles.ID)
+	}
+
+	err = s.incidentRepository.UpdateIncidentRoles(incidentRoles, actorID)
 	if err != nil {
 		log.Printf("UpdateIncidentRoles: Error updating incident roles: %v", err)
 		return err
 	}
 
 	log.Printf("UpdateIncidentRoles: Successfully updated roles for incident ID %d", incidentRoles.ID)
+	if previous != nil && (previous.Lead == nil || *previous.Lead != *incidentRoles.Lead) {
+		s.publish(ctx, models.WebhookIncidentLeadAssigned, incidentRoles.ID, map[string]interface{}{"lead": previous.Lead})
+	}
 	return nil
 }
 
-func (s *incidentService) UpdateIncidentCustomFields(incident *models.IncidentCustomFieldsUpdate) error {
+func (s *incidentService) UpdateIncidentCustomFields(ctx context.Context, incident *models.IncidentCustomFieldsUpdate) error {
 	log.Printf("UpdateIncidentCustomFields: Starting update process for incident ID %d", incident.ID)
 
 	if err := validators.ValidateStruct(incident); err != nil {
@@ -192,7 +339,23 @@ func (s *incidentService) UpdateIncidentCustomFields(incident *models.IncidentCu
 		return &validators.ValidationError{Err: err}
 	}
 
-	err := s.incidentRepository.UpdateIncidentCustomFields(incident)
+	if err := s.validateReferences(
+		idReference("Products", "products", incident.Products),
+		idReference("Areas", "areas", incident.Areas),
+		idReference("Causes", "causes", incident.Causes),
+		idReference("FaultySystems", "faulty-systems", incident.FaultySystems),
+		idReference("PerformanceIndicators", "performance-indicators", incident.PerformanceIndicators),
+	); err != nil {
+		log.Printf("UpdateIncidentCustomFields: Reference validation error: %v", err)
+		return err
+	}
+
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = s.incidentRepository.UpdateIncidentCustomFields(incident, actorID)
 	if err != nil {
 		log.Printf("UpdateIncidentCustomFields: Error updating incident custom fields: %v", err)
 		return err
@@ -201,3 +364,63 @@ func (s *incidentService) UpdateIncidentCustomFields(incident *models.IncidentCu
 	log.Printf("UpdateIncidentCustomFields: Successfully updated custom fields for incident ID %d", incident.ID)
 	return nil
 }
+
+func (s *incidentService) GetIncidentTimeline(incidentID int) ([]*models.IncidentEvent, error) {
+	log.Printf("GetIncidentTimeline: Starting timeline retrieval for incident ID %d", incidentID)
+
+	// Distinguish an unknown incident from one without history.
+	if _, err := s.incidentRepository.GetIncidentStatus(incidentID); err != nil {
+		log.Printf("GetIncidentTimeline: Error retrieving incident: %v", err)
+		return nil, err
+	}
+
+	events, err := s.incidentEventRepository.GetIncidentTimeline(incidentID)
+	if err != nil {
+		log.Printf("GetIncidentTimeline: Error retrieving timeline: %v", err)
+		return nil, err
+	}
+
+	log.Printf("GetIncidentTimeline: Successfully retrieved %d events for incident ID %d", len(events), incidentID)
+	return events, nil
+}
+
+// snapshot returns the incident as it is before a change that webhooks
+// report, or nil when there is nobody to report it to.
+func (s *incidentService) snapshot(incidentID int) *models.IncidentOutput {
+	if s.webhooks == nil {
+		return nil
+	}
+
+	incident, err := s.incidentRepository.GetIncidentByID(incidentID)
+	if err != nil {
+		log.Printf("snapshot: Error retrieving incident ID %d: %v", incidentID, err)
+		return nil
+	}
+	return incident
+}
+
+func (s *incidentService) publish(ctx context.Context, eventType string, incidentID int, previous map[string]interface{}) {
+	publishIncidentEvent(ctx, s.webhooks, s.incidentRepository, eventType, incidentID, previous)
+}
+
+// publishIncidentEvent reports a saved change to webhook subscribers along
+// with the incident as it is now. previous holds the values the change
+// replaced.
+func publishIncidentEvent(ctx context.Context, webhooks WebhookPublisher, incidentRepository repositories.IncidentRepository, eventType string, incidentID int, previous map[string]interface{}) {
+	if webhooks == nil {
+		return
+	}
+
+	incident, err := incidentRepository.GetIncidentByID(incidentID)
+	if err != nil {
+		log.Printf("publish: Error retrieving incident ID %d for %s: %v", incidentID, eventType, err)
+		return
+	}
+
+	data := &models.IncidentWebhookData{Incident: incident, Previous: previous}
+	if actorID, err := actorFromContext(ctx); err == nil {
+		data.ActorID = &actorID
+	}
+
+	webhooks.Publish(eventType, data)
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/repositories"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/validators"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubListIncidentRepository reports a fixed total and keeps the last query.
+type stubListIncidentRepository struct {
+	repositories.IncidentRepository
+	total  int
+	params *models.IncidentQueryParams
+}
+
+func (r *stubListIncidentRepository) GetIncidents(queryParams *models.IncidentQueryParams) ([]*models.IncidentOverviewOutput, int, error) {
+	r.params = queryParams
+	return []*models.IncidentOverviewOutput{}, r.total, nil
+}
+
+func TestGetIncidentsPagination(t *testing.T) {
+	t.Run("defaults to the first page", func(t *testing.T) {
+		incidents := &stubListIncidentRepository{total: 45}
+		service := &incidentService{incidentRepository: incidents}
+
+		_, pagination, err := service.GetIncidents(&models.IncidentQueryParams{})
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, incidents.params.Page)
+		assert.Equal(t, models.DefaultPageLimit, incidents.params.Limit)
+		assert.Equal(t, 3, pagination.TotalPages)
+		assert.Nil(t, pagination.Prev)
+		require.NotNil(t, pagination.Next)
+		assert.Equal(t, 2, *pagination.Next)
+	})
+
+	t.Run("the last page has no next page", func(t *testing.T) {
+		incidents := &stubListIncidentRepository{total: 45}
+		service := &incidentService{incidentRepository: incidents}
+
+		_, pagination, err := service.GetIncidents(&models.IncidentQueryParams{Page: 3, Limit: 20})
+		require.NoError(t, err)
+
+		assert.Equal(t, 40, incidents.params.Offset())
+		assert.Nil(t, pagination.Next)
+		require.NotNil(t, pagination.Prev)
+		assert.Equal(t, 2, *pagination.Prev)
+	})
+
+	invalid := map[string]*models.IncidentQueryParams{
+		"unknown sort":    {Sort: "title"},
+		"unknown order":   {Order: "sideways"},
+		"limit too large": {Limit: models.MaxPageLimit + 1},
+		"negative page":   {Page: -1},
+	}
+	for name, params := range invalid {
+		t.Run(name, func(t *testing.T) {
+			incidents := &stubListIncidentRepository{}
+			service := &incidentService{incidentRepository: incidents}
+
+			var validation *validators.ValidationError
+			_, _, err := service.GetIncidents(params)
+			assert.ErrorAs(t, err, &validation)
+			assert.Nil(t, incidents.params, "invalid queries never reach the database")
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"strings"
+
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+)
+
+// Incident statuses, compared case-insensitively against the names stored in
+// the statuses table.
+const (
+	StatusTriage        = "triage"
+	StatusInvestigating = "investigating"
+	StatusFixing        = "fixing"
+	StatusMonitoring    = "monitoring"
+	StatusCleaningUp    = "cleaning up"
+	StatusResolved      = "resolved"
+	StatusDocumentation = "documentation"
+	StatusInReview      = "in review"
+	StatusClosed        = "closed"
+	StatusCanceled      = "canceled"
+	StatusDeclined      = "declined"
+	StatusMerged        = "merged"
+)
+
+// statusTransitions is the incident lifecycle graph: each status lists the
+// statuses it may move to. Statuses without an entry are terminal.
+var statusTransitions = map[string][]string{
+	StatusTriage:        {StatusInvestigating, StatusDeclined, StatusMerged, StatusCanceled},
+	StatusInvestigating: {StatusFixing, StatusMonitoring, StatusResolved, StatusMerged, StatusCanceled},
+	StatusFixing:        {StatusInvestigating, StatusMonitoring, StatusResolved, StatusCanceled},
+	StatusMonitoring:    {StatusInvestigating, StatusFixing, StatusCleaningUp, StatusResolved},
+	StatusCleaningUp:    {StatusMonitoring, StatusResolved},
+	StatusResolved:      {StatusInvestigating, StatusDocumentation},
+	StatusDocumentation: {StatusInReview},
+	StatusInReview:      {StatusDocumentation, StatusClosed},
+}
+
+// terminalStatuses are the statuses an incident can no longer leave; every
+// other status counts as open.
+var terminalStatuses = []string{StatusClosed, StatusCanceled, StatusDeclined, StatusMerged}
+
+// enteredAtColumns is stamped when an incident enters the status.
+var enteredAtColumns = map[string]string{
+	StatusInvestigating: "investigating_at",
+	StatusFixing:        "fixing_at",
+	StatusMonitoring:    "monitoring_at",
+	StatusCleaningUp:    "cleaning_up_at",
+	StatusResolved:      "resolved_at",
+	StatusDocumentation: "documentation_at",
+	StatusInReview:      "in_review_at",
+	StatusClosed:        "closed_at",
+	StatusCanceled:      "canceled_at",
+	StatusDeclined:      "declined_at",
+	StatusMerged:        "merged_at",
+}
+
+// leftAtColumns is stamped when an incident leaves the status.
+var leftAtColumns = map[string]string{
+	StatusFixing:        "fixed_at",
+	StatusMonitoring:    "monitored_at",
+	StatusCleaningUp:    "cleaned_up_at",
+	StatusDocumentation: "documented_at",
+	StatusInReview:      "reviewed_at",
+}
+
+func normalizeStatus(status string) string {
+	return strings.ToLower(strings.TrimSpace(status))
+}
+
+// isKnownStatus reports whether the lifecycle graph knows the status.
+func isKnownStatus(status string) bool {
+	normalized := normalizeStatus(status)
+	_, ok := enteredAtColumns[normalized]
+	return ok || normalized == StatusTriage
+}
+
+// transitionTimestamps validates the move from one status to another and
+// returns the timestamp columns it must stamp.
+func transitionTimestamps(from, to string) ([]string, error) {
+	current, next := normalizeStatus(from), normalizeStatus(to)
+
+	allowed := false
+	for _, candidate := range statusTransitions[current] {
+		if candidate == next {
+			allowed = true
+			break
+		}
+	}
+
+	if !allowed {
+		return nil, &customErrors.InvalidTransitionError{From: from, To: to}
+	}
+
+	var columns []string
+	if column, ok := leftAtColumns[current]; ok {
+		columns = append(columns, column)
+	}
+	if column, ok := enteredAtColumns[next]; ok {
+		columns = append(columns, column)
+	}
+
+	return columns, nil
+}
+
+// planTransitions walks an incident from its current status through targets,
+// in order, for workflows that drive the lifecycle on the user's behalf. It
+// stops at the first move the graph does not allow, so an incident that is
+// still being worked on is left alone. statuses supplies the names as stored,
+// since incidents keep whatever casing the statuses table uses.
+func planTransitions(incidentID int, current string, statuses []*models.Option, at *models.CustomTime, targets ...string) []*models.IncidentStatusTransition {
+	names := make(map[string]string, len(statuses))
+	for _, status := range statuses {
+		names[normalizeStatus(status.Name)] = status.Name
+	}
+
+	var transitions []*models.IncidentStatusTransition
+	for _, target := range targets {
+		if normalizeStatus(current) == target {
+			continue
+		}
+
+		name, ok := names[target]
+		if !ok {
+			break
+		}
+
+		timestamps, err := transitionTimestamps(current, name)
+		if err != nil {
+			break
+		}
+
+		transitions = append(transitions, &models.IncidentStatusTransition{
+			ID:         incidentID,
+			From:       current,
+			To:         name,
+			Timestamps: timestamps,
+			At:         at,
+		})
+		current = name
+	}
+
+	return transitions
+}
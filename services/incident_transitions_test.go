@@ -0,0 +1,87 @@
+package services
+
+import (
+	"testing"
+
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransitionTimestamps(t *testing.T) {
+	tests := []struct {
+		name            string
+		from            string
+		to              string
+		expectedColumns []string
+		expectedErr     bool
+	}{
+		{
+			name:            "Triage To Investigating",
+			from:            "Triage",
+			to:              "Investigating",
+			expectedColumns: []string{"investigating_at"},
+		},
+		{
+			name:            "Fixing To Monitoring Stamps Both Ends",
+			from:            "Fixing",
+			to:              "Monitoring",
+			expectedColumns: []string{"fixed_at", "monitoring_at"},
+		},
+		{
+			name:            "In Review To Closed",
+			from:            "in review",
+			to:              "closed",
+			expectedColumns: []string{"reviewed_at", "closed_at"},
+		},
+		{
+			name:        "Skipping Documentation",
+			from:        "Resolved",
+			to:          "Closed",
+			expectedErr: true,
+		},
+		{
+			name:        "Leaving Terminal Status",
+			from:        "Closed",
+			to:          "Investigating",
+			expectedErr: true,
+		},
+		{
+			name:        "Unknown Status",
+			from:        "Investigating",
+			to:          "Exploded",
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns, err := transitionTimestamps(tt.from, tt.to)
+
+			if tt.expectedErr {
+				var transitionErr *customErrors.InvalidTransitionError
+				assert.ErrorAs(t, err, &transitionErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedColumns, columns)
+		})
+	}
+}
+
+func TestPlanTransitions(t *testing.T) {
+	statuses := []*models.Option{{Name: "Investigating"}, {Name: "Resolved"}, {Name: "Documentation"}, {Name: "In Review"}}
+
+	transitions := planTransitions(1, "Resolved", statuses, nil, StatusDocumentation, StatusInReview)
+	if assert.Len(t, transitions, 2) {
+		assert.Equal(t, "Resolved", transitions[0].From)
+		assert.Equal(t, "Documentation", transitions[0].To)
+		assert.Equal(t, "Documentation", transitions[1].From)
+		assert.Equal(t, "In Review", transitions[1].To)
+		assert.Equal(t, []string{"documented_at", "in_review_at"}, transitions[1].Timestamps)
+	}
+
+	assert.Len(t, planTransitions(1, "documentation", statuses, nil, StatusDocumentation, StatusInReview), 1, "statuses already reached are skipped")
+	assert.Empty(t, planTransitions(1, "Investigating", statuses, nil, StatusDocumentation), "open incidents are left alone")
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/validators"
+)
+
+func (s *incidentService) GetTriageQueue() ([]*models.TriageQueueItem, error) {
+	log.Println("GetTriageQueue: Starting triage queue retrieval")
+
+	queue, err := s.incidentRepository.GetTriageQueue(StatusTriage)
+	if err != nil {
+		log.Printf("GetTriageQueue: Error retrieving triage queue: %v", err)
+		return nil, err
+	}
+
+	log.Printf("GetTriageQueue: Successfully retrieved %d incidents", len(queue))
+	return queue, nil
+}
+
+// AcceptIncident confirms a triaged incident needs a response: it moves to
+// investigating under the given lead.
+func (s *incidentService) AcceptIncident(ctx context.Context, accept *models.IncidentAccept) error {
+	log.Printf("AcceptIncident: Starting accept process for incident ID %d", accept.ID)
+
+	if err := validators.ValidateStruct(accept); err != nil {
+		log.Printf("AcceptIncident: Validation error: %v", err)
+		return &validators.ValidationError{Err: err}
+	}
+
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Investigating is reachable from later statuses too, so only incidents
+	// still in triage can be accepted.
+	transition, err := s.triageTransition(accept.ID, StatusInvestigating, true)
+	if err != nil {
+		log.Printf("AcceptIncident: Rejected transition: %v", err)
+		return err
+	}
+	transition.Timestamps = append(transition.Timestamps, "accepted_at")
+
+	previous := s.snapshot(accept.ID)
+
+	if err := s.incidentRepository.AcceptIncident(transition, accept.Lead, actorID); err != nil {
+		log.Printf("AcceptIncident: Error accepting incident: %v", err)
+		return err
+	}
+
+	log.Printf("AcceptIncident: Successfully accepted incident ID %d", accept.ID)
+	s.publish(ctx, models.WebhookIncidentStatusChanged, accept.ID, map[string]interface{}{"status": transition.From})
+	if previous != nil && (previous.Lead == nil || *previous.Lead != accept.Lead) {
+		s.publish(ctx, models.WebhookIncidentLeadAssigned, accept.ID, map[string]interface{}{"lead": previous.Lead})
+	}
+	return nil
+}
+
+func (s *incidentService) DeclineIncident(ctx context.Context, decline *models.IncidentDecline) error {
+	log.Printf("DeclineIncident: Starting decline process for incident ID %d", decline.ID)
+
+	if err := validators.ValidateStruct(decline); err != nil {
+		log.Printf("DeclineIncident: Validation error: %v", err)
+		return &validators.ValidationError{Err: err}
+	}
+
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	transition, err := s.triageTransition(decline.ID, StatusDeclined, true)
+	if err != nil {
+		log.Printf("DeclineIncident: Rejected transition: %v", err)
+		return err
+	}
+
+	if err := s.incidentRepository.DeclineIncident(transition, decline.Reason, actorID); err != nil {
+		log.Printf("DeclineIncident: Error declining incident: %v", err)
+		return err
+	}
+
+	log.Printf("DeclineIncident: Successfully declined incident ID %d", decline.ID)
+	s.publish(ctx, models.WebhookIncidentStatusChanged, decline.ID, map[string]interface{}{"status": transition.From})
+	return nil
+}
+
+// MergeIncident folds a duplicate into another open incident. Incidents
+// already being investigated may be merged as well, as the lifecycle allows.
+func (s *incidentService) MergeIncident(ctx context.Context, merge *models.IncidentMerge) error {
+	log.Printf("MergeIncident: Starting merge of incident ID %d into %d", merge.ID, merge.Into)
+
+	if err := validators.ValidateStruct(merge); err != nil {
+		log.Printf("MergeIncident: Validation error: %v", err)
+		return &validators.ValidationError{Err: err}
+	}
+
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	transition, err := s.triageTransition(merge.ID, StatusMerged, false)
+	if err != nil {
+		log.Printf("MergeIncident: Rejected transition: %v", err)
+		return err
+	}
+
+	if err := s.incidentRepository.MergeIncident(transition, merge.Into, terminalStatuses, actorID); err != nil {
+		log.Printf("MergeIncident: Error merging incident: %v", err)
+		return err
+	}
+
+	log.Printf("MergeIncident: Successfully merged incident ID %d into %d", merge.ID, merge.Into)
+	s.publish(ctx, models.WebhookIncidentStatusChanged, merge.ID, map[string]interface{}{"status": transition.From})
+	return nil
+}
+
+// triageTransition plans the move of an incident to a triage outcome, using
+// the status name as stored.
+func (s *incidentService) triageTransition(incidentID int, target string, fromTriageOnly bool) (*models.IncidentStatusTransition, error) {
+	current, err := s.incidentRepository.GetIncidentStatus(incidentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if fromTriageOnly && normalizeStatus(current) != StatusTriage {
+		return nil, &customErrors.ConflictError{Msg: fmt.Sprintf("incident %d is not in triage", incidentID)}
+	}
+
+	statuses, err := s.optionsService.GetActiveOptions("status")
+	if err != nil {
+		return nil, err
+	}
+
+	transitions := planTransitions(incidentID, current, statuses, models.NewCustomTimeNow(), target)
+	if len(transitions) == 0 {
+		return nil, &customErrors.InvalidTransitionError{From: current, To: target}
+	}
+	return transitions[0], nil
+}
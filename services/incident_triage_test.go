@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/repositories"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/validators"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubTriageIncidentRepository records the triage outcome of one incident.
+type stubTriageIncidentRepository struct {
+	repositories.IncidentRepository
+	status     string
+	transition *models.IncidentStatusTransition
+	lead       int
+	into       int
+}
+
+func (r *stubTriageIncidentRepository) GetIncidentStatus(id int) (string, error) {
+	return r.status, nil
+}
+
+func (r *stubTriageIncidentRepository) AcceptIncident(transition *models.IncidentStatusTransition, lead, actorID int) error {
+	r.transition, r.lead = transition, lead
+	return nil
+}
+
+func (r *stubTriageIncidentRepository) MergeIncident(transition *models.IncidentStatusTransition, into int, closedStatuses []string, actorID int) error {
+	r.transition, r.into = transition, into
+	return nil
+}
+
+func TestTriageActions(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "user_id", 3)
+	newService := func(status string) (*incidentService, *stubTriageIncidentRepository) {
+		incidents := &stubTriageIncidentRepository{status: status}
+		return &incidentService{
+			incidentRepository: incidents,
+			optionsService: &stubOptionsService{active: map[string][]*models.Option{
+				"status": {{Name: "Triage"}, {Name: "Investigating"}, {Name: "Declined"}, {Name: "Merged"}},
+			}},
+		}, incidents
+	}
+
+	t.Run("accepting moves the incident to investigating", func(t *testing.T) {
+		service, incidents := newService("Triage")
+
+		require.NoError(t, service.AcceptIncident(ctx, &models.IncidentAccept{ID: 1, Lead: 9}))
+		assert.Equal(t, "Investigating", incidents.transition.To)
+		assert.Equal(t, []string{"investigating_at", "accepted_at"}, incidents.transition.Timestamps)
+		assert.Equal(t, 9, incidents.lead)
+	})
+
+	t.Run("only incidents in triage can be accepted", func(t *testing.T) {
+		service, incidents := newService("Fixing")
+
+		var conflict *customErrors.ConflictError
+		assert.ErrorAs(t, service.AcceptIncident(ctx, &models.IncidentAccept{ID: 1, Lead: 9}), &conflict)
+		assert.Nil(t, incidents.transition)
+	})
+
+	t.Run("declining needs a reason", func(t *testing.T) {
+		service, _ := newService("Triage")
+
+		var validation *validators.ValidationError
+		assert.ErrorAs(t, service.DeclineIncident(ctx, &models.IncidentDecline{ID: 1}), &validation)
+	})
+
+	t.Run("investigating incidents can be merged", func(t *testing.T) {
+		service, incidents := newService("Investigating")
+
+		require.NoError(t, service.MergeIncident(ctx, &models.IncidentMerge{ID: 1, Into: 2}))
+		assert.Equal(t, "Merged", incidents.transition.To)
+		assert.Equal(t, 2, incidents.into)
+	})
+
+	t.Run("an incident cannot be merged into itself", func(t *testing.T) {
+		service, incidents := newService("Triage")
+
+		var validation *validators.ValidationError
+		assert.ErrorAs(t, service.MergeIncident(ctx, &models.IncidentMerge{ID: 1, Into: 1}), &validation)
+		assert.Nil(t, incidents.transition)
+	})
+}
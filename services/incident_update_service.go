@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/repositories"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/validators"
+)
+
+type IncidentUpdateService interface {
+	CreateIncidentUpdate(ctx context.Context, update *models.IncidentUpdateInput) (*models.IncidentUpdate, error)
+	GetIncidentUpdates(incidentID int) ([]*models.IncidentUpdate, error)
+	EditIncidentUpdate(ctx context.Context, update *models.IncidentUpdateInput) (*models.IncidentUpdate, error)
+	DeleteIncidentUpdate(ctx context.Context, incidentID, updateID int) error
+}
+
+type incidentUpdateService struct {
+	incidentRepository       repositories.IncidentRepository
+	incidentUpdateRepository repositories.IncidentUpdateRepository
+}
+
+func NewIncidentUpdateService(incidentRepository repositories.IncidentRepository, incidentUpdateRepository repositories.IncidentUpdateRepository) IncidentUpdateService {
+	return &incidentUpdateService{incidentRepository: incidentRepository, incidentUpdateRepository: incidentUpdateRepository}
+}
+
+func (s *incidentUpdateService) CreateIncidentUpdate(ctx context.Context, update *models.IncidentUpdateInput) (*models.IncidentUpdate, error) {
+	log.Printf("CreateIncidentUpdate: Starting creation process for incident ID %d", update.IncidentID)
+
+	if err := validators.ValidateStruct(update); err != nil {
+		log.Printf("CreateIncidentUpdate: Validation error: %v", err)
+		return nil, &validators.ValidationError{Err: err}
+	}
+
+	authorID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.incidentRepository.GetIncidentStatus(update.IncidentID); err != nil {
+		log.Printf("CreateIncidentUpdate: Error retrieving incident: %v", err)
+		return nil, err
+	}
+
+	updateID, err := s.incidentUpdateRepository.CreateIncidentUpdate(update, authorID)
+	if err != nil {
+		log.Printf("CreateIncidentUpdate: Error creating update: %v", err)
+		return nil, err
+	}
+
+	log.Printf("CreateIncidentUpdate: Successfully created update %d", updateID)
+	return s.incidentUpdateRepository.GetIncidentUpdateByID(update.IncidentID, updateID)
+}
+
+func (s *incidentUpdateService) GetIncidentUpdates(incidentID int) ([]*models.IncidentUpdate, error) {
+	log.Printf("GetIncidentUpdates: Starting retrieval for incident ID %d", incidentID)
+
+	if _, err := s.incidentRepository.GetIncidentStatus(incidentID); err != nil {
+		log.Printf("GetIncidentUpdates: Error retrieving incident: %v", err)
+		return nil, err
+	}
+
+	updates, err := s.incidentUpdateRepository.GetIncidentUpdates(incidentID)
+	if err != nil {
+		log.Printf("GetIncidentUpdates: Error retrieving updates: %v", err)
+		return nil, err
+	}
+
+	log.Printf("GetIncidentUpdates: Successfully retrieved %d updates", len(updates))
+	return updates, nil
+}
+
+func (s *incidentUpdateService) EditIncidentUpdate(ctx context.Context, update *models.IncidentUpdateInput) (*models.IncidentUpdate, error) {
+	log.Printf("EditIncidentUpdate: Starting edit process for update %d", update.ID)
+
+	if err := validators.ValidateStruct(update); err != nil {
+		log.Printf("EditIncidentUpdate: Validation error: %v", err)
+		return nil, &validators.ValidationError{Err: err}
+	}
+
+	if err := s.ensureAuthor(ctx, update.IncidentID, update.ID, false); err != nil {
+		log.Printf("EditIncidentUpdate: Not allowed to edit update %d: %v", update.ID, err)
+		return nil, err
+	}
+
+	if err := s.incidentUpdateRepository.EditIncidentUpdate(update); err != nil {
+		log.Printf("EditIncidentUpdate: Error editing update: %v", err)
+		return nil, err
+	}
+
+	log.Printf("EditIncidentUpdate: Successfully edited update %d", update.ID)
+	return s.incidentUpdateRepository.GetIncidentUpdateByID(update.IncidentID, update.ID)
+}
+
+func (s *incidentUpdateService) DeleteIncidentUpdate(ctx context.Context, incidentID, updateID int) error {
+	log.Printf("DeleteIncidentUpdate: Starting delete process for update %d", updateID)
+
+	if err := s.ensureAuthor(ctx, incidentID, updateID, true); err != nil {
+		log.Printf("DeleteIncidentUpdate: Not allowed to delete update %d: %v", updateID, err)
+		return err
+	}
+
+	if err := s.incidentUpdateRepository.DeleteIncidentUpdate(incidentID, updateID); err != nil {
+		log.Printf("DeleteIncidentUpdate: Error deleting update: %v", err)
+		return err
+	}
+
+	log.Printf("DeleteIncidentUpdate: Successfully deleted update %d", updateID)
+	return nil
+}
+
+// ensureAuthor only lets responders change the notes they posted themselves.
+// Incident managers may also remove anyone's note when managerMayAct is set,
+// but never edit it, since it would still show the original author.
+func (s *incidentUpdateService) ensureAuthor(ctx context.Context, incidentID, updateID int, managerMayAct bool) error {
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	existing, err := s.incidentUpdateRepository.GetIncidentUpdateByID(incidentID, updateID)
+	if err != nil {
+		return err
+	}
+
+	if existing.AuthorID == actorID {
+		return nil
+	}
+
+	if managerMayAct && actorCan(ctx, models.PermissionIncidentsManage) {
+		return nil
+	}
+
+	return &customErrors.ForbiddenError{Msg: "only the author can change this update"}
+}
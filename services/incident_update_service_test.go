@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubIncidentUpdateRepository keeps incident updates in memory.
+type stubIncidentUpdateRepository struct {
+	repositories.IncidentUpdateRepository
+	updates map[int]*models.IncidentUpdate
+}
+
+func (r *stubIncidentUpdateRepository) GetIncidentUpdateByID(incidentID, updateID int) (*models.IncidentUpdate, error) {
+	update, ok := r.updates[updateID]
+	if !ok || update.IncidentID != incidentID {
+		return nil, &customErrors.NotFoundError{Msg: "incident update not found"}
+	}
+	return update, nil
+}
+
+func (r *stubIncidentUpdateRepository) EditIncidentUpdate(update *models.IncidentUpdateInput) error {
+	r.updates[update.ID].Body = update.Body
+	return nil
+}
+
+func (r *stubIncidentUpdateRepository) DeleteIncidentUpdate(incidentID, updateID int) error {
+	delete(r.updates, updateID)
+	return nil
+}
+
+func TestIncidentUpdateAuthorship(t *testing.T) {
+	newService := func() (IncidentUpdateService, *stubIncidentUpdateRepository) {
+		updates := &stubIncidentUpdateRepository{updates: map[int]*models.IncidentUpdate{
+			1: {ID: 1, IncidentID: 3, AuthorID: 7, Body: "rolled back deploy 42"},
+		}}
+		return NewIncidentUpdateService(&stubStatusIncidentRepository{incidentID: 3}, updates), updates
+	}
+	author := context.WithValue(context.WithValue(context.Background(), "user_id", 7), "role", models.RoleResponder)
+	responder := context.WithValue(context.WithValue(context.Background(), "user_id", 8), "role", models.RoleResponder)
+	manager := context.WithValue(context.WithValue(context.Background(), "user_id", 9), "role", models.RoleIncidentManager)
+
+	t.Run("authors edit their own updates", func(t *testing.T) {
+		service, _ := newService()
+
+		update, err := service.EditIncidentUpdate(author, &models.IncidentUpdateInput{ID: 1, IncidentID: 3, Body: "error rate dropping"})
+		require.NoError(t, err)
+		assert.Equal(t, "error rate dropping", update.Body)
+	})
+
+	t.Run("other responders cannot edit or delete", func(t *testing.T) {
+		service, updates := newService()
+
+		var forbidden *customErrors.ForbiddenError
+		_, err := service.EditIncidentUpdate(responder, &models.IncidentUpdateInput{ID: 1, IncidentID: 3, Body: "edited"})
+		assert.ErrorAs(t, err, &forbidden)
+		assert.ErrorAs(t, service.DeleteIncidentUpdate(responder, 3, 1), &forbidden)
+		assert.Equal(t, "rolled back deploy 42", updates.updates[1].Body)
+	})
+
+	t.Run("incident managers may delete but not edit others' updates", func(t *testing.T) {
+		service, updates := newService()
+
+		var forbidden *customErrors.ForbiddenError
+		_, err := service.EditIncidentUpdate(manager, &models.IncidentUpdateInput{ID: 1, IncidentID: 3, Body: "moderated"})
+		assert.ErrorAs(t, err, &forbidden)
+		assert.Equal(t, "rolled back deploy 42", updates.updates[1].Body)
+
+		require.NoError(t, service.DeleteIncidentUpdate(manager, 3, 1))
+		assert.Empty(t, updates.updates)
+	})
+
+	t.Run("updates of another incident are not found", func(t *testing.T) {
+		service, updates := newService()
+
+		var notFound *customErrors.NotFoundError
+		_, err := service.EditIncidentUpdate(author, &models.IncidentUpdateInput{ID: 1, IncidentID: 4, Body: "edited"})
+		assert.ErrorAs(t, err, &notFound)
+		assert.ErrorAs(t, service.DeleteIncidentUpdate(author, 4, 1), &notFound)
+		assert.Contains(t, updates.updates, 1)
+	})
+}
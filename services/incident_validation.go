@@ -0,0 +1,76 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/validators"
+)
+
+// optionReference is a set of values in an incident payload that must match
+// active options of a taxonomy, either by name or by ID.
+type optionReference struct {
+	field    string
+	taxonomy string
+	names    []*string
+	ids      []int
+}
+
+// nameReference matches the name without case and, once validated, rewrites
+// it to the option's own spelling so incidents store the canonical name.
+func nameReference(field, taxonomy string, name *string) optionReference {
+	reference := optionReference{field: field, taxonomy: taxonomy}
+	if name != nil && *name != "" {
+		reference.names = []*string{name}
+	}
+	return reference
+}
+
+func idReference(field, taxonomy string, ids []int) optionReference {
+	return optionReference{field: field, taxonomy: taxonomy, ids: ids}
+}
+
+// validateReferences checks every reference against the active options and
+// returns a ValidationError listing each offending value.
+func (s *incidentService) validateReferences(references ...optionReference) error {
+	var messages []string
+
+	for _, reference := range references {
+		if len(reference.names) == 0 && len(reference.ids) == 0 {
+			continue
+		}
+
+		options, err := s.optionsService.GetActiveOptions(reference.taxonomy)
+		if err != nil {
+			return err
+		}
+
+		names := make(map[string]string, len(options))
+		ids := make(map[int]bool, len(options))
+		for _, option := range options {
+			names[strings.ToLower(option.Name)] = option.Name
+			ids[option.ID] = true
+		}
+
+		for _, name := range reference.names {
+			canonical, ok := names[strings.ToLower(*name)]
+			if !ok {
+				messages = append(messages, fmt.Sprintf("%s %q is not an active option", reference.field, *name))
+				continue
+			}
+			*name = canonical
+		}
+
+		for _, id := range reference.ids {
+			if !ids[id] {
+				messages = append(messages, fmt.Sprintf("%s contains unknown or inactive ID %d", reference.field, id))
+			}
+		}
+	}
+
+	if len(messages) > 0 {
+		return &validators.ValidationError{Messages: messages}
+	}
+
+	return nil
+}
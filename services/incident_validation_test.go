@@ -0,0 +1,88 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/validators"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubOptionsService serves fixed active options; other methods are unused.
+type stubOptionsService struct {
+	OptionsService
+	active map[string][]*models.Option
+}
+
+func (s *stubOptionsService) GetActiveOptions(taxonomy string) ([]*models.Option, error) {
+	return s.active[taxonomy], nil
+}
+
+func TestValidateReferences(t *testing.T) {
+	service := &incidentService{optionsService: &stubOptionsService{active: map[string][]*models.Option{
+		"severity": {{ID: 1, Name: "SEV1", Active: true}, {ID: 2, Name: "SEV2", Active: true}},
+		"products": {{ID: 10, Name: "PIX", Active: true}},
+	}}}
+
+	severity, unknownSeverity := "sev2", "SEV9"
+
+	tests := []struct {
+		name             string
+		references       []optionReference
+		expectedMessages []string
+	}{
+		{
+			name: "All Active",
+			references: []optionReference{
+				nameReference("Severity", "severity", &severity),
+				idReference("Products", "products", []int{10}),
+			},
+		},
+		{
+			name: "Empty References Are Skipped",
+			references: []optionReference{
+				nameReference("Source", "sources", nil),
+				idReference("Causes", "causes", nil),
+			},
+		},
+		{
+			name: "Each Bad Field Is Listed",
+			references: []optionReference{
+				nameReference("Severity", "severity", &unknownSeverity),
+				idReference("Products", "products", []int{10, 11, 12}),
+			},
+			expectedMessages: []string{
+				`Severity "SEV9" is not an active option`,
+				"Products contains unknown or inactive ID 11",
+				"Products contains unknown or inactive ID 12",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.validateReferences(tt.references...)
+
+			if tt.expectedMessages == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var validationErr *validators.ValidationError
+			if assert.ErrorAs(t, err, &validationErr) {
+				assert.Equal(t, tt.expectedMessages, validationErr.ErrorMessages())
+			}
+		})
+	}
+}
+
+func TestValidateReferencesStoresCanonicalNames(t *testing.T) {
+	service := &incidentService{optionsService: &stubOptionsService{active: map[string][]*models.Option{
+		"severity": {{ID: 1, Name: "SEV1", Active: true}},
+	}}}
+	severity := "sev1"
+
+	require.NoError(t, service.validateReferences(nameReference("Severity", "severity", &severity)))
+	assert.Equal(t, "SEV1", severity)
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/config"
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/repositories"
+)
+
+// throttlePolicy describes how one scope backs off. Below maxAttempts each
+// failure waits backoffBase doubled per failure; from maxAttempts on the
+// subject is locked out for lockout, doubled per further failure. Both are
+// capped at lockoutMax.
+type throttlePolicy struct {
+	maxAttempts int
+	backoffBase time.Duration
+	lockout     time.Duration
+	lockoutMax  time.Duration
+}
+
+// delay returns how long a subject must wait after its nth failure.
+func (p throttlePolicy) delay(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+
+	base, exponent := p.backoffBase, failures-1
+	if failures >= p.maxAttempts {
+		base, exponent = p.lockout, failures-p.maxAttempts
+	}
+
+	delay := base
+	for i := 0; i < exponent && delay < p.lockoutMax; i++ {
+		delay *= 2
+	}
+
+	if delay > p.lockoutMax {
+		return p.lockoutMax
+	}
+	return delay
+}
+
+func (p throttlePolicy) locksOut(failures int) bool {
+	return failures >= p.maxAttempts
+}
+
+// loginThrottle tracks failed logins per account and per client IP.
+type loginThrottle struct {
+	attempts repositories.LoginAttemptRepository
+	events   repositories.SecurityEventRepository
+	policies map[string]throttlePolicy
+	window   time.Duration
+}
+
+func newLoginThrottle(attempts repositories.LoginAttemptRepository, events repositories.SecurityEventRepository) *loginThrottle {
+	cfg := config.GetConfig()
+
+	return &loginThrottle{
+		attempts: attempts,
+		events:   events,
+		policies: map[string]throttlePolicy{
+			models.LoginScopeAccount: {
+				maxAttempts: cfg.LoginMaxAttempts,
+				backoffBase: cfg.LoginBackoffBase,
+				lockout:     cfg.LoginLockoutDuration,
+				lockoutMax:  cfg.LoginLockoutMax,
+			},
+			// Many users can share an address, so IPs are not slowed down
+			// before they reach their own, higher limit.
+			models.LoginScopeIP: {
+				maxAttempts: cfg.LoginIPMaxAttempts,
+				lockout:     cfg.LoginLockoutDuration,
+				lockoutMax:  cfg.LoginLockoutMax,
+			},
+		},
+		window: cfg.LoginFailureWindow,
+	}
+}
+
+// check returns a TooManyRequestsError while the account or the IP is
+// waiting out a backoff or lockout.
+func (t *loginThrottle) check(email, ip string) error {
+	var retryAfter time.Duration
+
+	for scope, subject := range map[string]string{models.LoginScopeAccount: email, models.LoginScopeIP: ip} {
+		lockedUntil, err := t.attempts.GetLockedUntil(scope, subject)
+		if err != nil {
+			return err
+		}
+
+		if lockedUntil != nil {
+			if wait := time.Until(*lockedUntil); wait > retryAfter {
+				retryAfter = wait
+			}
+		}
+	}
+
+	if retryAfter > 0 {
+		return &customErrors.TooManyRequestsError{Msg: "too many failed login attempts, try again later", RetryAfter: retryAfter}
+	}
+
+	return nil
+}
+
+// recordFailure counts a failed login against the account and the IP, and
+// records a security event whenever either gets locked out.
+func (t *loginThrottle) recordFailure(email, ip string, userID *int) error {
+	for scope, subject := range map[string]string{models.LoginScopeAccount: email, models.LoginScopeIP: ip} {
+		policy := t.policies[scope]
+
+		failures, err := t.attempts.RecordFailure(scope, subject, t.window)
+		if err != nil {
+			return err
+		}
+
+		delay := policy.delay(failures)
+		if delay <= 0 {
+			continue
+		}
+
+		if err := t.attempts.LockUntil(scope, subject, time.Now().Add(delay)); err != nil {
+			return err
+		}
+
+		if !policy.locksOut(failures) {
+			continue
+		}
+
+		log.Printf("recordFailure: Locking %s %s for %s after %d failed attempts", scope, subject, delay, failures)
+
+		event := &models.SecurityEvent{
+			Type:   models.SecurityEventAccountLocked,
+			UserID: userID,
+			Email:  &email,
+			IP:     &ip,
+			Detail: stringPtr(fmt.Sprintf("locked for %s after %d failed login attempts", delay, failures)),
+		}
+		if scope == models.LoginScopeIP {
+			event.Type = models.SecurityEventIPLocked
+		}
+
+		if err := t.events.CreateSecurityEvent(event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reset forgets the failures of an account after a successful login or an
+// admin unlock. IP failures are left to expire, so a valid login on one
+// account does not clear an attack spread over others.
+func (t *loginThrottle) reset(email string) error {
+	return t.attempts.ClearFailures(models.LoginScopeAccount, email)
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThrottlePolicyDelay(t *testing.T) {
+	policy := throttlePolicy{
+		maxAttempts: 5,
+		backoffBase: time.Second,
+		lockout:     15 * time.Minute,
+		lockoutMax:  time.Hour,
+	}
+
+	tests := []struct {
+		failures int
+		delay    time.Duration
+		locked   bool
+	}{
+		{failures: 0, delay: 0},
+		{failures: 1, delay: time.Second},
+		{failures: 2, delay: 2 * time.Second},
+		{failures: 4, delay: 8 * time.Second},
+		{failures: 5, delay: 15 * time.Minute, locked: true},
+		{failures: 6, delay: 30 * time.Minute, locked: true},
+		{failures: 7, delay: time.Hour, locked: true},
+		{failures: 500, delay: time.Hour, locked: true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.delay, policy.delay(tt.failures), "failures=%d", tt.failures)
+		assert.Equal(t, tt.locked, policy.locksOut(tt.failures), "failures=%d", tt.failures)
+	}
+
+	ipPolicy := throttlePolicy{maxAttempts: 50, lockout: 15 * time.Minute, lockoutMax: time.Hour}
+	assert.Zero(t, ipPolicy.delay(49))
+	assert.Equal(t, 15*time.Minute, ipPolicy.delay(50))
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/config"
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/oidc"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/repositories"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/utils"
+)
+
+type OIDCService interface {
+	Enabled() bool
+	BeginLogin(ctx context.Context) (*models.OIDCLogin, error)
+	CompleteLogin(ctx context.Context, callback *models.OIDCCallback, ip string) (*models.User, error)
+	PostLoginRedirect() string
+	TrustsProviderMFA() bool
+}
+
+type oidcService struct {
+	provider          *oidc.Provider
+	issuer            string
+	allowedDomains    []string
+	postLoginRedirect string
+	trustProviderMFA  bool
+	userRepo          repositories.UserRepository
+	securityEventRepo repositories.SecurityEventRepository
+}
+
+func NewOIDCService(userRepo repositories.UserRepository, securityEventRepo repositories.SecurityEventRepository) OIDCService {
+	cfg := config.GetConfig()
+
+	service := newOIDCService(oidc.Config{
+		Issuer:       cfg.OIDCIssuer,
+		ClientID:     cfg.OIDCClientID,
+		ClientSecret: cfg.OIDCClientSecret,
+		RedirectURL:  cfg.OIDCRedirectURL,
+		Scopes:       cfg.OIDCScopes,
+	}, cfg.OIDCAllowedDomains, cfg.OIDCPostLoginRedirect, userRepo, securityEventRepo)
+	service.trustProviderMFA = cfg.OIDCTrustIDPMFA
+
+	return service
+}
+
+func newOIDCService(providerConfig oidc.Config, allowedDomains []string, postLoginRedirect string, userRepo repositories.UserRepository, securityEventRepo repositories.SecurityEventRepository) *oidcService {
+	service := &oidcService{
+		issuer:            providerConfig.Issuer,
+		allowedDomains:    allowedDomains,
+		postLoginRedirect: postLoginRedirect,
+		userRepo:          userRepo,
+		securityEventRepo: securityEventRepo,
+	}
+
+	if providerConfig.Issuer != "" {
+		service.provider = oidc.NewProvider(providerConfig)
+	}
+
+	return service
+}
+
+func (s *oidcService) Enabled() bool {
+	return s.provider != nil
+}
+
+func (s *oidcService) PostLoginRedirect() string {
+	return s.postLoginRedirect
+}
+
+// TrustsProviderMFA reports whether OIDC_TRUST_IDP_MFA lets single sign-on
+// skip the local two-factor challenge.
+func (s *oidcService) TrustsProviderMFA() bool {
+	return s.trustProviderMFA
+}
+
+// BeginLogin builds the provider URL for a new login. The state, nonce and
+// PKCE verifier travel together in the returned State so the callback can
+// check them without any server-side storage.
+func (s *oidcService) BeginLogin(ctx context.Context) (*models.OIDCLogin, error) {
+	if !s.Enabled() {
+		return nil, &customErrors.NotFoundError{Msg: "single sign-on is not configured"}
+	}
+
+	var parts [3]string
+	for i := range parts {
+		token, err := utils.GenerateToken(32)
+		if err != nil {
+			log.Printf("BeginLogin: Error generating state: %v", err)
+			return nil, err
+		}
+		parts[i] = token
+	}
+	state, nonce, verifier := parts[0], parts[1], parts[2]
+
+	url, err := s.provider.AuthCodeURL(ctx, state, nonce, oidc.CodeChallenge(verifier))
+	if err != nil {
+		log.Printf("BeginLogin: Error building authorization URL: %v", err)
+		return nil, err
+	}
+
+	return &models.OIDCLogin{URL: url, State: strings.Join(parts[:], ".")}, nil
+}
+
+// CompleteLogin exchanges the authorization code and returns the local user
+// for the identity, linking or provisioning one on first login.
+func (s *oidcService) CompleteLogin(ctx context.Context, callback *models.OIDCCallback, ip string) (*models.User, error) {
+	if !s.Enabled() {
+		return nil, &customErrors.NotFoundError{Msg: "single sign-on is not configured"}
+	}
+
+	if callback.Error != "" {
+		log.Printf("CompleteLogin: Provider returned error %s: %s", callback.Error, callback.ErrorDescription)
+		return nil, &customErrors.AuthenticationError{Msg: "single sign-on was not completed"}
+	}
+
+	stored := strings.Split(callback.StoredState, ".")
+	if len(stored) != 3 || callback.Code == "" ||
+		subtle.ConstantTimeCompare([]byte(stored[0]), []byte(callback.State)) != 1 {
+		return nil, &customErrors.AuthenticationError{Msg: "invalid or expired single sign-on request"}
+	}
+	nonce, verifier := stored[1], stored[2]
+
+	idToken, err := s.provider.Exchange(ctx, callback.Code, verifier)
+	if err != nil {
+		log.Printf("CompleteLogin: Error exchanging code: %v", err)
+		return nil, &customErrors.AuthenticationError{Msg: "single sign-on failed"}
+	}
+
+	claims, err := s.provider.VerifyIDToken(ctx, idToken, nonce)
+	if err != nil {
+		log.Printf("CompleteLogin: Error verifying ID token: %v", err)
+		return nil, &customErrors.AuthenticationError{Msg: "single sign-on failed"}
+	}
+
+	email := strings.ToLower(claims.Email)
+	if email == "" || !claims.EmailVerified {
+		return nil, &customErrors.ForbiddenError{Msg: "the identity provider did not return a verified email address"}
+	}
+
+	if !s.domainAllowed(email) {
+		log.Printf("CompleteLogin: Rejected login from %s", email)
+		return nil, &customErrors.ForbiddenError{Msg: "email domain is not allowed to sign in"}
+	}
+
+	user, err := s.userRepo.GetUserByOIDCSubject(s.issuer, claims.Subject)
+	var notFound *customErrors.NotFoundError
+	if err == nil {
+		return s.checkUser(user)
+	}
+	if !errors.As(err, &notFound) {
+		log.Printf("CompleteLogin: Error retrieving linked user: %v", err)
+		return nil, err
+	}
+
+	user, err = s.userRepo.GetUserByEmail(email)
+	switch {
+	case err == nil:
+		return s.linkUser(user, claims.Subject, ip)
+	case errors.As(err, &notFound):
+		return s.provisionUser(claims, email)
+	default:
+		log.Printf("CompleteLogin: Error retrieving user by email: %v", err)
+		return nil, err
+	}
+}
+
+func (s *oidcService) domainAllowed(email string) bool {
+	if len(s.allowedDomains) == 0 {
+		return true
+	}
+
+	domain := email[strings.LastIndex(email, "@")+1:]
+	for _, allowed := range s.allowedDomains {
+		if strings.EqualFold(domain, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// linkUser attaches the identity to an existing password account with the
+// same email address.
+func (s *oidcService) linkUser(user *models.User, subject, ip string) (*models.User, error) {
+	if _, err := s.checkUser(user); err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.LinkOIDCIdentity(user.ID, s.issuer, subject); err != nil {
+		log.Printf("CompleteLogin: Error linking identity to user ID %d: %v", user.ID, err)
+		return nil, err
+	}
+
+	log.Printf("CompleteLogin: Linked identity %s to user ID %d", subject, user.ID)
+
+	event := &models.SecurityEvent{Type: models.SecurityEventOIDCLinked, UserID: &user.ID, Email: &user.Email, IP: &ip}
+	if err := s.securityEventRepo.CreateSecurityEvent(event); err != nil {
+		log.Printf("CompleteLogin: Error recording security event: %v", err)
+	}
+
+	return s.userRepo.GetUserByID(user.ID)
+}
+
+// provisionUser creates a Viewer for a first-time login. The account gets a
+// random password it never uses; password reset can set a real one later.
+func (s *oidcService) provisionUser(claims *oidc.Claims, email string) (*models.User, error) {
+	name := claims.Name
+	if name == "" {
+		name = email[:strings.LastIndex(email, "@")]
+	}
+
+	password, err := utils.GenerateToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := s.userRepo.CreateOIDCUser(name, email, utils.GeneratePassword(password), s.issuer, claims.Subject)
+	if err != nil {
+		log.Printf("CompleteLogin: Error provisioning user: %v", err)
+		return nil, err
+	}
+
+	log.Printf("CompleteLogin: Provisioned user ID %d for %s", id, email)
+	return s.userRepo.GetUserByID(id)
+}
+
+func (s *oidcService) checkUser(user *models.User) (*models.User, error) {
+	if user.IsServiceAccount {
+		return nil, &customErrors.ForbiddenError{Msg: fmt.Sprintf("%s is a service account", user.Email)}
+	}
+	if user.DeactivatedAt != nil {
+		return nil, &customErrors.AuthenticationError{Msg: "this account has been deactivated"}
+	}
+	return user, nil
+}
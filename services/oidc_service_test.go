@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/oidc/oidctest"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubOIDCUserRepository keeps users in memory, keyed by ID.
+type stubOIDCUserRepository struct {
+	repositories.UserRepository
+	users    map[int]*models.User
+	subjects map[string]int
+}
+
+func newStubOIDCUserRepository(users ...*models.User) *stubOIDCUserRepository {
+	r := &stubOIDCUserRepository{users: map[int]*models.User{}, subjects: map[string]int{}}
+	for _, user := range users {
+		r.users[user.ID] = user
+	}
+	return r
+}
+
+func (r *stubOIDCUserRepository) GetUserByEmail(email string) (*models.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, &customErrors.NotFoundError{Msg: "user not found"}
+}
+
+func (r *stubOIDCUserRepository) GetUserByID(id int) (*models.User, error) {
+	if user, ok := r.users[id]; ok {
+		return user, nil
+	}
+	return nil, &customErrors.NotFoundError{Msg: "user not found"}
+}
+
+func (r *stubOIDCUserRepository) GetUserByOIDCSubject(issuer, subject string) (*models.User, error) {
+	if id, ok := r.subjects[issuer+subject]; ok {
+		return r.users[id], nil
+	}
+	return nil, &customErrors.NotFoundError{Msg: "no user is linked to this identity"}
+}
+
+func (r *stubOIDCUserRepository) LinkOIDCIdentity(id int, issuer, subject string) error {
+	for _, linked := range r.subjects {
+		if linked == id {
+			return &customErrors.ConflictError{Msg: "already linked"}
+		}
+	}
+	r.subjects[issuer+subject] = id
+	return nil
+}
+
+func (r *stubOIDCUserRepository) CreateOIDCUser(name, email, passwordHash, issuer, subject string) (int, error) {
+	id := len(r.users) + 100
+	r.users[id] = &models.User{ID: id, Name: name, Email: email, Password: passwordHash, Role: models.RoleViewer}
+	r.subjects[issuer+subject] = id
+	return id, nil
+}
+
+// login runs the whole authorization-code flow against the stub provider.
+func login(t *testing.T, service *oidcService, provider *oidctest.Provider) (*models.User, error) {
+	t.Helper()
+
+	begin, err := service.BeginLogin(context.Background())
+	require.NoError(t, err)
+
+	code, state, err := provider.Authorize(begin.URL)
+	require.NoError(t, err)
+
+	return service.CompleteLogin(context.Background(), &models.OIDCCallback{Code: code, State: state, StoredState: begin.State}, "10.0.0.1")
+}
+
+func TestOIDCLogin(t *testing.T) {
+	provider := oidctest.NewProvider()
+	defer provider.Close()
+
+	existing := &models.User{ID: 7, Name: "John", Email: "john@cloudwalk.io", Role: models.RoleAdmin}
+	userRepo := newStubOIDCUserRepository(existing)
+	events := &stubSecurityEventRepository{}
+	service := newOIDCService(provider.Config("http://localhost/callback"), []string{"cloudwalk.io"}, "/", userRepo, events)
+
+	t.Run("Provisions A New User", func(t *testing.T) {
+		provider.Claims = map[string]interface{}{"sub": "new-1", "email": "Jane@cloudwalk.io", "email_verified": true, "name": "Jane"}
+
+		user, err := login(t, service, provider)
+		require.NoError(t, err)
+		assert.Equal(t, "jane@cloudwalk.io", user.Email)
+		assert.Equal(t, models.RoleViewer, user.Role)
+
+		again, err := login(t, service, provider)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, again.ID)
+	})
+
+	t.Run("Links An Existing User By Email", func(t *testing.T) {
+		provider.Claims = map[string]interface{}{"sub": "john-1", "email": "john@cloudwalk.io", "email_verified": true}
+
+		user, err := login(t, service, provider)
+		require.NoError(t, err)
+		assert.Equal(t, 7, user.ID)
+		assert.Equal(t, models.RoleAdmin, user.Role)
+		if assert.Len(t, events.events, 1) {
+			assert.Equal(t, models.SecurityEventOIDCLinked, events.events[0].Type)
+		}
+	})
+
+	t.Run("Another Identity Cannot Take A Linked Account", func(t *testing.T) {
+		provider.Claims = map[string]interface{}{"sub": "john-2", "email": "john@cloudwalk.io", "email_verified": true}
+
+		_, err := login(t, service, provider)
+		var conflict *customErrors.ConflictError
+		assert.ErrorAs(t, err, &conflict)
+	})
+
+	t.Run("Rejects Other Domains", func(t *testing.T) {
+		provider.Claims = map[string]interface{}{"sub": "mallory", "email": "mallory@example.com", "email_verified": true}
+
+		_, err := login(t, service, provider)
+		var forbidden *customErrors.ForbiddenError
+		assert.ErrorAs(t, err, &forbidden)
+	})
+
+	t.Run("Rejects Unverified Email", func(t *testing.T) {
+		provider.Claims = map[string]interface{}{"sub": "unverified", "email": "bob@cloudwalk.io", "email_verified": false}
+
+		_, err := login(t, service, provider)
+		var forbidden *customErrors.ForbiddenError
+		assert.ErrorAs(t, err, &forbidden)
+	})
+
+	t.Run("Rejects A Mismatched State", func(t *testing.T) {
+		provider.Claims = map[string]interface{}{"sub": "new-1", "email": "jane@cloudwalk.io", "email_verified": true}
+
+		begin, err := service.BeginLogin(context.Background())
+		require.NoError(t, err)
+		code, _, err := provider.Authorize(begin.URL)
+		require.NoError(t, err)
+
+		_, err = service.CompleteLogin(context.Background(), &models.OIDCCallback{Code: code, State: "forged", StoredState: begin.State}, "")
+		var authErr *customErrors.AuthenticationError
+		assert.ErrorAs(t, err, &authErr)
+	})
+}
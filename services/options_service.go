@@ -1,9 +1,15 @@
 package services
 
 import (
+	"fmt"
 	"log"
+	"sync"
+	"time"
+
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
 	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
 	"github.com/pamateus-henrique/infinitepay-firewatchers-api/repositories"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/validators"
 )
 
 type OptionsService interface {
@@ -15,14 +21,33 @@ type OptionsService interface {
 	GetPerformanceIndicators() ([]*models.PerformanceIndicator, error)
 	GetFaultySystems() ([]*models.FaultySystem, error)
 	GetCauses() ([]*models.Cause, error)
+	GetSources() ([]*models.Source, error)
+	ListOptions(taxonomy string) ([]*models.Option, error)
+	CreateOption(option *models.OptionInput) (*models.Option, error)
+	RenameOption(option *models.OptionInput) (*models.Option, error)
+	ReorderOptions(order *models.OptionOrder) ([]*models.Option, error)
+	SetOptionActive(taxonomy string, id int, active bool) error
+	GetActiveOptions(taxonomy string) ([]*models.Option, error)
+}
+
+// optionsCacheTTL bounds how long other instances may serve stale options
+// after an admin change; changes made through this instance apply at once.
+const optionsCacheTTL = time.Minute
+
+type cachedOptions struct {
+	options   []*models.Option
+	expiresAt time.Time
 }
 
 type optionsService struct {
 	optionsRepository repositories.OptionsRepository
+
+	cacheMu sync.Mutex
+	cache   map[string]cachedOptions
 }
 
 func NewOptionsService(optionsRepository repositories.OptionsRepository) OptionsService {
-	return &optionsService{optionsRepository: optionsRepository}
+	return &optionsService{optionsRepository: optionsRepository, cache: make(map[string]cachedOptions)}
 }
 
 func (s *optionsService) GetTypes() ([]*models.Type, error) {
@@ -128,3 +153,188 @@ func (s *optionsService) GetCauses() ([]*models.Cause, error) {
 	log.Printf("GetCauses: Successfully retrieved %d causes", len(causes))
 	return causes, nil
 }
+
+func (s *optionsService) GetSources() ([]*models.Source, error) {
+	log.Println("GetSources: Starting sources retrieval process")
+
+	sources, err := s.optionsRepository.GetSources()
+	if err != nil {
+		log.Printf("GetSources: Error retrieving sources: %v", err)
+		return nil, err
+	}
+
+	log.Printf("GetSources: Successfully retrieved %d sources", len(sources))
+	return sources, nil
+}
+
+func (s *optionsService) ListOptions(taxonomy string) ([]*models.Option, error) {
+	log.Printf("ListOptions: Starting retrieval of all %s options", taxonomy)
+
+	options, err := s.optionsRepository.ListOptions(taxonomy)
+	if err != nil {
+		log.Printf("ListOptions: Error retrieving options: %v", err)
+		return nil, err
+	}
+
+	log.Printf("ListOptions: Successfully retrieved %d %s options", len(options), taxonomy)
+	return options, nil
+}
+
+func (s *optionsService) CreateOption(option *models.OptionInput) (*models.Option, error) {
+	log.Printf("CreateOption: Starting creation of %s option", option.Taxonomy)
+
+	if err := s.validateOptionName(option); err != nil {
+		log.Printf("CreateOption: Validation error: %v", err)
+		return nil, err
+	}
+
+	id, err := s.optionsRepository.CreateOption(option.Taxonomy, option.Name)
+	if err != nil {
+		log.Printf("CreateOption: Error creating option: %v", err)
+		return nil, err
+	}
+
+	s.invalidateOptions(option.Taxonomy)
+
+	log.Printf("CreateOption: Successfully created %s option %d", option.Taxonomy, id)
+	return s.optionsRepository.GetOptionByID(option.Taxonomy, id)
+}
+
+func (s *optionsService) RenameOption(option *models.OptionInput) (*models.Option, error) {
+	log.Printf("RenameOption: Starting rename of %s option %d", option.Taxonomy, option.ID)
+
+	if err := s.validateOptionName(option); err != nil {
+		log.Printf("RenameOption: Validation error: %v", err)
+		return nil, err
+	}
+
+	// Renaming a status rewrites it on every incident, so only its spelling
+	// may change; anything else would move incidents along the lifecycle.
+	if option.Taxonomy == "status" {
+		current, err := s.optionsRepository.GetOptionByID(option.Taxonomy, option.ID)
+		if err != nil {
+			log.Printf("RenameOption: Error retrieving option: %v", err)
+			return nil, err
+		}
+
+		if normalizeStatus(current.Name) != normalizeStatus(option.Name) {
+			log.Printf("RenameOption: Refusing to rename status %q to %q", current.Name, option.Name)
+			return nil, &validators.ValidationError{Messages: []string{"A status can only be renamed to a different spelling of the same status"}}
+		}
+	}
+
+	if err := s.optionsRepository.RenameOption(option.Taxonomy, option.ID, option.Name); err != nil {
+		log.Printf("RenameOption: Error renaming option: %v", err)
+		return nil, err
+	}
+
+	s.invalidateOptions(option.Taxonomy)
+
+	log.Printf("RenameOption: Successfully renamed %s option %d", option.Taxonomy, option.ID)
+	return s.optionsRepository.GetOptionByID(option.Taxonomy, option.ID)
+}
+
+func (s *optionsService) ReorderOptions(order *models.OptionOrder) ([]*models.Option, error) {
+	log.Printf("ReorderOptions: Starting reorder of %s options", order.Taxonomy)
+
+	if err := validators.ValidateStruct(order); err != nil {
+		log.Printf("ReorderOptions: Validation error: %v", err)
+		return nil, &validators.ValidationError{Err: err}
+	}
+
+	if err := s.optionsRepository.ReorderOptions(order.Taxonomy, order.IDs); err != nil {
+		log.Printf("ReorderOptions: Error reordering options: %v", err)
+		return nil, err
+	}
+
+	s.invalidateOptions(order.Taxonomy)
+
+	log.Printf("ReorderOptions: Successfully reordered %s options", order.Taxonomy)
+	return s.optionsRepository.ListOptions(order.Taxonomy)
+}
+
+func (s *optionsService) SetOptionActive(taxonomy string, id int, active bool) error {
+	log.Printf("SetOptionActive: Setting %s option %d active=%t", taxonomy, id, active)
+
+	if !active {
+		// Open incidents must keep pointing at selectable values.
+		count, err := s.optionsRepository.CountOpenIncidentsUsingOption(taxonomy, id, terminalStatuses)
+		if err != nil {
+			log.Printf("SetOptionActive: Error counting incidents using option: %v", err)
+			return err
+		}
+
+		if count > 0 {
+			log.Printf("SetOptionActive: %s option %d is used by %d open incidents", taxonomy, id, count)
+			return &customErrors.ConflictError{Msg: fmt.Sprintf("option is still used by %d open incident(s)", count)}
+		}
+	}
+
+	if err := s.optionsRepository.SetOptionActive(taxonomy, id, active); err != nil {
+		log.Printf("SetOptionActive: Error updating option: %v", err)
+		return err
+	}
+
+	s.invalidateOptions(taxonomy)
+
+	log.Printf("SetOptionActive: Successfully updated %s option %d", taxonomy, id)
+	return nil
+}
+
+// GetActiveOptions returns the active values of a taxonomy, served from an
+// in-memory cache refreshed every optionsCacheTTL.
+func (s *optionsService) GetActiveOptions(taxonomy string) ([]*models.Option, error) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	if cached, ok := s.cache[taxonomy]; ok && time.Now().Before(cached.expiresAt) {
+		return cached.options, nil
+	}
+
+	options, err := s.optionsRepository.ListOptions(taxonomy)
+	if err != nil {
+		log.Printf("GetActiveOptions: Error retrieving %s options: %v", taxonomy, err)
+		return nil, err
+	}
+
+	active := make([]*models.Option, 0, len(options))
+	for _, option := range options {
+		if option.Active {
+			active = append(active, option)
+		}
+	}
+
+	s.cache[taxonomy] = cachedOptions{options: active, expiresAt: time.Now().Add(optionsCacheTTL)}
+	return active, nil
+}
+
+func (s *optionsService) invalidateOptions(taxonomy string) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	delete(s.cache, taxonomy)
+}
+
+// validateOptionName checks the payload and that no other value of the same
+// taxonomy already uses the name.
+func (s *optionsService) validateOptionName(option *models.OptionInput) error {
+	if err := validators.ValidateStruct(option); err != nil {
+		return &validators.ValidationError{Err: err}
+	}
+
+	// Statuses drive the incident lifecycle, so only names it knows are allowed.
+	if option.Taxonomy == "status" && !isKnownStatus(option.Name) {
+		return &validators.ValidationError{Messages: []string{"Name is not a status known to the incident lifecycle"}}
+	}
+
+	exists, err := s.optionsRepository.OptionNameExists(option.Taxonomy, option.Name, option.ID)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return &validators.ValidationError{Messages: []string{"Name already exists"}}
+	}
+
+	return nil
+}
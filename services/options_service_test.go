@@ -0,0 +1,174 @@
+package services
+
+import (
+	"testing"
+
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/repositories"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/validators"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubOptionsRepository keeps the values of every taxonomy in memory and
+// counts how often they are listed.
+type stubOptionsRepository struct {
+	repositories.OptionsRepository
+	options   map[string][]*models.Option
+	inUse     map[int]int
+	listCalls int
+}
+
+func (r *stubOptionsRepository) ListOptions(taxonomy string) ([]*models.Option, error) {
+	r.listCalls++
+	return r.options[taxonomy], nil
+}
+
+func (r *stubOptionsRepository) GetOptionByID(taxonomy string, id int) (*models.Option, error) {
+	for _, option := range r.options[taxonomy] {
+		if option.ID == id {
+			return option, nil
+		}
+	}
+	return nil, &customErrors.NotFoundError{Msg: "option not found"}
+}
+
+func (r *stubOptionsRepository) OptionNameExists(taxonomy, name string, excludeID int) (bool, error) {
+	for _, option := range r.options[taxonomy] {
+		if option.ID != excludeID && normalizeStatus(option.Name) == normalizeStatus(name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *stubOptionsRepository) CreateOption(taxonomy, name string) (int, error) {
+	id := len(r.options[taxonomy]) + 1
+	r.options[taxonomy] = append(r.options[taxonomy], &models.Option{ID: id, Name: name, Active: true})
+	return id, nil
+}
+
+func (r *stubOptionsRepository) RenameOption(taxonomy string, id int, name string) error {
+	option, err := r.GetOptionByID(taxonomy, id)
+	if err != nil {
+		return err
+	}
+	option.Name = name
+	return nil
+}
+
+func (r *stubOptionsRepository) SetOptionActive(taxonomy string, id int, active bool) error {
+	option, err := r.GetOptionByID(taxonomy, id)
+	if err != nil {
+		return err
+	}
+	option.Active = active
+	return nil
+}
+
+func (r *stubOptionsRepository) CountOpenIncidentsUsingOption(taxonomy string, id int, closedStatuses []string) (int, error) {
+	return r.inUse[id], nil
+}
+
+func newStubOptionsRepository() *stubOptionsRepository {
+	return &stubOptionsRepository{
+		options: map[string][]*models.Option{
+			"status":   {{ID: 1, Name: "Investigating", Active: true}, {ID: 2, Name: "Fixing", Active: true}},
+			"products": {{ID: 1, Name: "PIX", Active: true}, {ID: 2, Name: "Boleto", Active: true}},
+		},
+		inUse: map[int]int{},
+	}
+}
+
+func TestOptionNames(t *testing.T) {
+	tests := []struct {
+		name   string
+		option models.OptionInput
+		valid  bool
+	}{
+		{"new product", models.OptionInput{Taxonomy: "products", Name: "Card"}, true},
+		{"empty name", models.OptionInput{Taxonomy: "products", Name: ""}, false},
+		{"taken name", models.OptionInput{Taxonomy: "products", Name: "pix"}, false},
+		{"known status", models.OptionInput{Taxonomy: "status", Name: "Monitoring"}, true},
+		{"unknown status", models.OptionInput{Taxonomy: "status", Name: "Waiting"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewOptionsService(newStubOptionsRepository())
+
+			_, err := service.CreateOption(&tt.option)
+			if tt.valid {
+				assert.NoError(t, err)
+				return
+			}
+
+			var validation *validators.ValidationError
+			assert.ErrorAs(t, err, &validation)
+		})
+	}
+}
+
+func TestRenameStatus(t *testing.T) {
+	t.Run("the spelling of a status can change", func(t *testing.T) {
+		options := newStubOptionsRepository()
+		service := NewOptionsService(options)
+
+		option, err := service.RenameOption(&models.OptionInput{Taxonomy: "status", ID: 1, Name: "INVESTIGATING"})
+		require.NoError(t, err)
+		assert.Equal(t, "INVESTIGATING", option.Name)
+	})
+
+	t.Run("a status cannot become another lifecycle status", func(t *testing.T) {
+		options := newStubOptionsRepository()
+		service := NewOptionsService(options)
+
+		var validation *validators.ValidationError
+		_, err := service.RenameOption(&models.OptionInput{Taxonomy: "status", ID: 1, Name: "Canceled"})
+		assert.ErrorAs(t, err, &validation)
+		assert.Equal(t, "Investigating", options.options["status"][0].Name)
+	})
+
+	t.Run("other taxonomies rename freely", func(t *testing.T) {
+		service := NewOptionsService(newStubOptionsRepository())
+
+		option, err := service.RenameOption(&models.OptionInput{Taxonomy: "products", ID: 1, Name: "Pix Automático"})
+		require.NoError(t, err)
+		assert.Equal(t, "Pix Automático", option.Name)
+	})
+}
+
+func TestDeactivateOptionInUse(t *testing.T) {
+	options := newStubOptionsRepository()
+	options.inUse[1] = 2
+	service := NewOptionsService(options)
+
+	var conflict *customErrors.ConflictError
+	assert.ErrorAs(t, service.SetOptionActive("products", 1, false), &conflict)
+	assert.True(t, options.options["products"][0].Active)
+
+	assert.NoError(t, service.SetOptionActive("products", 2, false))
+	assert.False(t, options.options["products"][1].Active)
+}
+
+func TestActiveOptionsCache(t *testing.T) {
+	options := newStubOptionsRepository()
+	service := NewOptionsService(options)
+
+	active, err := service.GetActiveOptions("products")
+	require.NoError(t, err)
+	assert.Len(t, active, 2)
+
+	_, err = service.GetActiveOptions("products")
+	require.NoError(t, err)
+	assert.Equal(t, 1, options.listCalls, "active options are served from the cache")
+
+	require.NoError(t, service.SetOptionActive("products", 2, false))
+
+	active, err = service.GetActiveOptions("products")
+	require.NoError(t, err)
+	assert.Equal(t, 2, options.listCalls, "changes drop the cached options")
+	require.Len(t, active, 1)
+	assert.Equal(t, "PIX", active[0].Name)
+}
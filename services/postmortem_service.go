@@ -0,0 +1,334 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/repositories"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/validators"
+)
+
+type PostmortemService interface {
+	GetPostmortem(incidentID int) (*models.Postmortem, error)
+	StartPostmortem(ctx context.Context, incidentID int) (*models.Postmortem, error)
+	EditPostmortem(ctx context.Context, edit *models.PostmortemEdit) (*models.Postmortem, error)
+	SubmitPostmortem(ctx context.Context, submission *models.PostmortemSubmission) (*models.Postmortem, error)
+	ApprovePostmortem(ctx context.Context, incidentID int) (*models.Postmortem, error)
+	RequestPostmortemChanges(ctx context.Context, request *models.PostmortemChangeRequest) (*models.Postmortem, error)
+	GetPostmortemTemplates() ([]*models.PostmortemTemplate, error)
+	SavePostmortemTemplate(template *models.PostmortemTemplateInput) error
+	DeletePostmortemTemplate(typeID int) error
+}
+
+type postmortemService struct {
+	incidentRepository   repositories.IncidentRepository
+	postmortemRepository repositories.PostmortemRepository
+	userRepository       repositories.UserRepository
+	optionsService       OptionsService
+	webhooks             WebhookPublisher
+}
+
+func NewPostmortemService(incidentRepository repositories.IncidentRepository, postmortemRepository repositories.PostmortemRepository, userRepository repositories.UserRepository, optionsService OptionsService, webhooks WebhookPublisher) PostmortemService {
+	return &postmortemService{
+		incidentRepository:   incidentRepository,
+		postmortemRepository: postmortemRepository,
+		userRepository:       userRepository,
+		optionsService:       optionsService,
+		webhooks:             webhooks,
+	}
+}
+
+func (s *postmortemService) GetPostmortem(incidentID int) (*models.Postmortem, error) {
+	if _, err := s.incidentRepository.GetIncidentStatus(incidentID); err != nil {
+		log.Printf("GetPostmortem: Error retrieving incident: %v", err)
+		return nil, err
+	}
+
+	return s.postmortemRepository.GetPostmortem(incidentID)
+}
+
+// StartPostmortem creates the draft from the template of the incident's type,
+// falling back to the default template.
+func (s *postmortemService) StartPostmortem(ctx context.Context, incidentID int) (*models.Postmortem, error) {
+	log.Printf("StartPostmortem: Starting postmortem of incident ID %d", incidentID)
+
+	authorID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	incident, err := s.incidentRepository.GetIncidentByID(incidentID)
+	if err != nil {
+		log.Printf("StartPostmortem: Error retrieving incident: %v", err)
+		return nil, err
+	}
+
+	sections := models.DefaultPostmortemTemplate
+	template, err := s.postmortemRepository.GetPostmortemTemplateForType(incident.Type)
+	var notFound *customErrors.NotFoundError
+	switch {
+	case err == nil:
+		sections = template.PostmortemSections
+	case !errors.As(err, &notFound):
+		log.Printf("StartPostmortem: Error retrieving template: %v", err)
+		return nil, err
+	}
+
+	transitions, err := s.lifecycleTransitions(incidentID, StatusDocumentation)
+	if err != nil {
+		return nil, err
+	}
+
+	postmortem := &models.Postmortem{IncidentID: incidentID, AuthorID: authorID, PostmortemSections: sections}
+	if err := s.postmortemRepository.CreatePostmortem(postmortem, transitions); err != nil {
+		log.Printf("StartPostmortem: Error creating postmortem: %v", err)
+		return nil, err
+	}
+
+	s.publishTransitions(ctx, transitions)
+
+	return s.postmortemRepository.GetPostmortem(incidentID)
+}
+
+// EditPostmortem changes the sections of a draft. A postmortem in review has
+// to be sent back with a change request before it can be edited.
+func (s *postmortemService) EditPostmortem(ctx context.Context, edit *models.PostmortemEdit) (*models.Postmortem, error) {
+	log.Printf("EditPostmortem: Starting edit of postmortem of incident ID %d", edit.IncidentID)
+
+	if err := validators.ValidateStruct(edit); err != nil {
+		log.Printf("EditPostmortem: Validation error: %v", err)
+		return nil, &validators.ValidationError{Err: err}
+	}
+
+	if _, err := actorFromContext(ctx); err != nil {
+		return nil, err
+	}
+
+	postmortem, err := s.postmortemRepository.GetPostmortem(edit.IncidentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if postmortem.Status != models.PostmortemDraft {
+		return nil, &customErrors.ConflictError{Msg: "only draft postmortems can be edited"}
+	}
+
+	edit.Apply(&postmortem.PostmortemSections)
+	if err := s.postmortemRepository.UpdatePostmortemSections(edit.IncidentID, &postmortem.PostmortemSections); err != nil {
+		log.Printf("EditPostmortem: Error updating postmortem: %v", err)
+		return nil, err
+	}
+
+	return s.postmortemRepository.GetPostmortem(edit.IncidentID)
+}
+
+// SubmitPostmortem asks the named reviewers for approval and moves the
+// incident into review.
+func (s *postmortemService) SubmitPostmortem(ctx context.Context, submission *models.PostmortemSubmission) (*models.Postmortem, error) {
+	log.Printf("SubmitPostmortem: Submitting postmortem of incident ID %d", submission.IncidentID)
+
+	if err := validators.ValidateStruct(submission); err != nil {
+		log.Printf("SubmitPostmortem: Validation error: %v", err)
+		return nil, &validators.ValidationError{Err: err}
+	}
+
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	postmortem, err := s.postmortemRepository.GetPostmortem(submission.IncidentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if postmortem.Status != models.PostmortemDraft {
+		return nil, &customErrors.ConflictError{Msg: "only draft postmortems can be submitted"}
+	}
+
+	if err := s.validateReviewers(actorID, submission.Reviewers); err != nil {
+		return nil, err
+	}
+
+	transitions, err := s.lifecycleTransitions(submission.IncidentID, StatusDocumentation, StatusInReview)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.postmortemRepository.SubmitPostmortem(submission.IncidentID, submission.Reviewers, transitions, actorID); err != nil {
+		log.Printf("SubmitPostmortem: Error submitting postmortem: %v", err)
+		return nil, err
+	}
+
+	s.publishTransitions(ctx, transitions)
+
+	return s.postmortemRepository.GetPostmortem(submission.IncidentID)
+}
+
+func (s *postmortemService) ApprovePostmortem(ctx context.Context, incidentID int) (*models.Postmortem, error) {
+	log.Printf("ApprovePostmortem: Approving postmortem of incident ID %d", incidentID)
+
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	postmortem, err := s.postmortemRepository.GetPostmortem(incidentID)
+	if err != nil {
+		return nil, err
+	}
+
+	reviewer := findReviewer(postmortem, actorID)
+	if reviewer == nil {
+		return nil, &customErrors.ForbiddenError{Msg: "only the named reviewers can approve this postmortem"}
+	}
+
+	if postmortem.Status != models.PostmortemInReview {
+		return nil, &customErrors.ConflictError{Msg: "only postmortems in review can be approved"}
+	}
+
+	if reviewer.ApprovedAt != nil {
+		return nil, &customErrors.ConflictError{Msg: "you already approved this postmortem"}
+	}
+
+	approved, err := s.postmortemRepository.ApprovePostmortem(incidentID, actorID, models.NewCustomTimeNow())
+	if err != nil {
+		log.Printf("ApprovePostmortem: Error approving postmortem: %v", err)
+		return nil, err
+	}
+
+	if approved {
+		log.Printf("ApprovePostmortem: Postmortem of incident ID %d approved by every reviewer", incidentID)
+	}
+
+	return s.postmortemRepository.GetPostmortem(incidentID)
+}
+
+// RequestPostmortemChanges sends a postmortem in review back to draft. Any
+// reviewer or incident manager may ask for changes.
+func (s *postmortemService) RequestPostmortemChanges(ctx context.Context, request *models.PostmortemChangeRequest) (*models.Postmortem, error) {
+	log.Printf("RequestPostmortemChanges: Requesting changes to postmortem of incident ID %d", request.IncidentID)
+
+	if err := validators.ValidateStruct(request); err != nil {
+		log.Printf("RequestPostmortemChanges: Validation error: %v", err)
+		return nil, &validators.ValidationError{Err: err}
+	}
+
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	postmortem, err := s.postmortemRepository.GetPostmortem(request.IncidentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if findReviewer(postmortem, actorID) == nil && !actorCan(ctx, models.PermissionIncidentsManage) {
+		return nil, &customErrors.ForbiddenError{Msg: "only the named reviewers can request changes to this postmortem"}
+	}
+
+	if postmortem.Status != models.PostmortemInReview {
+		return nil, &customErrors.ConflictError{Msg: "only postmortems in review can be sent back"}
+	}
+
+	transitions, err := s.lifecycleTransitions(request.IncidentID, StatusDocumentation)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.postmortemRepository.RequestPostmortemChanges(request, transitions, actorID); err != nil {
+		log.Printf("RequestPostmortemChanges: Error updating postmortem: %v", err)
+		return nil, err
+	}
+
+	s.publishTransitions(ctx, transitions)
+
+	return s.postmortemRepository.GetPostmortem(request.IncidentID)
+}
+
+func (s *postmortemService) GetPostmortemTemplates() ([]*models.PostmortemTemplate, error) {
+	return s.postmortemRepository.GetPostmortemTemplates()
+}
+
+func (s *postmortemService) SavePostmortemTemplate(template *models.PostmortemTemplateInput) error {
+	log.Printf("SavePostmortemTemplate: Saving template for type ID %d", template.TypeID)
+
+	if err := validators.ValidateStruct(template); err != nil {
+		log.Printf("SavePostmortemTemplate: Validation error: %v", err)
+		return &validators.ValidationError{Err: err}
+	}
+
+	return s.postmortemRepository.SavePostmortemTemplate(template)
+}
+
+func (s *postmortemService) DeletePostmortemTemplate(typeID int) error {
+	return s.postmortemRepository.DeletePostmortemTemplate(typeID)
+}
+
+// validateReviewers requires active people other than the submitter.
+func (s *postmortemService) validateReviewers(actorID int, reviewers []int) error {
+	var messages []string
+	for _, reviewerID := range reviewers {
+		if reviewerID == actorID {
+			messages = append(messages, "You cannot review your own submission")
+			continue
+		}
+
+		user, err := s.userRepository.GetUserByID(reviewerID)
+		var notFound *customErrors.NotFoundError
+		if errors.As(err, &notFound) {
+			messages = append(messages, fmt.Sprintf("Reviewer %d does not exist", reviewerID))
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if user.IsServiceAccount || user.DeactivatedAt != nil {
+			messages = append(messages, fmt.Sprintf("Reviewer %d cannot review postmortems", reviewerID))
+		}
+	}
+
+	if len(messages) > 0 {
+		return &validators.ValidationError{Messages: messages}
+	}
+	return nil
+}
+
+// lifecycleTransitions plans the status changes a postmortem step makes on
+// its incident.
+func (s *postmortemService) lifecycleTransitions(incidentID int, targets ...string) ([]*models.IncidentStatusTransition, error) {
+	current, err := s.incidentRepository.GetIncidentStatus(incidentID)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses, err := s.optionsService.GetActiveOptions("status")
+	if err != nil {
+		return nil, err
+	}
+
+	return planTransitions(incidentID, current, statuses, models.NewCustomTimeNow(), targets...), nil
+}
+
+// publishTransitions reports each status change a postmortem step applied,
+// once the step is saved.
+func (s *postmortemService) publishTransitions(ctx context.Context, transitions []*models.IncidentStatusTransition) {
+	for _, transition := range transitions {
+		publishIncidentEvent(ctx, s.webhooks, s.incidentRepository, models.WebhookIncidentStatusChanged, transition.ID, map[string]interface{}{"status": transition.From})
+	}
+}
+
+func findReviewer(postmortem *models.Postmortem, userID int) *models.PostmortemReviewer {
+	for _, reviewer := range postmortem.Reviewers {
+		if reviewer.UserID == userID {
+			return reviewer
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/repositories"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/validators"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubPostmortemRepository keeps a single postmortem; templates are unused.
+// Transitions are recorded and, when incidents is set, applied to it.
+type stubPostmortemRepository struct {
+	repositories.PostmortemRepository
+	postmortem  *models.Postmortem
+	transitions []*models.IncidentStatusTransition
+	incidents   *stubTransitionIncidentRepository
+}
+
+func (r *stubPostmortemRepository) applyTransitions(transitions []*models.IncidentStatusTransition) {
+	r.transitions = append(r.transitions, transitions...)
+	if r.incidents != nil && len(transitions) > 0 {
+		r.incidents.status = transitions[len(transitions)-1].To
+	}
+}
+
+func (r *stubPostmortemRepository) GetPostmortem(incidentID int) (*models.Postmortem, error) {
+	if r.postmortem == nil || r.postmortem.IncidentID != incidentID {
+		return nil, &customErrors.NotFoundError{Msg: "postmortem not found"}
+	}
+	return r.postmortem, nil
+}
+
+func (r *stubPostmortemRepository) SubmitPostmortem(incidentID int, reviewers []int, transitions []*models.IncidentStatusTransition, actorID int) error {
+	r.postmortem.Status = models.PostmortemInReview
+	r.postmortem.Reviewers = nil
+	for _, reviewerID := range reviewers {
+		r.postmortem.Reviewers = append(r.postmortem.Reviewers, &models.PostmortemReviewer{UserID: reviewerID})
+	}
+	r.applyTransitions(transitions)
+	return nil
+}
+
+func (r *stubPostmortemRepository) RequestPostmortemChanges(request *models.PostmortemChangeRequest, transitions []*models.IncidentStatusTransition, actorID int) error {
+	r.postmortem.Status = models.PostmortemDraft
+	r.applyTransitions(transitions)
+	return nil
+}
+
+func (r *stubPostmortemRepository) ApprovePostmortem(incidentID, reviewerID int, at *models.CustomTime) (bool, error) {
+	approved := true
+	for _, reviewer := range r.postmortem.Reviewers {
+		if reviewer.UserID == reviewerID {
+			reviewer.ApprovedAt = at
+		}
+		approved = approved && reviewer.ApprovedAt != nil
+	}
+	if approved {
+		r.postmortem.Status = models.PostmortemApproved
+	}
+	return approved, nil
+}
+
+func TestPostmortemReview(t *testing.T) {
+	postmortems := &stubPostmortemRepository{postmortem: &models.Postmortem{IncidentID: 3, Status: models.PostmortemDraft, AuthorID: 7}}
+	service := NewPostmortemService(
+		&stubStatusIncidentRepository{incidentID: 3},
+		postmortems,
+		&stubUserRepository{user: &models.User{ID: 8, Email: "jane@example.com"}},
+		&stubOptionsService{active: map[string][]*models.Option{"status": {{Name: "investigating"}, {Name: "documentation"}, {Name: "in review"}}}},
+		nil,
+	)
+	author := context.WithValue(context.Background(), "user_id", 7)
+	reviewer := context.WithValue(context.Background(), "user_id", 8)
+
+	var validationErr *validators.ValidationError
+	_, err := service.SubmitPostmortem(author, &models.PostmortemSubmission{IncidentID: 3, Reviewers: []int{7}})
+	assert.ErrorAs(t, err, &validationErr, "authors cannot review their own postmortem")
+
+	postmortem, err := service.SubmitPostmortem(author, &models.PostmortemSubmission{IncidentID: 3, Reviewers: []int{8}})
+	require.NoError(t, err)
+	assert.Equal(t, models.PostmortemInReview, postmortem.Status)
+	assert.Empty(t, postmortems.transitions, "an incident still under investigation keeps its status")
+
+	var conflict *customErrors.ConflictError
+	_, err = service.EditPostmortem(author, &models.PostmortemEdit{IncidentID: 3})
+	assert.ErrorAs(t, err, &conflict)
+
+	var forbidden *customErrors.ForbiddenError
+	_, err = service.ApprovePostmortem(author, 3)
+	assert.ErrorAs(t, err, &forbidden)
+
+	postmortem, err = service.ApprovePostmortem(reviewer, 3)
+	require.NoError(t, err)
+	assert.Equal(t, models.PostmortemApproved, postmortem.Status)
+
+	_, err = service.ApprovePostmortem(reviewer, 3)
+	assert.ErrorAs(t, err, &conflict)
+}
+
+// recordingPublisher keeps the webhook events it is asked to publish.
+type recordingPublisher struct {
+	events []string
+	data   []*models.IncidentWebhookData
+}
+
+func (p *recordingPublisher) Publish(eventType string, data interface{}) {
+	p.events = append(p.events, eventType)
+	p.data = append(p.data, data.(*models.IncidentWebhookData))
+}
+
+func TestPostmortemPublishesStatusChanges(t *testing.T) {
+	incidents := &stubTransitionIncidentRepository{status: "Resolved"}
+	postmortems := &stubPostmortemRepository{postmortem: &models.Postmortem{IncidentID: 3, Status: models.PostmortemDraft, AuthorID: 7}, incidents: incidents}
+	webhooks := &recordingPublisher{}
+	service := NewPostmortemService(
+		incidents,
+		postmortems,
+		&stubUserRepository{user: &models.User{ID: 8, Email: "jane@example.com"}},
+		&stubOptionsService{active: map[string][]*models.Option{"status": {{Name: "Resolved"}, {Name: "Documentation"}, {Name: "In Review"}}}},
+		webhooks,
+	)
+	author := context.WithValue(context.Background(), "user_id", 7)
+	reviewer := context.WithValue(context.Background(), "user_id", 8)
+
+	_, err := service.SubmitPostmortem(author, &models.PostmortemSubmission{IncidentID: 3, Reviewers: []int{8}})
+	require.NoError(t, err)
+	require.Len(t, webhooks.events, 2, "one event per applied transition")
+	assert.Equal(t, []string{models.WebhookIncidentStatusChanged, models.WebhookIncidentStatusChanged}, webhooks.events)
+	assert.Equal(t, "Resolved", webhooks.data[0].Previous["status"])
+	assert.Equal(t, "Documentation", webhooks.data[1].Previous["status"])
+	assert.Equal(t, 7, *webhooks.data[1].ActorID)
+
+	_, err = service.RequestPostmortemChanges(reviewer, &models.PostmortemChangeRequest{IncidentID: 3, Reason: "Add the timeline"})
+	require.NoError(t, err)
+	require.Len(t, webhooks.events, 3)
+	assert.Equal(t, "In Review", webhooks.data[2].Previous["status"])
+	assert.Equal(t, "Documentation", webhooks.data[2].Incident.Status)
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"log"
+
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/repositories"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/validators"
+)
+
+type SecurityEventService interface {
+	GetSecurityEvents(queryParams *models.SecurityEventQueryParams) ([]*models.SecurityEvent, *models.Pagination, error)
+}
+
+type securityEventService struct {
+	securityEventRepository repositories.SecurityEventRepository
+}
+
+func NewSecurityEventService(securityEventRepository repositories.SecurityEventRepository) SecurityEventService {
+	return &securityEventService{securityEventRepository: securityEventRepository}
+}
+
+func (s *securityEventService) GetSecurityEvents(queryParams *models.SecurityEventQueryParams) ([]*models.SecurityEvent, *models.Pagination, error) {
+	log.Println("GetSecurityEvents: Starting security events retrieval")
+
+	if err := validators.ValidateStruct(queryParams); err != nil {
+		log.Printf("GetSecurityEvents: Validation error: %v", err)
+		return nil, nil, &validators.ValidationError{Err: err}
+	}
+
+	if queryParams.Page == 0 {
+		queryParams.Page = 1
+	}
+	if queryParams.Limit == 0 {
+		queryParams.Limit = models.DefaultPageLimit
+	}
+
+	events, total, err := s.securityEventRepository.GetSecurityEvents(queryParams)
+	if err != nil {
+		log.Printf("GetSecurityEvents: Error retrieving security events: %v", err)
+		return nil, nil, err
+	}
+
+	log.Printf("GetSecurityEvents: Successfully retrieved %d security events", len(events))
+	return events, models.NewPagination(queryParams.Page, queryParams.Limit, total), nil
+}
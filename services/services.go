@@ -4,4 +4,19 @@ package services
 type Services struct {
     UserService UserService
     IncidentService IncidentService
-    OptionsService  OptionsService}
+    OptionsService  OptionsService
+    IncidentUpdateService IncidentUpdateService
+    SessionService SessionService
+    SecurityEventService SecurityEventService
+    TwoFactorService TwoFactorService
+    APIKeyService APIKeyService
+    OIDCService OIDCService
+    AvatarService AvatarService
+    IncidentAttachmentService IncidentAttachmentService
+    PostmortemService PostmortemService
+    ActionItemService ActionItemService
+    WebhookService WebhookService
+    AlertService AlertService
+    IncidentLinkService IncidentLinkService
+}
+
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/config"
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/repositories"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/utils"
+)
+
+// refreshTokenBytes is the entropy of a refresh token.
+const refreshTokenBytes = 32
+
+type SessionService interface {
+	CreateSession(user *models.User, userAgent, ip string) (*models.AuthTokens, error)
+	RefreshSession(refreshToken string) (*models.AuthTokens, error)
+	Logout(refreshToken string) error
+	ListSessions(ctx context.Context) ([]*models.Session, error)
+	RevokeSession(ctx context.Context, sessionID int) error
+	IsSessionActive(sessionID int) (bool, error)
+}
+
+type sessionService struct {
+	sessionRepository repositories.SessionRepository
+	userRepository    repositories.UserRepository
+	accessTokenTTL    time.Duration
+	refreshTokenTTL   time.Duration
+}
+
+func NewSessionService(sessionRepository repositories.SessionRepository, userRepository repositories.UserRepository) SessionService {
+	cfg := config.GetConfig()
+
+	return &sessionService{
+		sessionRepository: sessionRepository,
+		userRepository:    userRepository,
+		accessTokenTTL:    cfg.AccessTokenTTL,
+		refreshTokenTTL:   cfg.RefreshTokenTTL,
+	}
+}
+
+func (s *sessionService) CreateSession(user *models.User, userAgent, ip string) (*models.AuthTokens, error) {
+	log.Printf("CreateSession: Starting session creation for user ID %d", user.ID)
+
+	if user.DeactivatedAt != nil {
+		return nil, &customErrors.AuthenticationError{Msg: "this account has been deactivated"}
+	}
+
+	refreshToken, err := utils.GenerateToken(refreshTokenBytes)
+	if err != nil {
+		log.Printf("CreateSession: Error generating refresh token: %v", err)
+		return nil, err
+	}
+
+	refreshExpiresAt := time.Now().Add(s.refreshTokenTTL)
+	session := &models.Session{
+		UserID:    user.ID,
+		UserAgent: &userAgent,
+		IP:        &ip,
+		ExpiresAt: models.NewCustomTime(refreshExpiresAt),
+	}
+
+	sessionID, err := s.sessionRepository.CreateSession(session, utils.HashToken(refreshToken))
+	if err != nil {
+		log.Printf("CreateSession: Error storing session: %v", err)
+		return nil, err
+	}
+
+	log.Printf("CreateSession: Session %d created for user ID %d", sessionID, user.ID)
+	return s.issueTokens(user, sessionID, refreshToken, refreshExpiresAt)
+}
+
+func (s *sessionService) RefreshSession(refreshToken string) (*models.AuthTokens, error) {
+	log.Println("RefreshSession: Starting token refresh")
+
+	if refreshToken == "" {
+		return nil, &customErrors.AuthenticationError{Msg: "missing refresh token"}
+	}
+
+	tokenHash := utils.HashToken(refreshToken)
+
+	session, err := s.sessionRepository.GetSessionByTokenHash(tokenHash)
+	var notFound *customErrors.NotFoundError
+	if errors.As(err, &notFound) {
+		s.revokeOnReuse(tokenHash)
+		return nil, &customErrors.AuthenticationError{Msg: "invalid refresh token"}
+	}
+	if err != nil {
+		log.Printf("RefreshSession: Error retrieving session: %v", err)
+		return nil, err
+	}
+
+	if session.RevokedAt != nil || time.Now().After(time.Time(*session.ExpiresAt)) {
+		log.Printf("RefreshSession: Session %d is revoked or expired", session.ID)
+		return nil, &customErrors.AuthenticationError{Msg: "session has expired, please log in again"}
+	}
+
+	user, err := s.userRepository.GetUserByID(session.UserID)
+	if err != nil {
+		log.Printf("RefreshSession: Error retrieving user: %v", err)
+		return nil, err
+	}
+
+	if user.DeactivatedAt != nil {
+		return nil, &customErrors.AuthenticationError{Msg: "this account has been deactivated"}
+	}
+
+	newRefreshToken, err := utils.GenerateToken(refreshTokenBytes)
+	if err != nil {
+		log.Printf("RefreshSession: Error generating refresh token: %v", err)
+		return nil, err
+	}
+
+	refreshExpiresAt := time.Now().Add(s.refreshTokenTTL)
+	if err := s.sessionRepository.RotateSessionToken(session.ID, tokenHash, utils.HashToken(newRefreshToken), refreshExpiresAt); err != nil {
+		log.Printf("RefreshSession: Error rotating refresh token: %v", err)
+		return nil, err
+	}
+
+	log.Printf("RefreshSession: Session %d refreshed", session.ID)
+	return s.issueTokens(user, session.ID, newRefreshToken, refreshExpiresAt)
+}
+
+// revokeOnReuse revokes the session when an already rotated refresh token is
+// presented again, which means the token was copied by someone else.
+func (s *sessionService) revokeOnReuse(tokenHash string) {
+	session, err := s.sessionRepository.GetSessionByPreviousTokenHash(tokenHash)
+	if err != nil {
+		return
+	}
+
+	log.Printf("RefreshSession: Rotated refresh token reused, revoking session %d", session.ID)
+	if err := s.sessionRepository.RevokeSession(session.ID); err != nil {
+		log.Printf("RefreshSession: Error revoking session %d: %v", session.ID, err)
+	}
+}
+
+func (s *sessionService) Logout(refreshToken string) error {
+	log.Println("Logout: Starting logout")
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	session, err := s.sessionRepository.GetSessionByTokenHash(utils.HashToken(refreshToken))
+	var notFound *customErrors.NotFoundError
+	if errors.As(err, &notFound) {
+		return nil
+	}
+	if err != nil {
+		log.Printf("Logout: Error retrieving session: %v", err)
+		return err
+	}
+
+	if err := s.sessionRepository.RevokeSession(session.ID); err != nil {
+		log.Printf("Logout: Error revoking session: %v", err)
+		return err
+	}
+
+	log.Printf("Logout: Session %d revoked", session.ID)
+	return nil
+}
+
+func (s *sessionService) ListSessions(ctx context.Context) ([]*models.Session, error) {
+	userID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("ListSessions: Retrieving sessions for user ID %d", userID)
+
+	sessions, err := s.sessionRepository.ListActiveSessions(userID)
+	if err != nil {
+		log.Printf("ListSessions: Error retrieving sessions: %v", err)
+		return nil, err
+	}
+
+	currentID, _ := ctx.Value("session_id").(int)
+	for _, session := range sessions {
+		session.Current = session.ID == currentID
+	}
+
+	return sessions, nil
+}
+
+func (s *sessionService) RevokeSession(ctx context.Context, sessionID int) error {
+	userID, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("RevokeSession: User ID %d revoking session %d", userID, sessionID)
+
+	if err := s.sessionRepository.RevokeUserSession(userID, sessionID); err != nil {
+		log.Printf("RevokeSession: Error revoking session: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *sessionService) IsSessionActive(sessionID int) (bool, error) {
+	return s.sessionRepository.IsSessionActive(sessionID)
+}
+
+func (s *sessionService) issueTokens(user *models.User, sessionID int, refreshToken string, refreshExpiresAt time.Time) (*models.AuthTokens, error) {
+	accessExpiresAt := time.Now().Add(s.accessTokenTTL)
+
+	accessToken, err := utils.GenerateJWT(user.Name, user.ID, user.Role, sessionID, accessExpiresAt)
+	if err != nil {
+		log.Printf("issueTokens: Error generating JWT: %v", err)
+		return nil, err
+	}
+
+	return &models.AuthTokens{
+		SessionID:        sessionID,
+		AccessToken:      accessToken,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
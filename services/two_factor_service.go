@@ -0,0 +1,417 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/config"
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/repositories"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/utils"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/validators"
+)
+
+// recoveryCodeCount is how many single-use recovery codes a user gets.
+const recoveryCodeCount = 10
+
+type TwoFactorService interface {
+	StartLoginChallenge(user *models.User) (*models.TwoFactorChallenge, error)
+	EnrollWithChallenge(input *models.TwoFactorChallengeInput) (*models.TwoFactorSetup, error)
+	VerifyLoginChallenge(input *models.TwoFactorVerify, ip string) (*models.User, []string, error)
+	BeginEnrollment(ctx context.Context) (*models.TwoFactorSetup, error)
+	EnableTwoFactor(ctx context.Context, input *models.TwoFactorCode) ([]string, error)
+	DisableTwoFactor(ctx context.Context, input *models.TwoFactorDisable) error
+	RegenerateRecoveryCodes(ctx context.Context, input *models.TwoFactorCode) ([]string, error)
+}
+
+type twoFactorService struct {
+	userRepo          repositories.UserRepository
+	userTokenRepo     repositories.UserTokenRepository
+	recoveryCodeRepo  repositories.RecoveryCodeRepository
+	securityEventRepo repositories.SecurityEventRepository
+	loginThrottle     *loginThrottle
+	issuer            string
+	requiredRoles     []string
+	challengeTTL      time.Duration
+}
+
+func NewTwoFactorService(userRepo repositories.UserRepository, userTokenRepo repositories.UserTokenRepository, recoveryCodeRepo repositories.RecoveryCodeRepository, loginAttemptRepo repositories.LoginAttemptRepository, securityEventRepo repositories.SecurityEventRepository) TwoFactorService {
+	cfg := config.GetConfig()
+
+	return &twoFactorService{
+		userRepo:          userRepo,
+		userTokenRepo:     userTokenRepo,
+		recoveryCodeRepo:  recoveryCodeRepo,
+		securityEventRepo: securityEventRepo,
+		loginThrottle:     newLoginThrottle(loginAttemptRepo, securityEventRepo),
+		issuer:            cfg.TOTPIssuer,
+		requiredRoles:     cfg.TOTPRequiredRoles,
+		challengeTTL:      cfg.LoginChallengeTTL,
+	}
+}
+
+// requiresTwoFactor reports whether TOTP_REQUIRED_ROLES forces 2FA on a role.
+func (s *twoFactorService) requiresTwoFactor(role string) bool {
+	for _, required := range s.requiredRoles {
+		if strings.EqualFold(required, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// StartLoginChallenge is the end of the first login step. It returns nil when
+// the password is enough, or a challenge the client must complete through
+// VerifyLoginChallenge before it gets a session.
+func (s *twoFactorService) StartLoginChallenge(user *models.User) (*models.TwoFactorChallenge, error) {
+	enrolled := user.TOTPEnabledAt != nil
+	if !enrolled && !s.requiresTwoFactor(user.Role) {
+		return nil, nil
+	}
+
+	log.Printf("StartLoginChallenge: Issuing two-factor challenge for user ID %d", user.ID)
+
+	token, err := utils.GenerateToken(userTokenBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(s.challengeTTL)
+	if err := s.userTokenRepo.CreateUserToken(user.ID, models.TokenPurposeLoginChallenge, utils.HashToken(token), expiresAt); err != nil {
+		log.Printf("StartLoginChallenge: Error storing challenge: %v", err)
+		return nil, err
+	}
+
+	return &models.TwoFactorChallenge{
+		Challenge:          token,
+		ExpiresAt:          expiresAt,
+		EnrollmentRequired: !enrolled,
+	}, nil
+}
+
+// EnrollWithChallenge lets a user whose role requires 2FA set it up in the
+// middle of logging in, before they have a session.
+func (s *twoFactorService) EnrollWithChallenge(input *models.TwoFactorChallengeInput) (*models.TwoFactorSetup, error) {
+	log.Println("EnrollWithChallenge: Starting enrollment from login challenge")
+
+	if err := validators.ValidateStruct(input); err != nil {
+		log.Printf("EnrollWithChallenge: Validation error: %v", err)
+		return nil, &validators.ValidationError{Err: err}
+	}
+
+	user, err := s.challengeUser(input.Challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabledAt != nil {
+		return nil, &customErrors.ConflictError{Msg: "two-factor authentication is already enabled"}
+	}
+
+	return s.beginEnrollment(user)
+}
+
+func (s *twoFactorService) VerifyLoginChallenge(input *models.TwoFactorVerify, ip string) (*models.User, []string, error) {
+	log.Println("VerifyLoginChallenge: Starting second login step")
+
+	if err := validators.ValidateStruct(input); err != nil {
+		log.Printf("VerifyLoginChallenge: Validation error: %v", err)
+		return nil, nil, &validators.ValidationError{Err: err}
+	}
+
+	user, err := s.challengeUser(input.Challenge)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	email := strings.ToLower(user.Email)
+	if err := s.loginThrottle.check(email, ip); err != nil {
+		log.Printf("VerifyLoginChallenge: Verification throttled: %v", err)
+		return nil, nil, err
+	}
+
+	enrolling := user.TOTPEnabledAt == nil
+	if enrolling && user.TOTPSecret == nil {
+		return nil, nil, &validators.ValidationError{Messages: []string{"Set up an authenticator app before verifying"}}
+	}
+
+	var verified bool
+	if input.RecoveryCode != "" && !enrolling {
+		verified, err = s.recoveryCodeRepo.ConsumeRecoveryCode(user.ID, hashRecoveryCode(input.RecoveryCode))
+		if verified {
+			s.recordSecurityEvent(models.SecurityEventRecoveryCode, user, ip)
+		}
+	} else {
+		verified, err = s.verifyCode(user, input.Code)
+	}
+	if err != nil {
+		log.Printf("VerifyLoginChallenge: Error verifying code: %v", err)
+		return nil, nil, err
+	}
+
+	if !verified {
+		log.Printf("VerifyLoginChallenge: Invalid code for user ID %d", user.ID)
+		if err := s.loginThrottle.recordFailure(email, ip, &user.ID); err != nil {
+			log.Printf("VerifyLoginChallenge: Error recording failed attempt: %v", err)
+		}
+		return nil, nil, &customErrors.AuthenticationError{Msg: "invalid two-factor code"}
+	}
+
+	// Consuming the challenge last keeps it usable for a retry after a typo,
+	// and makes a second completion of the same challenge fail.
+	if _, err := s.userTokenRepo.ConsumeUserToken(models.TokenPurposeLoginChallenge, utils.HashToken(input.Challenge)); err != nil {
+		log.Printf("VerifyLoginChallenge: Error consuming challenge: %v", err)
+		return nil, nil, &customErrors.AuthenticationError{Msg: "login challenge is invalid or has expired"}
+	}
+
+	var recoveryCodes []string
+	if enrolling {
+		if recoveryCodes, err = s.enable(user, ip); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := s.loginThrottle.reset(email); err != nil {
+		log.Printf("VerifyLoginChallenge: Error clearing failed attempts: %v", err)
+	}
+
+	log.Printf("VerifyLoginChallenge: User ID %d passed two-factor verification", user.ID)
+	return user, recoveryCodes, nil
+}
+
+func (s *twoFactorService) BeginEnrollment(ctx context.Context) (*models.TwoFactorSetup, error) {
+	user, err := s.contextUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("BeginEnrollment: Starting enrollment for user ID %d", user.ID)
+
+	if user.TOTPEnabledAt != nil {
+		return nil, &customErrors.ConflictError{Msg: "two-factor authentication is already enabled"}
+	}
+
+	return s.beginEnrollment(user)
+}
+
+func (s *twoFactorService) EnableTwoFactor(ctx context.Context, input *models.TwoFactorCode) ([]string, error) {
+	if err := validators.ValidateStruct(input); err != nil {
+		log.Printf("EnableTwoFactor: Validation error: %v", err)
+		return nil, &validators.ValidationError{Err: err}
+	}
+
+	user, err := s.contextUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("EnableTwoFactor: Confirming enrollment for user ID %d", user.ID)
+
+	if user.TOTPEnabledAt != nil {
+		return nil, &customErrors.ConflictError{Msg: "two-factor authentication is already enabled"}
+	}
+
+	if user.TOTPSecret == nil {
+		return nil, &validators.ValidationError{Messages: []string{"Start the two-factor setup before enabling it"}}
+	}
+
+	if err := s.requireCode(user, input.Code); err != nil {
+		return nil, err
+	}
+
+	return s.enable(user, "")
+}
+
+func (s *twoFactorService) DisableTwoFactor(ctx context.Context, input *models.TwoFactorDisable) error {
+	if err := validators.ValidateStruct(input); err != nil {
+		log.Printf("DisableTwoFactor: Validation error: %v", err)
+		return &validators.ValidationError{Err: err}
+	}
+
+	user, err := s.contextUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("DisableTwoFactor: Disabling two-factor for user ID %d", user.ID)
+
+	if user.TOTPEnabledAt == nil {
+		return &customErrors.ConflictError{Msg: "two-factor authentication is not enabled"}
+	}
+
+	if s.requiresTwoFactor(user.Role) {
+		return &customErrors.ForbiddenError{Msg: fmt.Sprintf("two-factor authentication is required for the %s role", user.Role)}
+	}
+
+	if err := utils.ComparePassword(input.Password, user.Password); err != nil {
+		return &validators.ValidationError{Messages: []string{"Password is incorrect"}}
+	}
+
+	if err := s.requireCode(user, input.Code); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.DisableTOTP(user.ID); err != nil {
+		log.Printf("DisableTwoFactor: Error disabling TOTP: %v", err)
+		return err
+	}
+
+	if err := s.recoveryCodeRepo.DeleteRecoveryCodes(user.ID); err != nil {
+		log.Printf("DisableTwoFactor: Error deleting recovery codes: %v", err)
+		return err
+	}
+
+	s.recordSecurityEvent(models.SecurityEventTwoFactorOff, user, "")
+	return nil
+}
+
+func (s *twoFactorService) RegenerateRecoveryCodes(ctx context.Context, input *models.TwoFactorCode) ([]string, error) {
+	if err := validators.ValidateStruct(input); err != nil {
+		log.Printf("RegenerateRecoveryCodes: Validation error: %v", err)
+		return nil, &validators.ValidationError{Err: err}
+	}
+
+	user, err := s.contextUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("RegenerateRecoveryCodes: Regenerating codes for user ID %d", user.ID)
+
+	if user.TOTPEnabledAt == nil {
+		return nil, &customErrors.ConflictError{Msg: "two-factor authentication is not enabled"}
+	}
+
+	if err := s.requireCode(user, input.Code); err != nil {
+		return nil, err
+	}
+
+	return s.newRecoveryCodes(user.ID)
+}
+
+func (s *twoFactorService) beginEnrollment(user *models.User) (*models.TwoFactorSetup, error) {
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.SetTOTPSecret(user.ID, secret); err != nil {
+		log.Printf("beginEnrollment: Error storing secret: %v", err)
+		return nil, err
+	}
+
+	return &models.TwoFactorSetup{
+		Secret: secret,
+		URI:    utils.TOTPURI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+func (s *twoFactorService) enable(user *models.User, ip string) ([]string, error) {
+	if err := s.userRepo.EnableTOTP(user.ID); err != nil {
+		log.Printf("enable: Error enabling TOTP: %v", err)
+		return nil, err
+	}
+
+	s.recordSecurityEvent(models.SecurityEventTwoFactorOn, user, ip)
+	return s.newRecoveryCodes(user.ID)
+}
+
+// verifyCode checks an authenticator code and refuses to accept the same
+// code, or an older one, twice.
+func (s *twoFactorService) verifyCode(user *models.User, code string) (bool, error) {
+	if user.TOTPSecret == nil {
+		return false, nil
+	}
+
+	step, ok := utils.ValidateTOTP(*user.TOTPSecret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+
+	return s.userRepo.ClaimTOTPStep(user.ID, step)
+}
+
+// requireCode is verifyCode for signed-in users, where a wrong code is a bad
+// request rather than a failed login.
+func (s *twoFactorService) requireCode(user *models.User, code string) error {
+	verified, err := s.verifyCode(user, code)
+	if err != nil {
+		return err
+	}
+	if !verified {
+		return &validators.ValidationError{Messages: []string{"Code is invalid or has already been used"}}
+	}
+	return nil
+}
+
+func (s *twoFactorService) newRecoveryCodes(userID int) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+
+	for i := range codes {
+		buf := make([]byte, 5)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(buf))
+		codes[i] = code[:4] + "-" + code[4:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	if err := s.recoveryCodeRepo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		log.Printf("newRecoveryCodes: Error storing recovery codes: %v", err)
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// hashRecoveryCode ignores case, spaces and dashes, so codes can be typed the
+// way they read.
+func hashRecoveryCode(code string) string {
+	normalized := strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(code)))
+	return utils.HashToken(normalized)
+}
+
+func (s *twoFactorService) challengeUser(challenge string) (*models.User, error) {
+	userID, err := s.userTokenRepo.GetUserToken(models.TokenPurposeLoginChallenge, utils.HashToken(challenge))
+
+	var notFound *customErrors.NotFoundError
+	if errors.As(err, &notFound) {
+		return nil, &customErrors.AuthenticationError{Msg: "login challenge is invalid or has expired"}
+	}
+	if err != nil {
+		log.Printf("challengeUser: Error retrieving challenge: %v", err)
+		return nil, err
+	}
+
+	return s.userRepo.GetUserByID(userID)
+}
+
+func (s *twoFactorService) contextUser(ctx context.Context) (*models.User, error) {
+	userID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.userRepo.GetUserByID(userID)
+}
+
+func (s *twoFactorService) recordSecurityEvent(eventType string, user *models.User, ip string) {
+	event := &models.SecurityEvent{Type: eventType, UserID: &user.ID, Email: &user.Email}
+	if ip != "" {
+		event.IP = &ip
+	}
+
+	if err := s.securityEventRepo.CreateSecurityEvent(event); err != nil {
+		log.Printf("recordSecurityEvent: Error recording %s event: %v", eventType, err)
+	}
+}
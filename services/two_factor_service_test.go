@@ -0,0 +1,104 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubRecoveryCodeRepository keeps unused code hashes in memory.
+type stubRecoveryCodeRepository struct {
+	unused map[string]bool
+}
+
+func (r *stubRecoveryCodeRepository) ReplaceRecoveryCodes(userID int, codeHashes []string) error {
+	r.unused = map[string]bool{}
+	for _, hash := range codeHashes {
+		r.unused[hash] = true
+	}
+	return nil
+}
+
+func (r *stubRecoveryCodeRepository) ConsumeRecoveryCode(userID int, codeHash string) (bool, error) {
+	if !r.unused[codeHash] {
+		return false, nil
+	}
+	delete(r.unused, codeHash)
+	return true, nil
+}
+
+func (r *stubRecoveryCodeRepository) DeleteRecoveryCodes(userID int) error {
+	r.unused = nil
+	return nil
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	secret, _ := utils.GenerateTOTPSecret()
+	user := &models.User{ID: 7, Email: "john@example.com", Role: models.RoleResponder, TOTPSecret: &secret, TOTPEnabledAt: models.NewCustomTimeNow()}
+
+	recoveryCodes := &stubRecoveryCodeRepository{}
+	service := &twoFactorService{
+		userRepo:          &stubUserRepository{user: user},
+		userTokenRepo:     &stubUserTokenRepository{tokens: map[string]int{}},
+		recoveryCodeRepo:  recoveryCodes,
+		securityEventRepo: &stubSecurityEventRepository{},
+		loginThrottle:     &loginThrottle{attempts: newStubLoginAttemptRepository(), policies: map[string]throttlePolicy{}},
+		challengeTTL:      time.Minute,
+	}
+
+	codes, err := service.newRecoveryCodes(user.ID)
+	assert.NoError(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+
+	challenge, err := service.StartLoginChallenge(user)
+	assert.NoError(t, err)
+	if !assert.NotNil(t, challenge) {
+		return
+	}
+	assert.False(t, challenge.EnrollmentRequired)
+
+	code, _ := utils.TOTPCode(secret, utils.TOTPStep(time.Now()))
+	var authErr *customErrors.AuthenticationError
+
+	_, _, err = service.VerifyLoginChallenge(&models.TwoFactorVerify{Challenge: challenge.Challenge, Code: "000000"}, "10.0.0.1")
+	assert.ErrorAs(t, err, &authErr, "a wrong code leaves the challenge usable")
+
+	verified, _, err := service.VerifyLoginChallenge(&models.TwoFactorVerify{Challenge: challenge.Challenge, Code: code}, "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, user, verified)
+
+	_, _, err = service.VerifyLoginChallenge(&models.TwoFactorVerify{Challenge: challenge.Challenge, Code: code}, "10.0.0.1")
+	assert.ErrorAs(t, err, &authErr, "challenges are single-use")
+
+	// A code cannot be replayed on a new challenge either, but a recovery
+	// code still gets the user in, once
+	challenge, _ = service.StartLoginChallenge(user)
+	_, _, err = service.VerifyLoginChallenge(&models.TwoFactorVerify{Challenge: challenge.Challenge, Code: code}, "10.0.0.1")
+	assert.ErrorAs(t, err, &authErr)
+
+	_, _, err = service.VerifyLoginChallenge(&models.TwoFactorVerify{Challenge: challenge.Challenge, RecoveryCode: " " + codes[3] + " "}, "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Len(t, recoveryCodes.unused, recoveryCodeCount-1)
+}
+
+func TestStartLoginChallengeForRequiredRole(t *testing.T) {
+	service := &twoFactorService{
+		userTokenRepo: &stubUserTokenRepository{tokens: map[string]int{}},
+		requiredRoles: []string{models.RoleAdmin},
+		challengeTTL:  time.Minute,
+	}
+
+	challenge, err := service.StartLoginChallenge(&models.User{ID: 1, Role: models.RoleViewer})
+	assert.NoError(t, err)
+	assert.Nil(t, challenge, "password-only login for roles that do not require 2FA")
+
+	challenge, err = service.StartLoginChallenge(&models.User{ID: 2, Role: models.RoleAdmin})
+	assert.NoError(t, err)
+	if assert.NotNil(t, challenge) {
+		assert.True(t, challenge.EnrollmentRequired)
+	}
+}
@@ -2,9 +2,16 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"strings"
+	"time"
 
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/config"
 	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/mailer"
 	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
 	"github.com/pamateus-henrique/infinitepay-firewatchers-api/repositories"
 	"github.com/pamateus-henrique/infinitepay-firewatchers-api/utils"
@@ -13,16 +20,52 @@ import (
 
 type UserService interface {
 	Register(user *models.Register) error
-	Login(login *models.Login) (*models.User, error)
-	GetAllUsersPublicData() ([]*models.UserPublicData, error)
+	Login(login *models.Login, ip string) (*models.User, error)
+	GetUsers(queryParams *models.UserQueryParams) ([]*models.UserPublicData, *models.Pagination, error)
+	GetUser(ctx context.Context, userID int) (interface{}, error)
+	GetProfile(ctx context.Context) (*models.UserProfile, error)
+	UpdateProfile(ctx context.Context, update *models.ProfileUpdate) (*models.UserProfile, error)
+	ChangePassword(ctx context.Context, input *models.PasswordChange) error
+	DeactivateUser(ctx context.Context, userID int) error
+	ReactivateUser(ctx context.Context, userID int) error
+	UpdateUserRole(roleUpdate *models.UserRoleUpdate) error
+	UpdateUserTeam(teamUpdate *models.UserTeamUpdate) error
+	ForgotPassword(input *models.ForgotPassword) error
+	ResetPassword(input *models.ResetPassword) error
+	VerifyEmail(input *models.VerifyEmail) error
+	ResendVerificationEmail(ctx context.Context) error
+	UnlockUser(ctx context.Context, userID int) error
 }
 
+// userTokenBytes is the entropy of emailed reset and verification tokens.
+const userTokenBytes = 32
+
 type userService struct {
-	userRepo repositories.UserRepository
+	userRepo             repositories.UserRepository
+	userTokenRepo        repositories.UserTokenRepository
+	sessionRepo          repositories.SessionRepository
+	securityEventRepo    repositories.SecurityEventRepository
+	loginThrottle        *loginThrottle
+	mailer               mailer.Mailer
+	appURL               string
+	passwordResetTTL     time.Duration
+	emailVerificationTTL time.Duration
 }
 
-func NewUserService(userRepo repositories.UserRepository) UserService {
-	return &userService{userRepo: userRepo}
+func NewUserService(userRepo repositories.UserRepository, userTokenRepo repositories.UserTokenRepository, sessionRepo repositories.SessionRepository, loginAttemptRepo repositories.LoginAttemptRepository, securityEventRepo repositories.SecurityEventRepository, mailer mailer.Mailer) UserService {
+	cfg := config.GetConfig()
+
+	return &userService{
+		userRepo:             userRepo,
+		userTokenRepo:        userTokenRepo,
+		sessionRepo:          sessionRepo,
+		securityEventRepo:    securityEventRepo,
+		loginThrottle:        newLoginThrottle(loginAttemptRepo, securityEventRepo),
+		mailer:               mailer,
+		appURL:               strings.TrimRight(cfg.AppURL, "/"),
+		passwordResetTTL:     cfg.PasswordResetTTL,
+		emailVerificationTTL: cfg.EmailVerificationTTL,
+	}
 }
 
 func (s *userService) Register(user *models.Register) error {
@@ -44,16 +87,24 @@ func (s *userService) Register(user *models.Register) error {
 	user.Password = utils.GeneratePassword(user.Password)
 
 	log.Println("Register: Creating user")
-	err = s.userRepo.CreateUser(user)
+	userID, err := s.userRepo.CreateUser(user)
 	if err != nil {
 		log.Printf("Register: Error creating user: %v", err)
-	} else {
-		log.Println("Register: User created successfully")
+		return err
+	}
+
+	log.Println("Register: User created successfully")
+
+	// The account exists at this point; a mail outage must not fail the
+	// registration, the user can ask for a new link later.
+	if err := s.sendVerificationEmail(userID, user.Name, user.Email); err != nil {
+		log.Printf("Register: Error sending verification email: %v", err)
 	}
-	return err
+
+	return nil
 }
 
-func (s *userService) Login(login *models.Login) (*models.User, error) {
+func (s *userService) Login(login *models.Login, ip string) (*models.User, error) {
 	log.Println("Login: Starting login process")
 
 	if err := validators.ValidateStruct(login); err != nil {
@@ -61,33 +112,521 @@ func (s *userService) Login(login *models.Login) (*models.User, error) {
 		return nil, &validators.ValidationError{Err: err}
 	}
 
+	email := strings.ToLower(strings.TrimSpace(login.Email))
+
+	if err := s.loginThrottle.check(email, ip); err != nil {
+		log.Printf("Login: Login throttled: %v", err)
+		return nil, err
+	}
+
 	log.Println("Login: Retrieving user by email")
 	user, err := s.userRepo.GetUserByEmail(login.Email)
 
 	if err != nil {
 		log.Println("Login: User not found or error retrieving user")
+		s.recordLoginFailure(email, ip, nil)
 		return nil, &customErrors.AuthenticationError{Msg: "Invalid Email or password"}
 	}
 
 	log.Println("Login: Comparing passwords")
-	if err := utils.ComparePassword(login.Password, user.Password); err != nil {
+	if user.IsServiceAccount || utils.ComparePassword(login.Password, user.Password) != nil {
 		log.Println("Login: Password comparison failed")
+		s.recordLoginFailure(email, ip, &user.ID)
 		return nil, &customErrors.AuthenticationError{Msg: "Invalid Email or password"}
 	}
 
+	if err := s.loginThrottle.reset(email); err != nil {
+		log.Printf("Login: Error clearing failed attempts: %v", err)
+	}
+
+	if user.DeactivatedAt != nil {
+		log.Printf("Login: User ID %d is deactivated", user.ID)
+		return nil, &customErrors.AuthenticationError{Msg: "this account has been deactivated"}
+	}
+
 	log.Println("Login: Login successful")
 	return user, nil
 }
 
-func (s *userService) GetAllUsersPublicData() ([]*models.UserPublicData, error) {
-	log.Println("GetAllUsersPublicData: Starting retrieval of all users' public data")
+// recordLoginFailure must not turn a wrong password into a server error, so
+// tracking failures are only logged.
+func (s *userService) recordLoginFailure(email, ip string, userID *int) {
+	if err := s.loginThrottle.recordFailure(email, ip, userID); err != nil {
+		log.Printf("Login: Error recording failed attempt: %v", err)
+	}
+}
+
+func (s *userService) GetUsers(queryParams *models.UserQueryParams) ([]*models.UserPublicData, *models.Pagination, error) {
+	log.Println("GetUsers: Starting user directory retrieval")
+
+	if err := validators.ValidateStruct(queryParams); err != nil {
+		log.Printf("GetUsers: Validation error: %v", err)
+		return nil, nil, &validators.ValidationError{Err: err}
+	}
+
+	if queryParams.Page == 0 {
+		queryParams.Page = 1
+	}
+	if queryParams.Limit == 0 {
+		queryParams.Limit = models.DefaultPageLimit
+	}
+
+	users, total, err := s.userRepo.GetUsers(queryParams)
+	if err != nil {
+		log.Printf("GetUsers: Error retrieving users: %v", err)
+		return nil, nil, err
+	}
+
+	log.Printf("GetUsers: Successfully retrieved %d users", len(users))
+	return users, models.NewPagination(queryParams.Page, queryParams.Limit, total), nil
+}
+
+// GetUser returns the full profile to the user themselves and to user
+// managers, and the same public data as the directory to everyone else.
+func (s *userService) GetUser(ctx context.Context, userID int) (interface{}, error) {
+	log.Printf("GetUser: Retrieving user ID %d", userID)
 
-	users, err := s.userRepo.GetAllUsersPublicData()
+	actorID, err := actorFromContext(ctx)
 	if err != nil {
-		log.Printf("GetAllUsersPublicData: Error retrieving users' public data: %v", err)
 		return nil, err
 	}
 
-	log.Printf("GetAllUsersPublicData: Successfully retrieved public data for %d users", len(users))
-	return users, nil
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		log.Printf("GetUser: Error retrieving user: %v", err)
+		return nil, err
+	}
+
+	if actorID == userID || actorCan(ctx, models.PermissionUsersManage) {
+		return user.Profile(), nil
+	}
+	return user.PublicData(), nil
+}
+
+func (s *userService) GetProfile(ctx context.Context) (*models.UserProfile, error) {
+	userID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getProfile(userID)
+}
+
+func (s *userService) getProfile(userID int) (*models.UserProfile, error) {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		log.Printf("GetProfile: Error retrieving user: %v", err)
+		return nil, err
+	}
+
+	return user.Profile(), nil
+}
+
+func (s *userService) UpdateProfile(ctx context.Context, update *models.ProfileUpdate) (*models.UserProfile, error) {
+	userID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("UpdateProfile: Starting profile update for user ID %d", userID)
+
+	if err := validators.ValidateStruct(update); err != nil {
+		log.Printf("UpdateProfile: Validation error: %v", err)
+		return nil, &validators.ValidationError{Err: err}
+	}
+
+	if err := s.userRepo.UpdateProfile(userID, update); err != nil {
+		log.Printf("UpdateProfile: Error updating profile: %v", err)
+		return nil, err
+	}
+
+	return s.getProfile(userID)
+}
+
+// ChangePassword needs the current password even though the caller is logged
+// in, so a forgotten open session is not enough to take over the account.
+// Every other session is logged out.
+func (s *userService) ChangePassword(ctx context.Context, input *models.PasswordChange) error {
+	userID, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("ChangePassword: Starting password change for user ID %d", userID)
+
+	if _, viaAPIKey := ctx.Value("api_key_id").(int); viaAPIKey {
+		return &customErrors.ForbiddenError{Msg: "passwords cannot be changed with an API key"}
+	}
+
+	if err := validators.ValidateStruct(input); err != nil {
+		log.Printf("ChangePassword: Validation error: %v", err)
+		return &validators.ValidationError{Err: err}
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		log.Printf("ChangePassword: Error retrieving user: %v", err)
+		return err
+	}
+
+	if user.IsServiceAccount {
+		return &customErrors.ForbiddenError{Msg: "service accounts have no password"}
+	}
+
+	if utils.ComparePassword(input.CurrentPassword, user.Password) != nil {
+		log.Println("ChangePassword: Current password does not match")
+		return &validators.ValidationError{Messages: []string{"Current password is incorrect"}}
+	}
+
+	if err := s.userRepo.UpdateUserPassword(user.ID, utils.GeneratePassword(input.NewPassword)); err != nil {
+		log.Printf("ChangePassword: Error updating password: %v", err)
+		return err
+	}
+
+	sessionID, _ := ctx.Value("session_id").(int)
+	if err := s.sessionRepo.RevokeOtherUserSessions(user.ID, sessionID); err != nil {
+		log.Printf("ChangePassword: Error revoking other sessions: %v", err)
+		return err
+	}
+
+	s.recordSecurityEvent(models.SecurityEventPasswordChanged, user, nil)
+
+	log.Printf("ChangePassword: Password changed for user ID %d", user.ID)
+	return nil
+}
+
+// DeactivateUser blocks every way into the account: logins, sessions and
+// API keys. The user row stays, so their incidents and comments are kept.
+func (s *userService) DeactivateUser(ctx context.Context, userID int) error {
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("DeactivateUser: User ID %d deactivating user ID %d", actorID, userID)
+
+	if actorID == userID {
+		return &customErrors.ForbiddenError{Msg: "you cannot deactivate your own account"}
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		log.Printf("DeactivateUser: Error retrieving user: %v", err)
+		return err
+	}
+
+	if user.DeactivatedAt != nil {
+		return &customErrors.ConflictError{Msg: "user is already deactivated"}
+	}
+
+	if err := s.userRepo.SetUserDeactivated(userID, true); err != nil {
+		log.Printf("DeactivateUser: Error deactivating user: %v", err)
+		return err
+	}
+
+	if err := s.sessionRepo.RevokeAllUserSessions(userID); err != nil {
+		log.Printf("DeactivateUser: Error revoking sessions: %v", err)
+		return err
+	}
+
+	s.recordSecurityEvent(models.SecurityEventDeactivated, user, &actorID)
+
+	log.Printf("DeactivateUser: User ID %d deactivated", userID)
+	return nil
+}
+
+func (s *userService) ReactivateUser(ctx context.Context, userID int) error {
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("ReactivateUser: User ID %d reactivating user ID %d", actorID, userID)
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		log.Printf("ReactivateUser: Error retrieving user: %v", err)
+		return err
+	}
+
+	if user.DeactivatedAt == nil {
+		return &customErrors.ConflictError{Msg: "user is not deactivated"}
+	}
+
+	if err := s.userRepo.SetUserDeactivated(userID, false); err != nil {
+		log.Printf("ReactivateUser: Error reactivating user: %v", err)
+		return err
+	}
+
+	s.recordSecurityEvent(models.SecurityEventReactivated, user, &actorID)
+
+	log.Printf("ReactivateUser: User ID %d reactivated", userID)
+	return nil
+}
+
+// recordSecurityEvent only logs failures; the change it describes has
+// already been made.
+func (s *userService) recordSecurityEvent(eventType string, user *models.User, actorID *int) {
+	event := &models.SecurityEvent{Type: eventType, UserID: &user.ID, ActorID: actorID, Email: &user.Email}
+	if err := s.securityEventRepo.CreateSecurityEvent(event); err != nil {
+		log.Printf("recordSecurityEvent: Error recording %s event: %v", eventType, err)
+	}
+}
+
+// UpdateUserRole logs the user out of every session when the role changes,
+// so the old role's permissions do not outlive their access tokens.
+func (s *userService) UpdateUserRole(roleUpdate *models.UserRoleUpdate) error {
+	log.Printf("UpdateUserRole: Starting role update for user ID %d", roleUpdate.ID)
+
+	if err := validators.ValidateStruct(roleUpdate); err != nil {
+		log.Printf("UpdateUserRole: Validation error: %v", err)
+		return &validators.ValidationError{Err: err}
+	}
+
+	if !models.IsValidRole(roleUpdate.Role) {
+		log.Printf("UpdateUserRole: Unknown role %q", roleUpdate.Role)
+		return &validators.ValidationError{Messages: []string{"Role must be one of: " + strings.Join(models.Roles(), ", ")}}
+	}
+
+	user, err := s.userRepo.GetUserByID(roleUpdate.ID)
+	if err != nil {
+		log.Printf("UpdateUserRole: Error retrieving user: %v", err)
+		return err
+	}
+
+	previousRole := user.Role
+	if err := s.userRepo.UpdateUserRole(roleUpdate.ID, roleUpdate.Role); err != nil {
+		log.Printf("UpdateUserRole: Error updating role: %v", err)
+		return err
+	}
+
+	if previousRole != roleUpdate.Role {
+		if err := s.sessionRepo.RevokeAllUserSessions(roleUpdate.ID); err != nil {
+			log.Printf("UpdateUserRole: Error revoking sessions: %v", err)
+			return err
+		}
+	}
+
+	log.Printf("UpdateUserRole: Successfully set role of user ID %d to %s", roleUpdate.ID, roleUpdate.Role)
+	return nil
+}
+
+func (s *userService) UpdateUserTeam(teamUpdate *models.UserTeamUpdate) error {
+	log.Printf("UpdateUserTeam: Starting team update for user ID %d", teamUpdate.ID)
+
+	if err := validators.ValidateStruct(teamUpdate); err != nil {
+		log.Printf("UpdateUserTeam: Validation error: %v", err)
+		return &validators.ValidationError{Err: err}
+	}
+
+	if err := s.userRepo.UpdateUserTeam(teamUpdate.ID, teamUpdate.Team); err != nil {
+		log.Printf("UpdateUserTeam: Error updating team: %v", err)
+		return err
+	}
+
+	log.Printf("UpdateUserTeam: Successfully set team of user ID %d to %s", teamUpdate.ID, teamUpdate.Team)
+	return nil
+}
+
+func (s *userService) UnlockUser(ctx context.Context, userID int) error {
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("UnlockUser: User ID %d unlocking user ID %d", actorID, userID)
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		log.Printf("UnlockUser: Error retrieving user: %v", err)
+		return err
+	}
+
+	email := strings.ToLower(user.Email)
+	if err := s.loginThrottle.reset(email); err != nil {
+		log.Printf("UnlockUser: Error clearing failed attempts: %v", err)
+		return err
+	}
+
+	event := &models.SecurityEvent{
+		Type:    models.SecurityEventAccountUnlocked,
+		UserID:  &user.ID,
+		ActorID: &actorID,
+		Email:   &email,
+	}
+	if err := s.securityEventRepo.CreateSecurityEvent(event); err != nil {
+		log.Printf("UnlockUser: Error recording security event: %v", err)
+		return err
+	}
+
+	log.Printf("UnlockUser: User ID %d unlocked", userID)
+	return nil
+}
+
+func (s *userService) ForgotPassword(input *models.ForgotPassword) error {
+	log.Println("ForgotPassword: Starting password reset request")
+
+	if err := validators.ValidateStruct(input); err != nil {
+		log.Printf("ForgotPassword: Validation error: %v", err)
+		return &validators.ValidationError{Err: err}
+	}
+
+	// Unknown emails succeed silently so the endpoint cannot be used to find
+	// out which addresses have an account.
+	user, err := s.userRepo.GetUserByEmail(input.Email)
+	if err != nil {
+		log.Println("ForgotPassword: User not found or error retrieving user")
+		return nil
+	}
+
+	if user.DeactivatedAt != nil {
+		log.Printf("ForgotPassword: User ID %d is deactivated", user.ID)
+		return nil
+	}
+
+	if err := s.userTokenRepo.InvalidateUserTokens(user.ID, models.TokenPurposePasswordReset); err != nil {
+		log.Printf("ForgotPassword: Error invalidating previous tokens: %v", err)
+		return err
+	}
+
+	token, err := s.createUserToken(user.ID, models.TokenPurposePasswordReset, s.passwordResetTTL)
+	if err != nil {
+		log.Printf("ForgotPassword: Error creating reset token: %v", err)
+		return err
+	}
+
+	msg := &mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your Firewatchers password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to choose a new password. It expires in %s and can only be used once.\n\n%s/reset-password?token=%s\n\nIf you did not ask for a password reset, you can ignore this email.\n",
+			user.Name, s.passwordResetTTL, s.appURL, token),
+	}
+
+	if err := s.mailer.Send(msg); err != nil {
+		log.Printf("ForgotPassword: Error sending reset email: %v", err)
+		return err
+	}
+
+	log.Printf("ForgotPassword: Reset email sent to user ID %d", user.ID)
+	return nil
+}
+
+func (s *userService) ResetPassword(input *models.ResetPassword) error {
+	log.Println("ResetPassword: Starting password reset")
+
+	if err := validators.ValidateStruct(input); err != nil {
+		log.Printf("ResetPassword: Validation error: %v", err)
+		return &validators.ValidationError{Err: err}
+	}
+
+	userID, err := s.consumeUserToken(models.TokenPurposePasswordReset, input.Token)
+	if err != nil {
+		log.Printf("ResetPassword: Error consuming reset token: %v", err)
+		return err
+	}
+
+	if err := s.userRepo.UpdateUserPassword(userID, utils.GeneratePassword(input.Password)); err != nil {
+		log.Printf("ResetPassword: Error updating password: %v", err)
+		return err
+	}
+
+	// Whoever knew the old password may still hold a session
+	if err := s.sessionRepo.RevokeAllUserSessions(userID); err != nil {
+		log.Printf("ResetPassword: Error revoking sessions: %v", err)
+		return err
+	}
+
+	log.Printf("ResetPassword: Password reset for user ID %d", userID)
+	return nil
+}
+
+func (s *userService) VerifyEmail(input *models.VerifyEmail) error {
+	log.Println("VerifyEmail: Starting email verification")
+
+	if err := validators.ValidateStruct(input); err != nil {
+		log.Printf("VerifyEmail: Validation error: %v", err)
+		return &validators.ValidationError{Err: err}
+	}
+
+	userID, err := s.consumeUserToken(models.TokenPurposeEmailVerification, input.Token)
+	if err != nil {
+		log.Printf("VerifyEmail: Error consuming verification token: %v", err)
+		return err
+	}
+
+	if err := s.userRepo.MarkEmailVerified(userID); err != nil {
+		log.Printf("VerifyEmail: Error marking email as verified: %v", err)
+		return err
+	}
+
+	log.Printf("VerifyEmail: Email verified for user ID %d", userID)
+	return nil
+}
+
+func (s *userService) ResendVerificationEmail(ctx context.Context) error {
+	userID, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("ResendVerificationEmail: Starting for user ID %d", userID)
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		log.Printf("ResendVerificationEmail: Error retrieving user: %v", err)
+		return err
+	}
+
+	if user.EmailVerifiedAt != nil {
+		return &customErrors.ConflictError{Msg: "email is already verified"}
+	}
+
+	if err := s.userTokenRepo.InvalidateUserTokens(user.ID, models.TokenPurposeEmailVerification); err != nil {
+		log.Printf("ResendVerificationEmail: Error invalidating previous tokens: %v", err)
+		return err
+	}
+
+	return s.sendVerificationEmail(user.ID, user.Name, user.Email)
+}
+
+func (s *userService) sendVerificationEmail(userID int, name, email string) error {
+	token, err := s.createUserToken(userID, models.TokenPurposeEmailVerification, s.emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	msg := &mailer.Message{
+		To:      email,
+		Subject: "Verify your Firewatchers email",
+		Body: fmt.Sprintf("Hi %s,\n\nConfirm your email address by opening the link below. It expires in %s.\n\n%s/verify-email?token=%s\n",
+			name, s.emailVerificationTTL, s.appURL, token),
+	}
+
+	return s.mailer.Send(msg)
+}
+
+// createUserToken stores the hash of a new single-use token and returns the
+// token itself, which is only ever sent by email.
+func (s *userService) createUserToken(userID int, purpose string, ttl time.Duration) (string, error) {
+	token, err := utils.GenerateToken(userTokenBytes)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.userTokenRepo.CreateUserToken(userID, purpose, utils.HashToken(token), time.Now().Add(ttl)); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (s *userService) consumeUserToken(purpose, token string) (int, error) {
+	userID, err := s.userTokenRepo.ConsumeUserToken(purpose, utils.HashToken(token))
+
+	var notFound *customErrors.NotFoundError
+	if errors.As(err, &notFound) {
+		return 0, &validators.ValidationError{Messages: []string{"Token is invalid or has expired"}}
+	}
+
+	return userID, err
 }
@@ -0,0 +1,311 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	customErrors "github.com/pamateus-henrique/infinitepay-firewatchers-api/errors"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/mailer"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/repositories"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/utils"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/validators"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubUserRepository keeps a single user; other methods are unused.
+type stubUserRepository struct {
+	repositories.UserRepository
+	user *models.User
+}
+
+func (r *stubUserRepository) GetUserByEmail(email string) (*models.User, error) {
+	if r.user == nil || r.user.Email != email {
+		return nil, &customErrors.NotFoundError{Msg: "user not found"}
+	}
+	return r.user, nil
+}
+
+func (r *stubUserRepository) GetUserByID(id int) (*models.User, error) {
+	if r.user == nil || r.user.ID != id {
+		return nil, &customErrors.NotFoundError{Msg: "user not found"}
+	}
+	return r.user, nil
+}
+
+func (r *stubUserRepository) ClaimTOTPStep(id int, step int64) (bool, error) {
+	if r.user.TOTPLastStep != nil && *r.user.TOTPLastStep >= step {
+		return false, nil
+	}
+	r.user.TOTPLastStep = &step
+	return true, nil
+}
+
+func (r *stubUserRepository) UpdateUserPassword(id int, passwordHash string) error {
+	r.user.Password = passwordHash
+	return nil
+}
+
+func (r *stubUserRepository) UpdateUserAvatar(id int, avatarURL string) error {
+	r.user.Avatar_url = avatarURL
+	return nil
+}
+
+func (r *stubUserRepository) UpdateUserRole(id int, role string) error {
+	r.user.Role = role
+	return nil
+}
+
+func (r *stubUserRepository) SetUserDeactivated(id int, deactivated bool) error {
+	r.user.DeactivatedAt = nil
+	if deactivated {
+		r.user.DeactivatedAt = models.NewCustomTime(time.Now())
+	}
+	return nil
+}
+
+// stubUserTokenRepository stores tokens in memory, keyed by hash.
+type stubUserTokenRepository struct {
+	tokens map[string]int
+}
+
+func (r *stubUserTokenRepository) CreateUserToken(userID int, purpose, tokenHash string, expiresAt time.Time) error {
+	r.tokens[purpose+tokenHash] = userID
+	return nil
+}
+
+func (r *stubUserTokenRepository) GetUserToken(purpose, tokenHash string) (int, error) {
+	userID, ok := r.tokens[purpose+tokenHash]
+	if !ok {
+		return 0, &customErrors.NotFoundError{Msg: "token not found"}
+	}
+	return userID, nil
+}
+
+func (r *stubUserTokenRepository) ConsumeUserToken(purpose, tokenHash string) (int, error) {
+	userID, ok := r.tokens[purpose+tokenHash]
+	if !ok {
+		return 0, &customErrors.NotFoundError{Msg: "token not found"}
+	}
+	delete(r.tokens, purpose+tokenHash)
+	return userID, nil
+}
+
+func (r *stubUserTokenRepository) InvalidateUserTokens(userID int, purpose string) error {
+	return nil
+}
+
+// stubSessionRepository records revocations; other methods are unused.
+type stubSessionRepository struct {
+	repositories.SessionRepository
+	revokedUsers []int
+	keptSessions []int
+}
+
+func (r *stubSessionRepository) RevokeAllUserSessions(userID int) error {
+	r.revokedUsers = append(r.revokedUsers, userID)
+	return nil
+}
+
+func (r *stubSessionRepository) RevokeOtherUserSessions(userID, keepID int) error {
+	r.revokedUsers = append(r.revokedUsers, userID)
+	r.keptSessions = append(r.keptSessions, keepID)
+	return nil
+}
+
+// stubLoginAttemptRepository keeps failures in memory, ignoring the window.
+type stubLoginAttemptRepository struct {
+	failures    map[string]int
+	lockedUntil map[string]time.Time
+}
+
+func newStubLoginAttemptRepository() *stubLoginAttemptRepository {
+	return &stubLoginAttemptRepository{failures: map[string]int{}, lockedUntil: map[string]time.Time{}}
+}
+
+func (r *stubLoginAttemptRepository) GetLockedUntil(scope, subject string) (*time.Time, error) {
+	if until, ok := r.lockedUntil[scope+subject]; ok {
+		return &until, nil
+	}
+	return nil, nil
+}
+
+func (r *stubLoginAttemptRepository) RecordFailure(scope, subject string, window time.Duration) (int, error) {
+	r.failures[scope+subject]++
+	return r.failures[scope+subject], nil
+}
+
+func (r *stubLoginAttemptRepository) LockUntil(scope, subject string, until time.Time) error {
+	r.lockedUntil[scope+subject] = until
+	return nil
+}
+
+func (r *stubLoginAttemptRepository) ClearFailures(scope, subject string) error {
+	delete(r.failures, scope+subject)
+	delete(r.lockedUntil, scope+subject)
+	return nil
+}
+
+// stubSecurityEventRepository records events; listing is unused.
+type stubSecurityEventRepository struct {
+	repositories.SecurityEventRepository
+	events []*models.SecurityEvent
+}
+
+func (r *stubSecurityEventRepository) CreateSecurityEvent(event *models.SecurityEvent) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func TestLoginThrottling(t *testing.T) {
+	userRepo := &stubUserRepository{user: &models.User{ID: 7, Email: "john@example.com", Password: utils.GeneratePassword("password123")}}
+	attempts := newStubLoginAttemptRepository()
+	events := &stubSecurityEventRepository{}
+	service := NewUserService(userRepo, nil, nil, attempts, events, mailer.NewMemoryMailer())
+
+	wrong := &models.Login{Email: "john@example.com", Password: "wrong-password"}
+	var authErr *customErrors.AuthenticationError
+	var tooManyErr *customErrors.TooManyRequestsError
+
+	_, err := service.Login(wrong, "10.0.0.1")
+	assert.ErrorAs(t, err, &authErr)
+
+	// An immediate retry is rejected until the backoff passes
+	_, err = service.Login(&models.Login{Email: "john@example.com", Password: "password123"}, "10.0.0.2")
+	if assert.ErrorAs(t, err, &tooManyErr) {
+		assert.Greater(t, tooManyErr.RetryAfter, time.Duration(0))
+	}
+
+	// Keep failing with each backoff already elapsed until the lockout
+	for i := 0; i < 4; i++ {
+		attempts.lockedUntil = map[string]time.Time{}
+		_, err = service.Login(wrong, "10.0.0.1")
+		assert.ErrorAs(t, err, &authErr)
+	}
+
+	until := attempts.lockedUntil[models.LoginScopeAccount+"john@example.com"]
+	assert.WithinDuration(t, time.Now().Add(15*time.Minute), until, time.Minute)
+	if assert.Len(t, events.events, 1) {
+		assert.Equal(t, models.SecurityEventAccountLocked, events.events[0].Type)
+		assert.Equal(t, 7, *events.events[0].UserID)
+	}
+
+	// A successful login clears the account failures
+	attempts.lockedUntil = map[string]time.Time{}
+	_, err = service.Login(&models.Login{Email: "john@example.com", Password: "password123"}, "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Zero(t, attempts.failures[models.LoginScopeAccount+"john@example.com"])
+}
+
+func TestPasswordReset(t *testing.T) {
+	userRepo := &stubUserRepository{user: &models.User{ID: 7, Name: "John Doe", Email: "john@example.com"}}
+	sessionRepo := &stubSessionRepository{}
+	mail := mailer.NewMemoryMailer()
+	service := NewUserService(userRepo, &stubUserTokenRepository{tokens: map[string]int{}}, sessionRepo, newStubLoginAttemptRepository(), &stubSecurityEventRepository{}, mail)
+
+	assert.NoError(t, service.ForgotPassword(&models.ForgotPassword{Email: "nobody@example.com"}))
+	assert.Empty(t, mail.Messages(), "unknown emails must not receive a link")
+
+	assert.NoError(t, service.ForgotPassword(&models.ForgotPassword{Email: "john@example.com"}))
+	if !assert.Len(t, mail.Messages(), 1) {
+		return
+	}
+
+	token := regexp.MustCompile(`token=([\w-]+)`).FindStringSubmatch(mail.Messages()[0].Body)[1]
+	reset := &models.ResetPassword{Token: token, Password: "new-password"}
+
+	assert.NoError(t, service.ResetPassword(reset))
+	assert.NoError(t, utils.ComparePassword("new-password", userRepo.user.Password))
+	assert.Equal(t, []int{7}, sessionRepo.revokedUsers)
+
+	var validationErr *validators.ValidationError
+	assert.ErrorAs(t, service.ResetPassword(reset), &validationErr, "tokens are single-use")
+}
+
+func TestChangePassword(t *testing.T) {
+	userRepo := &stubUserRepository{user: &models.User{ID: 7, Email: "john@example.com", Password: utils.GeneratePassword("password123")}}
+	sessionRepo := &stubSessionRepository{}
+	service := NewUserService(userRepo, nil, sessionRepo, newStubLoginAttemptRepository(), &stubSecurityEventRepository{}, mailer.NewMemoryMailer())
+
+	ctx := context.WithValue(context.WithValue(context.Background(), "user_id", 7), "session_id", 3)
+
+	var validationErr *validators.ValidationError
+	err := service.ChangePassword(ctx, &models.PasswordChange{CurrentPassword: "wrong-password", NewPassword: "new-password"})
+	assert.ErrorAs(t, err, &validationErr)
+
+	var forbidden *customErrors.ForbiddenError
+	apiKeyCtx := context.WithValue(ctx, "api_key_id", 1)
+	err = service.ChangePassword(apiKeyCtx, &models.PasswordChange{CurrentPassword: "password123", NewPassword: "new-password"})
+	assert.ErrorAs(t, err, &forbidden)
+
+	assert.NoError(t, service.ChangePassword(ctx, &models.PasswordChange{CurrentPassword: "password123", NewPassword: "new-password"}))
+	assert.NoError(t, utils.ComparePassword("new-password", userRepo.user.Password))
+	assert.Equal(t, []int{3}, sessionRepo.keptSessions, "the current session stays logged in")
+}
+
+func TestDeactivateUser(t *testing.T) {
+	userRepo := &stubUserRepository{user: &models.User{ID: 7, Email: "john@example.com", Password: utils.GeneratePassword("password123")}}
+	sessionRepo := &stubSessionRepository{}
+	events := &stubSecurityEventRepository{}
+	service := NewUserService(userRepo, nil, sessionRepo, newStubLoginAttemptRepository(), events, mailer.NewMemoryMailer())
+
+	var forbidden *customErrors.ForbiddenError
+	assert.ErrorAs(t, service.DeactivateUser(context.WithValue(context.Background(), "user_id", 7), 7), &forbidden)
+
+	admin := context.WithValue(context.Background(), "user_id", 1)
+	assert.NoError(t, service.DeactivateUser(admin, 7))
+	assert.Equal(t, []int{7}, sessionRepo.revokedUsers)
+
+	var authErr *customErrors.AuthenticationError
+	_, err := service.Login(&models.Login{Email: "john@example.com", Password: "password123"}, "10.0.0.1")
+	assert.ErrorAs(t, err, &authErr)
+
+	var conflict *customErrors.ConflictError
+	assert.ErrorAs(t, service.DeactivateUser(admin, 7), &conflict)
+
+	assert.NoError(t, service.ReactivateUser(admin, 7))
+	_, err = service.Login(&models.Login{Email: "john@example.com", Password: "password123"}, "10.0.0.1")
+	assert.NoError(t, err)
+
+	if assert.Len(t, events.events, 2) {
+		assert.Equal(t, models.SecurityEventDeactivated, events.events[0].Type)
+		assert.Equal(t, models.SecurityEventReactivated, events.events[1].Type)
+	}
+}
+
+func TestGetUserVisibility(t *testing.T) {
+	userRepo := &stubUserRepository{user: &models.User{ID: 7, Name: "John Doe", Email: "john@example.com", Role: models.RoleResponder}}
+	service := NewUserService(userRepo, nil, nil, newStubLoginAttemptRepository(), &stubSecurityEventRepository{}, mailer.NewMemoryMailer())
+
+	responder := context.WithValue(context.WithValue(context.Background(), "user_id", 2), "role", models.RoleResponder)
+	user, err := service.GetUser(responder, 7)
+	assert.NoError(t, err)
+	assert.IsType(t, &models.UserPublicData{}, user, "other users only see the directory data")
+
+	self := context.WithValue(context.WithValue(context.Background(), "user_id", 7), "role", models.RoleResponder)
+	user, err = service.GetUser(self, 7)
+	assert.NoError(t, err)
+	assert.IsType(t, &models.UserProfile{}, user)
+
+	admin := context.WithValue(context.WithValue(context.Background(), "user_id", 1), "role", models.RoleAdmin)
+	user, err = service.GetUser(admin, 7)
+	assert.NoError(t, err)
+	if assert.IsType(t, &models.UserProfile{}, user) {
+		assert.Equal(t, "john@example.com", user.(*models.UserProfile).Email)
+	}
+}
+
+func TestUpdateUserRoleRevokesSessions(t *testing.T) {
+	userRepo := &stubUserRepository{user: &models.User{ID: 7, Email: "john@example.com", Role: models.RoleAdmin}}
+	sessionRepo := &stubSessionRepository{}
+	service := NewUserService(userRepo, nil, sessionRepo, newStubLoginAttemptRepository(), &stubSecurityEventRepository{}, mailer.NewMemoryMailer())
+
+	assert.NoError(t, service.UpdateUserRole(&models.UserRoleUpdate{ID: 7, Role: models.RoleAdmin}))
+	assert.Empty(t, sessionRepo.revokedUsers, "keeping the role keeps the sessions")
+
+	assert.NoError(t, service.UpdateUserRole(&models.UserRoleUpdate{ID: 7, Role: models.RoleViewer}))
+	assert.Equal(t, models.RoleViewer, userRepo.user.Role)
+	assert.Equal(t, []int{7}, sessionRepo.revokedUsers, "tokens issued for the old role are logged out")
+}
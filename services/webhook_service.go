@@ -0,0 +1,429 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/config"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/repositories"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/utils"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/validators"
+)
+
+const (
+	// webhookBatchSize is how many due deliveries a worker claims at once.
+	webhookBatchSize = 20
+	// webhookResponseLimit caps how much of a response body is logged.
+	webhookResponseLimit = 2048
+)
+
+// WebhookPublisher queues events for the subscriptions listening to them.
+type WebhookPublisher interface {
+	Publish(eventType string, data interface{})
+}
+
+type WebhookService interface {
+	WebhookPublisher
+	CreateSubscription(ctx context.Context, input *models.WebhookSubscriptionInput) (*models.CreatedWebhookSubscription, error)
+	GetSubscriptions() ([]*models.WebhookSubscription, error)
+	GetSubscription(id int) (*models.WebhookSubscription, error)
+	UpdateSubscription(update *models.WebhookSubscriptionUpdate) (*models.WebhookSubscription, error)
+	RotateSecret(id int) (*models.CreatedWebhookSubscription, error)
+	DeleteSubscription(id int) error
+	GetDeliveries(queryParams *models.WebhookDeliveryQueryParams) ([]*models.WebhookDelivery, *models.Pagination, error)
+	Redeliver(subscriptionID, deliveryID int) (*models.WebhookDelivery, error)
+	// Run sends due deliveries until ctx is done.
+	Run(ctx context.Context)
+	// DeliverDue sends one batch of due deliveries and returns how many it
+	// attempted.
+	DeliverDue(ctx context.Context) (int, error)
+}
+
+type webhookService struct {
+	webhookRepository repositories.WebhookRepository
+	client            *http.Client
+	pollInterval      time.Duration
+	timeout           time.Duration
+	maxAttempts       int
+	backoffBase       time.Duration
+	backoffMax        time.Duration
+}
+
+func NewWebhookService(webhookRepository repositories.WebhookRepository) WebhookService {
+	cfg := config.GetConfig()
+	return &webhookService{
+		webhookRepository: webhookRepository,
+		client:            newWebhookClient(cfg.WebhookTimeout),
+		pollInterval:      cfg.WebhookPollInterval,
+		timeout:           cfg.WebhookTimeout,
+		maxAttempts:       cfg.WebhookMaxAttempts,
+		backoffBase:       cfg.WebhookBackoffBase,
+		backoffMax:        cfg.WebhookBackoffMax,
+	}
+}
+
+// newWebhookClient returns a client that only connects to public addresses.
+// The check runs on the resolved address of every connection, redirects
+// included, so a subscription cannot reach internal services through DNS.
+func newWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("webhook target %s is not a public address", host)
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		// No proxy: it would be the one dialed, hiding the real target.
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+}
+
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsUnspecified() && !ip.IsMulticast()
+}
+
+// Publish never fails the caller: the change it reports is already saved,
+// so a queueing error is only logged.
+func (s *webhookService) Publish(eventType string, data interface{}) {
+	eventID, err := utils.GenerateToken(16)
+	if err != nil {
+		log.Printf("Publish: Error generating event ID: %v", err)
+		return
+	}
+
+	payload, err := json.Marshal(&models.WebhookEvent{ID: eventID, Type: eventType, CreatedAt: models.NewCustomTimeNow(), Data: data})
+	if err != nil {
+		log.Printf("Publish: Error encoding %s event: %v", eventType, err)
+		return
+	}
+
+	queued, err := s.webhookRepository.EnqueueDeliveries(eventID, eventType, payload)
+	if err != nil {
+		log.Printf("Publish: Error queueing %s event: %v", eventType, err)
+		return
+	}
+
+	if queued > 0 {
+		log.Printf("Publish: Queued %s event %s for %d subscriptions", eventType, eventID, queued)
+	}
+}
+
+func (s *webhookService) CreateSubscription(ctx context.Context, input *models.WebhookSubscriptionInput) (*models.CreatedWebhookSubscription, error) {
+	log.Printf("CreateSubscription: Starting creation of webhook subscription %q", input.Name)
+
+	if err := validators.ValidateStruct(input); err != nil {
+		log.Printf("CreateSubscription: Validation error: %v", err)
+		return nil, &validators.ValidationError{Err: err}
+	}
+
+	if err := validateWebhookEvents(input.Events); err != nil {
+		return nil, err
+	}
+
+	actorID, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := input.Secret
+	if secret == "" {
+		if secret, err = utils.GenerateToken(32); err != nil {
+			return nil, err
+		}
+	}
+
+	subscription := &models.WebhookSubscription{
+		Name:      input.Name,
+		URL:       input.URL,
+		Secret:    secret,
+		Events:    input.Events,
+		CreatedBy: &actorID,
+	}
+
+	id, err := s.webhookRepository.CreateSubscription(subscription)
+	if err != nil {
+		log.Printf("CreateSubscription: Error creating subscription: %v", err)
+		return nil, err
+	}
+
+	created, err := s.webhookRepository.GetSubscriptionByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CreatedWebhookSubscription{Subscription: created, Secret: secret}, nil
+}
+
+func (s *webhookService) GetSubscriptions() ([]*models.WebhookSubscription, error) {
+	return s.webhookRepository.GetSubscriptions()
+}
+
+func (s *webhookService) GetSubscription(id int) (*models.WebhookSubscription, error) {
+	return s.webhookRepository.GetSubscriptionByID(id)
+}
+
+func (s *webhookService) UpdateSubscription(update *models.WebhookSubscriptionUpdate) (*models.WebhookSubscription, error) {
+	log.Printf("UpdateSubscription: Starting update of webhook subscription %d", update.ID)
+
+	if err := validators.ValidateStruct(update); err != nil {
+		log.Printf("UpdateSubscription: Validation error: %v", err)
+		return nil, &validators.ValidationError{Err: err}
+	}
+
+	subscription, err := s.webhookRepository.GetSubscriptionByID(update.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Name != nil {
+		subscription.Name = *update.Name
+	}
+	if update.URL != nil {
+		subscription.URL = *update.URL
+	}
+	if update.Events != nil {
+		if err := validateWebhookEvents(update.Events); err != nil {
+			return nil, err
+		}
+		subscription.Events = update.Events
+	}
+	if update.Active != nil {
+		subscription.Active = *update.Active
+	}
+
+	if err := s.webhookRepository.UpdateSubscription(subscription); err != nil {
+		log.Printf("UpdateSubscription: Error updating subscription: %v", err)
+		return nil, err
+	}
+
+	return s.webhookRepository.GetSubscriptionByID(update.ID)
+}
+
+// RotateSecret replaces the signing secret. Deliveries still queued are
+// signed with the new one when they are sent.
+func (s *webhookService) RotateSecret(id int) (*models.CreatedWebhookSubscription, error) {
+	log.Printf("RotateSecret: Rotating secret of webhook subscription %d", id)
+
+	secret, err := utils.GenerateToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.webhookRepository.UpdateSubscriptionSecret(id, secret); err != nil {
+		return nil, err
+	}
+
+	subscription, err := s.webhookRepository.GetSubscriptionByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CreatedWebhookSubscription{Subscription: subscription, Secret: secret}, nil
+}
+
+func (s *webhookService) DeleteSubscription(id int) error {
+	return s.webhookRepository.DeleteSubscription(id)
+}
+
+func (s *webhookService) GetDeliveries(queryParams *models.WebhookDeliveryQueryParams) ([]*models.WebhookDelivery, *models.Pagination, error) {
+	if err := validators.ValidateStruct(queryParams); err != nil {
+		log.Printf("GetDeliveries: Validation error: %v", err)
+		return nil, nil, &validators.ValidationError{Err: err}
+	}
+
+	if _, err := s.webhookRepository.GetSubscriptionByID(queryParams.SubscriptionID); err != nil {
+		return nil, nil, err
+	}
+
+	if queryParams.Page == 0 {
+		queryParams.Page = 1
+	}
+	if queryParams.Limit == 0 {
+		queryParams.Limit = models.DefaultPageLimit
+	}
+
+	deliveries, total, err := s.webhookRepository.GetDeliveries(queryParams)
+	if err != nil {
+		log.Printf("GetDeliveries: Error retrieving deliveries: %v", err)
+		return nil, nil, err
+	}
+
+	return deliveries, models.NewPagination(queryParams.Page, queryParams.Limit, total), nil
+}
+
+func (s *webhookService) Redeliver(subscriptionID, deliveryID int) (*models.WebhookDelivery, error) {
+	log.Printf("Redeliver: Starting redelivery of webhook delivery %d", deliveryID)
+
+	delivery, err := s.webhookRepository.GetDeliveryByID(subscriptionID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := s.webhookRepository.Redeliver(delivery)
+	if err != nil {
+		log.Printf("Redeliver: Error queueing redelivery: %v", err)
+		return nil, err
+	}
+
+	return s.webhookRepository.GetDeliveryByID(subscriptionID, id)
+}
+
+func (s *webhookService) Run(ctx context.Context) {
+	log.Printf("Run: Delivering webhooks every %s", s.pollInterval)
+
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Keep draining while full batches come back
+		for {
+			attempted, err := s.DeliverDue(ctx)
+			if err != nil {
+				log.Printf("Run: Error delivering webhooks: %v", err)
+			}
+			if err != nil || attempted < webhookBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *webhookService) DeliverDue(ctx context.Context) (int, error) {
+	// The lease outlasts a send, so a delivery is never sent twice at once
+	deliveries, err := s.webhookRepository.ClaimDueDeliveries(webhookBatchSize, s.timeout+time.Minute)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, delivery := range deliveries {
+		attempt := s.send(ctx, delivery)
+		if err := s.webhookRepository.RecordAttempt(attempt); err != nil {
+			log.Printf("DeliverDue: Error recording attempt of delivery %d: %v", delivery.ID, err)
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+// send posts a delivery once and decides what happens next: done, retried
+// after a backoff, or given up on after maxAttempts.
+func (s *webhookService) send(ctx context.Context, delivery *models.ClaimedWebhookDelivery) *models.WebhookAttempt {
+	attempt := &models.WebhookAttempt{DeliveryID: delivery.ID}
+
+	statusCode, body, err := s.post(ctx, delivery)
+	if statusCode != 0 {
+		attempt.ResponseStatus = &statusCode
+		attempt.ResponseBody = &body
+	}
+
+	switch {
+	case err == nil && statusCode >= 200 && statusCode < 300:
+		attempt.Status = models.WebhookDeliverySucceeded
+		return attempt
+	case err != nil:
+		message := err.Error()
+		attempt.Error = &message
+	default:
+		message := fmt.Sprintf("unexpected response status %d", statusCode)
+		attempt.Error = &message
+	}
+
+	attempts := delivery.Attempts + 1
+	if attempts >= s.maxAttempts {
+		log.Printf("send: Giving up on delivery %d after %d attempts: %s", delivery.ID, attempts, *attempt.Error)
+		attempt.Status = models.WebhookDeliveryFailed
+		return attempt
+	}
+
+	attempt.Status = models.WebhookDeliveryPending
+	attempt.NextAttemptAt = models.NewCustomTime(time.Now().UTC().Add(webhookBackoff(attempts, s.backoffBase, s.backoffMax)))
+	return attempt
+}
+
+func (s *webhookService) post(ctx context.Context, delivery *models.ClaimedWebhookDelivery) (int, string, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "Firewatchers-Webhooks/1.0")
+	request.Header.Set("X-Firewatchers-Event", delivery.EventType)
+	request.Header.Set("X-Firewatchers-Delivery", delivery.EventID)
+	request.Header.Set("X-Firewatchers-Signature", utils.SignPayload(delivery.Secret, time.Now(), delivery.Payload))
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return 0, "", err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, webhookResponseLimit))
+	if err != nil {
+		return response.StatusCode, "", err
+	}
+
+	return response.StatusCode, string(body), nil
+}
+
+// webhookBackoff is the wait after the given number of failed attempts:
+// base, then doubling up to max.
+func webhookBackoff(attempts int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+func validateWebhookEvents(events []string) error {
+	known := map[string]bool{models.WebhookAllEvents: true}
+	for _, eventType := range models.WebhookEventTypes() {
+		known[eventType] = true
+	}
+
+	var messages []string
+	for _, event := range events {
+		if !known[event] {
+			messages = append(messages, fmt.Sprintf("Unknown webhook event %q", event))
+		}
+	}
+
+	if len(messages) > 0 {
+		return &validators.ValidationError{Messages: messages}
+	}
+	return nil
+}
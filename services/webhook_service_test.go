@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/models"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/repositories"
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubWebhookRepository hands out queued deliveries and records attempts.
+type stubWebhookRepository struct {
+	repositories.WebhookRepository
+	due      []*models.ClaimedWebhookDelivery
+	attempts []*models.WebhookAttempt
+}
+
+func (r *stubWebhookRepository) ClaimDueDeliveries(limit int, lease time.Duration) ([]*models.ClaimedWebhookDelivery, error) {
+	due := r.due
+	r.due = nil
+	return due, nil
+}
+
+func (r *stubWebhookRepository) RecordAttempt(attempt *models.WebhookAttempt) error {
+	r.attempts = append(r.attempts, attempt)
+	return nil
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	base, max := 30*time.Second, 5*time.Minute
+
+	assert.Equal(t, 30*time.Second, webhookBackoff(1, base, max))
+	assert.Equal(t, time.Minute, webhookBackoff(2, base, max))
+	assert.Equal(t, 4*time.Minute, webhookBackoff(4, base, max))
+	assert.Equal(t, max, webhookBackoff(5, base, max))
+	assert.Equal(t, max, webhookBackoff(50, base, max))
+}
+
+func TestDeliverDue(t *testing.T) {
+	payload := models.WebhookPayload(`{"type":"incident.created"}`)
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !utils.VerifyPayloadSignature("s3cret", r.Header.Get("X-Firewatchers-Signature"), body, time.Now(), time.Minute) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "incident.created", r.Header.Get("X-Firewatchers-Event"))
+		assert.Equal(t, "evt-1", r.Header.Get("X-Firewatchers-Delivery"))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	repo := &stubWebhookRepository{}
+	service := &webhookService{
+		webhookRepository: repo,
+		client:            server.Client(),
+		timeout:           time.Second,
+		maxAttempts:       3,
+		backoffBase:       time.Minute,
+		backoffMax:        time.Hour,
+	}
+	deliver := func(attempts int, secret string) *models.WebhookAttempt {
+		repo.due = []*models.ClaimedWebhookDelivery{{
+			ID: 1, EventID: "evt-1", EventType: models.WebhookIncidentCreated,
+			Payload: payload, Attempts: attempts, URL: server.URL, Secret: secret,
+		}}
+		attempted, err := service.DeliverDue(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 1, attempted)
+		return repo.attempts[len(repo.attempts)-1]
+	}
+
+	t.Run("a signed delivery succeeds", func(t *testing.T) {
+		attempt := deliver(0, "s3cret")
+		assert.Equal(t, models.WebhookDeliverySucceeded, attempt.Status)
+		assert.Nil(t, attempt.Error)
+	})
+
+	t.Run("a rejected delivery is retried later", func(t *testing.T) {
+		attempt := deliver(0, "wrong")
+		assert.Equal(t, models.WebhookDeliveryPending, attempt.Status)
+		require.NotNil(t, attempt.ResponseStatus)
+		assert.Equal(t, http.StatusUnauthorized, *attempt.ResponseStatus)
+		require.NotNil(t, attempt.NextAttemptAt)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), time.Time(*attempt.NextAttemptAt), 5*time.Second)
+	})
+
+	t.Run("the last attempt gives up", func(t *testing.T) {
+		status = http.StatusInternalServerError
+		attempt := deliver(2, "s3cret")
+		assert.Equal(t, models.WebhookDeliveryFailed, attempt.Status)
+		assert.Nil(t, attempt.NextAttemptAt)
+		require.NotNil(t, attempt.Error)
+	})
+}
+
+func TestWebhookClientRejectsInternalTargets(t *testing.T) {
+	for _, address := range []string{"127.0.0.1", "10.1.2.3", "192.168.0.10", "169.254.169.254", "::1", "fd00::1", "0.0.0.0"} {
+		assert.False(t, isPublicIP(net.ParseIP(address)), address)
+	}
+	assert.True(t, isPublicIP(net.ParseIP("8.8.8.8")))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("internal target was reached")
+	}))
+	defer server.Close()
+
+	_, err := newWebhookClient(time.Second).Post(server.URL, "application/json", nil)
+	assert.ErrorContains(t, err, "is not a public address")
+}
@@ -0,0 +1,37 @@
+// Package storage keeps uploaded files such as avatars and incident
+// attachments outside the database.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+
+	"github.com/pamateus-henrique/infinitepay-firewatchers-api/config"
+)
+
+// ErrBlobNotFound is returned by Get for a key that was never stored or has
+// been deleted.
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore stores opaque content under slash-separated keys such as
+// "avatars/7/thumbnail.png". Keys are generated by the services, never taken
+// from user input.
+type BlobStore interface {
+	Put(ctx context.Context, key string, content io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// NewBlobStore picks the implementation configured by BLOB_STORE. Only
+// "local" exists for now.
+func NewBlobStore(cfg *config.Config) (BlobStore, error) {
+	switch cfg.BlobStoreDriver {
+	case "local":
+		log.Printf("NewBlobStore: Storing files under %s", cfg.BlobStoreDir)
+		return NewLocalBlobStore(cfg.BlobStoreDir)
+	default:
+		return nil, errors.New("unknown BLOB_STORE " + cfg.BlobStoreDriver)
+	}
+}
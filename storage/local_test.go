@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalBlobStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalBlobStore(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put(ctx, "avatars/7/original", strings.NewReader("first")))
+	require.NoError(t, store.Put(ctx, "avatars/7/original", strings.NewReader("second")))
+
+	blob, err := store.Get(ctx, "avatars/7/original")
+	require.NoError(t, err)
+	content, _ := io.ReadAll(blob)
+	blob.Close()
+	assert.Equal(t, "second", string(content))
+
+	require.NoError(t, store.Delete(ctx, "avatars/7/original"))
+	require.NoError(t, store.Delete(ctx, "avatars/7/original"), "deleting twice is not an error")
+
+	_, err = store.Get(ctx, "avatars/7/original")
+	assert.ErrorIs(t, err, ErrBlobNotFound)
+
+	for _, key := range []string{"", "../outside", "avatars/../../outside", "/absolute", "avatars//7"} {
+		assert.Error(t, store.Put(ctx, key, strings.NewReader("x")), key)
+	}
+}
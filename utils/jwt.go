@@ -9,11 +9,13 @@ import (
 )
 
 
-func GenerateJWT(username string, id int) (string, error){
+func GenerateJWT(username string, id int, role string, sessionID int, expiresAt time.Time) (string, error){
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"username": username,
-		"user_id": id, 
-        "exp": time.Now().Add(time.Hour * 24).Unix(), })
+		"user_id": id,
+		"role": role,
+		"session_id": sessionID,
+        "exp": expiresAt.Unix(), })
 
 	secret, ok := os.LookupEnv("JWT_SECRET");
 	
@@ -29,4 +31,4 @@ func GenerateJWT(username string, id int) (string, error){
 
 	return jwt,nil
 
-	}
\ No newline at end of file
+	}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignPayload returns the signature header of a webhook body, in the form
+// "t=<unix>,v1=<hex HMAC-SHA256 of "<unix>.<body>">". Covering the timestamp
+// lets receivers reject replayed deliveries.
+func SignPayload(secret string, timestamp time.Time, body []byte) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", unix, payloadMAC(secret, unix, body))
+}
+
+// VerifyPayloadSignature checks a header produced by SignPayload, accepting
+// timestamps up to tolerance away from now.
+func VerifyPayloadSignature(secret, header string, body []byte, now time.Time, tolerance time.Duration) bool {
+	var unix, signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			unix = value
+		case "v1":
+			signature = value
+		}
+	}
+
+	seconds, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil || signature == "" {
+		return false
+	}
+
+	if skew := now.Sub(time.Unix(seconds, 0)); skew > tolerance || skew < -tolerance {
+		return false
+	}
+
+	expected := payloadMAC(secret, unix, body)
+	return hmac.Equal([]byte(signature), []byte(expected))
+}
+
+func payloadMAC(secret, unix string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unix + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
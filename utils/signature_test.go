@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPayloadSignature(t *testing.T) {
+	body := []byte(`{"type":"incident.created"}`)
+	sentAt := time.Unix(1700000000, 0)
+
+	header := SignPayload("s3cret", sentAt, body)
+	assert.Regexp(t, `^t=1700000000,v1=[0-9a-f]{64}$`, header)
+
+	assert.True(t, VerifyPayloadSignature("s3cret", header, body, sentAt.Add(time.Minute), 5*time.Minute))
+	assert.False(t, VerifyPayloadSignature("other", header, body, sentAt, 5*time.Minute))
+	assert.False(t, VerifyPayloadSignature("s3cret", header, []byte(`{"type":"incident.closed"}`), sentAt, 5*time.Minute))
+	assert.False(t, VerifyPayloadSignature("s3cret", header, body, sentAt.Add(time.Hour), 5*time.Minute), "stale deliveries are replays")
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+)
+
+// Thumbnail center-crops img to a square and scales it to size x size.
+// Shrinking averages every source pixel that falls in a target pixel, which
+// avoids the aliasing of plain sampling; enlarging repeats pixels.
+func Thumbnail(img image.Image, size int) *image.NRGBA {
+	bounds := img.Bounds()
+	side := bounds.Dx()
+	if bounds.Dy() < side {
+		side = bounds.Dy()
+	}
+	originX := bounds.Min.X + (bounds.Dx()-side)/2
+	originY := bounds.Min.Y + (bounds.Dy()-side)/2
+
+	thumb := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		y0, y1 := span(y, size, side)
+		for x := 0; x < size; x++ {
+			x0, x1 := span(x, size, side)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pixel := color.NRGBA64Model.Convert(img.At(originX+sx, originY+sy)).(color.NRGBA64)
+					r += uint64(pixel.R)
+					g += uint64(pixel.G)
+					b += uint64(pixel.B)
+					a += uint64(pixel.A)
+					n++
+				}
+			}
+
+			thumb.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(b / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+
+	return thumb
+}
+
+// span returns the source range [from, to) covered by target pixel i, never
+// empty.
+func span(i, size, side int) (int, int) {
+	from := i * side / size
+	to := (i + 1) * side / size
+	if to <= from {
+		to = from + 1
+	}
+	return from, to
+}
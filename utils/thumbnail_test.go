@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThumbnail(t *testing.T) {
+	// A 40x20 image: the centered 20x20 square is left half red, right half
+	// blue, and the cropped sides are green
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			switch {
+			case x < 10 || x >= 30:
+				img.SetNRGBA(x, y, color.NRGBA{G: 255, A: 255})
+			case x < 20:
+				img.SetNRGBA(x, y, color.NRGBA{R: 255, A: 255})
+			default:
+				img.SetNRGBA(x, y, color.NRGBA{B: 255, A: 255})
+			}
+		}
+	}
+
+	thumb := Thumbnail(img, 4)
+	assert.Equal(t, image.Rect(0, 0, 4, 4), thumb.Bounds())
+	assert.Equal(t, color.NRGBA{R: 255, A: 255}, thumb.NRGBAAt(0, 0))
+	assert.Equal(t, color.NRGBA{B: 255, A: 255}, thumb.NRGBAAt(3, 3))
+
+	enlarged := Thumbnail(img, 64)
+	assert.Equal(t, image.Rect(0, 0, 64, 64), enlarged.Bounds())
+	assert.Equal(t, color.NRGBA{R: 255, A: 255}, enlarged.NRGBAAt(0, 63))
+}
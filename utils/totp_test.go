@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The SHA1 vectors of RFC 6238 appendix B, truncated to six digits.
+func TestTOTPCode(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, v := range vectors {
+		code, err := TOTPCode(secret, TOTPStep(time.Unix(v.unix, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, v.code, code, "unix=%d", v.unix)
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	assert.NoError(t, err)
+
+	now := time.Unix(1700000000, 0)
+	previous, _ := TOTPCode(secret, TOTPStep(now)-1)
+	stale, _ := TOTPCode(secret, TOTPStep(now)-3)
+
+	step, ok := ValidateTOTP(secret, previous, now)
+	assert.True(t, ok, "codes from the previous period are accepted")
+	assert.Equal(t, TOTPStep(now)-1, step)
+
+	_, ok = ValidateTOTP(secret, stale, now)
+	assert.False(t, ok)
+
+	_, ok = ValidateTOTP(secret, "12345", now)
+	assert.False(t, ok)
+}
@@ -64,6 +64,22 @@ func formatErrorMessage(fe validator.FieldError) string {
         return field + " must be greater than or equal to " + fe.Param()
     case "lte":
         return field + " must be less than or equal to " + fe.Param()
+    case "min":
+        return field + " must contain at least " + fe.Param() + " item(s)"
+    case "max":
+        return field + " must be at most " + fe.Param() + " characters long"
+    case "datetime":
+        return field + " must be a date in the format " + fe.Param()
+    case "gt":
+        return field + " must be greater than " + fe.Param()
+    case "oneof":
+        return field + " must be one of: " + fe.Param()
+    case "len":
+        return field + " must be exactly " + fe.Param() + " characters long"
+    case "numeric":
+        return field + " must contain only digits"
+    case "required_without":
+        return field + " is required when " + fe.Param() + " is not provided"
     default:
         return field + " is not valid"
     }